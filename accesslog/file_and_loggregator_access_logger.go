@@ -35,6 +35,7 @@ type FileAndLoggregatorAccessLogger struct {
 	disableXFFLogging      bool
 	disableSourceIPLogging bool
 	redactQueryParams      string
+	format                 string
 	logger                 logger.Logger
 	logsender              schema.LogSender
 }
@@ -71,6 +72,7 @@ func CreateRunningAccessLogger(logger logger.Logger, logsender schema.LogSender,
 		disableXFFLogging:      config.Logging.DisableLogForwardedFor,
 		disableSourceIPLogging: config.Logging.DisableLogSourceIP,
 		redactQueryParams:      config.Logging.RedactQueryParams,
+		format:                 config.AccessLog.Format,
 		logger:                 logger,
 		logsender:              logsender,
 	}
@@ -112,6 +114,7 @@ func (x *FileAndLoggregatorAccessLogger) Log(r schema.AccessLogRecord) {
 	r.DisableXFFLogging = x.disableXFFLogging
 	r.DisableSourceIPLogging = x.disableSourceIPLogging
 	r.RedactQueryParams = x.redactQueryParams
+	r.Format = x.format
 	x.channel <- r
 }
 
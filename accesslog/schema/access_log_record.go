@@ -104,6 +104,12 @@ type AccessLogRecord struct {
 	DisableSourceIPLogging bool
 	RedactQueryParams      string
 	RouterError            string
+	GRPCStatus             string
+	HedgeWinner            string
+	BackendGroup           string
+	TraceID                string
+	SpanID                 string
+	Format                 string
 	record                 []byte
 }
 
@@ -138,6 +144,10 @@ func (r *AccessLogRecord) getRecord() []byte {
 }
 
 func (r *AccessLogRecord) makeRecord() []byte {
+	if r.Format == config.ACCESS_LOG_FORMAT_JSON {
+		return r.makeJSONRecord()
+	}
+
 	var appID, destIPandPort, appIndex string
 
 	if r.RouteEndpoint != nil {
@@ -202,6 +212,21 @@ func (r *AccessLogRecord) makeRecord() []byte {
 	b.WriteString(`x_cf_routererror:`)
 	b.WriteDashOrStringValue(r.RouterError)
 
+	if r.GRPCStatus != "" {
+		b.WriteString(` grpc_status:`)
+		b.WriteDashOrStringValue(r.GRPCStatus)
+	}
+
+	if r.HedgeWinner != "" {
+		b.WriteString(` hedge_winner:`)
+		b.WriteDashOrStringValue(r.HedgeWinner)
+	}
+
+	if r.BackendGroup != "" {
+		b.WriteString(` backend_group:`)
+		b.WriteDashOrStringValue(r.BackendGroup)
+	}
+
 	r.addExtraHeaders(b)
 
 	return b.Bytes()
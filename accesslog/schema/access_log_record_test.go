@@ -2,6 +2,8 @@ package schema_test
 
 import (
 	"bytes"
+	"encoding/json"
+	"strings"
 
 	"code.cloudfoundry.org/gorouter/accesslog/schema"
 	"code.cloudfoundry.org/gorouter/config"
@@ -72,6 +74,17 @@ var _ = Describe("AccessLogRecord", func() {
 			Eventually(r).Should(Say(`x_cf_routererror:"some-router-error"`))
 		})
 
+		Context("when the request was received over HTTP/2", func() {
+			It("records the frontend protocol", func() {
+				record.Request.Proto = "HTTP/2.0"
+				record.Request.ProtoMajor = 2
+				record.Request.ProtoMinor = 0
+
+				r := BufferReader(bytes.NewBufferString(record.LogMessage()))
+				Eventually(r).Should(Say(`"FakeRequestMethod http://example.com/request HTTP/2.0" `))
+			})
+		})
+
 		Context("when DisableSourceIPLogging is specified", func() {
 			It("does not write RemoteAddr as part of the access log", func() {
 				record.DisableSourceIPLogging = true
@@ -186,6 +199,39 @@ var _ = Describe("AccessLogRecord", func() {
 			})
 		})
 
+		Context("with a grpc status", func() {
+			BeforeEach(func() {
+				record.GRPCStatus = "14"
+			})
+
+			It("appends the grpc status after the router error", func() {
+				r := BufferReader(bytes.NewBufferString(record.LogMessage()))
+				Eventually(r).Should(Say(`x_cf_routererror:"some-router-error" grpc_status:"14"`))
+			})
+		})
+
+		Context("with a hedged request", func() {
+			BeforeEach(func() {
+				record.HedgeWinner = "hedge"
+			})
+
+			It("appends the attempt that won after the router error", func() {
+				r := BufferReader(bytes.NewBufferString(record.LogMessage()))
+				Eventually(r).Should(Say(`x_cf_routererror:"some-router-error" hedge_winner:"hedge"`))
+			})
+		})
+
+		Context("with a backend group", func() {
+			BeforeEach(func() {
+				record.BackendGroup = "green"
+			})
+
+			It("appends the backend group after the router error", func() {
+				r := BufferReader(bytes.NewBufferString(record.LogMessage()))
+				Eventually(r).Should(Say(`x_cf_routererror:"some-router-error" backend_group:"green"`))
+			})
+		})
+
 		Context("with route endpoint missing", func() {
 			BeforeEach(func() {
 				record = &schema.AccessLogRecord{}
@@ -260,6 +306,128 @@ var _ = Describe("AccessLogRecord", func() {
 		})
 	})
 
+	Describe("LogMessage in the json format", func() {
+		var fields map[string]interface{}
+
+		logFields := func() map[string]interface{} {
+			fields := map[string]interface{}{}
+			Expect(json.Unmarshal([]byte(record.LogMessage()), &fields)).To(Succeed())
+			return fields
+		}
+
+		BeforeEach(func() {
+			record.Format = config.ACCESS_LOG_FORMAT_JSON
+			record.TraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+			record.SpanID = "00f067aa0ba902b7"
+		})
+
+		It("makes a json record with typed values", func() {
+			fields = logFields()
+			Expect(fields).To(HaveKeyWithValue("host", "FakeRequestHost"))
+			Expect(fields).To(HaveKeyWithValue("started_at", "2000-01-01T00:00:00Z"))
+			Expect(fields).To(HaveKeyWithValue("method", "FakeRequestMethod"))
+			Expect(fields).To(HaveKeyWithValue("uri", "http://example.com/request"))
+			Expect(fields).To(HaveKeyWithValue("protocol", "FakeRequestProto"))
+			Expect(fields).To(HaveKeyWithValue("status", 200.0))
+			Expect(fields).To(HaveKeyWithValue("request_bytes_received", 30.0))
+			Expect(fields).To(HaveKeyWithValue("body_bytes_sent", 23.0))
+			Expect(fields).To(HaveKeyWithValue("referer", "FakeReferer"))
+			Expect(fields).To(HaveKeyWithValue("user_agent", "FakeUserAgent"))
+			Expect(fields).To(HaveKeyWithValue("remote_addr", "FakeRemoteAddr"))
+			Expect(fields).To(HaveKeyWithValue("backend_addr", "1.2.3.4:1234"))
+			Expect(fields).To(HaveKeyWithValue("x_forwarded_for", "FakeProxy1, FakeProxy2"))
+			Expect(fields).To(HaveKeyWithValue("x_forwarded_proto", "FakeOriginalRequestProto"))
+			Expect(fields).To(HaveKeyWithValue("vcap_request_id", "abc-123-xyz-pdq"))
+			Expect(fields).To(HaveKeyWithValue("response_time_ms", 60000.0))
+			Expect(fields).To(HaveKeyWithValue("gorouter_time_ms", 10000.0))
+			Expect(fields).To(HaveKeyWithValue("app_time_ms", 50000.0))
+			Expect(fields).To(HaveKeyWithValue("app_id", "FakeApplicationId"))
+			Expect(fields).To(HaveKeyWithValue("app_index", 3.0))
+			Expect(fields).To(HaveKeyWithValue("x_cf_routererror", "some-router-error"))
+			Expect(fields).To(HaveKeyWithValue("trace_id", "4bf92f3577b34da6a3ce929d0e0e4736"))
+			Expect(fields).To(HaveKeyWithValue("span_id", "00f067aa0ba902b7"))
+		})
+
+		It("logs the attempt of a hedged request that won", func() {
+			record.HedgeWinner = "primary"
+
+			Expect(logFields()).To(HaveKeyWithValue("hedge_winner", "primary"))
+		})
+
+		It("logs the backend group of the request", func() {
+			record.BackendGroup = "green"
+
+			Expect(logFields()).To(HaveKeyWithValue("backend_group", "green"))
+		})
+
+		It("writes one json object per line", func() {
+			b := new(bytes.Buffer)
+			_, err := record.WriteTo(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(b.String()).To(HavePrefix("{"))
+			Expect(b.String()).To(HaveSuffix("}\n"))
+			Expect(strings.Count(b.String(), "\n")).To(Equal(1))
+		})
+
+		Context("with values missing", func() {
+			BeforeEach(func() {
+				record.Request.Header = http.Header{}
+				record.StatusCode = 0
+				record.AppRequestStartedAt = time.Time{}
+				record.RouterError = ""
+				record.TraceID = ""
+				record.SpanID = ""
+			})
+
+			It("leaves the missing values out", func() {
+				fields = logFields()
+				for _, key := range []string{"status", "referer", "user_agent", "x_forwarded_for", "vcap_request_id", "app_time_ms", "x_cf_routererror", "trace_id", "span_id", "hedge_winner", "backend_group"} {
+					Expect(fields).NotTo(HaveKey(key))
+				}
+			})
+		})
+
+		Context("when DisableSourceIPLogging and DisableXFFLogging are specified", func() {
+			It("does not log the source ip or x_forwarded_for", func() {
+				record.DisableSourceIPLogging = true
+				record.DisableXFFLogging = true
+
+				fields = logFields()
+				Expect(fields).NotTo(HaveKey("remote_addr"))
+				Expect(fields).NotTo(HaveKey("x_forwarded_for"))
+			})
+		})
+
+		Context("when RedactQueryParams is set to hash", func() {
+			It("logs the redacted uri", func() {
+				record.Request.URL.RawQuery = "query=value"
+				record.RedactQueryParams = config.REDACT_QUERY_PARMS_HASH
+				record.Request.Method = http.MethodGet
+
+				fields = logFields()
+				Expect(fields["uri"]).To(HaveSuffix("?hash=9c9042adbe045596c2299990920eaa18536d66a1"))
+			})
+		})
+
+		Context("with extra headers", func() {
+			It("logs the extra headers that are set", func() {
+				record.Request.Header.Set("X-Something-Cool", "very-cool")
+				record.ExtraHeadersToLog = []string{"X-Something-Cool", "X-Missing"}
+
+				fields = logFields()
+				Expect(fields["extra_headers"]).To(Equal(map[string]interface{}{"x_something_cool": "very-cool"}))
+			})
+		})
+
+		Context("with route endpoint missing", func() {
+			It("does not create a log message", func() {
+				record.RouteEndpoint = nil
+				Expect(record.LogMessage()).To(Equal(""))
+			})
+		})
+	})
+
 	Describe("WriteTo", func() {
 		It("writes the correct log line to the io.Writer", func() {
 			b := new(bytes.Buffer)
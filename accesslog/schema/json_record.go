@@ -0,0 +1,120 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// jsonRecord is an access log record in the json format. Values that are
+// logged as "-" in the text format are left out.
+type jsonRecord struct {
+	Host                 string            `json:"host"`
+	StartedAt            time.Time         `json:"started_at"`
+	Method               string            `json:"method"`
+	URI                  string            `json:"uri"`
+	Protocol             string            `json:"protocol"`
+	Status               int               `json:"status,omitempty"`
+	RequestBytesReceived int               `json:"request_bytes_received"`
+	BodyBytesSent        int               `json:"body_bytes_sent"`
+	Referer              string            `json:"referer,omitempty"`
+	UserAgent            string            `json:"user_agent,omitempty"`
+	RemoteAddr           string            `json:"remote_addr,omitempty"`
+	BackendAddr          string            `json:"backend_addr,omitempty"`
+	XForwardedFor        string            `json:"x_forwarded_for,omitempty"`
+	XForwardedProto      string            `json:"x_forwarded_proto,omitempty"`
+	VcapRequestID        string            `json:"vcap_request_id,omitempty"`
+	ResponseTimeMs       *float64          `json:"response_time_ms,omitempty"`
+	GorouterTimeMs       *float64          `json:"gorouter_time_ms,omitempty"`
+	AppTimeMs            *float64          `json:"app_time_ms,omitempty"`
+	AppID                string            `json:"app_id,omitempty"`
+	AppIndex             *int              `json:"app_index,omitempty"`
+	InstanceID           string            `json:"instance_id,omitempty"`
+	RouterError          string            `json:"x_cf_routererror,omitempty"`
+	GRPCStatus           string            `json:"grpc_status,omitempty"`
+	HedgeWinner          string            `json:"hedge_winner,omitempty"`
+	BackendGroup         string            `json:"backend_group,omitempty"`
+	TraceID              string            `json:"trace_id,omitempty"`
+	SpanID               string            `json:"span_id,omitempty"`
+	ExtraHeaders         map[string]string `json:"extra_headers,omitempty"`
+}
+
+func (r *AccessLogRecord) makeJSONRecord() []byte {
+	headers := r.Request.Header
+	if r.HeadersOverride != nil {
+		headers = r.HeadersOverride
+	}
+
+	record := jsonRecord{
+		Host:                 r.Request.Host,
+		StartedAt:            r.RoundtripStartedAt,
+		Method:               r.Request.Method,
+		URI:                  redactURI(*r),
+		Protocol:             r.Request.Proto,
+		Status:               r.StatusCode,
+		RequestBytesReceived: r.RequestBytesReceived,
+		BodyBytesSent:        r.BodyBytesSent,
+		Referer:              headers.Get("Referer"),
+		UserAgent:            headers.Get("User-Agent"),
+		XForwardedProto:      headers.Get("X-Forwarded-Proto"),
+		VcapRequestID:        headers.Get("X-Vcap-Request-Id"),
+		ResponseTimeMs:       milliseconds(r.roundtripTime()),
+		GorouterTimeMs:       milliseconds(r.gorouterTime()),
+		RouterError:          r.RouterError,
+		GRPCStatus:           r.GRPCStatus,
+		HedgeWinner:          r.HedgeWinner,
+		BackendGroup:         r.BackendGroup,
+		TraceID:              r.TraceID,
+		SpanID:               r.SpanID,
+	}
+
+	if !r.AppRequestStartedAt.IsZero() {
+		record.AppTimeMs = milliseconds(r.appTime())
+	}
+	if !r.DisableSourceIPLogging {
+		record.RemoteAddr = r.Request.RemoteAddr
+	}
+	if !r.DisableXFFLogging {
+		record.XForwardedFor = headers.Get("X-Forwarded-For")
+	}
+
+	if r.RouteEndpoint != nil {
+		record.BackendAddr = r.RouteEndpoint.CanonicalAddr()
+		record.AppID = r.RouteEndpoint.ApplicationId
+		record.InstanceID = r.RouteEndpoint.PrivateInstanceId
+		if index, err := strconv.Atoi(r.RouteEndpoint.PrivateInstanceIndex); err == nil {
+			record.AppIndex = &index
+		}
+	}
+
+	if len(r.ExtraHeadersToLog) > 0 {
+		record.ExtraHeaders = make(map[string]string, len(r.ExtraHeadersToLog))
+		for _, header := range r.ExtraHeadersToLog {
+			if value := r.Request.Header.Get(header); value != "" {
+				// X-Something-Cool -> x_something_cool
+				headerName := strings.Replace(strings.ToLower(header), "-", "_", -1)
+				record.ExtraHeaders[headerName] = value
+			}
+		}
+	}
+
+	b := new(bytes.Buffer)
+	encoder := json.NewEncoder(b)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(record); err != nil {
+		return nil
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n"))
+}
+
+// milliseconds converts a duration in seconds, as computed for the text
+// format, to milliseconds. Negative durations are unknown and return nil.
+func milliseconds(seconds float64) *float64 {
+	if seconds < 0 {
+		return nil
+	}
+	ms := seconds * 1000
+	return &ms
+}
@@ -18,6 +18,9 @@ import (
 const (
 	LOAD_BALANCE_RR           string = "round-robin"
 	LOAD_BALANCE_LC           string = "least-connection"
+	LOAD_BALANCE_WRR          string = "weighted-round-robin"
+	LOAD_BALANCE_P2C          string = "power-of-two-choices"
+	LOAD_BALANCE_CH           string = "consistent-hash"
 	SHARD_ALL                 string = "all"
 	SHARD_SEGMENTS            string = "segments"
 	SHARD_SHARED_AND_SEGMENTS string = "shared-and-segments"
@@ -27,12 +30,15 @@ const (
 	REDACT_QUERY_PARMS_NONE   string = "none"
 	REDACT_QUERY_PARMS_ALL    string = "all"
 	REDACT_QUERY_PARMS_HASH   string = "hash"
+	ACCESS_LOG_FORMAT_TEXT    string = "text"
+	ACCESS_LOG_FORMAT_JSON    string = "json"
 )
 
-var LoadBalancingStrategies = []string{LOAD_BALANCE_RR, LOAD_BALANCE_LC}
+var LoadBalancingStrategies = []string{LOAD_BALANCE_RR, LOAD_BALANCE_LC, LOAD_BALANCE_WRR, LOAD_BALANCE_P2C, LOAD_BALANCE_CH}
 var AllowedShardingModes = []string{SHARD_ALL, SHARD_SEGMENTS, SHARD_SHARED_AND_SEGMENTS}
 var AllowedForwardedClientCertModes = []string{ALWAYS_FORWARD, FORWARD, SANITIZE_SET}
 var AllowedQueryParmRedactionModes = []string{REDACT_QUERY_PARMS_NONE, REDACT_QUERY_PARMS_ALL, REDACT_QUERY_PARMS_HASH}
+var AllowedAccessLogFormats = []string{ACCESS_LOG_FORMAT_TEXT, ACCESS_LOG_FORMAT_JSON}
 
 type StringSet map[string]struct{}
 
@@ -144,17 +150,183 @@ type FormatConfig struct {
 type AccessLog struct {
 	File            string `yaml:"file"`
 	EnableStreaming bool   `yaml:"enable_streaming"`
+	Format          string `yaml:"format"`
+}
+
+var defaultAccessLogConfig = AccessLog{
+	Format: ACCESS_LOG_FORMAT_TEXT,
 }
 
 type Tracing struct {
-	EnableZipkin bool   `yaml:"enable_zipkin"`
-	EnableW3C    bool   `yaml:"enable_w3c"`
-	W3CTenantID  string `yaml:"w3c_tenant_id"`
+	EnableZipkin  bool                `yaml:"enable_zipkin"`
+	EnableW3C     bool                `yaml:"enable_w3c"`
+	W3CTenantID   string              `yaml:"w3c_tenant_id"`
+	OpenTelemetry OpenTelemetryConfig `yaml:"open_telemetry"`
+}
+
+type OpenTelemetryConfig struct {
+	Enabled     bool              `yaml:"enabled"`
+	Endpoint    string            `yaml:"endpoint"`
+	URLPath     string            `yaml:"url_path"`
+	Insecure    bool              `yaml:"insecure"`
+	Headers     map[string]string `yaml:"headers,omitempty"`
+	SampleRatio float64           `yaml:"sample_ratio"`
+	ParentBased bool              `yaml:"parent_based"`
+}
+
+var defaultTracingConfig = Tracing{
+	OpenTelemetry: OpenTelemetryConfig{
+		URLPath:     "/v1/traces",
+		SampleRatio: 1,
+		ParentBased: true,
+	},
+}
+
+type TCPRoutingConfig struct {
+	Ports       []uint16      `yaml:"ports,omitempty"`
+	IdleTimeout time.Duration `yaml:"idle_timeout,omitempty"`
+}
+
+var defaultTCPRoutingConfig = TCPRoutingConfig{
+	IdleTimeout: 5 * time.Minute,
+}
+
+type PrometheusConfig struct {
+	Enabled                 bool   `yaml:"enabled"`
+	Host                    string `yaml:"host"`
+	Port                    uint16 `yaml:"port"`
+	DisableDropsondeMetrics bool   `yaml:"disable_dropsonde_metrics"`
+}
+
+var defaultPrometheusConfig = PrometheusConfig{
+	Host: "0.0.0.0",
+}
+
+type LocalityAwareRoutingConfig struct {
+	Enabled           bool `yaml:"enabled"`
+	MinHealthyPercent int  `yaml:"min_healthy_percent"`
+}
+
+type BackendHealthCheckConfig struct {
+	Enabled            bool          `yaml:"enabled"`
+	Interval           time.Duration `yaml:"interval"`
+	Timeout            time.Duration `yaml:"timeout"`
+	HealthyThreshold   int           `yaml:"healthy_threshold"`
+	UnhealthyThreshold int           `yaml:"unhealthy_threshold"`
+	MaxConcurrent      int           `yaml:"max_concurrent"`
+}
+
+var defaultBackendHealthCheckConfig = BackendHealthCheckConfig{
+	Interval:           10 * time.Second,
+	Timeout:            2 * time.Second,
+	HealthyThreshold:   2,
+	UnhealthyThreshold: 3,
+	MaxConcurrent:      20,
+}
+
+type OutlierDetectionConfig struct {
+	Enabled            bool          `yaml:"enabled"`
+	Consecutive5xx     int           `yaml:"consecutive_5xx"`
+	ErrorRatePercent   int           `yaml:"error_rate_percent"`
+	MinRequests        int           `yaml:"min_requests"`
+	Interval           time.Duration `yaml:"interval"`
+	BaseEjectionTime   time.Duration `yaml:"base_ejection_time"`
+	MaxEjectionTime    time.Duration `yaml:"max_ejection_time"`
+	MaxEjectionPercent int           `yaml:"max_ejection_percent"`
+}
+
+var defaultOutlierDetectionConfig = OutlierDetectionConfig{
+	Consecutive5xx:     5,
+	ErrorRatePercent:   50,
+	MinRequests:        20,
+	Interval:           10 * time.Second,
+	BaseEjectionTime:   30 * time.Second,
+	MaxEjectionTime:    300 * time.Second,
+	MaxEjectionPercent: 10,
+}
+
+type SlowStartConfig struct {
+	Window           time.Duration `yaml:"window"`
+	MinWeightPercent int           `yaml:"min_weight_percent"`
+}
+
+var defaultSlowStartConfig = SlowStartConfig{
+	MinWeightPercent: 10,
+}
+
+type RetryPolicyConfig struct {
+	MaxAttempts        int           `yaml:"max_attempts"`
+	PerTryTimeout      time.Duration `yaml:"per_try_timeout"`
+	RetryOnStatusCodes []int         `yaml:"retry_on_status_codes"`
+	BaseBackoff        time.Duration `yaml:"base_backoff"`
+	MaxBackoff         time.Duration `yaml:"max_backoff"`
+}
+
+var defaultRetryPolicyConfig = RetryPolicyConfig{
+	MaxAttempts: 3,
+	MaxBackoff:  time.Second,
+}
+
+type RetryBudgetConfig struct {
+	Enabled             bool `yaml:"enabled"`
+	Percent             int  `yaml:"percent"`
+	MinRetriesPerSecond int  `yaml:"min_retries_per_second"`
+}
+
+var defaultRetryBudgetConfig = RetryBudgetConfig{
+	Percent:             20,
+	MinRetriesPerSecond: 10,
+}
+
+type HedgingConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	Delay   time.Duration `yaml:"delay"`
+}
+
+var defaultHedgingConfig = HedgingConfig{
+	Delay: 100 * time.Millisecond,
+}
+
+// RouteFilesConfig is the directory of the files of registration messages
+// the router watches for routes.
+type RouteFilesConfig struct {
+	Directory    string        `yaml:"directory"`
+	SyncInterval time.Duration `yaml:"sync_interval"`
+}
+
+var defaultRouteFilesConfig = RouteFilesConfig{
+	SyncInterval: time.Second,
+}
+
+// RoutingTableSnapshotConfig is the file the router saves its routing table
+// to every interval and loads it from when it starts.
+type RoutingTableSnapshotConfig struct {
+	Path     string        `yaml:"path"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+var defaultRoutingTableSnapshotConfig = RoutingTableSnapshotConfig{
+	Interval: 30 * time.Second,
+}
+
+// StaticRouteConfig routes its uris to its endpoints without a registrar.
+type StaticRouteConfig struct {
+	URIs      []string               `yaml:"uris"`
+	Endpoints []StaticEndpointConfig `yaml:"endpoints"`
+}
+
+type StaticEndpointConfig struct {
+	Host                string `yaml:"host"`
+	Port                uint16 `yaml:"port"`
+	TLS                 bool   `yaml:"tls"`
+	ServerCertDomainSAN string `yaml:"server_cert_domain_san"`
 }
 
 type TLSPem struct {
-	CertChain  string `yaml:"cert_chain"`
-	PrivateKey string `yaml:"private_key"`
+	CertChain      string `yaml:"cert_chain"`
+	PrivateKey     string `yaml:"private_key"`
+	CertChainPath  string `yaml:"cert_chain_path,omitempty"`
+	PrivateKeyPath string `yaml:"private_key_path,omitempty"`
 }
 
 var defaultLoggingConfig = LoggingConfig{
@@ -264,6 +436,25 @@ type Config struct {
 	PidFile     string `yaml:"pid_file,omitempty"`
 	LoadBalance string `yaml:"balancing_algorithm,omitempty"`
 
+	LocalityAwareRouting LocalityAwareRoutingConfig `yaml:"locality_aware_routing,omitempty"`
+
+	BackendHealthChecks BackendHealthCheckConfig `yaml:"backend_health_checks,omitempty"`
+
+	OutlierDetection OutlierDetectionConfig `yaml:"outlier_detection,omitempty"`
+
+	SlowStart SlowStartConfig `yaml:"slow_start,omitempty"`
+
+	RetryPolicy RetryPolicyConfig `yaml:"retry_policy,omitempty"`
+	RetryBudget RetryBudgetConfig `yaml:"retry_budget,omitempty"`
+
+	Hedging HedgingConfig `yaml:"hedging,omitempty"`
+
+	StaticRoutes []StaticRouteConfig `yaml:"static_routes,omitempty"`
+
+	RouteFiles RouteFilesConfig `yaml:"route_files,omitempty"`
+
+	RoutingTableSnapshot RoutingTableSnapshotConfig `yaml:"routing_table_snapshot,omitempty"`
+
 	DisableKeepAlives   bool `yaml:"disable_keep_alives"`
 	MaxIdleConns        int  `yaml:"max_idle_conns,omitempty"`
 	MaxIdleConnsPerHost int  `yaml:"max_idle_conns_per_host,omitempty"`
@@ -279,12 +470,28 @@ type Config struct {
 	SendHttpStartStopServerEvent bool `yaml:"send_http_start_stop_server_event,omitempty"`
 
 	SendHttpStartStopClientEvent bool `yaml:"send_http_start_stop_client_event,omitempty"`
+
+	EnableHTTP2 bool `yaml:"enable_http2"`
+
+	TCPRouting TCPRoutingConfig `yaml:"tcp_routing,omitempty"`
+
+	EnableTLSPassthrough bool `yaml:"enable_tls_passthrough,omitempty"`
+
+	TLSCertificateReloadInterval time.Duration `yaml:"tls_certificate_reload_interval,omitempty"`
+
+	Prometheus PrometheusConfig `yaml:"prometheus,omitempty"`
+
+	// configYAML is the document the config was initialized from, kept to
+	// tell which settings a reload changes.
+	configYAML []byte
 }
 
 var defaultConfig = Config{
 	Status:        defaultStatusConfig,
 	Nats:          []NatsConfig{defaultNatsConfig},
 	Logging:       defaultLoggingConfig,
+	Tracing:       defaultTracingConfig,
+	AccessLog:     defaultAccessLogConfig,
 	Port:          8081,
 	Index:         0,
 	GoMaxProcs:    -1,
@@ -338,6 +545,27 @@ var defaultConfig = Config{
 	SendHttpStartStopServerEvent: true,
 
 	SendHttpStartStopClientEvent: true,
+
+	EnableHTTP2: false,
+
+	Prometheus: defaultPrometheusConfig,
+
+	BackendHealthChecks: defaultBackendHealthCheckConfig,
+
+	OutlierDetection: defaultOutlierDetectionConfig,
+
+	SlowStart: defaultSlowStartConfig,
+
+	RetryPolicy: defaultRetryPolicyConfig,
+	RetryBudget: defaultRetryBudgetConfig,
+
+	Hedging: defaultHedgingConfig,
+
+	RouteFiles: defaultRouteFilesConfig,
+
+	TCPRouting: defaultTCPRoutingConfig,
+
+	RoutingTableSnapshot: defaultRoutingTableSnapshotConfig,
 }
 
 func DefaultConfig() (*Config, error) {
@@ -435,20 +663,12 @@ func (c *Config) Process() error {
 			return fmt.Errorf("router.tls_pem must be provided if router.enable_ssl is set to true")
 		}
 
-		for _, v := range c.TLSPEM {
-			if len(v.PrivateKey) == 0 || len(v.CertChain) == 0 {
-				return fmt.Errorf("Error parsing PEM blocks of router.tls_pem, missing cert or key.")
-			}
-
-			certificate, err := tls.X509KeyPair([]byte(v.CertChain), []byte(v.PrivateKey))
-			if err != nil {
-				errMsg := fmt.Sprintf("Error loading key pair: %s", err.Error())
-				return fmt.Errorf(errMsg)
-			}
-			c.SSLCertificates = append(c.SSLCertificates, certificate)
-
-		}
 		var err error
+		c.SSLCertificates, err = c.LoadSSLCertificates()
+		if err != nil {
+			return err
+		}
+
 		c.CipherSuites, err = c.processCipherSuites()
 		if err != nil {
 			return err
@@ -476,6 +696,42 @@ func (c *Config) Process() error {
 		errMsg := fmt.Sprintf("Invalid load balancing algorithm %s. Allowed values are %s", c.LoadBalance, LoadBalancingStrategies)
 		return fmt.Errorf(errMsg)
 	}
+	if c.LocalityAwareRouting.Enabled && c.Zone == "" {
+		return fmt.Errorf("locality_aware_routing requires zone to be set")
+	}
+	if c.LocalityAwareRouting.MinHealthyPercent < 0 || c.LocalityAwareRouting.MinHealthyPercent > 100 {
+		return fmt.Errorf("locality_aware_routing.min_healthy_percent must be between 0 and 100")
+	}
+	if err := c.validateBackendHealthChecks(); err != nil {
+		return err
+	}
+	if err := c.validateOutlierDetection(); err != nil {
+		return err
+	}
+	if c.SlowStart.Window < 0 {
+		return fmt.Errorf("slow_start.window must not be negative")
+	}
+	if c.SlowStart.Window > 0 && (c.SlowStart.MinWeightPercent < 1 || c.SlowStart.MinWeightPercent > 100) {
+		return fmt.Errorf("slow_start.min_weight_percent must be between 1 and 100")
+	}
+	if err := c.RetryPolicy.Validate("retry_policy"); err != nil {
+		return err
+	}
+	if c.RetryBudget.Enabled && (c.RetryBudget.Percent < 0 || c.RetryBudget.MinRetriesPerSecond < 0) {
+		return fmt.Errorf("retry_budget.percent and retry_budget.min_retries_per_second must not be negative")
+	}
+	if c.Hedging.Enabled && c.Hedging.Delay <= 0 {
+		return fmt.Errorf("hedging.delay must be greater than 0")
+	}
+	if err := c.validateStaticRoutes(); err != nil {
+		return err
+	}
+	if c.RouteFiles.Directory != "" && c.RouteFiles.SyncInterval <= 0 {
+		return fmt.Errorf("route_files.sync_interval must be greater than 0")
+	}
+	if c.RoutingTableSnapshot.Path != "" && c.RoutingTableSnapshot.Interval <= 0 {
+		return fmt.Errorf("routing_table_snapshot.interval must be greater than 0")
+	}
 	if c.LoadBalancerHealthyThreshold < 0 {
 		errMsg := fmt.Sprintf("Invalid load balancer healthy threshold: %s", c.LoadBalancerHealthyThreshold)
 		return fmt.Errorf(errMsg)
@@ -505,6 +761,24 @@ func (c *Config) Process() error {
 		return fmt.Errorf(errMsg)
 	}
 
+	if err := c.validateTCPRoutingPorts(); err != nil {
+		return err
+	}
+
+	if c.Tracing.OpenTelemetry.Enabled && c.Tracing.OpenTelemetry.Endpoint == "" {
+		return fmt.Errorf("tracing.open_telemetry.endpoint must be set when open telemetry is enabled")
+	}
+	if c.Tracing.OpenTelemetry.SampleRatio < 0 || c.Tracing.OpenTelemetry.SampleRatio > 1 {
+		return fmt.Errorf("tracing.open_telemetry.sample_ratio must be between 0 and 1")
+	}
+
+	if c.Prometheus.Enabled && c.Prometheus.Port == 0 {
+		return fmt.Errorf("prometheus.port must be set when prometheus is enabled")
+	}
+	if c.Prometheus.DisableDropsondeMetrics && !c.Prometheus.Enabled {
+		return fmt.Errorf("prometheus.disable_dropsonde_metrics requires prometheus.enabled")
+	}
+
 	if c.RoutingTableShardingMode == SHARD_SEGMENTS && len(c.IsolationSegments) == 0 {
 		return fmt.Errorf("Expected isolation segments; routing table sharding mode set to segments and none provided.")
 	}
@@ -521,6 +795,18 @@ func (c *Config) Process() error {
 		return fmt.Errorf(errMsg)
 	}
 
+	validAccessLogFormat := false
+	for _, format := range AllowedAccessLogFormats {
+		if c.AccessLog.Format == format {
+			validAccessLogFormat = true
+			break
+		}
+	}
+	if !validAccessLogFormat {
+		errMsg := fmt.Sprintf("Invalid access log format: %s. Allowed values are %s", c.AccessLog.Format, AllowedAccessLogFormats)
+		return fmt.Errorf(errMsg)
+	}
+
 	if err := c.buildCertPool(); err != nil {
 		return err
 	}
@@ -530,6 +816,155 @@ func (c *Config) Process() error {
 	return nil
 }
 
+// LoadSSLCertificates builds the frontend certificates from router.tls_pem.
+// Entries with a cert_chain_path or private_key_path are read from disk on
+// every call, which lets the certificates be rotated by replacing the files.
+func (c *Config) LoadSSLCertificates() ([]tls.Certificate, error) {
+	var certificates []tls.Certificate
+	for _, v := range c.TLSPEM {
+		certChain, privateKey := []byte(v.CertChain), []byte(v.PrivateKey)
+
+		if v.CertChainPath != "" {
+			b, err := ioutil.ReadFile(v.CertChainPath)
+			if err != nil {
+				return nil, fmt.Errorf("Error reading router.tls_pem cert_chain_path: %s", err)
+			}
+			certChain = b
+		}
+
+		if v.PrivateKeyPath != "" {
+			b, err := ioutil.ReadFile(v.PrivateKeyPath)
+			if err != nil {
+				return nil, fmt.Errorf("Error reading router.tls_pem private_key_path: %s", err)
+			}
+			privateKey = b
+		}
+
+		if len(privateKey) == 0 || len(certChain) == 0 {
+			return nil, fmt.Errorf("Error parsing PEM blocks of router.tls_pem, missing cert or key.")
+		}
+
+		certificate, err := tls.X509KeyPair(certChain, privateKey)
+		if err != nil {
+			return nil, fmt.Errorf("Error loading key pair: %s", err.Error())
+		}
+		certificates = append(certificates, certificate)
+	}
+	return certificates, nil
+}
+
+func (c *Config) validateTCPRoutingPorts() error {
+	reserved := map[uint16]string{c.Status.Port: "status.port"}
+	if !c.DisableHTTP {
+		reserved[c.Port] = "port"
+	}
+	if c.EnableSSL {
+		reserved[c.SSLPort] = "ssl_port"
+	}
+
+	if len(c.TCPRouting.Ports) > 0 && c.TCPRouting.IdleTimeout <= 0 {
+		return fmt.Errorf("tcp_routing.idle_timeout must be greater than 0")
+	}
+
+	for _, port := range c.TCPRouting.Ports {
+		if port == 0 {
+			return fmt.Errorf("tcp_routing.ports must not contain 0")
+		}
+		if name, ok := reserved[port]; ok {
+			return fmt.Errorf("tcp_routing.ports: port %d is already used by %s", port, name)
+		}
+		reserved[port] = "tcp_routing.ports"
+	}
+	return nil
+}
+
+func (c *Config) validateBackendHealthChecks() error {
+	h := c.BackendHealthChecks
+	if !h.Enabled {
+		return nil
+	}
+
+	if h.Interval <= 0 {
+		return fmt.Errorf("backend_health_checks.interval must be greater than 0")
+	}
+	if h.Timeout <= 0 || h.Timeout > h.Interval {
+		return fmt.Errorf("backend_health_checks.timeout must be greater than 0 and not greater than backend_health_checks.interval")
+	}
+	if h.HealthyThreshold < 1 || h.UnhealthyThreshold < 1 {
+		return fmt.Errorf("backend_health_checks.healthy_threshold and backend_health_checks.unhealthy_threshold must be at least 1")
+	}
+	if h.MaxConcurrent < 1 {
+		return fmt.Errorf("backend_health_checks.max_concurrent must be at least 1")
+	}
+	return nil
+}
+
+// Validate checks the retry policy, where name is what it is called in
+// error messages.
+func (p RetryPolicyConfig) Validate(name string) error {
+	if p.MaxAttempts < 1 {
+		return fmt.Errorf("%s.max_attempts must be at least 1", name)
+	}
+	if p.PerTryTimeout < 0 {
+		return fmt.Errorf("%s.per_try_timeout must not be negative", name)
+	}
+	for _, code := range p.RetryOnStatusCodes {
+		if code < 400 || code > 599 {
+			return fmt.Errorf("%s.retry_on_status_codes must be between 400 and 599, got %d", name, code)
+		}
+	}
+	if p.BaseBackoff < 0 || p.MaxBackoff < p.BaseBackoff {
+		return fmt.Errorf("%s.base_backoff must not be negative and not greater than %s.max_backoff", name, name)
+	}
+	return nil
+}
+
+func (c *Config) validateStaticRoutes() error {
+	for i, r := range c.StaticRoutes {
+		if len(r.URIs) == 0 || len(r.Endpoints) == 0 {
+			return fmt.Errorf("static_routes[%d] must have uris and endpoints", i)
+		}
+		for _, uri := range r.URIs {
+			if uri == "" {
+				return fmt.Errorf("static_routes[%d].uris must not be empty", i)
+			}
+		}
+		for j, e := range r.Endpoints {
+			if e.Host == "" || e.Port == 0 {
+				return fmt.Errorf("static_routes[%d].endpoints[%d] must have a host and a port", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Config) validateOutlierDetection() error {
+	o := c.OutlierDetection
+	if !o.Enabled {
+		return nil
+	}
+
+	if o.Consecutive5xx < 0 {
+		return fmt.Errorf("outlier_detection.consecutive_5xx must not be negative")
+	}
+	if o.ErrorRatePercent < 0 || o.ErrorRatePercent > 100 {
+		return fmt.Errorf("outlier_detection.error_rate_percent must be between 0 and 100")
+	}
+	if o.Consecutive5xx == 0 && o.ErrorRatePercent == 0 {
+		return fmt.Errorf("outlier_detection requires outlier_detection.consecutive_5xx or outlier_detection.error_rate_percent to be set")
+	}
+	if o.ErrorRatePercent > 0 && (o.MinRequests < 1 || o.Interval <= 0) {
+		return fmt.Errorf("outlier_detection.min_requests must be at least 1 and outlier_detection.interval must be greater than 0")
+	}
+	if o.BaseEjectionTime <= 0 || o.MaxEjectionTime < o.BaseEjectionTime {
+		return fmt.Errorf("outlier_detection.base_ejection_time must be greater than 0 and not greater than outlier_detection.max_ejection_time")
+	}
+	if o.MaxEjectionPercent < 0 || o.MaxEjectionPercent > 100 {
+		return fmt.Errorf("outlier_detection.max_ejection_percent must be between 0 and 100")
+	}
+	return nil
+}
+
 func (c *Config) processCipherSuites() ([]uint16, error) {
 	cipherMap := map[string]uint16{
 		"RC4-SHA":                                 0x0005, // openssl formatted values
@@ -668,6 +1103,7 @@ func (c *Config) RoutingApiEnabled() bool {
 
 func (c *Config) Initialize(configYAML []byte) error {
 	c.Nats = []NatsConfig{}
+	c.configYAML = configYAML
 	return yaml.Unmarshal(configYAML, &c)
 }
 
@@ -5,6 +5,9 @@ import (
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 
 	yaml "gopkg.in/yaml.v2"
@@ -46,6 +49,61 @@ balancing_algorithm: least-connection
 				Expect(cfg.LoadBalance).To(Equal(LOAD_BALANCE_LC))
 			})
 
+			It("does not enable locality aware routing by default", func() {
+				Expect(config.LocalityAwareRouting.Enabled).To(BeFalse())
+				Expect(config.LocalityAwareRouting.MinHealthyPercent).To(Equal(0))
+			})
+
+			It("sets locality aware routing", func() {
+				cfg, err := DefaultConfig()
+				Expect(err).ToNot(HaveOccurred())
+				var b = []byte(`
+zone: z1
+locality_aware_routing:
+  enabled: true
+  min_healthy_percent: 50
+`)
+				Expect(cfg.Initialize(b)).To(Succeed())
+				Expect(cfg.Process()).To(Succeed())
+				Expect(cfg.LocalityAwareRouting.Enabled).To(BeTrue())
+				Expect(cfg.LocalityAwareRouting.MinHealthyPercent).To(Equal(50))
+			})
+
+			It("requires a zone for locality aware routing", func() {
+				cfg, err := DefaultConfig()
+				Expect(err).ToNot(HaveOccurred())
+				var b = []byte(`
+locality_aware_routing:
+  enabled: true
+`)
+				Expect(cfg.Initialize(b)).To(Succeed())
+				Expect(cfg.Process()).To(MatchError("locality_aware_routing requires zone to be set"))
+			})
+
+			It("does not allow a healthy percentage above 100", func() {
+				cfg, err := DefaultConfig()
+				Expect(err).ToNot(HaveOccurred())
+				var b = []byte(`
+zone: z1
+locality_aware_routing:
+  enabled: true
+  min_healthy_percent: 101
+`)
+				Expect(cfg.Initialize(b)).To(Succeed())
+				Expect(cfg.Process()).To(MatchError("locality_aware_routing.min_healthy_percent must be between 0 and 100"))
+			})
+
+			It("allows weighted round-robin", func() {
+				cfg, err := DefaultConfig()
+				Expect(err).ToNot(HaveOccurred())
+				var b = []byte(`
+balancing_algorithm: weighted-round-robin
+`)
+				cfg.Initialize(b)
+				Expect(cfg.Process()).To(Succeed())
+				Expect(cfg.LoadBalance).To(Equal(LOAD_BALANCE_WRR))
+			})
+
 			It("does not allow an invalid load balance strategy", func() {
 				cfg, err := DefaultConfig()
 				Expect(err).ToNot(HaveOccurred())
@@ -53,7 +111,7 @@ balancing_algorithm: least-connection
 balancing_algorithm: foo-bar
 `)
 				cfg.Initialize(b)
-				Expect(cfg.Process()).To(MatchError("Invalid load balancing algorithm foo-bar. Allowed values are [round-robin least-connection]"))
+				Expect(cfg.Process()).To(MatchError("Invalid load balancing algorithm foo-bar. Allowed values are [round-robin least-connection weighted-round-robin power-of-two-choices consistent-hash]"))
 			})
 		})
 
@@ -164,6 +222,7 @@ suspend_pruning_if_nats_unavailable: true
 			// access entries not present in config
 			Expect(config.AccessLog.File).To(Equal(""))
 			Expect(config.AccessLog.EnableStreaming).To(BeFalse())
+			Expect(config.AccessLog.Format).To(Equal("text"))
 		})
 
 		It("sets default sharding mode config", func() {
@@ -218,6 +277,19 @@ access_log:
 			Expect(config.AccessLog.EnableStreaming).To(BeTrue())
 		})
 
+		It("sets the access log format", func() {
+			var b = []byte(`
+access_log:
+  file: "/var/vcap/sys/log/gorouter/access.log"
+  format: json
+`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.AccessLog.File).To(Equal("/var/vcap/sys/log/gorouter/access.log"))
+			Expect(config.AccessLog.Format).To(Equal("json"))
+		})
+
 		It("sets logging config", func() {
 			var b = []byte(`
 logging:
@@ -523,6 +595,43 @@ enable_proxy: true
 			Expect(config.Tracing.W3CTenantID).To(BeEmpty())
 		})
 
+		It("sets Tracing.OpenTelemetry", func() {
+			var b = []byte(`
+tracing:
+  open_telemetry:
+    enabled: true
+    endpoint: collector.service.internal:4318
+    insecure: true
+    headers:
+      Authorization: Bearer token
+    sample_ratio: 0.25
+    parent_based: false
+`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Tracing.OpenTelemetry).To(Equal(OpenTelemetryConfig{
+				Enabled:     true,
+				Endpoint:    "collector.service.internal:4318",
+				URLPath:     "/v1/traces",
+				Insecure:    true,
+				Headers:     map[string]string{"Authorization": "Bearer token"},
+				SampleRatio: 0.25,
+				ParentBased: false,
+			}))
+		})
+
+		It("defaults Tracing.OpenTelemetry", func() {
+			var b = []byte(``)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Tracing.OpenTelemetry.Enabled).To(BeFalse())
+			Expect(config.Tracing.OpenTelemetry.URLPath).To(Equal("/v1/traces"))
+			Expect(config.Tracing.OpenTelemetry.SampleRatio).To(Equal(1.0))
+			Expect(config.Tracing.OpenTelemetry.ParentBased).To(BeTrue())
+		})
+
 		It("sets the proxy forwarded proto header", func() {
 			var b = []byte("force_forwarded_proto_https: true")
 			config.Initialize(b)
@@ -650,6 +759,35 @@ backends:
 			Expect(config.SendHttpStartStopClientEvent).To(BeFalse())
 		})
 
+		It("defaults EnableHTTP2 to false", func() {
+			Expect(config.EnableHTTP2).To(BeFalse())
+		})
+
+		It("sets EnableHTTP2", func() {
+			var b = []byte(`enable_http2: true`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config.EnableHTTP2).To(BeTrue())
+		})
+
+		It("defaults EnableTLSPassthrough to false", func() {
+			Expect(config.EnableTLSPassthrough).To(BeFalse())
+		})
+
+		It("sets EnableTLSPassthrough", func() {
+			var b = []byte(`enable_tls_passthrough: true`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config.EnableTLSPassthrough).To(BeTrue())
+		})
+
+		It("sets TLSCertificateReloadInterval", func() {
+			var b = []byte(`tls_certificate_reload_interval: 1m`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config.TLSCertificateReloadInterval).To(Equal(time.Minute))
+		})
+
 	})
 
 	Describe("Process", func() {
@@ -1127,6 +1265,67 @@ route_services_secret_decrypt_only: 1PfbARmvIn6cgyKorA1rqR2d34rBOo+z3qJGz17pi8Y=
 				})
 			})
 
+			Context("when TLSPEM refers to certificate files", func() {
+				var certDir string
+
+				BeforeEach(func() {
+					var err error
+					certDir, err = ioutil.TempDir("", "certs")
+					Expect(err).ToNot(HaveOccurred())
+
+					certPath := filepath.Join(certDir, "cert.pem")
+					keyPath := filepath.Join(certDir, "key.pem")
+					Expect(ioutil.WriteFile(certPath, certPEM1, 0600)).To(Succeed())
+					Expect(ioutil.WriteFile(keyPath, keyPEM1, 0600)).To(Succeed())
+
+					configSnippet.TLSPEM = []TLSPem{{CertChainPath: certPath, PrivateKeyPath: keyPath}}
+				})
+
+				AfterEach(func() {
+					os.RemoveAll(certDir)
+				})
+
+				It("loads the certificates from the files", func() {
+					configBytes := createYMLSnippet(configSnippet)
+					err := config.Initialize(configBytes)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(config.Process()).To(Succeed())
+					Expect(config.SSLCertificates).To(ConsistOf(expectedSSLCertificates[0]))
+				})
+
+				It("reads the files again on every load", func() {
+					configBytes := createYMLSnippet(configSnippet)
+					err := config.Initialize(configBytes)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(config.Process()).To(Succeed())
+
+					keyPEM, certPEM := test_util.CreateKeyPair("rotated.com")
+					Expect(ioutil.WriteFile(configSnippet.TLSPEM[0].CertChainPath, certPEM, 0600)).To(Succeed())
+					Expect(ioutil.WriteFile(configSnippet.TLSPEM[0].PrivateKeyPath, keyPEM, 0600)).To(Succeed())
+					rotated, err := tls.X509KeyPair(certPEM, keyPEM)
+					Expect(err).ToNot(HaveOccurred())
+
+					certificates, err := config.LoadSSLCertificates()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(certificates).To(ConsistOf(rotated))
+				})
+
+				Context("when a file cannot be read", func() {
+					BeforeEach(func() {
+						configSnippet.TLSPEM[0].PrivateKeyPath = filepath.Join(certDir, "missing.pem")
+					})
+
+					It("fails to validate", func() {
+						configBytes := createYMLSnippet(configSnippet)
+						err := config.Initialize(configBytes)
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(config.Process()).To(MatchError(HavePrefix("Error reading router.tls_pem private_key_path: ")))
+					})
+				})
+			})
+
 			Context("when cipher suites are of openssl format", func() {
 				BeforeEach(func() {
 					configSnippet.CipherString = "RC4-SHA:DES-CBC3-SHA:AES128-SHA:AES256-SHA:AES128-GCM-SHA256:AES256-GCM-SHA384:ECDHE-ECDSA-RC4-SHA:ECDHE-ECDSA-AES128-SHA:ECDHE-ECDSA-AES256-SHA:ECDHE-RSA-RC4-SHA:ECDHE-RSA-DES-CBC3-SHA:ECDHE-RSA-AES128-SHA:ECDHE-RSA-AES256-SHA:ECDHE-RSA-AES128-GCM-SHA256:ECDHE-ECDSA-AES128-GCM-SHA256:ECDHE-RSA-AES256-GCM-SHA384:ECDHE-ECDSA-AES256-GCM-SHA384:AES128-SHA256:ECDHE-ECDSA-AES128-SHA256:ECDHE-RSA-AES128-SHA256:ECDHE-RSA-CHACHA20-POLY1305:ECDHE-ECDSA-CHACHA20-POLY1305"
@@ -1456,6 +1655,431 @@ disable_http: true
 			})
 		})
 
+		Context("When given tcp routing ports", func() {
+			It("sets the ports", func() {
+				var b = []byte("tcp_routing:\n  ports: [1024, 1025]")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.TCPRouting.Ports).To(ConsistOf(uint16(1024), uint16(1025)))
+				Expect(config.TCPRouting.IdleTimeout).To(Equal(5 * time.Minute))
+			})
+
+			It("sets the idle timeout", func() {
+				var b = []byte("tcp_routing:\n  ports: [1024]\n  idle_timeout: 30s")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.TCPRouting.IdleTimeout).To(Equal(30 * time.Second))
+			})
+
+			It("returns a meaningful error when the idle timeout is not positive", func() {
+				var b = []byte("tcp_routing:\n  ports: [1024]\n  idle_timeout: 0s")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("tcp_routing.idle_timeout must be greater than 0"))
+			})
+
+			It("returns a meaningful error when a port is listed twice", func() {
+				var b = []byte("tcp_routing:\n  ports: [1024, 1024]")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("tcp_routing.ports: port 1024 is already used by tcp_routing.ports"))
+			})
+
+			It("returns a meaningful error when a port collides with the http port", func() {
+				var b = []byte("port: 8080\ntcp_routing:\n  ports: [8080]")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("tcp_routing.ports: port 8080 is already used by port"))
+			})
+
+			It("returns a meaningful error when a port is 0", func() {
+				var b = []byte("tcp_routing:\n  ports: [0]")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("tcp_routing.ports must not contain 0"))
+			})
+		})
+
+		Context("When given an access log format that is not supported", func() {
+			It("returns a meaningful error", func() {
+				var b = []byte("access_log:\n  format: xml")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("Invalid access log format: xml. Allowed values are [text json]"))
+			})
+		})
+
+		Context("When open telemetry tracing is enabled", func() {
+			It("returns a meaningful error when the endpoint is missing", func() {
+				var b = []byte("tracing:\n  open_telemetry:\n    enabled: true")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("tracing.open_telemetry.endpoint must be set when open telemetry is enabled"))
+			})
+
+			It("returns a meaningful error when the sample ratio is out of range", func() {
+				var b = []byte("tracing:\n  open_telemetry:\n    enabled: true\n    endpoint: localhost:4318\n    sample_ratio: 1.5")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("tracing.open_telemetry.sample_ratio must be between 0 and 1"))
+			})
+		})
+
+		Context("When given a prometheus listener", func() {
+			It("sets the listener", func() {
+				var b = []byte("prometheus:\n  enabled: true\n  port: 9100")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.Prometheus.Enabled).To(BeTrue())
+				Expect(config.Prometheus.Host).To(Equal("0.0.0.0"))
+				Expect(config.Prometheus.Port).To(Equal(uint16(9100)))
+			})
+
+			It("returns a meaningful error when the port is missing", func() {
+				var b = []byte("prometheus:\n  enabled: true")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("prometheus.port must be set when prometheus is enabled"))
+			})
+
+			It("returns a meaningful error when dropsonde metrics are disabled without prometheus", func() {
+				var b = []byte("prometheus:\n  disable_dropsonde_metrics: true")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("prometheus.disable_dropsonde_metrics requires prometheus.enabled"))
+			})
+		})
+
+		Context("When backend health checks are enabled", func() {
+			It("uses the default settings", func() {
+				var b = []byte("backend_health_checks:\n  enabled: true")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.BackendHealthChecks).To(Equal(BackendHealthCheckConfig{
+					Enabled:            true,
+					Interval:           10 * time.Second,
+					Timeout:            2 * time.Second,
+					HealthyThreshold:   2,
+					UnhealthyThreshold: 3,
+					MaxConcurrent:      20,
+				}))
+			})
+
+			It("returns a meaningful error when the timeout is longer than the interval", func() {
+				var b = []byte("backend_health_checks:\n  enabled: true\n  interval: 1s\n  timeout: 2s")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("backend_health_checks.timeout must be greater than 0 and not greater than backend_health_checks.interval"))
+			})
+
+			It("returns a meaningful error when a threshold is below 1", func() {
+				var b = []byte("backend_health_checks:\n  enabled: true\n  unhealthy_threshold: 0")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("backend_health_checks.healthy_threshold and backend_health_checks.unhealthy_threshold must be at least 1"))
+			})
+
+			It("does not validate the settings when health checks are disabled", func() {
+				var b = []byte("backend_health_checks:\n  interval: 0s")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+			})
+		})
+
+		Context("When outlier detection is enabled", func() {
+			It("uses the default settings", func() {
+				var b = []byte("outlier_detection:\n  enabled: true")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.OutlierDetection).To(Equal(OutlierDetectionConfig{
+					Enabled:            true,
+					Consecutive5xx:     5,
+					ErrorRatePercent:   50,
+					MinRequests:        20,
+					Interval:           10 * time.Second,
+					BaseEjectionTime:   30 * time.Second,
+					MaxEjectionTime:    300 * time.Second,
+					MaxEjectionPercent: 10,
+				}))
+			})
+
+			It("returns a meaningful error when neither detection method is set", func() {
+				var b = []byte("outlier_detection:\n  enabled: true\n  consecutive_5xx: 0\n  error_rate_percent: 0")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("outlier_detection requires outlier_detection.consecutive_5xx or outlier_detection.error_rate_percent to be set"))
+			})
+
+			It("returns a meaningful error when the error rate is above 100 percent", func() {
+				var b = []byte("outlier_detection:\n  enabled: true\n  error_rate_percent: 101")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("outlier_detection.error_rate_percent must be between 0 and 100"))
+			})
+
+			It("returns a meaningful error when the base ejection time is longer than the maximum", func() {
+				var b = []byte("outlier_detection:\n  enabled: true\n  base_ejection_time: 10m\n  max_ejection_time: 5m")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("outlier_detection.base_ejection_time must be greater than 0 and not greater than outlier_detection.max_ejection_time"))
+			})
+
+			It("does not validate the settings when outlier detection is disabled", func() {
+				var b = []byte("outlier_detection:\n  max_ejection_percent: 200")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+			})
+		})
+
+		Context("When slow start is configured", func() {
+			It("defaults to no slow start", func() {
+				Expect(config.Process()).To(Succeed())
+				Expect(config.SlowStart).To(Equal(SlowStartConfig{MinWeightPercent: 10}))
+			})
+
+			It("sets the slow start window", func() {
+				var b = []byte("slow_start:\n  window: 1m\n  min_weight_percent: 20")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.SlowStart).To(Equal(SlowStartConfig{Window: time.Minute, MinWeightPercent: 20}))
+			})
+
+			It("returns a meaningful error when the minimum weight is out of range", func() {
+				var b = []byte("slow_start:\n  window: 1m\n  min_weight_percent: 0")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("slow_start.min_weight_percent must be between 1 and 100"))
+			})
+		})
+
+		Context("When a retry policy is configured", func() {
+			It("defaults to three attempts without backoff", func() {
+				Expect(config.Process()).To(Succeed())
+				Expect(config.RetryPolicy).To(Equal(RetryPolicyConfig{MaxAttempts: 3, MaxBackoff: time.Second}))
+			})
+
+			It("sets the retry policy", func() {
+				var b = []byte(`
+retry_policy:
+  max_attempts: 5
+  per_try_timeout: 2s
+  retry_on_status_codes: [502, 503]
+  base_backoff: 10ms
+  max_backoff: 100ms
+`)
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.RetryPolicy).To(Equal(RetryPolicyConfig{
+					MaxAttempts:        5,
+					PerTryTimeout:      2 * time.Second,
+					RetryOnStatusCodes: []int{502, 503},
+					BaseBackoff:        10 * time.Millisecond,
+					MaxBackoff:         100 * time.Millisecond,
+				}))
+			})
+
+			It("returns a meaningful error when the maximum attempts are less than 1", func() {
+				var b = []byte("retry_policy:\n  max_attempts: 0")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("retry_policy.max_attempts must be at least 1"))
+			})
+
+			It("returns a meaningful error when a status code is not an error", func() {
+				var b = []byte("retry_policy:\n  retry_on_status_codes: [200]")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("retry_policy.retry_on_status_codes must be between 400 and 599, got 200"))
+			})
+
+			It("returns a meaningful error when the base backoff is greater than the maximum backoff", func() {
+				var b = []byte("retry_policy:\n  base_backoff: 2s")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("retry_policy.base_backoff must not be negative and not greater than retry_policy.max_backoff"))
+			})
+		})
+
+		Context("When a retry budget is configured", func() {
+			It("defaults to a disabled retry budget", func() {
+				Expect(config.Process()).To(Succeed())
+				Expect(config.RetryBudget).To(Equal(RetryBudgetConfig{Percent: 20, MinRetriesPerSecond: 10}))
+			})
+
+			It("sets the retry budget", func() {
+				var b = []byte("retry_budget:\n  enabled: true\n  percent: 10\n  min_retries_per_second: 5")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.RetryBudget).To(Equal(RetryBudgetConfig{Enabled: true, Percent: 10, MinRetriesPerSecond: 5}))
+			})
+
+			It("returns a meaningful error when the budget is negative", func() {
+				var b = []byte("retry_budget:\n  enabled: true\n  percent: -1")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("retry_budget.percent and retry_budget.min_retries_per_second must not be negative"))
+			})
+		})
+
+		Context("When hedging is configured", func() {
+			It("defaults to no hedging", func() {
+				Expect(config.Process()).To(Succeed())
+				Expect(config.Hedging).To(Equal(HedgingConfig{Delay: 100 * time.Millisecond}))
+			})
+
+			It("sets the hedging delay", func() {
+				var b = []byte("hedging:\n  enabled: true\n  delay: 50ms")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.Hedging).To(Equal(HedgingConfig{Enabled: true, Delay: 50 * time.Millisecond}))
+			})
+
+			It("returns a meaningful error when the delay is not positive", func() {
+				var b = []byte("hedging:\n  enabled: true\n  delay: 0s")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("hedging.delay must be greater than 0"))
+			})
+		})
+
+		Context("When static routes are configured", func() {
+			It("defaults to no static routes", func() {
+				Expect(config.Process()).To(Succeed())
+				Expect(config.StaticRoutes).To(BeEmpty())
+			})
+
+			It("sets the static routes", func() {
+				var b = []byte(`
+static_routes:
+- uris: [status.example.com, status.example.com/internal]
+  endpoints:
+  - host: 10.0.0.5
+    port: 8080
+  - host: 10.0.0.6
+    port: 8443
+    tls: true
+    server_cert_domain_san: status.internal
+`)
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.StaticRoutes).To(Equal([]StaticRouteConfig{{
+					URIs: []string{"status.example.com", "status.example.com/internal"},
+					Endpoints: []StaticEndpointConfig{
+						{Host: "10.0.0.5", Port: 8080},
+						{Host: "10.0.0.6", Port: 8443, TLS: true, ServerCertDomainSAN: "status.internal"},
+					},
+				}}))
+			})
+
+			It("returns a meaningful error when a static route has no endpoints", func() {
+				var b = []byte("static_routes:\n- uris: [status.example.com]")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("static_routes[0] must have uris and endpoints"))
+			})
+
+			It("returns a meaningful error when an endpoint has no port", func() {
+				var b = []byte("static_routes:\n- uris: [status.example.com]\n  endpoints:\n  - host: 10.0.0.5")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("static_routes[0].endpoints[0] must have a host and a port"))
+			})
+		})
+
+		Context("When route files are configured", func() {
+			It("defaults to no route files", func() {
+				Expect(config.Process()).To(Succeed())
+				Expect(config.RouteFiles).To(Equal(RouteFilesConfig{SyncInterval: time.Second}))
+			})
+
+			It("sets the directory and sync interval", func() {
+				var b = []byte("route_files:\n  directory: /var/vcap/routes\n  sync_interval: 5s")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.RouteFiles).To(Equal(RouteFilesConfig{Directory: "/var/vcap/routes", SyncInterval: 5 * time.Second}))
+			})
+
+			It("returns a meaningful error when the sync interval is not positive", func() {
+				var b = []byte("route_files:\n  directory: /var/vcap/routes\n  sync_interval: 0s")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("route_files.sync_interval must be greater than 0"))
+			})
+		})
+
+		Context("When a routing table snapshot is configured", func() {
+			It("defaults to no snapshot", func() {
+				Expect(config.Process()).To(Succeed())
+				Expect(config.RoutingTableSnapshot).To(Equal(RoutingTableSnapshotConfig{Interval: 30 * time.Second}))
+			})
+
+			It("sets the path and interval", func() {
+				var b = []byte("routing_table_snapshot:\n  path: /var/vcap/data/gorouter/routes.json\n  interval: 10s")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(Succeed())
+				Expect(config.RoutingTableSnapshot).To(Equal(RoutingTableSnapshotConfig{Path: "/var/vcap/data/gorouter/routes.json", Interval: 10 * time.Second}))
+			})
+
+			It("returns a meaningful error when the interval is not positive", func() {
+				var b = []byte("routing_table_snapshot:\n  path: /var/vcap/data/gorouter/routes.json\n  interval: 0s")
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process()).To(MatchError("routing_table_snapshot.interval must be greater than 0"))
+			})
+		})
+
 		Context("When given a routing_table_sharding_mode that is not supported ", func() {
 			var b = []byte(`routing_table_sharding_mode: foo`)
 
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ReloadableSettings are the settings, as dotted YAML keys, that a running
+// router applies when its configuration is reloaded. Changing any other
+// setting requires a restart.
+var ReloadableSettings = []string{
+	"logging.level",
+	"http_rewrite",
+	"sticky_session_cookie_names",
+	"endpoint_timeout",
+	"route_services_timeout",
+	"extra_headers_to_log",
+	"html_error_template_file",
+	"balancing_algorithm",
+	"static_routes",
+}
+
+// RestartRequiredChanges returns the settings, as dotted YAML keys, that
+// differ between the documents c and next were initialized from and that are
+// not in ReloadableSettings. Settings are compared before Process, so values
+// Process derives from a reloadable setting do not count as changes.
+func (c *Config) RestartRequiredChanges(next *Config) ([]string, error) {
+	current, err := restartRequiredSettings(c.configYAML)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := restartRequiredSettings(next.configYAML)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := diffSettings("", current, updated)
+	sort.Strings(changes)
+	return changes, nil
+}
+
+// restartRequiredSettings returns the settings of configYAML, defaults
+// included, without the reloadable ones.
+func restartRequiredSettings(configYAML []byte) (map[interface{}]interface{}, error) {
+	c, err := DefaultConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.Initialize(configYAML); err != nil {
+		return nil, err
+	}
+
+	b, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := map[interface{}]interface{}{}
+	if err := yaml.Unmarshal(b, &settings); err != nil {
+		return nil, err
+	}
+
+	for _, key := range ReloadableSettings {
+		deleteSetting(settings, strings.Split(key, "."))
+	}
+	return settings, nil
+}
+
+func deleteSetting(settings map[interface{}]interface{}, path []string) {
+	if len(path) == 1 {
+		delete(settings, path[0])
+		return
+	}
+
+	if nested, ok := settings[path[0]].(map[interface{}]interface{}); ok {
+		deleteSetting(nested, path[1:])
+	}
+}
+
+func diffSettings(prefix string, current, updated map[interface{}]interface{}) []string {
+	keys := map[interface{}]struct{}{}
+	for k := range current {
+		keys[k] = struct{}{}
+	}
+	for k := range updated {
+		keys[k] = struct{}{}
+	}
+
+	var changes []string
+	for k := range keys {
+		key, _ := k.(string)
+		key = prefix + key
+
+		currentNested, currentOK := current[k].(map[interface{}]interface{})
+		updatedNested, updatedOK := updated[k].(map[interface{}]interface{})
+		if currentOK && updatedOK {
+			changes = append(changes, diffSettings(key+".", currentNested, updatedNested)...)
+			continue
+		}
+
+		if !reflect.DeepEqual(current[k], updated[k]) {
+			changes = append(changes, key)
+		}
+	}
+	return changes
+}
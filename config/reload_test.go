@@ -0,0 +1,110 @@
+package config_test
+
+import (
+	. "code.cloudfoundry.org/gorouter/config"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RestartRequiredChanges", func() {
+	var current, next *Config
+
+	initialize := func(configYAML string) *Config {
+		c, err := DefaultConfig()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.Initialize([]byte(configYAML))).To(Succeed())
+		Expect(c.Process()).To(Succeed())
+		return c
+	}
+
+	BeforeEach(func() {
+		current = initialize(`
+port: 8081
+endpoint_timeout: 10s
+logging:
+  level: info
+  syslog: vcap.gorouter
+`)
+	})
+
+	It("returns no changes for the same document", func() {
+		next = initialize(`
+port: 8081
+endpoint_timeout: 10s
+logging:
+  level: info
+  syslog: vcap.gorouter
+`)
+		Expect(current.RestartRequiredChanges(next)).To(BeEmpty())
+	})
+
+	It("ignores changes to reloadable settings", func() {
+		next = initialize(`
+port: 8081
+endpoint_timeout: 20s
+balancing_algorithm: least-connection
+sticky_session_cookie_names: [SESSION]
+extra_headers_to_log: [X-Foo]
+logging:
+  level: debug
+  syslog: vcap.gorouter
+http_rewrite:
+  responses:
+    remove_headers:
+    - name: X-Vcap-Request-Id
+static_routes:
+- uris: [status.example.com]
+  endpoints:
+  - host: 10.0.0.5
+    port: 8080
+`)
+		Expect(current.RestartRequiredChanges(next)).To(BeEmpty())
+	})
+
+	It("ignores settings that are explicitly set to their default", func() {
+		next = initialize(`
+port: 8081
+endpoint_timeout: 10s
+enable_http2: true
+logging:
+  level: info
+  syslog: vcap.gorouter
+`)
+		Expect(current.RestartRequiredChanges(next)).To(BeEmpty())
+	})
+
+	It("does not count settings derived from reloadable ones as changes", func() {
+		next = initialize(`
+port: 8081
+endpoint_timeout: 20s
+logging:
+  level: info
+  syslog: vcap.gorouter
+`)
+		Expect(next.DrainTimeout).ToNot(Equal(current.DrainTimeout))
+		Expect(current.RestartRequiredChanges(next)).To(BeEmpty())
+	})
+
+	It("returns the changed settings that require a restart", func() {
+		next = initialize(`
+port: 8082
+endpoint_timeout: 10s
+enable_http2: false
+logging:
+  level: info
+  syslog: vcap.other
+tcp_routing:
+  idle_timeout: 1m
+backend_health_checks:
+  timeout: 3s
+`)
+		Expect(current.RestartRequiredChanges(next)).To(Equal([]string{
+			"backend_health_checks.timeout",
+			"enable_http2",
+			"logging.syslog",
+			"port",
+			"tcp_routing.idle_timeout",
+		}))
+	})
+})
@@ -0,0 +1,257 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// CertificateExpiryWarningPeriod is how long before their expiry Validate
+// starts warning about certificates.
+const CertificateExpiryWarningPeriod = 30 * 24 * time.Hour
+
+// Finding is a problem Validate found with a setting, given as a dotted YAML
+// key.
+type Finding struct {
+	Setting string `json:"setting"`
+	Message string `json:"message"`
+}
+
+// ValidationReport lists what is wrong with a configuration file. Errors keep
+// gorouter from starting or from serving traffic as configured; warnings are
+// settings that are accepted but most likely not what was intended.
+type ValidationReport struct {
+	File     string    `json:"file"`
+	Valid    bool      `json:"valid"`
+	Errors   []Finding `json:"errors"`
+	Warnings []Finding `json:"warnings"`
+}
+
+func (r *ValidationReport) addError(setting, format string, args ...interface{}) {
+	r.Errors = append(r.Errors, Finding{Setting: setting, Message: fmt.Sprintf(format, args...)})
+}
+
+func (r *ValidationReport) addWarning(setting, format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, Finding{Setting: setting, Message: fmt.Sprintf(format, args...)})
+}
+
+// ValidateFile loads the configuration file like InitConfigFromFile does and
+// checks it further for problems that only show once gorouter serves traffic.
+// Certificates are checked against now.
+func ValidateFile(path string, now time.Time) *ValidationReport {
+	report := &ValidationReport{
+		File:     path,
+		Errors:   []Finding{},
+		Warnings: []Finding{},
+	}
+	report.validate(path, now)
+	report.Valid = len(report.Errors) == 0
+	return report
+}
+
+func (r *ValidationReport) validate(path string, now time.Time) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		r.addError("", "%s", err)
+		return
+	}
+
+	configured, err := DefaultConfig()
+	if err != nil {
+		r.addError("", "%s", err)
+		return
+	}
+	if err := configured.Initialize(b); err != nil {
+		r.addError("", "%s", err)
+		return
+	}
+
+	c, err := DefaultConfig()
+	if err != nil {
+		r.addError("", "%s", err)
+		return
+	}
+	if err := c.Initialize(b); err != nil {
+		r.addError("", "%s", err)
+		return
+	}
+	if err := c.Process(); err != nil {
+		r.addError("", "%s", err)
+		return
+	}
+
+	r.checkCertificates(c, now)
+	r.checkCipherSuites(c)
+	r.checkIsolationSegments(c)
+	r.checkTimeouts(configured, c)
+}
+
+func (r *ValidationReport) checkCertificates(c *Config, now time.Time) {
+	if c.EnableSSL {
+		for i, cert := range c.SSLCertificates {
+			setting := fmt.Sprintf("tls_pem[%d]", i)
+			if leaf := r.checkCertificate(setting, cert, now); leaf != nil {
+				r.checkSubjectAltNames(setting, leaf)
+			}
+		}
+	}
+
+	clientCertificates := []struct {
+		setting string
+		cert    tls.Certificate
+	}{
+		{"backends", c.Backends.ClientAuthCertificate},
+		{"route_services", c.RouteServiceConfig.ClientAuthCertificate},
+		{"routing_api", c.RoutingApi.ClientAuthCertificate},
+	}
+	for _, cc := range clientCertificates {
+		if len(cc.cert.Certificate) > 0 {
+			r.checkCertificate(cc.setting, cc.cert, now)
+		}
+	}
+}
+
+func (r *ValidationReport) checkCertificate(setting string, cert tls.Certificate, now time.Time) *x509.Certificate {
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		r.addError(setting, "certificate cannot be parsed: %s", err)
+		return nil
+	}
+
+	name := leaf.Subject.CommonName
+	switch {
+	case now.After(leaf.NotAfter):
+		r.addError(setting, "certificate for %q expired on %s", name, leaf.NotAfter.Format(time.RFC3339))
+	case now.Before(leaf.NotBefore):
+		r.addError(setting, "certificate for %q is not valid before %s", name, leaf.NotBefore.Format(time.RFC3339))
+	case leaf.NotAfter.Sub(now) < CertificateExpiryWarningPeriod:
+		r.addWarning(setting, "certificate for %q expires on %s", name, leaf.NotAfter.Format(time.RFC3339))
+	}
+	return leaf
+}
+
+// checkSubjectAltNames warns about frontend certificates whose subject
+// alternative names do not cover their common name. Clients verify the server
+// name against the subject alternative names only, so such a certificate is
+// rejected for the name it was issued for.
+func (r *ValidationReport) checkSubjectAltNames(setting string, leaf *x509.Certificate) {
+	name := leaf.Subject.CommonName
+	switch {
+	case len(leaf.DNSNames) == 0 && len(leaf.IPAddresses) == 0:
+		r.addWarning(setting, "certificate for %q has no subject alternative names, clients that ignore the common name reject it", name)
+	case name != "" && leaf.VerifyHostname(name) != nil:
+		r.addWarning(setting, "certificate for %q has subject alternative names that do not cover its common name, clients that ignore the common name reject it for %q", name, name)
+	}
+}
+
+func (r *ValidationReport) checkCipherSuites(c *Config) {
+	if !c.EnableSSL {
+		return
+	}
+
+	var insecure []string
+	tls12Only := true
+	for _, suite := range c.CipherSuites {
+		if isInsecureCipherSuite(suite) {
+			insecure = append(insecure, tls.CipherSuiteName(suite))
+		}
+		if !isTLS12OnlyCipherSuite(suite) {
+			tls12Only = false
+		}
+	}
+	if len(insecure) > 0 {
+		r.addWarning("cipher_suites", "insecure cipher suites are enabled: %s", strings.Join(insecure, ", "))
+	}
+
+	if c.MinTLSVersion < tls.VersionTLS12 && tls12Only {
+		r.addWarning("min_tls_version", "%s is allowed but every cipher suite requires TLS 1.2, clients that do not support TLS 1.2 cannot connect", c.MinTLSVersionString)
+	}
+
+	for i, cert := range c.SSLCertificates {
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			continue
+		}
+
+		usable := false
+		for _, suite := range c.CipherSuites {
+			if cipherSuiteSupportsKey(suite, leaf.PublicKeyAlgorithm) {
+				usable = true
+				break
+			}
+		}
+		if usable {
+			continue
+		}
+
+		if c.MaxTLSVersion >= tls.VersionTLS13 {
+			r.addWarning(fmt.Sprintf("tls_pem[%d]", i), "no cipher suite can be used with the %s key of the certificate, it is only served to TLS 1.3 clients", leaf.PublicKeyAlgorithm)
+		} else {
+			r.addError(fmt.Sprintf("tls_pem[%d]", i), "no cipher suite can be used with the %s key of the certificate", leaf.PublicKeyAlgorithm)
+		}
+	}
+}
+
+func isInsecureCipherSuite(suite uint16) bool {
+	for _, s := range tls.InsecureCipherSuites() {
+		if s.ID == suite {
+			return true
+		}
+	}
+	return false
+}
+
+func isTLS12OnlyCipherSuite(suite uint16) bool {
+	name := tls.CipherSuiteName(suite)
+	return strings.Contains(name, "_GCM_") ||
+		strings.Contains(name, "_CHACHA20_") ||
+		strings.HasSuffix(name, "_SHA256")
+}
+
+func cipherSuiteSupportsKey(suite uint16, algorithm x509.PublicKeyAlgorithm) bool {
+	if strings.HasPrefix(tls.CipherSuiteName(suite), "TLS_ECDHE_ECDSA_") {
+		return algorithm == x509.ECDSA
+	}
+	return algorithm == x509.RSA
+}
+
+func (r *ValidationReport) checkIsolationSegments(c *Config) {
+	seen := map[string]bool{}
+	for _, segment := range c.IsolationSegments {
+		if strings.TrimSpace(segment) == "" {
+			r.addError("isolation_segments", "isolation segment names must not be empty")
+			continue
+		}
+		if seen[segment] {
+			r.addWarning("isolation_segments", "isolation segment %q is listed more than once", segment)
+		}
+		seen[segment] = true
+	}
+
+	if c.RoutingTableShardingMode == SHARD_ALL && len(c.IsolationSegments) > 0 {
+		r.addWarning("isolation_segments", "isolation segments are ignored when routing_table_sharding_mode is %s", SHARD_ALL)
+	}
+}
+
+// checkTimeouts compares the timeouts as configured, before Process adjusts
+// them, with the ones gorouter will run with.
+func (r *ValidationReport) checkTimeouts(configured, c *Config) {
+	if configured.DropletStaleThreshold < configured.StartResponseDelayInterval {
+		r.addWarning("droplet_stale_threshold", "%s is shorter than start_response_delay_interval and is raised to %s", configured.DropletStaleThreshold, c.DropletStaleThreshold)
+	}
+
+	if c.PruneStaleDropletsInterval > c.DropletStaleThreshold {
+		r.addWarning("prune_stale_droplets_interval", "%s is longer than droplet_stale_threshold (%s), stale routes keep being served until they are pruned", c.PruneStaleDropletsInterval, c.DropletStaleThreshold)
+	}
+
+	if c.DrainTimeout < c.EndpointTimeout {
+		r.addWarning("drain_timeout", "%s is shorter than endpoint_timeout (%s), requests in flight when draining ends are cut off", c.DrainTimeout, c.EndpointTimeout)
+	}
+
+	if c.EndpointDialTimeout >= c.EndpointTimeout {
+		r.addWarning("endpoint_timeout", "%s is not longer than the endpoint dial timeout (%s), requests can time out before a backend is connected", c.EndpointTimeout, c.EndpointDialTimeout)
+	}
+}
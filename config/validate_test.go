@@ -0,0 +1,254 @@
+package config_test
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ValidateFile", func() {
+	var (
+		configDir  string
+		configFile string
+		now        time.Time
+	)
+
+	indent := func(pem []byte) string {
+		return strings.Replace(strings.TrimSpace(string(pem)), "\n", "\n    ", -1)
+	}
+
+	sslConfig := func(certPEM, keyPEM []byte, extra string) string {
+		if !strings.Contains(extra, "cipher_suites:") {
+			extra += "cipher_suites: ECDHE-RSA-AES128-GCM-SHA256\n"
+		}
+		return fmt.Sprintf(`
+enable_ssl: true
+tls_pem:
+- cert_chain: |
+    %s
+  private_key: |
+    %s
+%s`, indent(certPEM), indent(keyPEM), extra)
+	}
+
+	validate := func(configYAML string) *ValidationReport {
+		Expect(ioutil.WriteFile(configFile, []byte(configYAML), 0600)).To(Succeed())
+		return ValidateFile(configFile, now)
+	}
+
+	settings := func(findings []Finding) []string {
+		var s []string
+		for _, f := range findings {
+			s = append(s, f.Setting)
+		}
+		return s
+	}
+
+	BeforeEach(func() {
+		var err error
+		configDir, err = ioutil.TempDir("", "config")
+		Expect(err).ToNot(HaveOccurred())
+		configFile = filepath.Join(configDir, "gorouter.yml")
+		now = time.Now()
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(configDir)
+	})
+
+	It("reports a valid configuration without findings", func() {
+		report := validate("port: 8081\n")
+		Expect(report.Valid).To(BeTrue())
+		Expect(report.File).To(Equal(configFile))
+		Expect(report.Errors).To(BeEmpty())
+		Expect(report.Warnings).To(BeEmpty())
+	})
+
+	It("reports files that cannot be read", func() {
+		report := ValidateFile(filepath.Join(configDir, "missing.yml"), now)
+		Expect(report.Valid).To(BeFalse())
+		Expect(report.Errors).To(HaveLen(1))
+	})
+
+	It("reports configurations that fail to load", func() {
+		report := validate("routing_table_sharding_mode: some-mode\n")
+		Expect(report.Valid).To(BeFalse())
+		Expect(report.Errors[0].Message).To(ContainSubstring("Invalid sharding mode"))
+	})
+
+	Describe("certificates", func() {
+		var keyPEM, certPEM []byte
+
+		BeforeEach(func() {
+			keyPEM, certPEM = test_util.CreateKeyPair("potato.com")
+		})
+
+		It("warns about certificates that expire soon", func() {
+			report := validate(sslConfig(certPEM, keyPEM, ""))
+			Expect(report.Valid).To(BeTrue())
+			Expect(report.Warnings).To(ConsistOf(Finding{
+				Setting: "tls_pem[0]",
+				Message: fmt.Sprintf(`certificate for "potato.com" expires on %s`, mustParseLeaf(certPEM).NotAfter.Format(time.RFC3339)),
+			}))
+		})
+
+		It("reports expired certificates", func() {
+			now = now.Add(2 * time.Hour)
+
+			report := validate(sslConfig(certPEM, keyPEM, ""))
+			Expect(report.Valid).To(BeFalse())
+			Expect(settings(report.Errors)).To(ConsistOf("tls_pem[0]"))
+			Expect(report.Errors[0].Message).To(ContainSubstring("expired on"))
+		})
+
+		It("reports certificates that are not valid yet", func() {
+			now = now.Add(-time.Hour)
+
+			report := validate(sslConfig(certPEM, keyPEM, ""))
+			Expect(report.Valid).To(BeFalse())
+			Expect(report.Errors[0].Message).To(ContainSubstring("is not valid before"))
+		})
+
+		It("warns about certificates without subject alternative names", func() {
+			certChain := test_util.CreateSignedCertWithRootCA(test_util.CertNames{CommonName: "spinach.com"})
+
+			report := validate(sslConfig(certChain.CertPEM, certChain.PrivKeyPEM, ""))
+			Expect(report.Warnings).To(ContainElement(Finding{
+				Setting: "tls_pem[0]",
+				Message: `certificate for "spinach.com" has no subject alternative names, clients that ignore the common name reject it`,
+			}))
+		})
+
+		It("warns about certificates whose subject alternative names do not cover the common name", func() {
+			certChain := test_util.CreateSignedCertWithRootCA(test_util.CertNames{
+				CommonName: "spinach.com",
+				SANs:       test_util.SubjectAltNames{DNS: "kale.com"},
+			})
+
+			report := validate(sslConfig(certChain.CertPEM, certChain.PrivKeyPEM, ""))
+			Expect(report.Warnings).To(ContainElement(Finding{
+				Setting: "tls_pem[0]",
+				Message: `certificate for "spinach.com" has subject alternative names that do not cover its common name, clients that ignore the common name reject it for "spinach.com"`,
+			}))
+		})
+
+		It("accepts certificates whose subject alternative names cover the common name with a wildcard", func() {
+			certChain := test_util.CreateSignedCertWithRootCA(test_util.CertNames{
+				CommonName: "www.spinach.com",
+				SANs:       test_util.SubjectAltNames{DNS: "*.spinach.com"},
+			})
+
+			report := validate(sslConfig(certChain.CertPEM, certChain.PrivKeyPEM, ""))
+			for _, warning := range report.Warnings {
+				Expect(warning.Message).NotTo(ContainSubstring("subject alternative names"))
+			}
+		})
+	})
+
+	Describe("cipher suites", func() {
+		var keyPEM, certPEM []byte
+
+		BeforeEach(func() {
+			keyPEM, certPEM = test_util.CreateKeyPair("potato.com")
+		})
+
+		It("warns about insecure cipher suites", func() {
+			report := validate(sslConfig(certPEM, keyPEM, "cipher_suites: ECDHE-RSA-RC4-SHA:ECDHE-RSA-AES128-GCM-SHA256\n"))
+			Expect(report.Warnings).To(ContainElement(Finding{
+				Setting: "cipher_suites",
+				Message: "insecure cipher suites are enabled: TLS_ECDHE_RSA_WITH_RC4_128_SHA",
+			}))
+		})
+
+		It("warns when old TLS versions are allowed but no cipher suite supports them", func() {
+			report := validate(sslConfig(certPEM, keyPEM, "min_tls_version: TLSv1.0\n"))
+			Expect(settings(report.Warnings)).To(ContainElement("min_tls_version"))
+		})
+
+		It("reports certificates that no cipher suite can be used with", func() {
+			report := validate(sslConfig(certPEM, keyPEM, "cipher_suites: ECDHE-ECDSA-AES128-GCM-SHA256\n"))
+			Expect(report.Valid).To(BeFalse())
+			Expect(report.Errors).To(ConsistOf(Finding{
+				Setting: "tls_pem[0]",
+				Message: "no cipher suite can be used with the RSA key of the certificate",
+			}))
+		})
+
+		It("only warns about them when TLS 1.3 is allowed", func() {
+			report := validate(sslConfig(certPEM, keyPEM, "cipher_suites: ECDHE-ECDSA-AES128-GCM-SHA256\nmax_tls_version: TLSv1.3\n"))
+			Expect(report.Valid).To(BeTrue())
+			Expect(report.Warnings).To(ContainElement(Finding{
+				Setting: "tls_pem[0]",
+				Message: "no cipher suite can be used with the RSA key of the certificate, it is only served to TLS 1.3 clients",
+			}))
+		})
+	})
+
+	Describe("isolation segments", func() {
+		It("warns about duplicated isolation segments", func() {
+			report := validate("routing_table_sharding_mode: segments\nisolation_segments: [is1, is1]\n")
+			Expect(report.Warnings).To(ConsistOf(Finding{
+				Setting: "isolation_segments",
+				Message: `isolation segment "is1" is listed more than once`,
+			}))
+		})
+
+		It("reports empty isolation segments", func() {
+			report := validate("routing_table_sharding_mode: segments\nisolation_segments: [is1, '']\n")
+			Expect(report.Valid).To(BeFalse())
+			Expect(settings(report.Errors)).To(ConsistOf("isolation_segments"))
+		})
+
+		It("warns when isolation segments are ignored", func() {
+			report := validate("routing_table_sharding_mode: all\nisolation_segments: [is1]\n")
+			Expect(report.Warnings).To(ConsistOf(Finding{
+				Setting: "isolation_segments",
+				Message: "isolation segments are ignored when routing_table_sharding_mode is all",
+			}))
+		})
+	})
+
+	Describe("timeouts", func() {
+		It("warns when droplet_stale_threshold is raised to start_response_delay_interval", func() {
+			report := validate("droplet_stale_threshold: 2s\nstart_response_delay_interval: 5s\nprune_stale_droplets_interval: 1s\n")
+			Expect(report.Warnings).To(ConsistOf(Finding{
+				Setting: "droplet_stale_threshold",
+				Message: "2s is shorter than start_response_delay_interval and is raised to 5s",
+			}))
+		})
+
+		It("warns when stale routes are pruned late", func() {
+			report := validate("droplet_stale_threshold: 10s\nprune_stale_droplets_interval: 1m\n")
+			Expect(settings(report.Warnings)).To(ConsistOf("prune_stale_droplets_interval"))
+		})
+
+		It("warns when draining ends before requests time out", func() {
+			report := validate("drain_timeout: 10s\nendpoint_timeout: 1m\n")
+			Expect(settings(report.Warnings)).To(ConsistOf("drain_timeout"))
+		})
+
+		It("warns when requests time out before a backend can be dialed", func() {
+			report := validate("endpoint_timeout: 1s\n")
+			Expect(settings(report.Warnings)).To(ConsistOf("endpoint_timeout"))
+		})
+	})
+})
+
+func mustParseLeaf(certPEM []byte) *x509.Certificate {
+	block, _ := pem.Decode(certPEM)
+	Expect(block).NotTo(BeNil())
+	cert, err := x509.ParseCertificate(block.Bytes)
+	Expect(err).NotTo(HaveOccurred())
+	return cert
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"encoding/hex"
 	"io"
 	"net/http"
 	"sync/atomic"
@@ -11,11 +12,14 @@ import (
 	router_http "code.cloudfoundry.org/gorouter/common/http"
 	"code.cloudfoundry.org/gorouter/logger"
 	"code.cloudfoundry.org/gorouter/proxy/utils"
+	"code.cloudfoundry.org/gorouter/tracing"
 
 	"github.com/uber-go/zap"
 	"github.com/urfave/negroni"
 )
 
+const grpcStatusHeader = "Grpc-Status"
+
 type accessLog struct {
 	accessLogger      accesslog.AccessLogger
 	extraHeadersToLog []string
@@ -65,10 +69,36 @@ func (a *accessLog) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http
 	alr.BodyBytesSent = proxyWriter.Size()
 	alr.StatusCode = proxyWriter.Status()
 	alr.RouterError = proxyWriter.Header().Get(router_http.CfRouterError)
+	alr.GRPCStatus = grpcStatus(proxyWriter.Header())
+	alr.HedgeWinner = reqInfo.HedgeWinner
+	alr.BackendGroup = reqInfo.BackendGroup
+	alr.TraceID, alr.SpanID = traceIDs(r)
 
 	a.accessLogger.Log(*alr)
 }
 
+// grpcStatus returns the grpc-status sent by the backend, which is either a
+// trailer, announced or not, or a header for trailers-only responses
+func grpcStatus(header http.Header) string {
+	if status := header.Get(grpcStatusHeader); status != "" {
+		return status
+	}
+	return header.Get(http.TrailerPrefix + grpcStatusHeader)
+}
+
+// traceIDs returns the ids of the span a request is traced with: the
+// OpenTelemetry span of the request if there is one, otherwise the W3C or
+// Zipkin ids sent to the backend.
+func traceIDs(r *http.Request) (string, string) {
+	if sc := tracing.SpanContextFromContext(r.Context()); sc.IsValid() {
+		return sc.TraceID().String(), sc.SpanID().String()
+	}
+	if traceparent := ParseW3CTraceparent(r.Header.Get(W3CTraceparentHeader)); traceparent != nil {
+		return hex.EncodeToString(traceparent.TraceID), hex.EncodeToString(traceparent.ParentID)
+	}
+	return r.Header.Get(B3TraceIdHeader), r.Header.Get(B3SpanIdHeader)
+}
+
 type countingReadCloser struct {
 	delegate io.ReadCloser
 	count    uint32
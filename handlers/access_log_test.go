@@ -143,6 +143,48 @@ var _ = Describe("AccessLog", func() {
 		})
 	})
 
+	Context("when the request was hedged", func() {
+		BeforeEach(func() {
+			hedgeHandler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				reqInfo, err := handlers.ContextRequestInfo(req)
+				if err == nil {
+					reqInfo.HedgeWinner = "hedge"
+				}
+			})
+
+			handler.UseHandlerFunc(hedgeHandler)
+		})
+		It("logs the attempt that won", func() {
+			handler.ServeHTTP(resp, req)
+
+			Expect(accessLogger.LogCallCount()).To(Equal(1))
+
+			alr := accessLogger.LogArgsForCall(0)
+			Expect(alr.HedgeWinner).To(Equal("hedge"))
+		})
+	})
+
+	Context("when the route split its traffic between apps", func() {
+		BeforeEach(func() {
+			groupHandler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				reqInfo, err := handlers.ContextRequestInfo(req)
+				if err == nil {
+					reqInfo.BackendGroup = "green"
+				}
+			})
+
+			handler.UseHandlerFunc(groupHandler)
+		})
+		It("logs the backend group", func() {
+			handler.ServeHTTP(resp, req)
+
+			Expect(accessLogger.LogCallCount()).To(Equal(1))
+
+			alr := accessLogger.LogArgsForCall(0)
+			Expect(alr.BackendGroup).To(Equal("green"))
+		})
+	})
+
 	Context("when request info is not set on the request context", func() {
 		BeforeEach(func() {
 			handler = negroni.New()
@@ -171,4 +213,68 @@ var _ = Describe("AccessLog", func() {
 		})
 	})
 
+	Context("when the response carries a Grpc-Status header", func() {
+		BeforeEach(func() {
+			resp.Header().Add("Grpc-Status", "0")
+		})
+
+		It("logs the grpc status", func() {
+			handler.ServeHTTP(resp, req)
+			Expect(accessLogger.LogCallCount()).To(Equal(1))
+
+			alr := accessLogger.LogArgsForCall(0)
+
+			Expect(alr.GRPCStatus).To(Equal("0"))
+		})
+	})
+
+	Context("when the response carries a Grpc-Status trailer", func() {
+		BeforeEach(func() {
+			resp.Header().Add(http.TrailerPrefix+"Grpc-Status", "14")
+		})
+
+		It("logs the grpc status", func() {
+			handler.ServeHTTP(resp, req)
+			Expect(accessLogger.LogCallCount()).To(Equal(1))
+
+			alr := accessLogger.LogArgsForCall(0)
+
+			Expect(alr.GRPCStatus).To(Equal("14"))
+		})
+	})
+
+	Context("when the request carries a traceparent header", func() {
+		BeforeEach(func() {
+			req.Header.Set(handlers.W3CTraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+			req.Header.Set(handlers.B3TraceIdHeader, "ignored-trace-id")
+		})
+
+		It("logs the trace and span ids", func() {
+			handler.ServeHTTP(resp, req)
+			Expect(accessLogger.LogCallCount()).To(Equal(1))
+
+			alr := accessLogger.LogArgsForCall(0)
+
+			Expect(alr.TraceID).To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+			Expect(alr.SpanID).To(Equal("00f067aa0ba902b7"))
+		})
+	})
+
+	Context("when the request carries zipkin headers", func() {
+		BeforeEach(func() {
+			req.Header.Set(handlers.B3TraceIdHeader, "463ac35c9f6413ad")
+			req.Header.Set(handlers.B3SpanIdHeader, "a2fb4a1d1a96d312")
+		})
+
+		It("logs the trace and span ids", func() {
+			handler.ServeHTTP(resp, req)
+			Expect(accessLogger.LogCallCount()).To(Equal(1))
+
+			alr := accessLogger.LogArgsForCall(0)
+
+			Expect(alr.TraceID).To(Equal("463ac35c9f6413ad"))
+			Expect(alr.SpanID).To(Equal("a2fb4a1d1a96d312"))
+		})
+	})
+
 })
@@ -13,6 +13,7 @@ import (
 	"code.cloudfoundry.org/gorouter/metrics"
 	"code.cloudfoundry.org/gorouter/registry"
 	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/gorouter/tracing"
 	"github.com/uber-go/zap"
 	"github.com/urfave/negroni"
 )
@@ -73,7 +74,13 @@ func (l *lookupHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request, next
 		return
 	}
 
+	_, span := tracing.StartSpan(r.Context(), "route lookup")
 	pool, err := l.lookup(r)
+	if pool != nil {
+		span.SetAttributes(tracing.String("route.host", pool.Host()), tracing.String("route.context_path", pool.ContextPath()))
+	}
+	span.End()
+
 	if _, ok := err.(InvalidInstanceHeaderError); ok {
 		l.handleInvalidInstanceHeader(rw, r)
 		return
@@ -105,6 +112,7 @@ func (l *lookupHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request, next
 		return
 	}
 	requestInfo.RoutePool = pool
+	requestInfo.BackendGroup = pool.BackendGroup(r)
 	next(rw, r)
 }
 
@@ -280,6 +280,31 @@ var _ = Describe("Lookup", func() {
 			})
 		})
 
+		Context("when the route splits its traffic between apps", func() {
+			BeforeEach(func() {
+				pool := route.NewPool(&route.PoolOpts{
+					Logger:            logger,
+					RetryAfterFailure: 2 * time.Minute,
+					Host:              "example.com",
+					ContextPath:       "/",
+				})
+				canary, err := route.ParseTrafficMatch("header:X-Canary=true")
+				Expect(err).NotTo(HaveOccurred())
+				pool.Put(route.NewEndpoint(&route.EndpointOpts{AppId: "blue", Host: "1.3.5.6", Port: 5679}))
+				pool.Put(route.NewEndpoint(&route.EndpointOpts{AppId: "green", Host: "1.2.3.6", Port: 5679, TrafficMatch: canary}))
+				reg.LookupReturns(pool)
+
+				req.Header.Set("X-Canary", "true")
+			})
+
+			It("picks the backend group of the request", func() {
+				Expect(nextCalled).To(BeTrue())
+				requestInfo, err := handlers.ContextRequestInfo(nextRequest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(requestInfo.BackendGroup).To(Equal("green"))
+			})
+		})
+
 		Context("when conn limit is reached for an endpoint", func() {
 			BeforeEach(func() {
 				pool := route.NewPool(&route.PoolOpts{
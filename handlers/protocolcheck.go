@@ -16,19 +16,22 @@ import (
 type protocolCheck struct {
 	logger      logger.Logger
 	errorWriter errorwriter.ErrorWriter
+	enableHTTP2 bool
 }
 
 // NewProtocolCheck creates a handler responsible for checking the protocol of
-// the request
-func NewProtocolCheck(logger logger.Logger, errorWriter errorwriter.ErrorWriter) negroni.Handler {
+// the request. HTTP/2 is only accepted when enableHTTP2 is set and the
+// request arrived over TLS, as it is only ever negotiated via ALPN.
+func NewProtocolCheck(logger logger.Logger, errorWriter errorwriter.ErrorWriter, enableHTTP2 bool) negroni.Handler {
 	return &protocolCheck{
 		logger:      logger,
 		errorWriter: errorWriter,
+		enableHTTP2: enableHTTP2,
 	}
 }
 
 func (p *protocolCheck) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
-	if !isProtocolSupported(r) {
+	if !p.isProtocolSupported(r) {
 		// must be hijacked, otherwise no response is sent back
 		conn, buf, err := p.hijack(rw)
 		if err != nil {
@@ -58,6 +61,9 @@ func (p *protocolCheck) hijack(rw http.ResponseWriter) (net.Conn, *bufio.ReadWri
 	return hijacker.Hijack()
 }
 
-func isProtocolSupported(request *http.Request) bool {
+func (p *protocolCheck) isProtocolSupported(request *http.Request) bool {
+	if p.enableHTTP2 && request.TLS != nil && request.ProtoMajor == 2 && request.ProtoMinor == 0 {
+		return true
+	}
 	return request.ProtoMajor == 1 && (request.ProtoMinor == 0 || request.ProtoMinor == 1)
 }
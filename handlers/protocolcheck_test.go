@@ -2,6 +2,7 @@ package handlers_test
 
 import (
 	"bufio"
+	"crypto/tls"
 	"net"
 	"net/http"
 
@@ -21,27 +22,35 @@ var _ = Describe("Protocolcheck", func() {
 		logger logger.Logger
 		ew     = errorwriter.NewPlaintextErrorWriter()
 
-		nextCalled bool
-		server     *ghttp.Server
-		n          *negroni.Negroni
+		nextCalled  bool
+		enableHTTP2 bool
+		server      *ghttp.Server
+		n           *negroni.Negroni
 	)
 
 	BeforeEach(func() {
 		logger = test_util.NewTestZapLogger("protocolcheck")
 		nextCalled = false
+		enableHTTP2 = false
+		server = ghttp.NewUnstartedServer()
+	})
 
+	JustBeforeEach(func() {
 		n = negroni.New()
 		n.UseFunc(func(rw http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
 			next(rw, req)
 		})
-		n.Use(handlers.NewProtocolCheck(logger, ew))
+		n.Use(handlers.NewProtocolCheck(logger, ew, enableHTTP2))
 		n.UseHandlerFunc(func(http.ResponseWriter, *http.Request) {
 			nextCalled = true
 		})
 
-		server = ghttp.NewUnstartedServer()
 		server.AppendHandlers(n.ServeHTTP)
-		server.Start()
+		if server.HTTPTestServer.EnableHTTP2 {
+			server.HTTPTestServer.StartTLS()
+		} else {
+			server.Start()
+		}
 	})
 
 	AfterEach(func() {
@@ -107,5 +116,65 @@ var _ = Describe("Protocolcheck", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
 		})
+
+		Context("when http2 is enabled", func() {
+			BeforeEach(func() {
+				enableHTTP2 = true
+			})
+
+			It("still rejects cleartext http2 with a 400 bad request", func() {
+				conn, err := net.Dial("tcp", server.Addr())
+				Expect(err).ToNot(HaveOccurred())
+				respReader := bufio.NewReader(conn)
+
+				conn.Write([]byte("PRI * HTTP/2.0\r\nHost: example.com\r\n\r\n"))
+
+				resp, err := http.ReadResponse(respReader, nil)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+				Expect(nextCalled).To(BeFalse())
+			})
+
+			Context("when the request is negotiated over TLS", func() {
+				BeforeEach(func() {
+					server.HTTPTestServer.EnableHTTP2 = true
+				})
+
+				It("passes the request through", func() {
+					client := &http.Client{Transport: &http.Transport{
+						TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+						ForceAttemptHTTP2: true,
+					}}
+
+					resp, err := client.Get(server.URL())
+					Expect(err).ToNot(HaveOccurred())
+					defer resp.Body.Close()
+
+					Expect(resp.ProtoMajor).To(Equal(2))
+					Expect(resp.StatusCode).To(Equal(200))
+					Expect(nextCalled).To(BeTrue())
+				})
+			})
+		})
+
+		Context("when http2 is disabled and the request is negotiated over TLS", func() {
+			BeforeEach(func() {
+				server.HTTPTestServer.EnableHTTP2 = true
+			})
+
+			It("returns a 400 bad request", func() {
+				client := &http.Client{Transport: &http.Transport{
+					TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+					ForceAttemptHTTP2: true,
+				}}
+
+				resp, err := client.Get(server.URL())
+				Expect(err).ToNot(HaveOccurred())
+				defer resp.Body.Close()
+
+				Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+				Expect(nextCalled).To(BeFalse())
+			})
+		})
 	})
 })
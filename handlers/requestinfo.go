@@ -28,6 +28,12 @@ type RequestInfo struct {
 	RouteServiceURL                           *url.URL
 	ShouldRouteToInternalRouteService         bool
 
+	// BackendGroup is the app of the route the request is sent to, when the
+	// route splits its traffic between apps
+	BackendGroup string
+	// HedgeWinner is the attempt of a hedged request that got the response
+	HedgeWinner string
+
 	BackendReqHeaders http.Header
 }
 
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/urfave/negroni"
+
+	"code.cloudfoundry.org/gorouter/proxy/utils"
+	"code.cloudfoundry.org/gorouter/tracing"
+)
+
+// Tracing is a handler that starts a server span for every request. The span
+// continues the trace of the traceparent header the request arrived with.
+type Tracing struct {
+	tracerProvider *tracing.TracerProvider
+}
+
+var _ negroni.Handler = new(Tracing)
+
+// NewTracing creates a new handler that starts a span for every request
+func NewTracing(tracerProvider *tracing.TracerProvider) *Tracing {
+	return &Tracing{
+		tracerProvider: tracerProvider,
+	}
+}
+
+func (t *Tracing) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	ctx := tracing.Extract(r.Context(), r.Header)
+	ctx, span := t.tracerProvider.Start(ctx, r.Method,
+		tracing.WithSpanKind(tracing.SpanKindServer),
+		tracing.WithAttributes(
+			tracing.String("http.method", r.Method),
+			tracing.String("http.host", r.Host),
+			tracing.String("http.target", r.URL.RequestURI()),
+			tracing.String("http.flavor", r.Proto),
+			tracing.String("http.user_agent", r.UserAgent()),
+			tracing.String("net.peer.ip", hostWithoutPort(r.RemoteAddr)),
+			tracing.String("cf.vcap_request_id", r.Header.Get(VcapRequestIdHeader)),
+		),
+	)
+	defer span.End()
+
+	next(rw, r.WithContext(ctx))
+
+	if reqInfo, err := ContextRequestInfo(r); err == nil && reqInfo.RouteEndpoint != nil {
+		span.SetAttributes(tracing.String("cf.app_id", reqInfo.RouteEndpoint.ApplicationId))
+	}
+
+	proxyWriter := rw.(utils.ProxyResponseWriter)
+	tracing.SetStatusCode(span, proxyWriter.Status())
+}
@@ -0,0 +1,91 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"code.cloudfoundry.org/gorouter/handlers"
+	logger_fakes "code.cloudfoundry.org/gorouter/logger/fakes"
+	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/gorouter/test_util"
+	"code.cloudfoundry.org/gorouter/tracing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/negroni"
+)
+
+var _ = Describe("Tracing", func() {
+	var (
+		handler      *negroni.Negroni
+		spanRecorder *tracing.SpanRecorder
+		status       int
+		nextSpan     tracing.SpanContext
+
+		resp *httptest.ResponseRecorder
+		req  *http.Request
+	)
+
+	BeforeEach(func() {
+		spanRecorder = tracing.NewSpanRecorder()
+		status = http.StatusTeapot
+		req = test_util.NewRequest("GET", "example.com", "/path?query=1", nil)
+		resp = httptest.NewRecorder()
+	})
+
+	JustBeforeEach(func() {
+		handler = negroni.New()
+		handler.Use(handlers.NewRequestInfo())
+		handler.Use(handlers.NewProxyWriter(new(logger_fakes.FakeLogger)))
+		handler.Use(handlers.NewTracing(tracing.NewTracerProviderWithProcessor(spanRecorder)))
+		handler.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			nextSpan = tracing.SpanContextFromContext(r.Context())
+
+			reqInfo, err := handlers.ContextRequestInfo(r)
+			Expect(err).NotTo(HaveOccurred())
+			reqInfo.RouteEndpoint = route.NewEndpoint(&route.EndpointOpts{AppId: "app-guid"})
+
+			rw.WriteHeader(status)
+		})
+	})
+
+	It("records a server span for the request", func() {
+		handler.ServeHTTP(resp, req)
+
+		Expect(spanRecorder.Ended()).To(HaveLen(1))
+		span := spanRecorder.Ended()[0]
+		Expect(span.Name()).To(Equal("GET"))
+		Expect(span.SpanKind()).To(Equal(tracing.SpanKindServer))
+		Expect(span.SpanContext()).To(Equal(nextSpan))
+		Expect(span.Parent().IsValid()).To(BeFalse())
+
+		Expect(span.Attribute("http.host")).To(Equal("example.com"))
+		Expect(span.Attribute("http.target")).To(Equal("/path?query=1"))
+		Expect(span.Attribute("http.status_code")).To(Equal(int64(http.StatusTeapot)))
+		Expect(span.Attribute("cf.app_id")).To(Equal("app-guid"))
+		Expect(span.Status().Code).To(Equal(tracing.StatusUnset))
+	})
+
+	It("continues the trace of the traceparent header", func() {
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+		handler.ServeHTTP(resp, req)
+
+		span := spanRecorder.Ended()[0]
+		Expect(span.SpanContext().TraceID().String()).To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+		Expect(span.Parent().SpanID().String()).To(Equal("00f067aa0ba902b7"))
+		Expect(span.Parent().IsRemote()).To(BeTrue())
+	})
+
+	Context("when the response is a server error", func() {
+		BeforeEach(func() {
+			status = http.StatusBadGateway
+		})
+
+		It("marks the span as failed", func() {
+			handler.ServeHTTP(resp, req)
+
+			Expect(spanRecorder.Ended()[0].Status().Code).To(Equal(tracing.StatusError))
+		})
+	})
+})
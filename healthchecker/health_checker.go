@@ -0,0 +1,281 @@
+package healthchecker
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/uber-go/zap"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/logger"
+	"code.cloudfoundry.org/gorouter/metrics"
+	"code.cloudfoundry.org/gorouter/proxy/handler"
+	"code.cloudfoundry.org/gorouter/proxy/utils"
+	"code.cloudfoundry.org/gorouter/route"
+)
+
+const userAgent = "gorouter-health-checker"
+
+// Registry lists the routes whose endpoints are checked.
+type Registry interface {
+	Pools() []*route.EndpointPool
+}
+
+// HealthChecker periodically checks every endpoint of the routing table and
+// records the results in the pools of the endpoint, which mark endpoints
+// that keep failing their checks unhealthy. Endpoints registered with a
+// health check path are checked with a GET of that path, which must answer
+// with a 2xx status code; all other endpoints are checked by opening a
+// connection to them. Like proxied requests, the GET speaks TLS to TLS
+// endpoints and HTTP/2 to endpoints registered with the http2 protocol.
+type HealthChecker struct {
+	registry Registry
+	reporter metrics.HealthCheckReporter
+	logger   logger.Logger
+
+	dial      handler.EndpointDialer
+	rawDial   handler.EndpointDialer
+	tlsConfig *tls.Config
+
+	interval           time.Duration
+	timeout            time.Duration
+	healthyThreshold   int
+	unhealthyThreshold int
+	maxConcurrent      int
+}
+
+// target is an endpoint to check, together with the pools of every route it
+// is registered for.
+type target struct {
+	endpoint *route.Endpoint
+	pools    []*route.EndpointPool
+}
+
+func NewHealthChecker(
+	logger logger.Logger,
+	cfg *config.Config,
+	registry Registry,
+	reporter metrics.HealthCheckReporter,
+	backendTLSConfig *tls.Config,
+) *HealthChecker {
+	c := cfg.BackendHealthChecks
+	if backendTLSConfig == nil {
+		backendTLSConfig = &tls.Config{}
+	}
+	return &HealthChecker{
+		registry:           registry,
+		reporter:           reporter,
+		logger:             logger,
+		dial:               handler.NewEndpointDialer(c.Timeout, backendTLSConfig),
+		rawDial:            handler.NewRawEndpointDialer(c.Timeout),
+		tlsConfig:          backendTLSConfig,
+		interval:           c.Interval,
+		timeout:            c.Timeout,
+		healthyThreshold:   c.HealthyThreshold,
+		unhealthyThreshold: c.UnhealthyThreshold,
+		maxConcurrent:      c.MaxConcurrent,
+	}
+}
+
+func (h *HealthChecker) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	close(done)
+
+	close(ready)
+	for {
+		select {
+		case <-ticker.C:
+			select {
+			case <-done:
+				done = make(chan struct{})
+				go func(done chan struct{}) {
+					h.checkAll(stop)
+					close(done)
+				}(done)
+			default:
+				h.logger.Info("health-checks-skipped", zap.String("reason", "previous checks still running"))
+			}
+		case <-signals:
+			close(stop)
+			<-done
+			h.logger.Info("exited")
+			return nil
+		}
+	}
+}
+
+// checkAll checks every endpoint of the routing table once, with up to
+// maxConcurrent checks at a time. Endpoints not checked yet when stop is
+// closed are skipped.
+func (h *HealthChecker) checkAll(stop <-chan struct{}) {
+	targets := h.targets()
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		unhealthy int
+	)
+	work := make(chan *target)
+	for i := 0; i < h.maxConcurrent; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range work {
+				if !h.check(t) {
+					mu.Lock()
+					unhealthy++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for _, t := range targets {
+		select {
+		case work <- t:
+		case <-stop:
+			break dispatch
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	h.reporter.CaptureUnhealthyBackends(unhealthy)
+}
+
+// targets returns the endpoints of the routing table. An endpoint that is
+// registered for several routes is checked once for all of them.
+func (h *HealthChecker) targets() []*target {
+	var targets []*target
+	byKey := map[string]*target{}
+
+	for _, pool := range h.registry.Pools() {
+		pool.Each(func(e *route.Endpoint) {
+			key := e.CanonicalAddr() + e.HealthCheckPath
+			t, ok := byKey[key]
+			if !ok {
+				t = &target{endpoint: e}
+				byKey[key] = t
+				targets = append(targets, t)
+			}
+			t.pools = append(t.pools, pool)
+		})
+	}
+	return targets
+}
+
+// check checks the endpoint of the target, records the result in its pools
+// and reports whether the endpoint is healthy.
+func (h *HealthChecker) check(t *target) bool {
+	err := h.probe(t.endpoint)
+	h.reporter.CaptureBackendHealthCheck(err == nil)
+
+	healthy, changed := true, false
+	for _, pool := range t.pools {
+		health, c := pool.HealthCheckResult(t.endpoint, err, h.healthyThreshold, h.unhealthyThreshold)
+		if health == route.HealthUnhealthy {
+			healthy = false
+		}
+		changed = changed || c
+	}
+
+	if changed {
+		logger := h.logger.With(zap.Nest("route-endpoint", t.endpoint.ToLogData()...))
+		if healthy {
+			logger.Info("endpoint-marked-healthy")
+		} else {
+			logger.Error("endpoint-marked-unhealthy", zap.Error(err))
+		}
+	}
+	return healthy
+}
+
+func (h *HealthChecker) probe(e *route.Endpoint) error {
+	// the router does not terminate TLS for passthrough endpoints, so they
+	// only get a plain TCP check
+	if e.TLSPassthrough {
+		conn, err := h.rawDial(e)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	if e.HealthCheckPath == "" {
+		conn, err := h.dial(e)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	return h.get(e)
+}
+
+// get sends a GET request for the health check path of the endpoint and
+// checks the status code of the response.
+func (h *HealthChecker) get(e *route.Endpoint) error {
+	transport := h.transport(e)
+	defer transport.CloseIdleConnections()
+
+	req, err := http.NewRequest("GET", scheme(e)+"://"+e.CanonicalAddr()+e.HealthCheckPath, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	client := &http.Client{Transport: transport, Timeout: h.timeout}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code %d", res.StatusCode)
+	}
+	return nil
+}
+
+// transport returns a transport for a single health check of the endpoint,
+// set up like the round trippers of the proxy: HTTP/2 endpoints get h2 over
+// TLS, negotiated via ALPN, or h2c with prior knowledge, and all others
+// HTTP/1.1.
+func (h *HealthChecker) transport(e *route.Endpoint) *http.Transport {
+	dialer := &net.Dialer{Timeout: h.timeout}
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSClientConfig:     utils.TLSConfigWithServerName(e.ServerCertDomainSAN, h.tlsConfig),
+		TLSHandshakeTimeout: h.timeout,
+		DisableKeepAlives:   true,
+	}
+
+	if e.IsHTTP2() {
+		protocols := new(http.Protocols)
+		if e.IsTLS() {
+			protocols.SetHTTP1(true)
+			protocols.SetHTTP2(true)
+		} else {
+			protocols.SetUnencryptedHTTP2(true)
+		}
+		transport.Protocols = protocols
+	}
+	return transport
+}
+
+func scheme(e *route.Endpoint) string {
+	if e.IsTLS() {
+		return "https"
+	}
+	return "http"
+}
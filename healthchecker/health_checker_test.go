@@ -0,0 +1,272 @@
+package healthchecker_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/healthchecker"
+	"code.cloudfoundry.org/gorouter/metrics/fakes"
+	"code.cloudfoundry.org/gorouter/registry"
+	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+)
+
+var _ = Describe("HealthChecker", func() {
+	var (
+		cfg              *config.Config
+		r                *registry.RouteRegistry
+		reporter         *fakes.FakeHealthCheckReporter
+		backendTLSConfig *tls.Config
+		process          ifrit.Process
+	)
+
+	endpointOpts := func(addr, healthCheckPath string) *route.EndpointOpts {
+		host, port, err := net.SplitHostPort(addr)
+		Expect(err).NotTo(HaveOccurred())
+		p, err := strconv.Atoi(port)
+		Expect(err).NotTo(HaveOccurred())
+
+		return &route.EndpointOpts{
+			Host:            host,
+			Port:            uint16(p),
+			HealthCheckPath: healthCheckPath,
+		}
+	}
+
+	newEndpoint := func(addr, healthCheckPath string) *route.Endpoint {
+		return route.NewEndpoint(endpointOpts(addr, healthCheckPath))
+	}
+
+	healthCheck := func(uri route.Uri) func() string {
+		return func() string {
+			b, err := r.Lookup(uri).MarshalJSON()
+			Expect(err).NotTo(HaveOccurred())
+			return string(b)
+		}
+	}
+
+	unhealthyBackends := func() int {
+		n := reporter.CaptureUnhealthyBackendsCallCount()
+		if n == 0 {
+			return -1
+		}
+		return reporter.CaptureUnhealthyBackendsArgsForCall(n - 1)
+	}
+
+	BeforeEach(func() {
+		var err error
+		cfg, err = config.DefaultConfig()
+		Expect(err).NotTo(HaveOccurred())
+		cfg.BackendHealthChecks = config.BackendHealthCheckConfig{
+			Enabled:            true,
+			Interval:           50 * time.Millisecond,
+			Timeout:            50 * time.Millisecond,
+			HealthyThreshold:   1,
+			UnhealthyThreshold: 1,
+			MaxConcurrent:      2,
+		}
+
+		r = registry.NewRouteRegistry(test_util.NewTestZapLogger("registry"), cfg, new(fakes.FakeRouteRegistryReporter))
+		reporter = new(fakes.FakeHealthCheckReporter)
+		backendTLSConfig = nil
+	})
+
+	JustBeforeEach(func() {
+		checker := healthchecker.NewHealthChecker(test_util.NewTestZapLogger("health-checker"), cfg, r, reporter, backendTLSConfig)
+		process = ifrit.Invoke(checker)
+		Eventually(process.Ready()).Should(BeClosed())
+	})
+
+	AfterEach(func() {
+		process.Signal(os.Interrupt)
+		Eventually(process.Wait()).Should(Receive())
+	})
+
+	Context("when the endpoint has a health check path", func() {
+		var (
+			server     *httptest.Server
+			statusCode int64
+			requests   chan *http.Request
+		)
+
+		BeforeEach(func() {
+			statusCode = http.StatusOK
+			requests = make(chan *http.Request, 100)
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				requests <- req
+				w.WriteHeader(int(atomic.LoadInt64(&statusCode)))
+			}))
+
+			r.Register("app.example.com", newEndpoint(server.Listener.Addr().String(), "/health"))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("gets the health check path", func() {
+			var req *http.Request
+			Eventually(requests).Should(Receive(&req))
+			Expect(req.Method).To(Equal("GET"))
+			Expect(req.URL.Path).To(Equal("/health"))
+			Expect(req.UserAgent()).To(Equal("gorouter-health-checker"))
+
+			Eventually(healthCheck("app.example.com")).Should(ContainSubstring(`"health_check":{"status":"healthy"`))
+			Eventually(reporter.CaptureBackendHealthCheckCallCount).Should(BeNumerically(">", 0))
+			Expect(reporter.CaptureBackendHealthCheckArgsForCall(0)).To(BeTrue())
+		})
+
+		It("marks the endpoint unhealthy while it answers with an error", func() {
+			atomic.StoreInt64(&statusCode, http.StatusServiceUnavailable)
+			Eventually(healthCheck("app.example.com")).Should(ContainSubstring(`"status":"unhealthy","checked_at":`))
+			Expect(healthCheck("app.example.com")()).To(ContainSubstring(`"error":"unexpected status code 503"`))
+			Eventually(unhealthyBackends).Should(Equal(1))
+
+			atomic.StoreInt64(&statusCode, http.StatusOK)
+			Eventually(healthCheck("app.example.com")).Should(ContainSubstring(`"health_check":{"status":"healthy"`))
+		})
+	})
+
+	Context("when the endpoint speaks TLS", func() {
+		var (
+			server *httptest.Server
+			protos chan string
+		)
+
+		BeforeEach(func() {
+			protos = make(chan string, 100)
+			server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				protos <- req.Proto
+			}))
+			server.EnableHTTP2 = true
+			server.StartTLS()
+
+			rootCAs := x509.NewCertPool()
+			rootCAs.AddCert(server.Certificate())
+			backendTLSConfig = &tls.Config{RootCAs: rootCAs}
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("gets the health check path over TLS", func() {
+			opts := endpointOpts(server.Listener.Addr().String(), "/health")
+			opts.UseTLS = true
+			r.Register("tls.example.com", route.NewEndpoint(opts))
+
+			Eventually(protos).Should(Receive(Equal("HTTP/1.1")))
+			Eventually(healthCheck("tls.example.com")).Should(ContainSubstring(`"health_check":{"status":"healthy"`))
+		})
+
+		It("gets the health check path with h2 when the endpoint speaks HTTP/2", func() {
+			opts := endpointOpts(server.Listener.Addr().String(), "/health")
+			opts.UseTLS = true
+			opts.Protocol = route.ProtocolHTTP2
+			r.Register("h2.example.com", route.NewEndpoint(opts))
+
+			Eventually(protos).Should(Receive(Equal("HTTP/2.0")))
+			Eventually(healthCheck("h2.example.com")).Should(ContainSubstring(`"health_check":{"status":"healthy"`))
+		})
+	})
+
+	Context("when the endpoint speaks h2c", func() {
+		var (
+			server *httptest.Server
+			protos chan string
+		)
+
+		BeforeEach(func() {
+			protos = make(chan string, 100)
+			server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				protos <- req.Proto
+			}))
+			// only accept HTTP/2 with prior knowledge, like gRPC servers
+			protocols := new(http.Protocols)
+			protocols.SetUnencryptedHTTP2(true)
+			server.Config.Protocols = protocols
+			server.Start()
+
+			opts := endpointOpts(server.Listener.Addr().String(), "/health")
+			opts.Protocol = route.ProtocolHTTP2
+			r.Register("h2c.example.com", route.NewEndpoint(opts))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("gets the health check path with h2c", func() {
+			Eventually(protos).Should(Receive(Equal("HTTP/2.0")))
+			Eventually(healthCheck("h2c.example.com")).Should(ContainSubstring(`"health_check":{"status":"healthy"`))
+		})
+	})
+
+	Context("when the endpoint has no health check path", func() {
+		var listener net.Listener
+
+		BeforeEach(func() {
+			var err error
+			listener, err = net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			go func() {
+				for {
+					conn, err := listener.Accept()
+					if err != nil {
+						return
+					}
+					conn.Close()
+				}
+			}()
+
+			r.Register("tcp.example.com", newEndpoint(listener.Addr().String(), ""))
+		})
+
+		AfterEach(func() {
+			listener.Close()
+		})
+
+		It("marks the endpoint healthy while it accepts connections", func() {
+			Eventually(healthCheck("tcp.example.com")).Should(ContainSubstring(`"health_check":{"status":"healthy"`))
+
+			listener.Close()
+			Eventually(healthCheck("tcp.example.com")).Should(ContainSubstring(`"health_check":{"status":"unhealthy"`))
+		})
+	})
+
+	Context("when an endpoint is registered for several routes", func() {
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			}))
+
+			endpoint := newEndpoint(server.Listener.Addr().String(), "/health")
+			r.Register("one.example.com", endpoint)
+			r.Register("two.example.com", endpoint)
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("checks it once for all of them", func() {
+			Eventually(healthCheck("one.example.com")).Should(ContainSubstring(`"status":"unhealthy"`))
+			Eventually(healthCheck("two.example.com")).Should(ContainSubstring(`"status":"unhealthy"`))
+			Eventually(unhealthyBackends).Should(Equal(1))
+		})
+	})
+})
@@ -0,0 +1,14 @@
+package healthchecker_test
+
+import (
+	"code.cloudfoundry.org/gorouter/test_util"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestHealthChecker(t *testing.T) {
+	RegisterFailHandler(Fail)
+	test_util.RunSpecWithHoneyCombReporter(t, "HealthChecker Suite")
+}
@@ -14,6 +14,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 
 	"code.cloudfoundry.org/gorouter/config"
@@ -292,6 +293,16 @@ func (s *testState) StartGorouterOrFail() {
 	Expect(err).ToNot(HaveOccurred())
 }
 
+// ReloadGorouter rewrites the config file of the running gorouter from s.cfg
+// and asks gorouter to reload it.
+func (s *testState) ReloadGorouter() {
+	cfgBytes, err := yaml.Marshal(s.cfg)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(ioutil.WriteFile(filepath.Join(s.tmpdir, "config.yml"), cfgBytes, 0644)).To(Succeed())
+
+	Expect(s.gorouterSession.Command.Process.Signal(syscall.SIGHUP)).To(Succeed())
+}
+
 func (s *testState) StopAndCleanup() {
 	if s.natsRunner != nil {
 		s.natsRunner.Stop()
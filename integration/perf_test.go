@@ -46,7 +46,7 @@ var _ = Describe("AccessLogRecord", func() {
 		Expect(err).ToNot(HaveOccurred())
 		var h *health.Health
 		proxy.NewProxy(logger, accesslog, ew, c, r, combinedReporter, &routeservice.RouteServiceConfig{},
-			&tls.Config{}, &tls.Config{}, h, rss.GetRoundTripper())
+			&tls.Config{}, &tls.Config{}, h, rss.GetRoundTripper(), nil)
 
 		b.Time("RegisterTime", func() {
 			for i := 0; i < 1000; i++ {
@@ -0,0 +1,93 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"code.cloudfoundry.org/gorouter/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Config reload", func() {
+	const (
+		newHeader      = "New-Header"
+		newHeaderValue = "newValue"
+	)
+
+	var (
+		testState    *testState
+		testApp      *httptest.Server
+		testAppRoute string
+	)
+
+	responseHeader := func() string {
+		req := testState.newRequest(fmt.Sprintf("http://%s", testAppRoute))
+		resp, err := testState.client.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		return resp.Header.Get(newHeader)
+	}
+
+	BeforeEach(func() {
+		testState = NewTestState()
+		testApp = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		testAppRoute = "potato.potato"
+
+		testState.StartGorouterOrFail()
+		testState.register(testApp, testAppRoute)
+	})
+
+	AfterEach(func() {
+		if testState != nil {
+			testState.StopAndCleanup()
+		}
+		testApp.Close()
+	})
+
+	It("applies reloadable settings on SIGHUP", func() {
+		Expect(responseHeader()).To(BeEmpty())
+
+		testState.cfg.HTTPRewrite.Responses.AddHeadersIfNotPresent = []config.HeaderNameValue{
+			{Name: newHeader, Value: newHeaderValue},
+		}
+		testState.ReloadGorouter()
+
+		Eventually(testState.gorouterSession).Should(Say("config-reloaded"))
+		Expect(responseHeader()).To(Equal(newHeaderValue))
+	})
+
+	It("reapplies the static routes on SIGHUP", func() {
+		staticRoute := "static.potato"
+		_, backendPort := hostnameAndPort(testApp.Listener.Addr().String())
+		testState.cfg.StaticRoutes = []config.StaticRouteConfig{{
+			URIs:      []string{staticRoute},
+			Endpoints: []config.StaticEndpointConfig{{Host: "127.0.0.1", Port: uint16(backendPort)}},
+		}}
+		testState.ReloadGorouter()
+
+		Eventually(testState.gorouterSession).Should(Say("config-reloaded"))
+		req := testState.newRequest(fmt.Sprintf("http://%s", staticRoute))
+		resp, err := testState.client.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	})
+
+	It("rejects reloads that change settings requiring a restart", func() {
+		testState.cfg.HTTPRewrite.Responses.AddHeadersIfNotPresent = []config.HeaderNameValue{
+			{Name: newHeader, Value: newHeaderValue},
+		}
+		testState.cfg.Index = 42
+		testState.ReloadGorouter()
+
+		Eventually(testState.gorouterSession).Should(Say("config-reload-rejected"))
+		Expect(testState.gorouterSession.ExitCode()).To(Equal(-1))
+		Expect(responseHeader()).To(BeEmpty())
+	})
+})
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
 	"runtime"
 	"syscall"
 	"time"
@@ -21,6 +26,7 @@ import (
 	"code.cloudfoundry.org/gorouter/common/secure"
 	"code.cloudfoundry.org/gorouter/config"
 	"code.cloudfoundry.org/gorouter/errorwriter"
+	"code.cloudfoundry.org/gorouter/healthchecker"
 	goRouterLogger "code.cloudfoundry.org/gorouter/logger"
 	"code.cloudfoundry.org/gorouter/mbus"
 	"code.cloudfoundry.org/gorouter/metrics"
@@ -28,8 +34,10 @@ import (
 	"code.cloudfoundry.org/gorouter/proxy"
 	rregistry "code.cloudfoundry.org/gorouter/registry"
 	"code.cloudfoundry.org/gorouter/route_fetcher"
+	"code.cloudfoundry.org/gorouter/route_watcher"
 	"code.cloudfoundry.org/gorouter/router"
 	"code.cloudfoundry.org/gorouter/routeservice"
+	"code.cloudfoundry.org/gorouter/tracing"
 	rvarz "code.cloudfoundry.org/gorouter/varz"
 	"code.cloudfoundry.org/lager"
 	routing_api "code.cloudfoundry.org/routing-api"
@@ -46,16 +54,22 @@ import (
 )
 
 var (
-	configFile string
-	h          *health.Health
+	configFile     string
+	validateConfig bool
+	h              *health.Health
 )
 
 func main() {
 	flag.StringVar(&configFile, "c", "", "Configuration File")
+	flag.BoolVar(&validateConfig, "validate", false, "Validate the configuration file given with -c and exit")
 	flag.Parse()
 
+	if validateConfig {
+		os.Exit(validate(configFile))
+	}
+
 	prefix := "gorouter.stdout"
-	tmpLogger, _ := createLogger(prefix, "INFO", "unix-epoch")
+	tmpLogger, _, _ := createLogger(prefix, "INFO", "unix-epoch")
 
 	c, err := config.DefaultConfig()
 	if err != nil {
@@ -74,17 +88,12 @@ func main() {
 	if c.Logging.Syslog != "" {
 		prefix = c.Logging.Syslog
 	}
-	logger, minLagerLogLevel := createLogger(prefix, c.Logging.Level, c.Logging.Format.Timestamp)
+	logger, logLevel, minLagerLogLevel := createLogger(prefix, c.Logging.Level, c.Logging.Format.Timestamp)
 	logger.Info("starting")
 
-	var ew errorwriter.ErrorWriter
-	if c.HTMLErrorTemplateFile != "" {
-		ew, err = errorwriter.NewHTMLErrorWriterFromFile(c.HTMLErrorTemplateFile)
-		if err != nil {
-			logger.Fatal("new-html-error-template-from-file", zap.Error(err))
-		}
-	} else {
-		ew = errorwriter.NewPlaintextErrorWriter()
+	ew, err := createErrorWriter(c)
+	if err != nil {
+		logger.Fatal("new-html-error-template-from-file", zap.Error(err))
 	}
 
 	err = dropsonde.Initialize(c.Logging.MetronAddress, c.Logging.JobName)
@@ -125,12 +134,26 @@ func main() {
 
 	sender := metric_sender.NewMetricSender(dropsonde.AutowiredEmitter())
 
-	metricsReporter := initializeMetrics(sender, c)
-	fdMonitor := initializeFDMonitor(sender, logger)
+	var metricsReporter metrics.MultiReporter
+	if !c.Prometheus.DisableDropsondeMetrics {
+		metricsReporter = append(metricsReporter, initializeMetrics(sender, c))
+	}
+	var prometheusReporter *metrics.PrometheusReporter
+	if c.Prometheus.Enabled {
+		prometheusReporter = initializePrometheus()
+		metricsReporter = append(metricsReporter, prometheusReporter)
+	}
 	registry := rregistry.NewRouteRegistry(logger.Session("registry"), c, metricsReporter)
 	if c.SuspendPruningIfNatsUnavailable {
 		registry.SuspendPruning(func() bool { return !(natsClient.Status() == nats.CONNECTED) })
 	}
+	registry.SetStaticRoutes(c.StaticRoutes)
+	if c.RoutingTableSnapshot.Path != "" {
+		err = registry.LoadSnapshot(c.RoutingTableSnapshot.Path)
+		if err != nil && !os.IsNotExist(err) {
+			logger.Error("failed-to-load-routing-table-snapshot", zap.Error(err))
+		}
+	}
 
 	varz := rvarz.NewVarz(registry)
 	compositeReporter := &metrics.CompositeReporter{VarzReporter: varz, ProxyReporter: metricsReporter}
@@ -153,16 +176,6 @@ func main() {
 		}
 	}
 
-	routeServiceConfig := routeservice.NewRouteServiceConfig(
-		logger.Session("proxy"),
-		c.RouteServiceEnabled,
-		c.RouteServicesHairpinning,
-		c.RouteServiceTimeout,
-		crypto,
-		cryptoPrev,
-		c.RouteServiceRecommendHttps,
-	)
-
 	backendTLSConfig := &tls.Config{
 		CipherSuites: c.CipherSuites,
 		RootCAs:      c.CAPool,
@@ -181,28 +194,60 @@ func main() {
 		logger.Fatal("new-route-services-server", zap.Error(err))
 	}
 
-	h = &health.Health{}
-	proxy := proxy.NewProxy(
+	tcpProxy := proxy.NewTCPProxy(
 		logger,
 		accessLogger,
-		ew,
 		c,
 		registry,
 		compositeReporter,
-		routeServiceConfig,
 		backendTLSConfig,
-		routeServiceTLSConfig,
-		h,
-		rss.GetRoundTripper(),
 	)
 
+	var tracerProvider *tracing.TracerProvider
+	if c.Tracing.OpenTelemetry.Enabled {
+		tracerProvider, err = tracing.NewTracerProvider(logger.Session("tracing"), c, &tls.Config{RootCAs: c.CAPool})
+		if err != nil {
+			logger.Fatal("error-creating-tracer-provider", zap.Error(err))
+		}
+	}
+
+	h = &health.Health{}
+	newProxy := func(c *config.Config, ew errorwriter.ErrorWriter) http.Handler {
+		routeServiceConfig := routeservice.NewRouteServiceConfig(
+			logger.Session("proxy"),
+			c.RouteServiceEnabled,
+			c.RouteServicesHairpinning,
+			c.RouteServiceTimeout,
+			crypto,
+			cryptoPrev,
+			c.RouteServiceRecommendHttps,
+		)
+
+		return proxy.NewProxy(
+			logger,
+			accessLogger,
+			ew,
+			c,
+			registry,
+			compositeReporter,
+			routeServiceConfig,
+			backendTLSConfig,
+			routeServiceTLSConfig,
+			h,
+			rss.GetRoundTripper(),
+			tracerProvider,
+		)
+	}
+	reloadableProxy := proxy.NewReloadableHandler(newProxy(c, ew))
+
 	var errorChannel chan error
 	errorChannel = nil
 
 	goRouter, err := router.NewRouter(
 		logger.Session("router"),
 		c,
-		proxy,
+		reloadableProxy,
+		tcpProxy,
 		natsClient,
 		registry,
 		varz,
@@ -225,18 +270,54 @@ func main() {
 		members = append(members, grouper.Member{Name: "router-fetcher", Runner: routeFetcher})
 	}
 
+	if c.RouteFiles.Directory != "" {
+		routeWatcher := route_watcher.NewRouteWatcher(logger.Session("route-watcher"), registry, c, clock.NewClock())
+		members = append(members, grouper.Member{Name: "route-watcher", Runner: routeWatcher})
+	}
+
+	if c.RoutingTableSnapshot.Path != "" {
+		snapshotter := rregistry.NewSnapshotter(logger.Session("snapshotter"), registry, c, clock.NewClock())
+		members = append(members, grouper.Member{Name: "snapshotter", Runner: snapshotter})
+	}
+
 	subscriber := mbus.NewSubscriber(natsClient, registry, c, natsReconnected, logger.Session("subscriber"))
-	natsMonitor := initializeNATSMonitor(subscriber, sender, logger)
 
-	members = append(members, grouper.Member{Name: "fdMonitor", Runner: fdMonitor})
+	if !c.Prometheus.DisableDropsondeMetrics {
+		fdMonitor := initializeFDMonitor(sender, logger)
+		members = append(members, grouper.Member{Name: "fdMonitor", Runner: fdMonitor})
+	}
 	members = append(members, grouper.Member{Name: "subscriber", Runner: subscriber})
-	members = append(members, grouper.Member{Name: "natsMonitor", Runner: natsMonitor})
+	if c.BackendHealthChecks.Enabled {
+		healthChecker := healthchecker.NewHealthChecker(logger.Session("health-checker"), c, registry, metricsReporter, backendTLSConfig)
+		members = append(members, grouper.Member{Name: "health-checker", Runner: healthChecker})
+	}
+	if !c.Prometheus.DisableDropsondeMetrics {
+		natsMonitor := initializeNATSMonitor(subscriber, sender, logger)
+		members = append(members, grouper.Member{Name: "natsMonitor", Runner: natsMonitor})
+	}
+	if prometheusReporter != nil {
+		prometheusReporter.MonitorNATSSubscription(subscriber)
+		addr := fmt.Sprintf("%s:%d", c.Prometheus.Host, c.Prometheus.Port)
+		members = append(members, grouper.Member{Name: "prometheus", Runner: prometheusServer(addr, prometheusReporter.Handler(), logger)})
+	}
 	members = append(members, grouper.Member{Name: "router", Runner: goRouter})
 
 	group := grouper.NewOrdered(os.Interrupt, members)
 
 	monitor := ifrit.Invoke(sigmon.New(group, syscall.SIGTERM, syscall.SIGINT, syscall.SIGUSR1))
 
+	reloader := &configReloader{
+		logger:     logger.Session("config-reloader"),
+		logLevel:   logLevel,
+		configFile: configFile,
+		config:     c,
+		newProxy:   newProxy,
+		proxy:      reloadableProxy,
+		tcpProxy:   tcpProxy,
+		registry:   registry,
+	}
+	reloadOnSIGHUP(logger, reloader, goRouter)
+
 	go func() {
 		time.Sleep(c.RouteLatencyMetricMuzzleDuration) // this way we avoid reporting metrics for pre-existing routes
 		metricsReporter.UnmuzzleRouteRegistrationLatency()
@@ -246,6 +327,9 @@ func main() {
 	h.SetHealth(health.Healthy)
 
 	err = <-monitor.Wait()
+	if tracerProvider != nil {
+		shutdownTracing(logger, tracerProvider)
+	}
 	if err != nil {
 		logger.Error("gorouter.exited-with-failure", zap.Error(err))
 		os.Exit(1)
@@ -254,6 +338,120 @@ func main() {
 	os.Exit(0)
 }
 
+// reloadOnSIGHUP reloads the configuration file and the frontend TLS
+// certificates whenever the process receives a SIGHUP. SIGHUP is not passed to
+// sigmon, which would stop the router on any signal it handles.
+func reloadOnSIGHUP(logger goRouterLogger.Logger, reloader *configReloader, goRouter *router.Router) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+
+	go func() {
+		for range sighup {
+			logger.Info("received-sighup")
+			reloader.reload()
+			_ = goRouter.ReloadCertificates()
+		}
+	}()
+}
+
+// configReloader applies the settings of the configuration file listed in
+// config.ReloadableSettings to the running router. A reload that changes any
+// other setting is rejected as a whole.
+type configReloader struct {
+	logger     goRouterLogger.Logger
+	logLevel   zap.AtomicLevel
+	configFile string
+	config     *config.Config
+	newProxy   func(*config.Config, errorwriter.ErrorWriter) http.Handler
+	proxy      *proxy.ReloadableHandler
+	tcpProxy   *proxy.TCPProxy
+	registry   *rregistry.RouteRegistry
+}
+
+func (r *configReloader) reload() {
+	if r.configFile == "" {
+		r.logger.Info("config-reload-skipped", zap.String("reason", "no configuration file"))
+		return
+	}
+
+	c, err := config.InitConfigFromFile(r.configFile)
+	if err != nil {
+		r.logger.Error("config-reload-failed", zap.Error(err))
+		return
+	}
+
+	changes, err := r.config.RestartRequiredChanges(c)
+	if err != nil {
+		r.logger.Error("config-reload-failed", zap.Error(err))
+		return
+	}
+	if len(changes) > 0 {
+		r.logger.Error("config-reload-rejected",
+			zap.String("reason", "changed settings require a restart"),
+			zap.Object("settings", changes),
+		)
+		return
+	}
+
+	var level zap.Level
+	if err := level.UnmarshalText([]byte(c.Logging.Level)); err != nil {
+		r.logger.Error("config-reload-failed", zap.Error(err))
+		return
+	}
+
+	ew, err := createErrorWriter(c)
+	if err != nil {
+		r.logger.Error("config-reload-failed", zap.Error(err))
+		return
+	}
+
+	r.logLevel.SetLevel(level)
+	r.proxy.Swap(r.newProxy(c, ew))
+	r.tcpProxy.Reload(c)
+	r.registry.SetStaticRoutes(c.StaticRoutes)
+	r.config = c
+	r.logger.Info("config-reloaded")
+}
+
+// validate prints the validation report of the configuration file as JSON and
+// returns the exit code of the process.
+func validate(configFile string) int {
+	if configFile == "" {
+		fmt.Fprintln(os.Stderr, "-validate requires a configuration file given with -c")
+		return 2
+	}
+
+	report := config.ValidateFile(configFile, time.Now())
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	fmt.Println(string(b))
+
+	if !report.Valid {
+		return 1
+	}
+	return 0
+}
+
+func createErrorWriter(c *config.Config) (errorwriter.ErrorWriter, error) {
+	if c.HTMLErrorTemplateFile != "" {
+		return errorwriter.NewHTMLErrorWriterFromFile(c.HTMLErrorTemplateFile)
+	}
+	return errorwriter.NewPlaintextErrorWriter(), nil
+}
+
+// shutdownTracing exports the spans that are still buffered before gorouter
+// exits.
+func shutdownTracing(logger goRouterLogger.Logger, tracerProvider *tracing.TracerProvider) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tracerProvider.Shutdown(ctx); err != nil {
+		logger.Error("error-shutting-down-tracer-provider", zap.Error(err))
+	}
+}
+
 func initializeFDMonitor(sender *metric_sender.MetricSender, logger goRouterLogger.Logger) *monitor.FileDescriptor {
 	pid := os.Getpid()
 	path := fmt.Sprintf("/proc/%d/fd", pid)
@@ -271,6 +469,41 @@ func initializeNATSMonitor(subscriber *mbus.Subscriber, sender *metric_sender.Me
 	}
 }
 
+func initializePrometheus() *metrics.PrometheusReporter {
+	reporter := metrics.NewPrometheusReporter()
+	reporter.MonitorFileDescriptors(fmt.Sprintf("/proc/%d/fd", os.Getpid()))
+	return reporter
+}
+
+// prometheusServer serves the Prometheus metrics on /metrics until it is
+// signalled.
+func prometheusServer(addr string, handler http.Handler, logger goRouterLogger.Logger) ifrit.Runner {
+	return ifrit.RunFunc(func(signals <-chan os.Signal, ready chan<- struct{}) error {
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			return err
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", handler)
+		server := &http.Server{Handler: mux}
+
+		errChan := make(chan error, 1)
+		go func() {
+			errChan <- server.Serve(listener)
+		}()
+		logger.Info("prometheus-listening", zap.String("address", listener.Addr().String()))
+		close(ready)
+
+		select {
+		case err := <-errChan:
+			return err
+		case <-signals:
+			return server.Close()
+		}
+	})
+}
+
 func initializeMetrics(sender *metric_sender.MetricSender, c *config.Config) *metrics.MetricsReporter {
 	// 5 sec is dropsonde default batching interval
 	batcher := metricbatcher.New(sender, 5*time.Second)
@@ -405,9 +638,11 @@ func newUaaClient(logger goRouterLogger.Logger, clock clock.Clock, c *config.Con
 	return uaaClient
 }
 
-func createLogger(component string, level string, timestampFormat string) (goRouterLogger.Logger, lager.LogLevel) {
-	var logLevel zap.Level
-	logLevel.UnmarshalText([]byte(level))
+func createLogger(component string, level string, timestampFormat string) (goRouterLogger.Logger, zap.AtomicLevel, lager.LogLevel) {
+	var zapLevel zap.Level
+	zapLevel.UnmarshalText([]byte(level))
+	logLevel := zap.DynamicLevel()
+	logLevel.SetLevel(zapLevel)
 
 	var minLagerLogLevel lager.LogLevel
 	switch minLagerLogLevel {
@@ -424,5 +659,5 @@ func createLogger(component string, level string, timestampFormat string) (goRou
 	}
 
 	lggr := goRouterLogger.NewLogger(component, timestampFormat, logLevel, zap.Output(os.Stdout))
-	return lggr, minLagerLogLevel
+	return lggr, logLevel, minLagerLogLevel
 }
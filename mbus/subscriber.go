@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"os"
 	"strings"
 	"time"
@@ -39,13 +40,71 @@ type RegistryMessage struct {
 	PrivateInstanceIndex    string            `json:"private_instance_index"`
 	IsolationSegment        string            `json:"isolation_segment"`
 	EndpointUpdatedAtNs     int64             `json:"endpoint_updated_at_ns"`
+	Protocol                string            `json:"protocol"`
+	ExternalPort            uint16            `json:"external_port"`
+	TLSPassthrough          bool              `json:"tls_passthrough"`
+	Weight                  int               `json:"weight"`
+	AvailabilityZone        string            `json:"availability_zone"`
+	HashKey                 string            `json:"hash_key"`
+	LoadBalancingAlgorithm  string            `json:"load_balancing_algorithm"`
+	HealthCheckPath         string            `json:"health_check_path"`
+	RetryPolicy             *RetryPolicy      `json:"retry_policy"`
+	TrafficPercent          int               `json:"traffic_percent"`
+	TrafficMatch            string            `json:"traffic_match"`
+	PathPattern             string            `json:"path_pattern"`
 }
 
-func (rm *RegistryMessage) makeEndpoint() (*route.Endpoint, error) {
+// RetryPolicy overrides settings of the retry policy of the router for the
+// routes of a Registry Message. Settings that are not set are taken from the
+// router.
+type RetryPolicy struct {
+	MaxAttempts        int   `json:"max_attempts"`
+	PerTryTimeoutMs    int64 `json:"per_try_timeout_ms"`
+	RetryOnStatusCodes []int `json:"retry_on_status_codes"`
+	BaseBackoffMs      int64 `json:"base_backoff_ms"`
+	MaxBackoffMs       int64 `json:"max_backoff_ms"`
+}
+
+// MakeEndpoint validates the message and returns the endpoint it registers.
+func (rm *RegistryMessage) MakeEndpoint() (*route.Endpoint, error) {
 	port, useTLS, err := rm.port()
 	if err != nil {
 		return nil, err
 	}
+	if err := rm.validateProtocol(); err != nil {
+		return nil, err
+	}
+	if rm.Weight < 0 {
+		return nil, fmt.Errorf("invalid weight %d, must not be negative", rm.Weight)
+	}
+	hashKey, err := route.ParseHashKey(rm.HashKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := rm.validateLoadBalancingAlgorithm(); err != nil {
+		return nil, err
+	}
+	if rm.HealthCheckPath != "" && !strings.HasPrefix(rm.HealthCheckPath, "/") {
+		return nil, fmt.Errorf("invalid health check path %q, must start with /", rm.HealthCheckPath)
+	}
+	retryPolicy, err := rm.RetryPolicy.config()
+	if err != nil {
+		return nil, err
+	}
+	if rm.TrafficPercent < 0 || rm.TrafficPercent > 100 {
+		return nil, fmt.Errorf("invalid traffic percent %d, must be between 0 and 100", rm.TrafficPercent)
+	}
+	trafficMatch, err := route.ParseTrafficMatch(rm.TrafficMatch)
+	if err != nil {
+		return nil, err
+	}
+	pathPattern, err := route.ParsePathPattern(rm.PathPattern)
+	if err != nil {
+		return nil, err
+	}
+	if !pathPattern.IsZero() && rm.ExternalPort != 0 {
+		return nil, fmt.Errorf("invalid path pattern %q, TCP routes of external ports have no paths", rm.PathPattern)
+	}
 	var updatedAt time.Time
 	if rm.EndpointUpdatedAtNs != 0 {
 		updatedAt = time.Unix(0, rm.EndpointUpdatedAtNs).UTC()
@@ -65,9 +124,31 @@ func (rm *RegistryMessage) makeEndpoint() (*route.Endpoint, error) {
 		IsolationSegment:        rm.IsolationSegment,
 		UseTLS:                  useTLS,
 		UpdatedAt:               updatedAt,
+		Protocol:                rm.Protocol,
+		TLSPassthrough:          rm.TLSPassthrough,
+		Weight:                  rm.Weight,
+		AvailabilityZone:        rm.AvailabilityZone,
+		HashKey:                 hashKey,
+		LoadBalancingAlgorithm:  rm.LoadBalancingAlgorithm,
+		HealthCheckPath:         rm.HealthCheckPath,
+		RetryPolicy:             retryPolicy,
+		TrafficPercent:          rm.TrafficPercent,
+		TrafficMatch:            trafficMatch,
+		PathPattern:             pathPattern,
 	}), nil
 }
 
+// RouteUris returns the uris of the message plus, when an external port is
+// mapped, the key of the TCP route for that router port
+func (rm *RegistryMessage) RouteUris() []route.Uri {
+	if rm.ExternalPort == 0 {
+		return rm.Uris
+	}
+	uris := make([]route.Uri, 0, len(rm.Uris)+1)
+	uris = append(uris, rm.Uris...)
+	return append(uris, route.TCPUri(rm.ExternalPort))
+}
+
 // ValidateMessage checks to ensure the registry message is valid
 func (rm *RegistryMessage) ValidateMessage() bool {
 	return rm.RouteServiceURL == "" || strings.HasPrefix(rm.RouteServiceURL, "https")
@@ -81,6 +162,63 @@ func (rm *RegistryMessage) port() (uint16, bool, error) {
 	return rm.Port, false, nil
 }
 
+// An empty protocol in the Registry Message means HTTP/1.1
+func (rm *RegistryMessage) validateProtocol() error {
+	switch rm.Protocol {
+	case "", route.ProtocolHTTP1, route.ProtocolHTTP2:
+		return nil
+	default:
+		return fmt.Errorf("invalid protocol %q, must be one of %q or %q", rm.Protocol, route.ProtocolHTTP1, route.ProtocolHTTP2)
+	}
+}
+
+// An empty load balancing algorithm in the Registry Message means the default
+// algorithm of the router
+func (rm *RegistryMessage) validateLoadBalancingAlgorithm() error {
+	if rm.LoadBalancingAlgorithm == "" {
+		return nil
+	}
+	for _, lb := range config.LoadBalancingStrategies {
+		if rm.LoadBalancingAlgorithm == lb {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid load balancing algorithm %q, must be one of %q", rm.LoadBalancingAlgorithm, config.LoadBalancingStrategies)
+}
+
+// config returns the retry policy as the route registers it, with zero
+// values for the settings taken from the router
+func (p *RetryPolicy) config() (*config.RetryPolicyConfig, error) {
+	if p == nil {
+		return nil, nil
+	}
+	if p.MaxAttempts < 0 || p.PerTryTimeoutMs < 0 || p.BaseBackoffMs < 0 || p.MaxBackoffMs < 0 {
+		return nil, errors.New("invalid retry policy, max_attempts, per_try_timeout_ms, base_backoff_ms and max_backoff_ms must not be negative")
+	}
+
+	policy := &config.RetryPolicyConfig{
+		MaxAttempts:        p.MaxAttempts,
+		PerTryTimeout:      time.Duration(p.PerTryTimeoutMs) * time.Millisecond,
+		RetryOnStatusCodes: p.RetryOnStatusCodes,
+		BaseBackoff:        time.Duration(p.BaseBackoffMs) * time.Millisecond,
+		MaxBackoff:         time.Duration(p.MaxBackoffMs) * time.Millisecond,
+	}
+
+	// the settings taken from the router are checked with its configuration,
+	// so they are replaced with values that pass
+	checked := *policy
+	if checked.MaxAttempts == 0 {
+		checked.MaxAttempts = 1
+	}
+	if checked.MaxBackoff == 0 {
+		checked.MaxBackoff = math.MaxInt64
+	}
+	if err := checked.Validate("retry_policy"); err != nil {
+		return nil, fmt.Errorf("invalid retry policy, %s", err)
+	}
+	return policy, nil
+}
+
 // Subscriber subscribes to NATS for all router.* messages and handles them
 type Subscriber struct {
 	mbusClient       Client
@@ -226,7 +364,7 @@ func (s *Subscriber) subscribeRoutes() (*nats.Subscription, error) {
 }
 
 func (s *Subscriber) registerEndpoint(msg *RegistryMessage) {
-	endpoint, err := msg.makeEndpoint()
+	endpoint, err := msg.MakeEndpoint()
 	if err != nil {
 		s.logger.Error("Unable to register route",
 			zap.Error(err),
@@ -235,13 +373,13 @@ func (s *Subscriber) registerEndpoint(msg *RegistryMessage) {
 		return
 	}
 
-	for _, uri := range msg.Uris {
+	for _, uri := range msg.RouteUris() {
 		s.routeRegistry.Register(uri, endpoint)
 	}
 }
 
 func (s *Subscriber) unregisterEndpoint(msg *RegistryMessage) {
-	endpoint, err := msg.makeEndpoint()
+	endpoint, err := msg.MakeEndpoint()
 	if err != nil {
 		s.logger.Error("Unable to unregister route",
 			zap.Error(err),
@@ -249,7 +387,7 @@ func (s *Subscriber) unregisterEndpoint(msg *RegistryMessage) {
 		)
 		return
 	}
-	for _, uri := range msg.Uris {
+	for _, uri := range msg.RouteUris() {
 		s.routeRegistry.Unregister(uri, endpoint)
 	}
 }
@@ -220,6 +220,38 @@ func easyjson639f989aDecodeCodeCloudfoundryOrgGorouterMbus2(in *jlexer.Lexer, ou
 			out.IsolationSegment = string(in.String())
 		case "endpoint_updated_at_ns":
 			out.EndpointUpdatedAtNs = int64(in.Int64())
+		case "protocol":
+			out.Protocol = string(in.String())
+		case "external_port":
+			out.ExternalPort = uint16(in.Uint16())
+		case "tls_passthrough":
+			out.TLSPassthrough = bool(in.Bool())
+		case "weight":
+			out.Weight = int(in.Int())
+		case "availability_zone":
+			out.AvailabilityZone = string(in.String())
+		case "hash_key":
+			out.HashKey = string(in.String())
+		case "load_balancing_algorithm":
+			out.LoadBalancingAlgorithm = string(in.String())
+		case "health_check_path":
+			out.HealthCheckPath = string(in.String())
+		case "retry_policy":
+			if in.IsNull() {
+				in.Skip()
+				out.RetryPolicy = nil
+			} else {
+				if out.RetryPolicy == nil {
+					out.RetryPolicy = new(RetryPolicy)
+				}
+				(*out.RetryPolicy).UnmarshalEasyJSON(in)
+			}
+		case "traffic_percent":
+			out.TrafficPercent = int(in.Int())
+		case "traffic_match":
+			out.TrafficMatch = string(in.String())
+		case "path_pattern":
+			out.PathPattern = string(in.String())
 		default:
 			in.SkipRecursive()
 		}
@@ -338,6 +370,82 @@ func easyjson639f989aEncodeCodeCloudfoundryOrgGorouterMbus2(out *jwriter.Writer,
 	first = false
 	out.RawString("\"endpoint_updated_at_ns\":")
 	out.Int64(int64(in.EndpointUpdatedAtNs))
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"protocol\":")
+	out.String(string(in.Protocol))
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"external_port\":")
+	out.Uint16(uint16(in.ExternalPort))
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"tls_passthrough\":")
+	out.Bool(bool(in.TLSPassthrough))
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"weight\":")
+	out.Int(int(in.Weight))
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"availability_zone\":")
+	out.String(string(in.AvailabilityZone))
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"hash_key\":")
+	out.String(string(in.HashKey))
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"load_balancing_algorithm\":")
+	out.String(string(in.LoadBalancingAlgorithm))
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"health_check_path\":")
+	out.String(string(in.HealthCheckPath))
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"retry_policy\":")
+	if in.RetryPolicy == nil {
+		out.RawString("null")
+	} else {
+		(*in.RetryPolicy).MarshalEasyJSON(out)
+	}
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"traffic_percent\":")
+	out.Int(int(in.TrafficPercent))
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"traffic_match\":")
+	out.String(string(in.TrafficMatch))
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"path_pattern\":")
+	out.String(string(in.PathPattern))
 	out.RawByte('}')
 }
 
@@ -364,3 +472,134 @@ func (v *RegistryMessage) UnmarshalJSON(data []byte) error {
 func (v *RegistryMessage) UnmarshalEasyJSON(l *jlexer.Lexer) {
 	easyjson639f989aDecodeCodeCloudfoundryOrgGorouterMbus2(l, v)
 }
+func easyjson639f989aDecodeCodeCloudfoundryOrgGorouterMbus3(in *jlexer.Lexer, out *RetryPolicy) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeString()
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "max_attempts":
+			out.MaxAttempts = int(in.Int())
+		case "per_try_timeout_ms":
+			out.PerTryTimeoutMs = int64(in.Int64())
+		case "retry_on_status_codes":
+			if in.IsNull() {
+				in.Skip()
+				out.RetryOnStatusCodes = nil
+			} else {
+				in.Delim('[')
+				if out.RetryOnStatusCodes == nil {
+					if !in.IsDelim(']') {
+						out.RetryOnStatusCodes = make([]int, 0, 8)
+					} else {
+						out.RetryOnStatusCodes = []int{}
+					}
+				} else {
+					out.RetryOnStatusCodes = (out.RetryOnStatusCodes)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v7 int
+					v7 = int(in.Int())
+					out.RetryOnStatusCodes = append(out.RetryOnStatusCodes, v7)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "base_backoff_ms":
+			out.BaseBackoffMs = int64(in.Int64())
+		case "max_backoff_ms":
+			out.MaxBackoffMs = int64(in.Int64())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson639f989aEncodeCodeCloudfoundryOrgGorouterMbus3(out *jwriter.Writer, in RetryPolicy) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"max_attempts\":")
+	out.Int(int(in.MaxAttempts))
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"per_try_timeout_ms\":")
+	out.Int64(int64(in.PerTryTimeoutMs))
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"retry_on_status_codes\":")
+	if in.RetryOnStatusCodes == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+		out.RawString("null")
+	} else {
+		out.RawByte('[')
+		for v8, v9 := range in.RetryOnStatusCodes {
+			if v8 > 0 {
+				out.RawByte(',')
+			}
+			out.Int(int(v9))
+		}
+		out.RawByte(']')
+	}
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"base_backoff_ms\":")
+	out.Int64(int64(in.BaseBackoffMs))
+	if !first {
+		out.RawByte(',')
+	}
+	first = false
+	out.RawString("\"max_backoff_ms\":")
+	out.Int64(int64(in.MaxBackoffMs))
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v RetryPolicy) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson639f989aEncodeCodeCloudfoundryOrgGorouterMbus3(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v RetryPolicy) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson639f989aEncodeCodeCloudfoundryOrgGorouterMbus3(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *RetryPolicy) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson639f989aDecodeCodeCloudfoundryOrgGorouterMbus3(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *RetryPolicy) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson639f989aDecodeCodeCloudfoundryOrgGorouterMbus3(l, v)
+}
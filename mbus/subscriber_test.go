@@ -470,6 +470,553 @@ var _ = Describe("Subscriber", func() {
 		})
 	})
 
+	Context("when the message contains a protocol", func() {
+		BeforeEach(func() {
+			process = ifrit.Invoke(sub)
+			Eventually(process.Ready()).Should(BeClosed())
+		})
+
+		It("endpoint is constructed with the protocol", func() {
+			msg := mbus.RegistryMessage{
+				Host:     "host",
+				App:      "app",
+				Port:     1111,
+				Uris:     []route.Uri{"test.example.com"},
+				Protocol: "http2",
+			}
+
+			data, err := json.Marshal(msg)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			Expect(originalEndpoint.Protocol).To(Equal(route.ProtocolHTTP2))
+			Expect(originalEndpoint.IsHTTP2()).To(BeTrue())
+		})
+
+		Context("when the protocol is not supported", func() {
+			It("does not update the registry", func() {
+				msg := mbus.RegistryMessage{
+					Host:     "host",
+					App:      "app",
+					Port:     1111,
+					Uris:     []route.Uri{"test.example.com"},
+					Protocol: "spdy",
+				}
+
+				data, err := json.Marshal(msg)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = natsClient.Publish("router.register", data)
+				Expect(err).ToNot(HaveOccurred())
+
+				Consistently(registry.RegisterCallCount).Should(BeZero())
+			})
+		})
+	})
+
+	Context("when the message flags the route as tls passthrough", func() {
+		BeforeEach(func() {
+			process = ifrit.Invoke(sub)
+			Eventually(process.Ready()).Should(BeClosed())
+		})
+
+		It("endpoint is constructed with tls passthrough", func() {
+			msg := mbus.RegistryMessage{
+				Host:           "host",
+				App:            "app",
+				Port:           1111,
+				Uris:           []route.Uri{"test.example.com"},
+				TLSPassthrough: true,
+			}
+
+			data, err := json.Marshal(msg)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			Expect(originalEndpoint.TLSPassthrough).To(BeTrue())
+		})
+	})
+
+	Context("when the message contains a weight", func() {
+		BeforeEach(func() {
+			process = ifrit.Invoke(sub)
+			Eventually(process.Ready()).Should(BeClosed())
+		})
+
+		It("endpoint is constructed with the weight", func() {
+			msg := mbus.RegistryMessage{
+				Host:   "host",
+				App:    "app",
+				Port:   1111,
+				Uris:   []route.Uri{"test.example.com"},
+				Weight: 3,
+			}
+
+			data, err := json.Marshal(msg)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			Expect(originalEndpoint.Weight).To(Equal(3))
+		})
+
+		Context("when the weight is negative", func() {
+			It("does not update the registry", func() {
+				msg := mbus.RegistryMessage{
+					Host:   "host",
+					App:    "app",
+					Port:   1111,
+					Uris:   []route.Uri{"test.example.com"},
+					Weight: -1,
+				}
+
+				data, err := json.Marshal(msg)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = natsClient.Publish("router.register", data)
+				Expect(err).ToNot(HaveOccurred())
+
+				Consistently(registry.RegisterCallCount).Should(BeZero())
+			})
+		})
+	})
+
+	Context("when the message contains an availability zone", func() {
+		BeforeEach(func() {
+			process = ifrit.Invoke(sub)
+			Eventually(process.Ready()).Should(BeClosed())
+		})
+
+		It("endpoint is constructed with the availability zone", func() {
+			msg := mbus.RegistryMessage{
+				Host:             "host",
+				App:              "app",
+				Port:             1111,
+				Uris:             []route.Uri{"test.example.com"},
+				AvailabilityZone: "z1",
+			}
+
+			data, err := json.Marshal(msg)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			Expect(originalEndpoint.AvailabilityZone).To(Equal("z1"))
+		})
+	})
+
+	Context("when the message contains a hash key", func() {
+		BeforeEach(func() {
+			process = ifrit.Invoke(sub)
+			Eventually(process.Ready()).Should(BeClosed())
+		})
+
+		It("endpoint is constructed with the hash key", func() {
+			msg := mbus.RegistryMessage{
+				Host:    "host",
+				App:     "app",
+				Port:    1111,
+				Uris:    []route.Uri{"test.example.com"},
+				HashKey: "header:X-User",
+			}
+
+			data, err := json.Marshal(msg)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			Expect(originalEndpoint.HashKey).To(Equal(route.HashKey{Source: route.HashOnHeader, Name: "X-User"}))
+		})
+
+		Context("when the hash key is not supported", func() {
+			It("does not update the registry", func() {
+				msg := mbus.RegistryMessage{
+					Host:    "host",
+					App:     "app",
+					Port:    1111,
+					Uris:    []route.Uri{"test.example.com"},
+					HashKey: "body:user",
+				}
+
+				data, err := json.Marshal(msg)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = natsClient.Publish("router.register", data)
+				Expect(err).ToNot(HaveOccurred())
+
+				Consistently(registry.RegisterCallCount).Should(BeZero())
+			})
+		})
+	})
+
+	Context("when the message contains a load balancing algorithm", func() {
+		BeforeEach(func() {
+			process = ifrit.Invoke(sub)
+			Eventually(process.Ready()).Should(BeClosed())
+		})
+
+		It("endpoint is constructed with the load balancing algorithm", func() {
+			msg := mbus.RegistryMessage{
+				Host:                   "host",
+				App:                    "app",
+				Port:                   1111,
+				Uris:                   []route.Uri{"test.example.com"},
+				LoadBalancingAlgorithm: "least-connection",
+			}
+
+			data, err := json.Marshal(msg)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			Expect(originalEndpoint.LoadBalancingAlgorithm).To(Equal("least-connection"))
+		})
+
+		Context("when the load balancing algorithm is not supported", func() {
+			It("does not update the registry", func() {
+				msg := mbus.RegistryMessage{
+					Host:                   "host",
+					App:                    "app",
+					Port:                   1111,
+					Uris:                   []route.Uri{"test.example.com"},
+					LoadBalancingAlgorithm: "random",
+				}
+
+				data, err := json.Marshal(msg)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = natsClient.Publish("router.register", data)
+				Expect(err).ToNot(HaveOccurred())
+
+				Consistently(registry.RegisterCallCount).Should(BeZero())
+			})
+		})
+	})
+
+	Context("when the message contains a health check path", func() {
+		BeforeEach(func() {
+			process = ifrit.Invoke(sub)
+			Eventually(process.Ready()).Should(BeClosed())
+		})
+
+		It("endpoint is constructed with the health check path", func() {
+			msg := mbus.RegistryMessage{
+				Host:            "host",
+				App:             "app",
+				Port:            1111,
+				Uris:            []route.Uri{"test.example.com"},
+				HealthCheckPath: "/healthz",
+			}
+
+			data, err := json.Marshal(msg)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			Expect(originalEndpoint.HealthCheckPath).To(Equal("/healthz"))
+		})
+
+		Context("when the health check path is not absolute", func() {
+			It("does not update the registry", func() {
+				msg := mbus.RegistryMessage{
+					Host:            "host",
+					App:             "app",
+					Port:            1111,
+					Uris:            []route.Uri{"test.example.com"},
+					HealthCheckPath: "healthz",
+				}
+
+				data, err := json.Marshal(msg)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = natsClient.Publish("router.register", data)
+				Expect(err).ToNot(HaveOccurred())
+
+				Consistently(registry.RegisterCallCount).Should(BeZero())
+			})
+		})
+	})
+
+	Context("when the message contains a retry policy", func() {
+		BeforeEach(func() {
+			process = ifrit.Invoke(sub)
+			Eventually(process.Ready()).Should(BeClosed())
+		})
+
+		It("endpoint is constructed with the retry policy", func() {
+			msg := mbus.RegistryMessage{
+				Host: "host",
+				App:  "app",
+				Port: 1111,
+				Uris: []route.Uri{"test.example.com"},
+				RetryPolicy: &mbus.RetryPolicy{
+					MaxAttempts:        5,
+					PerTryTimeoutMs:    2000,
+					RetryOnStatusCodes: []int{503},
+				},
+			}
+
+			data, err := json.Marshal(msg)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			Expect(originalEndpoint.RetryPolicy).To(Equal(&config.RetryPolicyConfig{
+				MaxAttempts:        5,
+				PerTryTimeout:      2 * time.Second,
+				RetryOnStatusCodes: []int{503},
+			}))
+		})
+
+		Context("when a retry status code is not an error", func() {
+			It("does not update the registry", func() {
+				msg := mbus.RegistryMessage{
+					Host:        "host",
+					App:         "app",
+					Port:        1111,
+					Uris:        []route.Uri{"test.example.com"},
+					RetryPolicy: &mbus.RetryPolicy{RetryOnStatusCodes: []int{200}},
+				}
+
+				data, err := json.Marshal(msg)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = natsClient.Publish("router.register", data)
+				Expect(err).ToNot(HaveOccurred())
+
+				Consistently(registry.RegisterCallCount).Should(BeZero())
+			})
+		})
+
+		Context("when the base backoff is greater than the max backoff", func() {
+			It("does not update the registry", func() {
+				msg := mbus.RegistryMessage{
+					Host:        "host",
+					App:         "app",
+					Port:        1111,
+					Uris:        []route.Uri{"test.example.com"},
+					RetryPolicy: &mbus.RetryPolicy{BaseBackoffMs: 500, MaxBackoffMs: 100},
+				}
+
+				data, err := json.Marshal(msg)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = natsClient.Publish("router.register", data)
+				Expect(err).ToNot(HaveOccurred())
+
+				Consistently(registry.RegisterCallCount).Should(BeZero())
+			})
+		})
+	})
+
+	Context("when the message contains a traffic split", func() {
+		BeforeEach(func() {
+			process = ifrit.Invoke(sub)
+			Eventually(process.Ready()).Should(BeClosed())
+		})
+
+		It("endpoint is constructed with the traffic split", func() {
+			msg := mbus.RegistryMessage{
+				Host:           "host",
+				App:            "app",
+				Port:           1111,
+				Uris:           []route.Uri{"test.example.com"},
+				TrafficPercent: 10,
+				TrafficMatch:   "header:X-Canary=true",
+			}
+
+			data, err := json.Marshal(msg)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			_, originalEndpoint := registry.RegisterArgsForCall(0)
+			Expect(originalEndpoint.TrafficPercent).To(Equal(10))
+			Expect(originalEndpoint.TrafficMatch).To(Equal(route.TrafficMatch{Source: "header", Name: "X-Canary", Value: "true"}))
+		})
+
+		Context("when the traffic percent is out of range", func() {
+			It("does not update the registry", func() {
+				msg := mbus.RegistryMessage{
+					Host:           "host",
+					App:            "app",
+					Port:           1111,
+					Uris:           []route.Uri{"test.example.com"},
+					TrafficPercent: 101,
+				}
+
+				data, err := json.Marshal(msg)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = natsClient.Publish("router.register", data)
+				Expect(err).ToNot(HaveOccurred())
+
+				Consistently(registry.RegisterCallCount).Should(BeZero())
+			})
+		})
+
+		Context("when the traffic match is not supported", func() {
+			It("does not update the registry", func() {
+				msg := mbus.RegistryMessage{
+					Host:         "host",
+					App:          "app",
+					Port:         1111,
+					Uris:         []route.Uri{"test.example.com"},
+					TrafficMatch: "query:canary=true",
+				}
+
+				data, err := json.Marshal(msg)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = natsClient.Publish("router.register", data)
+				Expect(err).ToNot(HaveOccurred())
+
+				Consistently(registry.RegisterCallCount).Should(BeZero())
+			})
+		})
+	})
+
+	Context("when the message contains a path pattern", func() {
+		BeforeEach(func() {
+			process = ifrit.Invoke(sub)
+			Eventually(process.Ready()).Should(BeClosed())
+		})
+
+		It("endpoint is constructed with the path pattern", func() {
+			msg := mbus.RegistryMessage{
+				Host:        "host",
+				App:         "app",
+				Port:        1111,
+				Uris:        []route.Uri{"test.example.com"},
+				PathPattern: "/api/v*/users/{id:[0-9]+}",
+			}
+
+			data, err := json.Marshal(msg)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(1))
+			uri, originalEndpoint := registry.RegisterArgsForCall(0)
+			Expect(uri).To(Equal(route.Uri("test.example.com")))
+			Expect(originalEndpoint.PathPattern.String()).To(Equal("/api/v*/users/{id:[0-9]+}"))
+		})
+
+		Context("when the path pattern is invalid", func() {
+			It("does not update the registry", func() {
+				msg := mbus.RegistryMessage{
+					Host:        "host",
+					App:         "app",
+					Port:        1111,
+					Uris:        []route.Uri{"test.example.com"},
+					PathPattern: "/users/{id:[0-9}",
+				}
+
+				data, err := json.Marshal(msg)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = natsClient.Publish("router.register", data)
+				Expect(err).ToNot(HaveOccurred())
+
+				Consistently(registry.RegisterCallCount).Should(BeZero())
+			})
+		})
+
+		Context("when the message also contains an external port", func() {
+			It("does not update the registry", func() {
+				msg := mbus.RegistryMessage{
+					Host:         "host",
+					App:          "app",
+					Port:         1111,
+					Uris:         []route.Uri{"test.example.com"},
+					PathPattern:  "/users/{id}",
+					ExternalPort: 8080,
+				}
+
+				data, err := json.Marshal(msg)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = natsClient.Publish("router.register", data)
+				Expect(err).ToNot(HaveOccurred())
+
+				Consistently(registry.RegisterCallCount).Should(BeZero())
+			})
+		})
+	})
+
+	Context("when the message contains an external port", func() {
+		var msg mbus.RegistryMessage
+
+		BeforeEach(func() {
+			process = ifrit.Invoke(sub)
+			Eventually(process.Ready()).Should(BeClosed())
+
+			msg = mbus.RegistryMessage{
+				Host:         "host",
+				App:          "app",
+				Port:         1111,
+				Uris:         []route.Uri{"test.example.com"},
+				ExternalPort: 1024,
+			}
+		})
+
+		It("registers the endpoint for the tcp route of that port", func() {
+			data, err := json.Marshal(msg)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = natsClient.Publish("router.register", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.RegisterCallCount).Should(Equal(2))
+			uri, _ := registry.RegisterArgsForCall(0)
+			Expect(uri).To(Equal(route.Uri("test.example.com")))
+			uri, endpoint := registry.RegisterArgsForCall(1)
+			Expect(uri).To(Equal(route.TCPUri(1024)))
+			Expect(endpoint.CanonicalAddr()).To(Equal("host:1111"))
+		})
+
+		It("unregisters the endpoint from the tcp route of that port", func() {
+			data, err := json.Marshal(msg)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = natsClient.Publish("router.unregister", data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(registry.UnregisterCallCount).Should(Equal(2))
+			uri, _ := registry.UnregisterArgsForCall(1)
+			Expect(uri).To(Equal(route.TCPUri(1024)))
+		})
+	})
+
 	Context("when the message contains an http url for route services", func() {
 		BeforeEach(func() {
 			sub = mbus.NewSubscriber(natsClient, registry, cfg, reconnected, l)
@@ -31,6 +31,9 @@ type ProxyReporter interface {
 	CaptureRouteServiceResponse(res *http.Response)
 	CaptureWebSocketUpdate()
 	CaptureWebSocketFailure()
+	CaptureTCPConnection()
+	CaptureTCPConnectionFailure()
+	CaptureRetryBudgetExhausted()
 }
 
 type ComponentTagged interface {
@@ -46,6 +49,13 @@ type RouteRegistryReporter interface {
 	CaptureRouteRegistrationLatency(t time.Duration)
 	UnmuzzleRouteRegistrationLatency()
 	CaptureUnregistryMessage(msg ComponentTagged)
+	route.OutlierReporter
+}
+
+//go:generate counterfeiter -o fakes/fake_health_check_reporter.go . HealthCheckReporter
+type HealthCheckReporter interface {
+	CaptureBackendHealthCheck(passed bool)
+	CaptureUnhealthyBackends(count int)
 }
 
 type CompositeReporter struct {
@@ -70,5 +80,5 @@ func (c *CompositeReporter) CaptureRoutingRequest(b *route.Endpoint) {
 
 func (c *CompositeReporter) CaptureRoutingResponseLatency(b *route.Endpoint, statusCode int, t time.Time, d time.Duration) {
 	c.VarzReporter.CaptureRoutingResponseLatency(b, statusCode, t, d)
-	c.ProxyReporter.CaptureRoutingResponseLatency(b, 0, time.Time{}, d)
+	c.ProxyReporter.CaptureRoutingResponseLatency(b, statusCode, t, d)
 }
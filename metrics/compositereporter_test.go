@@ -86,8 +86,9 @@ var _ = Describe("CompositeReporter", func() {
 		Expect(callTime).To(Equal(responseTime))
 		Expect(callDuration).To(Equal(responseDuration))
 
-		callEndpoint, _, _, callDuration = fakeProxyReporter.CaptureRoutingResponseLatencyArgsForCall(0)
+		callEndpoint, callStatusCode, _, callDuration = fakeProxyReporter.CaptureRoutingResponseLatencyArgsForCall(0)
 		Expect(callEndpoint).To(Equal(endpoint))
+		Expect(callStatusCode).To(Equal(response.StatusCode))
 		Expect(callDuration).To(Equal(responseDuration))
 	})
 
@@ -120,4 +121,16 @@ var _ = Describe("CompositeReporter", func() {
 
 		Expect(fakeProxyReporter.CaptureWebSocketFailureCallCount()).To(Equal(1))
 	})
+
+	It("forwards CaptureTCPConnection to proxy reporter", func() {
+		composite.CaptureTCPConnection()
+
+		Expect(fakeProxyReporter.CaptureTCPConnectionCallCount()).To(Equal(1))
+	})
+
+	It("forwards CaptureTCPConnectionFailure to proxy reporter", func() {
+		composite.CaptureTCPConnectionFailure()
+
+		Expect(fakeProxyReporter.CaptureTCPConnectionFailureCallCount()).To(Equal(1))
+	})
 })
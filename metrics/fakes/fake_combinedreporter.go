@@ -52,14 +52,23 @@ type FakeCombinedReporter struct {
 	captureRouteServiceResponseArgsForCall []struct {
 		res *http.Response
 	}
-	CaptureWebSocketUpdateStub         func()
-	captureWebSocketUpdateMutex        sync.RWMutex
-	captureWebSocketUpdateArgsForCall  []struct{}
-	CaptureWebSocketFailureStub        func()
-	captureWebSocketFailureMutex       sync.RWMutex
-	captureWebSocketFailureArgsForCall []struct{}
-	invocations                        map[string][][]interface{}
-	invocationsMutex                   sync.RWMutex
+	CaptureWebSocketUpdateStub             func()
+	captureWebSocketUpdateMutex            sync.RWMutex
+	captureWebSocketUpdateArgsForCall      []struct{}
+	CaptureTCPConnectionStub               func()
+	captureTCPConnectionMutex              sync.RWMutex
+	captureTCPConnectionArgsForCall        []struct{}
+	CaptureTCPConnectionFailureStub        func()
+	captureTCPConnectionFailureMutex       sync.RWMutex
+	captureTCPConnectionFailureArgsForCall []struct{}
+	CaptureWebSocketFailureStub            func()
+	captureWebSocketFailureMutex           sync.RWMutex
+	captureWebSocketFailureArgsForCall     []struct{}
+	CaptureRetryBudgetExhaustedStub        func()
+	captureRetryBudgetExhaustedMutex       sync.RWMutex
+	captureRetryBudgetExhaustedArgsForCall []struct{}
+	invocations                            map[string][][]interface{}
+	invocationsMutex                       sync.RWMutex
 }
 
 func (fake *FakeCombinedReporter) CaptureBackendExhaustedConns() {
@@ -273,6 +282,38 @@ func (fake *FakeCombinedReporter) CaptureWebSocketUpdateCallCount() int {
 	return len(fake.captureWebSocketUpdateArgsForCall)
 }
 
+func (fake *FakeCombinedReporter) CaptureTCPConnection() {
+	fake.captureTCPConnectionMutex.Lock()
+	fake.captureTCPConnectionArgsForCall = append(fake.captureTCPConnectionArgsForCall, struct{}{})
+	fake.recordInvocation("CaptureTCPConnection", []interface{}{})
+	fake.captureTCPConnectionMutex.Unlock()
+	if fake.CaptureTCPConnectionStub != nil {
+		fake.CaptureTCPConnectionStub()
+	}
+}
+
+func (fake *FakeCombinedReporter) CaptureTCPConnectionCallCount() int {
+	fake.captureTCPConnectionMutex.RLock()
+	defer fake.captureTCPConnectionMutex.RUnlock()
+	return len(fake.captureTCPConnectionArgsForCall)
+}
+
+func (fake *FakeCombinedReporter) CaptureTCPConnectionFailure() {
+	fake.captureTCPConnectionFailureMutex.Lock()
+	fake.captureTCPConnectionFailureArgsForCall = append(fake.captureTCPConnectionFailureArgsForCall, struct{}{})
+	fake.recordInvocation("CaptureTCPConnectionFailure", []interface{}{})
+	fake.captureTCPConnectionFailureMutex.Unlock()
+	if fake.CaptureTCPConnectionFailureStub != nil {
+		fake.CaptureTCPConnectionFailureStub()
+	}
+}
+
+func (fake *FakeCombinedReporter) CaptureTCPConnectionFailureCallCount() int {
+	fake.captureTCPConnectionFailureMutex.RLock()
+	defer fake.captureTCPConnectionFailureMutex.RUnlock()
+	return len(fake.captureTCPConnectionFailureArgsForCall)
+}
+
 func (fake *FakeCombinedReporter) CaptureWebSocketFailure() {
 	fake.captureWebSocketFailureMutex.Lock()
 	fake.captureWebSocketFailureArgsForCall = append(fake.captureWebSocketFailureArgsForCall, struct{}{})
@@ -289,6 +330,22 @@ func (fake *FakeCombinedReporter) CaptureWebSocketFailureCallCount() int {
 	return len(fake.captureWebSocketFailureArgsForCall)
 }
 
+func (fake *FakeCombinedReporter) CaptureRetryBudgetExhausted() {
+	fake.captureRetryBudgetExhaustedMutex.Lock()
+	fake.captureRetryBudgetExhaustedArgsForCall = append(fake.captureRetryBudgetExhaustedArgsForCall, struct{}{})
+	fake.recordInvocation("CaptureRetryBudgetExhausted", []interface{}{})
+	fake.captureRetryBudgetExhaustedMutex.Unlock()
+	if fake.CaptureRetryBudgetExhaustedStub != nil {
+		fake.CaptureRetryBudgetExhaustedStub()
+	}
+}
+
+func (fake *FakeCombinedReporter) CaptureRetryBudgetExhaustedCallCount() int {
+	fake.captureRetryBudgetExhaustedMutex.RLock()
+	defer fake.captureRetryBudgetExhaustedMutex.RUnlock()
+	return len(fake.captureRetryBudgetExhaustedArgsForCall)
+}
+
 func (fake *FakeCombinedReporter) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -314,8 +371,14 @@ func (fake *FakeCombinedReporter) Invocations() map[string][][]interface{} {
 	defer fake.captureRouteServiceResponseMutex.RUnlock()
 	fake.captureWebSocketUpdateMutex.RLock()
 	defer fake.captureWebSocketUpdateMutex.RUnlock()
+	fake.captureTCPConnectionMutex.RLock()
+	defer fake.captureTCPConnectionMutex.RUnlock()
+	fake.captureTCPConnectionFailureMutex.RLock()
+	defer fake.captureTCPConnectionFailureMutex.RUnlock()
 	fake.captureWebSocketFailureMutex.RLock()
 	defer fake.captureWebSocketFailureMutex.RUnlock()
+	fake.captureRetryBudgetExhaustedMutex.RLock()
+	defer fake.captureRetryBudgetExhaustedMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -35,6 +35,10 @@ type FakeProxyReporter struct {
 	captureBadRequestMutex       sync.RWMutex
 	captureBadRequestArgsForCall []struct {
 	}
+	CaptureRetryBudgetExhaustedStub        func()
+	captureRetryBudgetExhaustedMutex       sync.RWMutex
+	captureRetryBudgetExhaustedArgsForCall []struct {
+	}
 	CaptureRouteServiceResponseStub        func(*http.Response)
 	captureRouteServiceResponseMutex       sync.RWMutex
 	captureRouteServiceResponseArgsForCall []struct {
@@ -58,6 +62,14 @@ type FakeProxyReporter struct {
 		arg3 time.Time
 		arg4 time.Duration
 	}
+	CaptureTCPConnectionStub        func()
+	captureTCPConnectionMutex       sync.RWMutex
+	captureTCPConnectionArgsForCall []struct {
+	}
+	CaptureTCPConnectionFailureStub        func()
+	captureTCPConnectionFailureMutex       sync.RWMutex
+	captureTCPConnectionFailureArgsForCall []struct {
+	}
 	CaptureWebSocketFailureStub        func()
 	captureWebSocketFailureMutex       sync.RWMutex
 	captureWebSocketFailureArgsForCall []struct {
@@ -208,6 +220,29 @@ func (fake *FakeProxyReporter) CaptureBadRequestCalls(stub func()) {
 	fake.CaptureBadRequestStub = stub
 }
 
+func (fake *FakeProxyReporter) CaptureRetryBudgetExhausted() {
+	fake.captureRetryBudgetExhaustedMutex.Lock()
+	fake.captureRetryBudgetExhaustedArgsForCall = append(fake.captureRetryBudgetExhaustedArgsForCall, struct {
+	}{})
+	fake.recordInvocation("CaptureRetryBudgetExhausted", []interface{}{})
+	fake.captureRetryBudgetExhaustedMutex.Unlock()
+	if fake.CaptureRetryBudgetExhaustedStub != nil {
+		fake.CaptureRetryBudgetExhaustedStub()
+	}
+}
+
+func (fake *FakeProxyReporter) CaptureRetryBudgetExhaustedCallCount() int {
+	fake.captureRetryBudgetExhaustedMutex.RLock()
+	defer fake.captureRetryBudgetExhaustedMutex.RUnlock()
+	return len(fake.captureRetryBudgetExhaustedArgsForCall)
+}
+
+func (fake *FakeProxyReporter) CaptureRetryBudgetExhaustedCalls(stub func()) {
+	fake.captureRetryBudgetExhaustedMutex.Lock()
+	defer fake.captureRetryBudgetExhaustedMutex.Unlock()
+	fake.CaptureRetryBudgetExhaustedStub = stub
+}
+
 func (fake *FakeProxyReporter) CaptureRouteServiceResponse(arg1 *http.Response) {
 	fake.captureRouteServiceResponseMutex.Lock()
 	fake.captureRouteServiceResponseArgsForCall = append(fake.captureRouteServiceResponseArgsForCall, struct {
@@ -335,6 +370,52 @@ func (fake *FakeProxyReporter) CaptureRoutingResponseLatencyArgsForCall(i int) (
 	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
 }
 
+func (fake *FakeProxyReporter) CaptureTCPConnection() {
+	fake.captureTCPConnectionMutex.Lock()
+	fake.captureTCPConnectionArgsForCall = append(fake.captureTCPConnectionArgsForCall, struct {
+	}{})
+	fake.recordInvocation("CaptureTCPConnection", []interface{}{})
+	fake.captureTCPConnectionMutex.Unlock()
+	if fake.CaptureTCPConnectionStub != nil {
+		fake.CaptureTCPConnectionStub()
+	}
+}
+
+func (fake *FakeProxyReporter) CaptureTCPConnectionCallCount() int {
+	fake.captureTCPConnectionMutex.RLock()
+	defer fake.captureTCPConnectionMutex.RUnlock()
+	return len(fake.captureTCPConnectionArgsForCall)
+}
+
+func (fake *FakeProxyReporter) CaptureTCPConnectionCalls(stub func()) {
+	fake.captureTCPConnectionMutex.Lock()
+	defer fake.captureTCPConnectionMutex.Unlock()
+	fake.CaptureTCPConnectionStub = stub
+}
+
+func (fake *FakeProxyReporter) CaptureTCPConnectionFailure() {
+	fake.captureTCPConnectionFailureMutex.Lock()
+	fake.captureTCPConnectionFailureArgsForCall = append(fake.captureTCPConnectionFailureArgsForCall, struct {
+	}{})
+	fake.recordInvocation("CaptureTCPConnectionFailure", []interface{}{})
+	fake.captureTCPConnectionFailureMutex.Unlock()
+	if fake.CaptureTCPConnectionFailureStub != nil {
+		fake.CaptureTCPConnectionFailureStub()
+	}
+}
+
+func (fake *FakeProxyReporter) CaptureTCPConnectionFailureCallCount() int {
+	fake.captureTCPConnectionFailureMutex.RLock()
+	defer fake.captureTCPConnectionFailureMutex.RUnlock()
+	return len(fake.captureTCPConnectionFailureArgsForCall)
+}
+
+func (fake *FakeProxyReporter) CaptureTCPConnectionFailureCalls(stub func()) {
+	fake.captureTCPConnectionFailureMutex.Lock()
+	defer fake.captureTCPConnectionFailureMutex.Unlock()
+	fake.CaptureTCPConnectionFailureStub = stub
+}
+
 func (fake *FakeProxyReporter) CaptureWebSocketFailure() {
 	fake.captureWebSocketFailureMutex.Lock()
 	fake.captureWebSocketFailureArgsForCall = append(fake.captureWebSocketFailureArgsForCall, struct {
@@ -396,6 +477,8 @@ func (fake *FakeProxyReporter) Invocations() map[string][][]interface{} {
 	defer fake.captureBadGatewayMutex.RUnlock()
 	fake.captureBadRequestMutex.RLock()
 	defer fake.captureBadRequestMutex.RUnlock()
+	fake.captureRetryBudgetExhaustedMutex.RLock()
+	defer fake.captureRetryBudgetExhaustedMutex.RUnlock()
 	fake.captureRouteServiceResponseMutex.RLock()
 	defer fake.captureRouteServiceResponseMutex.RUnlock()
 	fake.captureRoutingRequestMutex.RLock()
@@ -404,6 +487,10 @@ func (fake *FakeProxyReporter) Invocations() map[string][][]interface{} {
 	defer fake.captureRoutingResponseMutex.RUnlock()
 	fake.captureRoutingResponseLatencyMutex.RLock()
 	defer fake.captureRoutingResponseLatencyMutex.RUnlock()
+	fake.captureTCPConnectionMutex.RLock()
+	defer fake.captureTCPConnectionMutex.RUnlock()
+	fake.captureTCPConnectionFailureMutex.RLock()
+	defer fake.captureTCPConnectionFailureMutex.RUnlock()
 	fake.captureWebSocketFailureMutex.RLock()
 	defer fake.captureWebSocketFailureMutex.RUnlock()
 	fake.captureWebSocketUpdateMutex.RLock()
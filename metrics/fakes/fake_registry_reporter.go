@@ -14,6 +14,15 @@ type FakeRouteRegistryReporter struct {
 	captureLookupTimeArgsForCall []struct {
 		arg1 time.Duration
 	}
+	CaptureOutlierEjectionStub        func(string)
+	captureOutlierEjectionMutex       sync.RWMutex
+	captureOutlierEjectionArgsForCall []struct {
+		arg1 string
+	}
+	CaptureOutlierRecoveryStub        func()
+	captureOutlierRecoveryMutex       sync.RWMutex
+	captureOutlierRecoveryArgsForCall []struct {
+	}
 	CaptureRegistryMessageStub        func(metrics.ComponentTagged)
 	captureRegistryMessageMutex       sync.RWMutex
 	captureRegistryMessageArgsForCall []struct {
@@ -79,6 +88,60 @@ func (fake *FakeRouteRegistryReporter) CaptureLookupTimeArgsForCall(i int) time.
 	return argsForCall.arg1
 }
 
+func (fake *FakeRouteRegistryReporter) CaptureOutlierEjection(arg1 string) {
+	fake.captureOutlierEjectionMutex.Lock()
+	fake.captureOutlierEjectionArgsForCall = append(fake.captureOutlierEjectionArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("CaptureOutlierEjection", []interface{}{arg1})
+	fake.captureOutlierEjectionMutex.Unlock()
+	if fake.CaptureOutlierEjectionStub != nil {
+		fake.CaptureOutlierEjectionStub(arg1)
+	}
+}
+
+func (fake *FakeRouteRegistryReporter) CaptureOutlierEjectionCallCount() int {
+	fake.captureOutlierEjectionMutex.RLock()
+	defer fake.captureOutlierEjectionMutex.RUnlock()
+	return len(fake.captureOutlierEjectionArgsForCall)
+}
+
+func (fake *FakeRouteRegistryReporter) CaptureOutlierEjectionCalls(stub func(string)) {
+	fake.captureOutlierEjectionMutex.Lock()
+	defer fake.captureOutlierEjectionMutex.Unlock()
+	fake.CaptureOutlierEjectionStub = stub
+}
+
+func (fake *FakeRouteRegistryReporter) CaptureOutlierEjectionArgsForCall(i int) string {
+	fake.captureOutlierEjectionMutex.RLock()
+	defer fake.captureOutlierEjectionMutex.RUnlock()
+	argsForCall := fake.captureOutlierEjectionArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeRouteRegistryReporter) CaptureOutlierRecovery() {
+	fake.captureOutlierRecoveryMutex.Lock()
+	fake.captureOutlierRecoveryArgsForCall = append(fake.captureOutlierRecoveryArgsForCall, struct {
+	}{})
+	fake.recordInvocation("CaptureOutlierRecovery", []interface{}{})
+	fake.captureOutlierRecoveryMutex.Unlock()
+	if fake.CaptureOutlierRecoveryStub != nil {
+		fake.CaptureOutlierRecoveryStub()
+	}
+}
+
+func (fake *FakeRouteRegistryReporter) CaptureOutlierRecoveryCallCount() int {
+	fake.captureOutlierRecoveryMutex.RLock()
+	defer fake.captureOutlierRecoveryMutex.RUnlock()
+	return len(fake.captureOutlierRecoveryArgsForCall)
+}
+
+func (fake *FakeRouteRegistryReporter) CaptureOutlierRecoveryCalls(stub func()) {
+	fake.captureOutlierRecoveryMutex.Lock()
+	defer fake.captureOutlierRecoveryMutex.Unlock()
+	fake.CaptureOutlierRecoveryStub = stub
+}
+
 func (fake *FakeRouteRegistryReporter) CaptureRegistryMessage(arg1 metrics.ComponentTagged) {
 	fake.captureRegistryMessageMutex.Lock()
 	fake.captureRegistryMessageArgsForCall = append(fake.captureRegistryMessageArgsForCall, struct {
@@ -263,6 +326,10 @@ func (fake *FakeRouteRegistryReporter) Invocations() map[string][][]interface{}
 	defer fake.invocationsMutex.RUnlock()
 	fake.captureLookupTimeMutex.RLock()
 	defer fake.captureLookupTimeMutex.RUnlock()
+	fake.captureOutlierEjectionMutex.RLock()
+	defer fake.captureOutlierEjectionMutex.RUnlock()
+	fake.captureOutlierRecoveryMutex.RLock()
+	defer fake.captureOutlierRecoveryMutex.RUnlock()
 	fake.captureRegistryMessageMutex.RLock()
 	defer fake.captureRegistryMessageMutex.RUnlock()
 	fake.captureRouteRegistrationLatencyMutex.RLock()
@@ -137,6 +137,39 @@ func (m *MetricsReporter) CaptureWebSocketFailure() {
 	m.Batcher.BatchIncrementCounter("websocket_failures")
 }
 
+func (m *MetricsReporter) CaptureTCPConnection() {
+	m.Batcher.BatchIncrementCounter("tcp_connections")
+}
+
+func (m *MetricsReporter) CaptureTCPConnectionFailure() {
+	m.Batcher.BatchIncrementCounter("tcp_connection_failures")
+}
+
+func (m *MetricsReporter) CaptureRetryBudgetExhausted() {
+	m.Batcher.BatchIncrementCounter("retry_budget_exhausted")
+}
+
+func (m *MetricsReporter) CaptureBackendHealthCheck(passed bool) {
+	if passed {
+		m.Batcher.BatchIncrementCounter("backend_health_checks.passed")
+	} else {
+		m.Batcher.BatchIncrementCounter("backend_health_checks.failed")
+	}
+}
+
+func (m *MetricsReporter) CaptureUnhealthyBackends(count int) {
+	m.Sender.SendValue("unhealthy_backends", float64(count), "")
+}
+
+func (m *MetricsReporter) CaptureOutlierEjection(reason string) {
+	m.Batcher.BatchIncrementCounter("outlier_ejections")
+	m.Batcher.BatchIncrementCounter("outlier_ejections." + reason)
+}
+
+func (m *MetricsReporter) CaptureOutlierRecovery() {
+	m.Batcher.BatchIncrementCounter("outlier_recoveries")
+}
+
 func getResponseCounterName(statusCode int) string {
 	statusCode = statusCode / 100
 	if statusCode >= 2 && statusCode <= 5 {
@@ -479,6 +479,59 @@ var _ = Describe("MetricsReporter", func() {
 		})
 	})
 
+	Context("tcp metrics", func() {
+		It("increments the tcp connections metric", func() {
+			metricReporter.CaptureTCPConnection()
+			Expect(batcher.BatchIncrementCounterCallCount()).To(Equal(1))
+			Expect(batcher.BatchIncrementCounterArgsForCall(0)).To(Equal("tcp_connections"))
+		})
+		It("increments the tcp connection failures metric", func() {
+			metricReporter.CaptureTCPConnectionFailure()
+			Expect(batcher.BatchIncrementCounterCallCount()).To(Equal(1))
+			Expect(batcher.BatchIncrementCounterArgsForCall(0)).To(Equal("tcp_connection_failures"))
+		})
+	})
+
+	Context("retry metrics", func() {
+		It("increments the retry budget exhausted metric", func() {
+			metricReporter.CaptureRetryBudgetExhausted()
+			Expect(batcher.BatchIncrementCounterCallCount()).To(Equal(1))
+			Expect(batcher.BatchIncrementCounterArgsForCall(0)).To(Equal("retry_budget_exhausted"))
+		})
+	})
+
+	Context("backend health check metrics", func() {
+		It("increments the passed and failed health checks metrics", func() {
+			metricReporter.CaptureBackendHealthCheck(true)
+			metricReporter.CaptureBackendHealthCheck(false)
+			Expect(batcher.BatchIncrementCounterCallCount()).To(Equal(2))
+			Expect(batcher.BatchIncrementCounterArgsForCall(0)).To(Equal("backend_health_checks.passed"))
+			Expect(batcher.BatchIncrementCounterArgsForCall(1)).To(Equal("backend_health_checks.failed"))
+		})
+		It("sends the number of unhealthy backends", func() {
+			metricReporter.CaptureUnhealthyBackends(3)
+			Expect(sender.SendValueCallCount()).To(Equal(1))
+			name, value, unit := sender.SendValueArgsForCall(0)
+			Expect(name).To(Equal("unhealthy_backends"))
+			Expect(value).To(BeEquivalentTo(3))
+			Expect(unit).To(Equal(""))
+		})
+	})
+
+	Context("outlier detection metrics", func() {
+		It("increments the outlier ejections metrics", func() {
+			metricReporter.CaptureOutlierEjection("consecutive_5xx")
+			Expect(batcher.BatchIncrementCounterCallCount()).To(Equal(2))
+			Expect(batcher.BatchIncrementCounterArgsForCall(0)).To(Equal("outlier_ejections"))
+			Expect(batcher.BatchIncrementCounterArgsForCall(1)).To(Equal("outlier_ejections.consecutive_5xx"))
+		})
+		It("increments the outlier recoveries metric", func() {
+			metricReporter.CaptureOutlierRecovery()
+			Expect(batcher.BatchIncrementCounterCallCount()).To(Equal(1))
+			Expect(batcher.BatchIncrementCounterArgsForCall(0)).To(Equal("outlier_recoveries"))
+		})
+	})
+
 	Describe("CaptureRouteRegistrationLatency", func() {
 		It("is muzzled by default", func() {
 			metricReporter.CaptureRouteRegistrationLatency(2 * time.Second)
@@ -0,0 +1,176 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/route"
+)
+
+// MetricReporter reports the proxy, route registry and backend health check
+// metrics.
+type MetricReporter interface {
+	ProxyReporter
+	RouteRegistryReporter
+	HealthCheckReporter
+}
+
+// MultiReporter forwards every metric to each of its reporters, so that
+// metrics can be emitted through dropsonde and Prometheus at the same time.
+type MultiReporter []MetricReporter
+
+func (m MultiReporter) CaptureBackendExhaustedConns() {
+	for _, r := range m {
+		r.CaptureBackendExhaustedConns()
+	}
+}
+
+func (m MultiReporter) CaptureBackendInvalidID() {
+	for _, r := range m {
+		r.CaptureBackendInvalidID()
+	}
+}
+
+func (m MultiReporter) CaptureBackendInvalidTLSCert() {
+	for _, r := range m {
+		r.CaptureBackendInvalidTLSCert()
+	}
+}
+
+func (m MultiReporter) CaptureBackendTLSHandshakeFailed() {
+	for _, r := range m {
+		r.CaptureBackendTLSHandshakeFailed()
+	}
+}
+
+func (m MultiReporter) CaptureBadRequest() {
+	for _, r := range m {
+		r.CaptureBadRequest()
+	}
+}
+
+func (m MultiReporter) CaptureBadGateway() {
+	for _, r := range m {
+		r.CaptureBadGateway()
+	}
+}
+
+func (m MultiReporter) CaptureRoutingRequest(b *route.Endpoint) {
+	for _, r := range m {
+		r.CaptureRoutingRequest(b)
+	}
+}
+
+func (m MultiReporter) CaptureRoutingResponse(statusCode int) {
+	for _, r := range m {
+		r.CaptureRoutingResponse(statusCode)
+	}
+}
+
+func (m MultiReporter) CaptureRoutingResponseLatency(b *route.Endpoint, statusCode int, t time.Time, d time.Duration) {
+	for _, r := range m {
+		r.CaptureRoutingResponseLatency(b, statusCode, t, d)
+	}
+}
+
+func (m MultiReporter) CaptureRouteServiceResponse(res *http.Response) {
+	for _, r := range m {
+		r.CaptureRouteServiceResponse(res)
+	}
+}
+
+func (m MultiReporter) CaptureWebSocketUpdate() {
+	for _, r := range m {
+		r.CaptureWebSocketUpdate()
+	}
+}
+
+func (m MultiReporter) CaptureWebSocketFailure() {
+	for _, r := range m {
+		r.CaptureWebSocketFailure()
+	}
+}
+
+func (m MultiReporter) CaptureTCPConnection() {
+	for _, r := range m {
+		r.CaptureTCPConnection()
+	}
+}
+
+func (m MultiReporter) CaptureTCPConnectionFailure() {
+	for _, r := range m {
+		r.CaptureTCPConnectionFailure()
+	}
+}
+
+func (m MultiReporter) CaptureRetryBudgetExhausted() {
+	for _, r := range m {
+		r.CaptureRetryBudgetExhausted()
+	}
+}
+
+func (m MultiReporter) CaptureRouteStats(totalRoutes int, msSinceLastUpdate int64) {
+	for _, r := range m {
+		r.CaptureRouteStats(totalRoutes, msSinceLastUpdate)
+	}
+}
+
+func (m MultiReporter) CaptureRoutesPruned(prunedRoutes uint64) {
+	for _, r := range m {
+		r.CaptureRoutesPruned(prunedRoutes)
+	}
+}
+
+func (m MultiReporter) CaptureLookupTime(t time.Duration) {
+	for _, r := range m {
+		r.CaptureLookupTime(t)
+	}
+}
+
+func (m MultiReporter) CaptureRegistryMessage(msg ComponentTagged) {
+	for _, r := range m {
+		r.CaptureRegistryMessage(msg)
+	}
+}
+
+func (m MultiReporter) CaptureRouteRegistrationLatency(t time.Duration) {
+	for _, r := range m {
+		r.CaptureRouteRegistrationLatency(t)
+	}
+}
+
+func (m MultiReporter) UnmuzzleRouteRegistrationLatency() {
+	for _, r := range m {
+		r.UnmuzzleRouteRegistrationLatency()
+	}
+}
+
+func (m MultiReporter) CaptureUnregistryMessage(msg ComponentTagged) {
+	for _, r := range m {
+		r.CaptureUnregistryMessage(msg)
+	}
+}
+
+func (m MultiReporter) CaptureBackendHealthCheck(passed bool) {
+	for _, r := range m {
+		r.CaptureBackendHealthCheck(passed)
+	}
+}
+
+func (m MultiReporter) CaptureUnhealthyBackends(count int) {
+	for _, r := range m {
+		r.CaptureUnhealthyBackends(count)
+	}
+}
+
+func (m MultiReporter) CaptureOutlierEjection(reason string) {
+	for _, r := range m {
+		r.CaptureOutlierEjection(reason)
+	}
+}
+
+func (m MultiReporter) CaptureOutlierRecovery() {
+	for _, r := range m {
+		r.CaptureOutlierRecovery()
+	}
+}
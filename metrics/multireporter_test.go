@@ -0,0 +1,65 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/metrics"
+	"code.cloudfoundry.org/gorouter/route"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MultiReporter", func() {
+	var (
+		first, second *metrics.PrometheusReporter
+		multi         metrics.MultiReporter
+		endpoint      *route.Endpoint
+	)
+
+	scrape := func(reporter *metrics.PrometheusReporter) string {
+		rw := httptest.NewRecorder()
+		reporter.Handler().ServeHTTP(rw, httptest.NewRequest("GET", "/metrics", nil))
+		return rw.Body.String()
+	}
+
+	BeforeEach(func() {
+		first = metrics.NewPrometheusReporter()
+		second = metrics.NewPrometheusReporter()
+		multi = metrics.MultiReporter{first, second}
+		endpoint = route.NewEndpoint(&route.EndpointOpts{Tags: map[string]string{"component": "uaa"}})
+	})
+
+	It("forwards proxy metrics to every reporter", func() {
+		multi.CaptureBadGateway()
+		multi.CaptureRoutingRequest(endpoint)
+		multi.CaptureRoutingResponseLatency(endpoint, http.StatusOK, time.Now(), time.Millisecond)
+		multi.CaptureTCPConnection()
+
+		for _, reporter := range []*metrics.PrometheusReporter{first, second} {
+			body := scrape(reporter)
+			Expect(body).To(ContainSubstring("gorouter_bad_gateways_total 1\n"))
+			Expect(body).To(ContainSubstring(`gorouter_requests_total{component="uaa"} 1`))
+			Expect(body).To(ContainSubstring(`gorouter_latency_seconds_count{component="uaa",status_class="2xx"} 1`))
+			Expect(body).To(ContainSubstring("gorouter_tcp_connections_total 1\n"))
+		}
+	})
+
+	It("forwards route registry metrics to every reporter", func() {
+		multi.CaptureRouteStats(4, 0)
+		multi.CaptureRegistryMessage(endpoint)
+		multi.UnmuzzleRouteRegistrationLatency()
+		multi.CaptureRouteRegistrationLatency(time.Millisecond)
+		multi.CaptureOutlierEjection("error_rate")
+
+		for _, reporter := range []*metrics.PrometheusReporter{first, second} {
+			body := scrape(reporter)
+			Expect(body).To(ContainSubstring("gorouter_total_routes 4\n"))
+			Expect(body).To(ContainSubstring(`gorouter_registry_messages_total{component="uaa"} 1`))
+			Expect(body).To(ContainSubstring("gorouter_route_registration_latency_seconds_count 1\n"))
+			Expect(body).To(ContainSubstring(`gorouter_outlier_ejections_total{reason="error_rate"} 1`))
+		}
+	})
+})
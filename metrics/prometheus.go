@@ -0,0 +1,301 @@
+package metrics
+
+import (
+	"bufio"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// prometheusDefBuckets are the default buckets of Prometheus client libraries,
+// meant for latencies of network services in seconds.
+var prometheusDefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// prometheusExponentialBuckets returns count buckets, the first of them
+// start, each bucket factor times the one before.
+func prometheusExponentialBuckets(start, factor float64, count int) []float64 {
+	buckets := make([]float64, count)
+	for i := range buckets {
+		buckets[i] = start
+		start *= factor
+	}
+	return buckets
+}
+
+// prometheusRegistry serves metric families in the Prometheus text exposition
+// format. It only covers what PrometheusReporter needs: counters, gauges and
+// histograms with optional labels, and gauges read when they are scraped.
+type prometheusRegistry struct {
+	lock     sync.Mutex
+	families []*prometheusFamily
+}
+
+func (r *prometheusRegistry) register(f *prometheusFamily) *prometheusFamily {
+	r.lock.Lock()
+	r.families = append(r.families, f)
+	r.lock.Unlock()
+	return f
+}
+
+func (r *prometheusRegistry) counter(name, help string) *prometheusValue {
+	return r.counterVec(name, help).WithLabelValues()
+}
+
+func (r *prometheusRegistry) counterVec(name, help string, labels ...string) prometheusCounterVec {
+	return prometheusCounterVec{r.register(newPrometheusFamily(name, help, "counter", newPrometheusValue, labels...))}
+}
+
+func (r *prometheusRegistry) gauge(name, help string) *prometheusValue {
+	return r.register(newPrometheusFamily(name, help, "gauge", newPrometheusValue)).with().(*prometheusValue)
+}
+
+func (r *prometheusRegistry) gaugeFunc(name, help string, read func() float64) {
+	r.register(newPrometheusFamily(name, help, "gauge", func() prometheusSeries { return prometheusGaugeFunc(read) }))
+}
+
+func (r *prometheusRegistry) histogram(name, help string, buckets []float64) *prometheusHistogram {
+	return r.histogramVec(name, help, buckets).WithLabelValues()
+}
+
+func (r *prometheusRegistry) histogramVec(name, help string, buckets []float64, labels ...string) prometheusHistogramVec {
+	newSeries := func() prometheusSeries { return newPrometheusHistogram(buckets) }
+	return prometheusHistogramVec{r.register(newPrometheusFamily(name, help, "histogram", newSeries, labels...))}
+}
+
+func (r *prometheusRegistry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	r.lock.Lock()
+	families := append([]*prometheusFamily(nil), r.families...)
+	r.lock.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		f.write(bw)
+	}
+	_ = bw.Flush()
+}
+
+// prometheusSeries is one series of a metric family, identified by its label
+// values.
+type prometheusSeries interface {
+	write(w *bufio.Writer, name string, labels []string, values []string)
+}
+
+// prometheusFamily is a metric with its help text, type and label names, and
+// its series. A family without label names has exactly one series, which is
+// written even before it is first used.
+type prometheusFamily struct {
+	name      string
+	help      string
+	kind      string
+	labels    []string
+	newSeries func() prometheusSeries
+
+	lock   sync.RWMutex
+	series map[string]*prometheusLabelledSeries
+}
+
+type prometheusLabelledSeries struct {
+	values []string
+	series prometheusSeries
+}
+
+type prometheusCounterVec struct {
+	*prometheusFamily
+}
+
+func (v prometheusCounterVec) WithLabelValues(values ...string) *prometheusValue {
+	return v.with(values...).(*prometheusValue)
+}
+
+type prometheusHistogramVec struct {
+	*prometheusFamily
+}
+
+func (v prometheusHistogramVec) WithLabelValues(values ...string) *prometheusHistogram {
+	return v.with(values...).(*prometheusHistogram)
+}
+
+func newPrometheusFamily(name, help, kind string, newSeries func() prometheusSeries, labels ...string) *prometheusFamily {
+	f := &prometheusFamily{
+		name:      name,
+		help:      help,
+		kind:      kind,
+		labels:    labels,
+		newSeries: newSeries,
+		series:    map[string]*prometheusLabelledSeries{},
+	}
+	if len(labels) == 0 {
+		f.with()
+	}
+	return f
+}
+
+// with returns the series of the label values, creating it on first use.
+func (f *prometheusFamily) with(values ...string) prometheusSeries {
+	key := strings.Join(values, "\xff")
+
+	f.lock.RLock()
+	s, ok := f.series[key]
+	f.lock.RUnlock()
+	if ok {
+		return s.series
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if s, ok := f.series[key]; ok {
+		return s.series
+	}
+	s = &prometheusLabelledSeries{values: values, series: f.newSeries()}
+	f.series[key] = s
+	return s.series
+}
+
+func (f *prometheusFamily) write(w *bufio.Writer) {
+	f.lock.RLock()
+	series := make([]*prometheusLabelledSeries, 0, len(f.series))
+	for _, s := range f.series {
+		series = append(series, s)
+	}
+	f.lock.RUnlock()
+
+	if len(series) == 0 {
+		return
+	}
+	sort.Slice(series, func(i, j int) bool {
+		return strings.Join(series[i].values, "\xff") < strings.Join(series[j].values, "\xff")
+	})
+
+	w.WriteString("# HELP " + f.name + " " + escapePrometheusHelp(f.help) + "\n")
+	w.WriteString("# TYPE " + f.name + " " + f.kind + "\n")
+	for _, s := range series {
+		s.series.write(w, f.name, f.labels, s.values)
+	}
+}
+
+// prometheusValue is the value of a counter or gauge series.
+type prometheusValue struct {
+	bits uint64 // float64 bits, accessed atomically
+}
+
+func newPrometheusValue() prometheusSeries {
+	return &prometheusValue{}
+}
+
+func (v *prometheusValue) Inc() {
+	v.Add(1)
+}
+
+func (v *prometheusValue) Add(delta float64) {
+	for {
+		old := atomic.LoadUint64(&v.bits)
+		updated := math.Float64bits(math.Float64frombits(old) + delta)
+		if atomic.CompareAndSwapUint64(&v.bits, old, updated) {
+			return
+		}
+	}
+}
+
+func (v *prometheusValue) Set(value float64) {
+	atomic.StoreUint64(&v.bits, math.Float64bits(value))
+}
+
+func (v *prometheusValue) write(w *bufio.Writer, name string, labels []string, values []string) {
+	writePrometheusSample(w, name, labels, values, math.Float64frombits(atomic.LoadUint64(&v.bits)))
+}
+
+// prometheusGaugeFunc is a gauge series read whenever it is scraped.
+type prometheusGaugeFunc func() float64
+
+func (g prometheusGaugeFunc) write(w *bufio.Writer, name string, labels []string, values []string) {
+	writePrometheusSample(w, name, labels, values, g())
+}
+
+// prometheusHistogram counts observations in buckets by their upper bound.
+type prometheusHistogram struct {
+	buckets []float64
+
+	lock   sync.Mutex
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func newPrometheusHistogram(buckets []float64) *prometheusHistogram {
+	return &prometheusHistogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+func (h *prometheusHistogram) Observe(value float64) {
+	i := sort.SearchFloat64s(h.buckets, value)
+
+	h.lock.Lock()
+	if i < len(h.counts) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += value
+	h.lock.Unlock()
+}
+
+func (h *prometheusHistogram) write(w *bufio.Writer, name string, labels []string, values []string) {
+	h.lock.Lock()
+	counts := append([]uint64(nil), h.counts...)
+	count, sum := h.count, h.sum
+	h.lock.Unlock()
+
+	bucketLabels := append(append([]string(nil), labels...), "le")
+	var cumulative uint64
+	for i, upperBound := range h.buckets {
+		cumulative += counts[i]
+		bucketValues := append(append([]string(nil), values...), formatPrometheusValue(upperBound))
+		writePrometheusSample(w, name+"_bucket", bucketLabels, bucketValues, float64(cumulative))
+	}
+	writePrometheusSample(w, name+"_bucket", bucketLabels, append(append([]string(nil), values...), "+Inf"), float64(count))
+	writePrometheusSample(w, name+"_sum", labels, values, sum)
+	writePrometheusSample(w, name+"_count", labels, values, float64(count))
+}
+
+func writePrometheusSample(w *bufio.Writer, name string, labels []string, values []string, value float64) {
+	w.WriteString(name)
+	if len(labels) > 0 {
+		w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(label + `="` + escapePrometheusLabelValue(values[i]) + `"`)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteString(" " + formatPrometheusValue(value) + "\n")
+}
+
+func formatPrometheusValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	prometheusHelpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	prometheusLabelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapePrometheusHelp(s string) string {
+	return prometheusHelpEscaper.Replace(s)
+}
+
+func escapePrometheusLabelValue(s string) string {
+	return prometheusLabelValueEscaper.Replace(s)
+}
@@ -0,0 +1,270 @@
+package metrics
+
+import (
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/metrics/monitor"
+	"code.cloudfoundry.org/gorouter/route"
+)
+
+const prometheusNamespace = "gorouter"
+
+// PrometheusReporter keeps the proxy and route registry metrics in Prometheus
+// metrics that are scraped from Handler, instead of sending them to a metron
+// agent.
+type PrometheusReporter struct {
+	registry *prometheusRegistry
+
+	backendExhaustedConns     *prometheusValue
+	backendInvalidID          *prometheusValue
+	backendInvalidTLSCert     *prometheusValue
+	backendTLSHandshakeFailed *prometheusValue
+	rejectedRequests          *prometheusValue
+	badGateways               *prometheusValue
+	requests                  prometheusCounterVec
+	routedAppRequests         *prometheusValue
+	responses                 prometheusCounterVec
+	routeServiceResponses     prometheusCounterVec
+	latency                   prometheusHistogramVec
+	websocketUpgrades         *prometheusValue
+	websocketFailures         *prometheusValue
+	tcpConnections            *prometheusValue
+	tcpConnectionFailures     *prometheusValue
+	retryBudgetExhausted      *prometheusValue
+
+	totalRoutes              *prometheusValue
+	timeSinceLastUpdate      *prometheusValue
+	routesPruned             *prometheusValue
+	lookupTime               *prometheusHistogram
+	registryMessages         prometheusCounterVec
+	unregistryMessages       prometheusCounterVec
+	routeRegistrationLatency *prometheusHistogram
+
+	backendHealthChecks prometheusCounterVec
+	unhealthyBackends   *prometheusValue
+
+	outlierEjections  prometheusCounterVec
+	outlierRecoveries *prometheusValue
+
+	unmuzzled uint64
+}
+
+func NewPrometheusReporter() *PrometheusReporter {
+	r := &prometheusRegistry{}
+	counter := func(name, help string) *prometheusValue {
+		return r.counter(prometheusNamespace+"_"+name, help)
+	}
+	counterVec := func(name, help string, labels ...string) prometheusCounterVec {
+		return r.counterVec(prometheusNamespace+"_"+name, help, labels...)
+	}
+	gauge := func(name, help string) *prometheusValue {
+		return r.gauge(prometheusNamespace+"_"+name, help)
+	}
+
+	p := &PrometheusReporter{
+		registry: r,
+
+		backendExhaustedConns:     counter("backend_exhausted_conns_total", "Requests rejected because the backend reached its connection limit."),
+		backendInvalidID:          counter("backend_invalid_id_total", "Backend connections rejected because the backend reported an unexpected instance ID."),
+		backendInvalidTLSCert:     counter("backend_invalid_tls_cert_total", "Backend connections rejected because of an invalid TLS certificate."),
+		backendTLSHandshakeFailed: counter("backend_tls_handshake_failed_total", "Backend connections that failed the TLS handshake."),
+		rejectedRequests:          counter("rejected_requests_total", "Requests rejected as bad requests."),
+		badGateways:               counter("bad_gateways_total", "Requests answered with 502 Bad Gateway."),
+		requests:                  counterVec("requests_total", "Requests routed, by component tag of the backend.", "component"),
+		routedAppRequests:         counter("routed_app_requests_total", "Requests routed to applications."),
+		responses:                 counterVec("responses_total", "Responses, by status class.", "status_class"),
+		routeServiceResponses:     counterVec("route_services_responses_total", "Route service responses, by status class.", "status_class"),
+		latency: r.histogramVec(prometheusNamespace+"_latency_seconds",
+			"Time from receiving a request until the backend response is complete, by component tag of the backend and status class.",
+			prometheusDefBuckets, "component", "status_class"),
+		websocketUpgrades:     counter("websocket_upgrades_total", "Successful websocket upgrades."),
+		websocketFailures:     counter("websocket_failures_total", "Failed websocket upgrades."),
+		tcpConnections:        counter("tcp_connections_total", "TCP connections forwarded to a backend."),
+		tcpConnectionFailures: counter("tcp_connection_failures_total", "TCP connections that could not be forwarded to a backend."),
+		retryBudgetExhausted:  counter("retry_budget_exhausted_total", "Requests not retried because the retry budget of their route was exhausted."),
+
+		totalRoutes:         gauge("total_routes", "Routes in the routing table."),
+		timeSinceLastUpdate: gauge("seconds_since_last_registry_update", "Time since the routing table last changed."),
+		routesPruned:        counter("routes_pruned_total", "Stale routes pruned from the routing table."),
+		lookupTime: r.histogram(prometheusNamespace+"_route_lookup_seconds",
+			"Time to look up the route of a request in the routing table.",
+			prometheusExponentialBuckets(0.000001, 4, 10)),
+		registryMessages:   counterVec("registry_messages_total", "Route registration messages, by component tag.", "component"),
+		unregistryMessages: counterVec("unregistry_messages_total", "Route unregistration messages, by component tag.", "component"),
+		routeRegistrationLatency: r.histogram(prometheusNamespace+"_route_registration_latency_seconds",
+			"Time from a route being registered until it is added to the routing table.",
+			prometheusDefBuckets),
+
+		backendHealthChecks: counterVec("backend_health_checks_total", "Active health checks of backends, by result.", "result"),
+		unhealthyBackends:   gauge("unhealthy_backends", "Backends that failed their active health checks."),
+
+		outlierEjections:  counterVec("outlier_ejections_total", "Backends ejected by outlier detection, by reason.", "reason"),
+		outlierRecoveries: counter("outlier_recoveries_total", "Backends returned to their routes after an ejection by outlier detection."),
+	}
+
+	r.gaugeFunc("go_goroutines", "Number of goroutines that currently exist.", func() float64 {
+		return float64(runtime.NumGoroutine())
+	})
+	r.gaugeFunc("go_memstats_heap_alloc_bytes", "Number of heap bytes allocated and still in use.", func() float64 {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		return float64(stats.HeapAlloc)
+	})
+	return p
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (p *PrometheusReporter) Handler() http.Handler {
+	return p.registry
+}
+
+// MonitorNATSSubscription reports the messages buffered and dropped by the
+// NATS subscription. They are read whenever the metrics are scraped.
+func (p *PrometheusReporter) MonitorNATSSubscription(subscriber monitor.Subscriber) {
+	p.registry.gaugeFunc(prometheusNamespace+"_buffered_messages", "NATS messages buffered by the subscription and not processed yet.", func() float64 {
+		n, _ := subscriber.Pending()
+		return float64(n)
+	})
+	p.registry.gaugeFunc(prometheusNamespace+"_total_dropped_messages", "NATS messages dropped by the subscription because its buffer was full.", func() float64 {
+		n, _ := subscriber.Dropped()
+		return float64(n)
+	})
+}
+
+// MonitorFileDescriptors reports the number of file descriptors listed in
+// path, usually /proc/<pid>/fd. It is read whenever the metrics are scraped.
+func (p *PrometheusReporter) MonitorFileDescriptors(path string) {
+	p.registry.gaugeFunc(prometheusNamespace+"_file_descriptors", "File descriptors open by the process.", func() float64 {
+		dir, err := os.Open(path)
+		if err != nil {
+			return 0
+		}
+		defer dir.Close()
+		names, _ := dir.Readdirnames(-1)
+		return float64(len(names))
+	})
+}
+
+func (p *PrometheusReporter) CaptureBackendExhaustedConns() {
+	p.backendExhaustedConns.Inc()
+}
+
+func (p *PrometheusReporter) CaptureBackendInvalidID() {
+	p.backendInvalidID.Inc()
+}
+
+func (p *PrometheusReporter) CaptureBackendInvalidTLSCert() {
+	p.backendInvalidTLSCert.Inc()
+}
+
+func (p *PrometheusReporter) CaptureBackendTLSHandshakeFailed() {
+	p.backendTLSHandshakeFailed.Inc()
+}
+
+func (p *PrometheusReporter) CaptureBadRequest() {
+	p.rejectedRequests.Inc()
+}
+
+func (p *PrometheusReporter) CaptureBadGateway() {
+	p.badGateways.Inc()
+}
+
+func (p *PrometheusReporter) CaptureRoutingRequest(b *route.Endpoint) {
+	componentName := b.Tags["component"]
+	p.requests.WithLabelValues(componentName).Inc()
+	if strings.HasPrefix(componentName, "dea-") {
+		p.routedAppRequests.Inc()
+	}
+}
+
+func (p *PrometheusReporter) CaptureRoutingResponse(statusCode int) {
+	p.responses.WithLabelValues(getResponseCounterName(statusCode)).Inc()
+}
+
+func (p *PrometheusReporter) CaptureRoutingResponseLatency(b *route.Endpoint, statusCode int, _ time.Time, d time.Duration) {
+	p.latency.WithLabelValues(b.Tags["component"], getResponseCounterName(statusCode)).Observe(d.Seconds())
+}
+
+func (p *PrometheusReporter) CaptureRouteServiceResponse(res *http.Response) {
+	var statusCode int
+	if res != nil {
+		statusCode = res.StatusCode
+	}
+	p.routeServiceResponses.WithLabelValues(getResponseCounterName(statusCode)).Inc()
+}
+
+func (p *PrometheusReporter) CaptureWebSocketUpdate() {
+	p.websocketUpgrades.Inc()
+}
+
+func (p *PrometheusReporter) CaptureWebSocketFailure() {
+	p.websocketFailures.Inc()
+}
+
+func (p *PrometheusReporter) CaptureTCPConnection() {
+	p.tcpConnections.Inc()
+}
+
+func (p *PrometheusReporter) CaptureTCPConnectionFailure() {
+	p.tcpConnectionFailures.Inc()
+}
+
+func (p *PrometheusReporter) CaptureRetryBudgetExhausted() {
+	p.retryBudgetExhausted.Inc()
+}
+
+func (p *PrometheusReporter) CaptureRouteStats(totalRoutes int, msSinceLastUpdate int64) {
+	p.totalRoutes.Set(float64(totalRoutes))
+	p.timeSinceLastUpdate.Set((time.Duration(msSinceLastUpdate) * time.Millisecond).Seconds())
+}
+
+func (p *PrometheusReporter) CaptureRoutesPruned(routesPruned uint64) {
+	p.routesPruned.Add(float64(routesPruned))
+}
+
+func (p *PrometheusReporter) CaptureLookupTime(t time.Duration) {
+	p.lookupTime.Observe(t.Seconds())
+}
+
+func (p *PrometheusReporter) CaptureRegistryMessage(msg ComponentTagged) {
+	p.registryMessages.WithLabelValues(msg.Component()).Inc()
+}
+
+func (p *PrometheusReporter) CaptureUnregistryMessage(msg ComponentTagged) {
+	p.unregistryMessages.WithLabelValues(msg.Component()).Inc()
+}
+
+func (p *PrometheusReporter) UnmuzzleRouteRegistrationLatency() {
+	atomic.StoreUint64(&p.unmuzzled, 1)
+}
+
+func (p *PrometheusReporter) CaptureRouteRegistrationLatency(t time.Duration) {
+	if atomic.LoadUint64(&p.unmuzzled) == 1 {
+		p.routeRegistrationLatency.Observe(t.Seconds())
+	}
+}
+
+func (p *PrometheusReporter) CaptureBackendHealthCheck(passed bool) {
+	result := "failed"
+	if passed {
+		result = "passed"
+	}
+	p.backendHealthChecks.WithLabelValues(result).Inc()
+}
+
+func (p *PrometheusReporter) CaptureUnhealthyBackends(count int) {
+	p.unhealthyBackends.Set(float64(count))
+}
+
+func (p *PrometheusReporter) CaptureOutlierEjection(reason string) {
+	p.outlierEjections.WithLabelValues(reason).Inc()
+}
+
+func (p *PrometheusReporter) CaptureOutlierRecovery() {
+	p.outlierRecoveries.Inc()
+}
@@ -0,0 +1,204 @@
+package metrics_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/metrics"
+	"code.cloudfoundry.org/gorouter/metrics/fakes"
+	"code.cloudfoundry.org/gorouter/route"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PrometheusReporter", func() {
+	var (
+		reporter *metrics.PrometheusReporter
+		endpoint *route.Endpoint
+	)
+
+	scrape := func() string {
+		rw := httptest.NewRecorder()
+		reporter.Handler().ServeHTTP(rw, httptest.NewRequest("GET", "/metrics", nil))
+		Expect(rw.Code).To(Equal(http.StatusOK))
+		return rw.Body.String()
+	}
+
+	BeforeEach(func() {
+		reporter = metrics.NewPrometheusReporter()
+		endpoint = route.NewEndpoint(&route.EndpointOpts{Tags: map[string]string{"component": "dea-1"}})
+	})
+
+	It("serves the metrics in the text exposition format", func() {
+		rw := httptest.NewRecorder()
+		reporter.Handler().ServeHTTP(rw, httptest.NewRequest("GET", "/metrics", nil))
+
+		Expect(rw.Header().Get("Content-Type")).To(Equal("text/plain; version=0.0.4; charset=utf-8"))
+		Expect(rw.Body.String()).To(ContainSubstring("# HELP gorouter_bad_gateways_total Requests answered with 502 Bad Gateway.\n# TYPE gorouter_bad_gateways_total counter\ngorouter_bad_gateways_total 0\n"))
+		Expect(rw.Body.String()).To(ContainSubstring("# TYPE gorouter_total_routes gauge\n"))
+		Expect(rw.Body.String()).To(ContainSubstring("# TYPE gorouter_latency_seconds histogram\n"))
+		Expect(rw.Body.String()).To(MatchRegexp(`(?m)^go_goroutines \d+$`))
+		Expect(rw.Body.String()).To(MatchRegexp(`(?m)^go_memstats_heap_alloc_bytes \d+`))
+	})
+
+	It("escapes label values", func() {
+		reporter.CaptureOutlierEjection("say \"hi\"\\n")
+
+		Expect(scrape()).To(ContainSubstring(`gorouter_outlier_ejections_total{reason="say \"hi\"\\n"} 1`))
+	})
+
+	It("counts rejected requests and bad gateways", func() {
+		reporter.CaptureBadRequest()
+		reporter.CaptureBadGateway()
+		reporter.CaptureBadGateway()
+
+		body := scrape()
+		Expect(body).To(ContainSubstring("gorouter_rejected_requests_total 1\n"))
+		Expect(body).To(ContainSubstring("gorouter_bad_gateways_total 2\n"))
+	})
+
+	It("counts backend failures", func() {
+		reporter.CaptureBackendExhaustedConns()
+		reporter.CaptureBackendInvalidID()
+		reporter.CaptureBackendInvalidTLSCert()
+		reporter.CaptureBackendTLSHandshakeFailed()
+
+		body := scrape()
+		Expect(body).To(ContainSubstring("gorouter_backend_exhausted_conns_total 1\n"))
+		Expect(body).To(ContainSubstring("gorouter_backend_invalid_id_total 1\n"))
+		Expect(body).To(ContainSubstring("gorouter_backend_invalid_tls_cert_total 1\n"))
+		Expect(body).To(ContainSubstring("gorouter_backend_tls_handshake_failed_total 1\n"))
+	})
+
+	It("counts requests per component tag", func() {
+		reporter.CaptureRoutingRequest(endpoint)
+		reporter.CaptureRoutingRequest(route.NewEndpoint(&route.EndpointOpts{Tags: map[string]string{"component": "CloudController"}}))
+
+		body := scrape()
+		Expect(body).To(ContainSubstring(`gorouter_requests_total{component="dea-1"} 1`))
+		Expect(body).To(ContainSubstring(`gorouter_requests_total{component="CloudController"} 1`))
+		Expect(body).To(ContainSubstring("gorouter_routed_app_requests_total 1\n"))
+	})
+
+	It("counts responses per status class", func() {
+		reporter.CaptureRoutingResponse(http.StatusOK)
+		reporter.CaptureRoutingResponse(http.StatusNotFound)
+		reporter.CaptureRoutingResponse(0)
+		reporter.CaptureRouteServiceResponse(&http.Response{StatusCode: http.StatusBadGateway})
+		reporter.CaptureRouteServiceResponse(nil)
+
+		body := scrape()
+		Expect(body).To(ContainSubstring(`gorouter_responses_total{status_class="2xx"} 1`))
+		Expect(body).To(ContainSubstring(`gorouter_responses_total{status_class="4xx"} 1`))
+		Expect(body).To(ContainSubstring(`gorouter_responses_total{status_class="xxx"} 1`))
+		Expect(body).To(ContainSubstring(`gorouter_route_services_responses_total{status_class="5xx"} 1`))
+		Expect(body).To(ContainSubstring(`gorouter_route_services_responses_total{status_class="xxx"} 1`))
+	})
+
+	It("observes the response latency per component tag and status class", func() {
+		reporter.CaptureRoutingResponseLatency(endpoint, http.StatusOK, time.Now(), 30*time.Millisecond)
+
+		body := scrape()
+		Expect(body).To(ContainSubstring(`gorouter_latency_seconds_bucket{component="dea-1",status_class="2xx",le="0.05"} 1`))
+		Expect(body).To(ContainSubstring(`gorouter_latency_seconds_bucket{component="dea-1",status_class="2xx",le="0.025"} 0`))
+		Expect(body).To(ContainSubstring(`gorouter_latency_seconds_bucket{component="dea-1",status_class="2xx",le="+Inf"} 1`))
+		Expect(body).To(ContainSubstring(`gorouter_latency_seconds_sum{component="dea-1",status_class="2xx"} 0.03`))
+		Expect(body).To(ContainSubstring(`gorouter_latency_seconds_count{component="dea-1",status_class="2xx"} 1`))
+	})
+
+	It("counts websocket and tcp connections", func() {
+		reporter.CaptureWebSocketUpdate()
+		reporter.CaptureWebSocketFailure()
+		reporter.CaptureTCPConnection()
+		reporter.CaptureTCPConnectionFailure()
+
+		body := scrape()
+		Expect(body).To(ContainSubstring("gorouter_websocket_upgrades_total 1\n"))
+		Expect(body).To(ContainSubstring("gorouter_websocket_failures_total 1\n"))
+		Expect(body).To(ContainSubstring("gorouter_tcp_connections_total 1\n"))
+		Expect(body).To(ContainSubstring("gorouter_tcp_connection_failures_total 1\n"))
+	})
+
+	It("counts requests not retried because of the retry budget", func() {
+		reporter.CaptureRetryBudgetExhausted()
+
+		Expect(scrape()).To(ContainSubstring("gorouter_retry_budget_exhausted_total 1\n"))
+	})
+
+	It("reports the route registry", func() {
+		reporter.CaptureRouteStats(12, 1500)
+		reporter.CaptureRoutesPruned(3)
+		reporter.CaptureLookupTime(2 * time.Microsecond)
+		reporter.CaptureRegistryMessage(route.NewEndpoint(&route.EndpointOpts{Tags: map[string]string{"component": "uaa"}}))
+		reporter.CaptureUnregistryMessage(route.NewEndpoint(&route.EndpointOpts{}))
+
+		body := scrape()
+		Expect(body).To(ContainSubstring("gorouter_total_routes 12\n"))
+		Expect(body).To(ContainSubstring("gorouter_seconds_since_last_registry_update 1.5\n"))
+		Expect(body).To(ContainSubstring("gorouter_routes_pruned_total 3\n"))
+		Expect(body).To(ContainSubstring("gorouter_route_lookup_seconds_count 1\n"))
+		Expect(body).To(ContainSubstring(`gorouter_registry_messages_total{component="uaa"} 1`))
+		Expect(body).To(ContainSubstring(`gorouter_unregistry_messages_total{component=""} 1`))
+	})
+
+	It("reports the backend health checks", func() {
+		reporter.CaptureBackendHealthCheck(true)
+		reporter.CaptureBackendHealthCheck(false)
+		reporter.CaptureBackendHealthCheck(false)
+		reporter.CaptureUnhealthyBackends(2)
+
+		body := scrape()
+		Expect(body).To(ContainSubstring(`gorouter_backend_health_checks_total{result="passed"} 1`))
+		Expect(body).To(ContainSubstring(`gorouter_backend_health_checks_total{result="failed"} 2`))
+		Expect(body).To(ContainSubstring("gorouter_unhealthy_backends 2\n"))
+	})
+
+	It("counts outlier ejections by reason and recoveries", func() {
+		reporter.CaptureOutlierEjection("consecutive_5xx")
+		reporter.CaptureOutlierEjection("error_rate")
+		reporter.CaptureOutlierEjection("error_rate")
+		reporter.CaptureOutlierRecovery()
+
+		body := scrape()
+		Expect(body).To(ContainSubstring(`gorouter_outlier_ejections_total{reason="consecutive_5xx"} 1`))
+		Expect(body).To(ContainSubstring(`gorouter_outlier_ejections_total{reason="error_rate"} 2`))
+		Expect(body).To(ContainSubstring("gorouter_outlier_recoveries_total 1\n"))
+	})
+
+	It("only observes the route registration latency once unmuzzled", func() {
+		reporter.CaptureRouteRegistrationLatency(time.Second)
+		Expect(scrape()).To(ContainSubstring("gorouter_route_registration_latency_seconds_count 0\n"))
+
+		reporter.UnmuzzleRouteRegistrationLatency()
+		reporter.CaptureRouteRegistrationLatency(time.Second)
+		Expect(scrape()).To(ContainSubstring("gorouter_route_registration_latency_seconds_count 1\n"))
+	})
+
+	It("reports the messages buffered and dropped by the NATS subscription", func() {
+		subscriber := new(fakes.FakeSubscriber)
+		subscriber.PendingReturns(1000, nil)
+		subscriber.DroppedReturns(7, nil)
+		reporter.MonitorNATSSubscription(subscriber)
+
+		body := scrape()
+		Expect(body).To(ContainSubstring("gorouter_buffered_messages 1000\n"))
+		Expect(body).To(ContainSubstring("gorouter_total_dropped_messages 7\n"))
+	})
+
+	It("reports the open file descriptors", func() {
+		procPath, err := ioutil.TempDir("", "proc")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(procPath)
+		for _, name := range []string{"0", "1", "2"} {
+			Expect(os.Symlink("/dev/null", filepath.Join(procPath, name))).To(Succeed())
+		}
+		reporter.MonitorFileDescriptors(procPath)
+
+		Expect(scrape()).To(ContainSubstring("gorouter_file_descriptors 3\n"))
+	})
+})
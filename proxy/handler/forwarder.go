@@ -6,7 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"code.cloudfoundry.org/gorouter/logger"
@@ -16,7 +18,10 @@ import (
 
 type Forwarder struct {
 	BackendReadTimeout time.Duration
-	Logger             logger.Logger
+	// IdleTimeout closes raw TCP sessions that see no traffic in either
+	// direction for that long. Zero disables it.
+	IdleTimeout time.Duration
+	Logger      logger.Logger
 }
 
 // ForwardIO sets up websocket forwarding with a backend
@@ -78,6 +83,105 @@ func (f *Forwarder) ForwardIO(clientConn, backendConn io.ReadWriter) int {
 	return http.StatusSwitchingProtocols
 }
 
+// ForwardTCP copies bytes between a client and a backend connection of a raw
+// TCP route.
+//
+// When one side stops sending, the write half of the other connection is
+// closed so that the peer sees EOF. Sessions are given up once neither
+// direction has seen traffic for the IdleTimeout, so that connections whose
+// peer went away without closing them are not held forever. It returns once
+// both directions are done, with the number of bytes received from the client
+// and sent to it.
+func (f *Forwarder) ForwardTCP(clientConn, backendConn net.Conn) (received, sent int64) {
+	done := make(chan struct{}, 2)
+
+	client, backend := clientConn, backendConn
+	if f.IdleTimeout > 0 {
+		deadline := &idleDeadline{timeout: f.IdleTimeout}
+		deadline.touch()
+		client = &idleConn{Conn: clientConn, deadline: deadline}
+		backend = &idleConn{Conn: backendConn, deadline: deadline}
+	}
+
+	go func() {
+		received, _ = io.Copy(backend, client)
+		closeWrite(backendConn)
+		done <- struct{}{}
+	}()
+	go func() {
+		sent, _ = io.Copy(client, backend)
+		closeWrite(clientConn)
+		done <- struct{}{}
+	}()
+
+	<-done
+	<-done
+	return received, sent
+}
+
+// closeWrite half-closes conn when it supports it and closes it otherwise
+func closeWrite(conn net.Conn) {
+	if c, ok := conn.(interface{ CloseWrite() error }); ok {
+		_ = c.CloseWrite()
+		return
+	}
+	_ = conn.Close()
+}
+
+// idleDeadline is the time of the last traffic of a TCP session in either
+// direction.
+type idleDeadline struct {
+	timeout    time.Duration
+	lastActive int64 // unix nanoseconds, accessed atomically
+}
+
+func (d *idleDeadline) touch() {
+	atomic.StoreInt64(&d.lastActive, time.Now().UnixNano())
+}
+
+func (d *idleDeadline) idle() bool {
+	return time.Since(time.Unix(0, atomic.LoadInt64(&d.lastActive))) >= d.timeout
+}
+
+// idleConn sets the read and write deadlines of a connection of a TCP session
+// to the idle timeout on every call. A read that times out is retried as long
+// as the other direction of the session saw traffic within the timeout.
+type idleConn struct {
+	net.Conn
+	deadline *idleDeadline
+}
+
+func (c *idleConn) Read(b []byte) (int, error) {
+	for {
+		_ = c.Conn.SetReadDeadline(time.Now().Add(c.deadline.timeout))
+		n, err := c.Conn.Read(b)
+		if n > 0 {
+			c.deadline.touch()
+		}
+		if isTimeout(err) && (n > 0 || !c.deadline.idle()) {
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (c *idleConn) Write(b []byte) (int, error) {
+	_ = c.Conn.SetWriteDeadline(time.Now().Add(c.deadline.timeout))
+	n, err := c.Conn.Write(b)
+	if n > 0 {
+		c.deadline.touch()
+	}
+	return n, err
+}
+
+func isTimeout(err error) bool {
+	ne, ok := err.(net.Error)
+	return ok && ne.Timeout()
+}
+
 func isValidWebsocketResponse(resp *http.Response) bool {
 	ok := resp.StatusCode == http.StatusSwitchingProtocols
 	return ok
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"errors"
 	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"runtime"
 	"sync"
@@ -130,8 +132,98 @@ var _ = Describe("Forwarder", func() {
 			}).Should(BeNumerically("<=", beforeGoroutineCount))
 		})
 	})
+
+	Describe("ForwardTCP", func() {
+		var tcpClient, clientPeer, tcpBackend, backendPeer net.Conn
+
+		BeforeEach(func() {
+			tcpClient, clientPeer = tcpConnPair()
+			tcpBackend, backendPeer = tcpConnPair()
+		})
+
+		AfterEach(func() {
+			tcpClient.Close()
+			clientPeer.Close()
+			tcpBackend.Close()
+			backendPeer.Close()
+		})
+
+		It("copies data both ways until both sides are done and reports the byte counts", func() {
+			go func() {
+				defer GinkgoRecover()
+				_, err := clientPeer.Write([]byte("ping"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(clientPeer.(*net.TCPConn).CloseWrite()).To(Succeed())
+			}()
+			go func() {
+				defer GinkgoRecover()
+				b, err := ioutil.ReadAll(backendPeer)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(b)).To(Equal("ping"))
+				_, err = backendPeer.Write([]byte("pong!"))
+				Expect(err).NotTo(HaveOccurred())
+				backendPeer.Close()
+			}()
+
+			received, sent := forwarder.ForwardTCP(tcpClient, tcpBackend)
+			Expect(received).To(Equal(int64(4)))
+			Expect(sent).To(Equal(int64(5)))
+
+			b, err := ioutil.ReadAll(clientPeer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal("pong!"))
+		})
+
+		Context("when the session is idle", func() {
+			BeforeEach(func() {
+				forwarder.IdleTimeout = 100 * time.Millisecond
+			})
+
+			It("gives up the session after the idle timeout", func() {
+				done := make(chan struct{})
+				go func() {
+					forwarder.ForwardTCP(tcpClient, tcpBackend)
+					close(done)
+				}()
+
+				Eventually(done).Should(BeClosed())
+			})
+
+			It("keeps the session while one direction has traffic", func() {
+				done := make(chan struct{})
+				go func() {
+					forwarder.ForwardTCP(tcpClient, tcpBackend)
+					close(done)
+				}()
+
+				buf := make([]byte, 4)
+				for i := 0; i < 5; i++ {
+					_, err := backendPeer.Write([]byte("tick"))
+					Expect(err).NotTo(HaveOccurred())
+					_, err = io.ReadFull(clientPeer, buf)
+					Expect(err).NotTo(HaveOccurred())
+					time.Sleep(50 * time.Millisecond)
+				}
+				Expect(done).NotTo(BeClosed())
+
+				Eventually(done).Should(BeClosed())
+			})
+		})
+	})
 })
 
+func tcpConnPair() (net.Conn, net.Conn) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	ExpectWithOffset(1, err).NotTo(HaveOccurred())
+	defer ln.Close()
+
+	dialed, err := net.Dial("tcp", ln.Addr().String())
+	ExpectWithOffset(1, err).NotTo(HaveOccurred())
+	accepted, err := ln.Accept()
+	ExpectWithOffset(1, err).NotTo(HaveOccurred())
+	return accepted, dialed
+}
+
 func NewMockConn(fakeBackend io.Reader) *MockReadWriter {
 	return &MockReadWriter{
 		buffer: &bytes.Buffer{},
@@ -172,42 +172,87 @@ func (h *RequestHandler) serveTcp(
 	onConnectionSucceeded connSuccessCB,
 	onConnectionFailed connFailureCB,
 ) (int, error) {
-	var err error
-	var backendConnection net.Conn
-	var endpoint *route.Endpoint
-
 	if onConnectionSucceeded == nil {
 		onConnectionSucceeded = nilConnSuccessCB
 	}
-	if onConnectionFailed == nil {
-		onConnectionFailed = nilConnFailureCB
+
+	backendConnection, endpoint, err := DialEndpoint(iter, NewEndpointDialer(h.endpointDialTimeout, h.tlsConfigTemplate), onConnectionFailed)
+	if err == NoEndpointsAvailable {
+		h.HandleBadGateway(err, h.request)
+		return 0, err
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer backendConnection.Close()
+
+	err = onConnectionSucceeded(backendConnection, endpoint)
+	if err != nil {
+		return 0, err
 	}
 
+	client, _, err := h.hijack()
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+
+	// Any status code has already been sent to the client,
+	// but this is the value that gets written to the access logs
+	backendStatusCode := h.forwarder.ForwardIO(client, backendConnection)
+	return backendStatusCode, nil
+}
+
+// EndpointDialer opens a connection to the backend of an endpoint
+type EndpointDialer func(endpoint *route.Endpoint) (net.Conn, error)
+
+// NewEndpointDialer returns a dialer that speaks TLS to endpoints registered
+// with a TLS port and plain TCP to all others.
+func NewEndpointDialer(dialTimeout time.Duration, tlsConfigTemplate *tls.Config) EndpointDialer {
 	dialer := &net.Dialer{
-		Timeout: h.endpointDialTimeout, // untested
+		Timeout: dialTimeout, // untested
+	}
+
+	return func(endpoint *route.Endpoint) (net.Conn, error) {
+		if endpoint.IsTLS() {
+			tlsConfigLocal := utils.TLSConfigWithServerName(endpoint.ServerCertDomainSAN, tlsConfigTemplate)
+			return tls.DialWithDialer(dialer, "tcp", endpoint.CanonicalAddr(), tlsConfigLocal)
+		}
+		return net.DialTimeout("tcp", endpoint.CanonicalAddr(), dialTimeout)
+	}
+}
+
+// NewRawEndpointDialer returns a dialer that speaks plain TCP to every
+// endpoint, for traffic that is already encrypted by the client.
+func NewRawEndpointDialer(dialTimeout time.Duration) EndpointDialer {
+	return func(endpoint *route.Endpoint) (net.Conn, error) {
+		return net.DialTimeout("tcp", endpoint.CanonicalAddr(), dialTimeout)
+	}
+}
+
+// DialEndpoint opens a connection to the next endpoint of the iterator,
+// moving on to the next one up to MaxRetries times when dialing fails.
+func DialEndpoint(
+	iter route.EndpointIterator,
+	dial EndpointDialer,
+	onConnectionFailed func(error),
+) (net.Conn, *route.Endpoint, error) {
+	if onConnectionFailed == nil {
+		onConnectionFailed = nilConnFailureCB
 	}
 
 	retry := 0
 	for {
-		endpoint = iter.Next()
+		endpoint := iter.Next()
 		if endpoint == nil {
-			err = NoEndpointsAvailable
-			h.HandleBadGateway(err, h.request)
-			return 0, err
+			return nil, nil, NoEndpointsAvailable
 		}
 
 		iter.PreRequest(endpoint)
-
-		if endpoint.IsTLS() {
-			tlsConfigLocal := utils.TLSConfigWithServerName(endpoint.ServerCertDomainSAN, h.tlsConfigTemplate)
-			backendConnection, err = tls.DialWithDialer(dialer, "tcp", endpoint.CanonicalAddr(), tlsConfigLocal)
-		} else {
-			backendConnection, err = net.DialTimeout("tcp", endpoint.CanonicalAddr(), h.endpointDialTimeout)
-		}
-
+		backendConnection, err := dial(endpoint)
 		iter.PostRequest(endpoint)
 		if err == nil {
-			break
+			return backendConnection, endpoint, nil
 		}
 
 		iter.EndpointFailed(err)
@@ -215,29 +260,9 @@ func (h *RequestHandler) serveTcp(
 
 		retry++
 		if retry == MaxRetries {
-			return 0, err
+			return nil, endpoint, err
 		}
 	}
-	if backendConnection == nil {
-		return 0, nil
-	}
-	defer backendConnection.Close()
-
-	err = onConnectionSucceeded(backendConnection, endpoint)
-	if err != nil {
-		return 0, err
-	}
-
-	client, _, err := h.hijack()
-	if err != nil {
-		return 0, err
-	}
-	defer client.Close()
-
-	// Any status code has already been sent to the client,
-	// but this is the value that gets written to the access logs
-	backendStatusCode := h.forwarder.ForwardIO(client, backendConnection)
-	return backendStatusCode, nil
 }
 
 func (h *RequestHandler) setupRequest(endpoint *route.Endpoint) {
@@ -25,6 +25,7 @@ import (
 	"code.cloudfoundry.org/gorouter/registry"
 	"code.cloudfoundry.org/gorouter/route"
 	"code.cloudfoundry.org/gorouter/routeservice"
+	"code.cloudfoundry.org/gorouter/tracing"
 	"github.com/cloudfoundry/dropsonde"
 	"github.com/uber-go/zap"
 	"github.com/urfave/negroni"
@@ -74,6 +75,7 @@ func NewProxy(
 	routeServiceTLSConfig *tls.Config,
 	health *health.Health,
 	routeServicesTransport http.RoundTripper,
+	tracerProvider *tracing.TracerProvider,
 ) http.Handler {
 
 	p := &proxy{
@@ -165,6 +167,9 @@ func NewProxy(
 	n.Use(handlers.NewRequestInfo())
 	n.Use(handlers.NewProxyWriter(logger))
 	n.Use(handlers.NewVcapRequestIdHeader(logger))
+	if tracerProvider != nil {
+		n.Use(handlers.NewTracing(tracerProvider))
+	}
 	if cfg.SendHttpStartStopServerEvent {
 		n.Use(handlers.NewHTTPStartStop(dropsonde.DefaultEmitter, logger))
 	}
@@ -174,7 +179,7 @@ func NewProxy(
 	n.Use(handlers.NewProxyHealthcheck(cfg.HealthCheckUserAgent, p.health, logger))
 	n.Use(zipkinHandler)
 	n.Use(w3cHandler)
-	n.Use(handlers.NewProtocolCheck(logger, errorWriter))
+	n.Use(handlers.NewProtocolCheck(logger, errorWriter, cfg.EnableHTTP2))
 	n.Use(handlers.NewLookup(registry, reporter, logger, errorWriter, cfg.EmptyPoolResponseCode503))
 	n.Use(handlers.NewClientCert(
 		SkipSanitize(routeServiceHandler.(*handlers.RouteService)),
@@ -247,8 +252,9 @@ func (p *proxy) ServeHTTP(responseWriter http.ResponseWriter, request *http.Requ
 	}
 
 	stickyEndpointId := getStickySession(request, p.stickySessionCookieNames)
+	hashKey := reqInfo.RoutePool.HashKey().Value(request)
 	endpointIterator := &wrappedIterator{
-		nested: reqInfo.RoutePool.Endpoints(p.defaultLoadBalance, stickyEndpointId),
+		nested: reqInfo.RoutePool.GroupEndpoints(p.defaultLoadBalance, stickyEndpointId, hashKey, reqInfo.BackendGroup),
 
 		afterNext: func(endpoint *route.Endpoint) {
 			if endpoint != nil {
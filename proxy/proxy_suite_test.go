@@ -20,6 +20,7 @@ import (
 	"code.cloudfoundry.org/gorouter/registry"
 	"code.cloudfoundry.org/gorouter/routeservice"
 	"code.cloudfoundry.org/gorouter/test_util"
+	"code.cloudfoundry.org/gorouter/tracing"
 
 	"testing"
 	"time"
@@ -53,6 +54,7 @@ var (
 	fakeEmitter             *fake.FakeEventEmitter
 	fakeRouteServicesClient *sharedfakes.RoundTripper
 	skipSanitization        func(req *http.Request) bool
+	tracerProvider          *tracing.TracerProvider
 	ew                      = errorwriter.NewPlaintextErrorWriter()
 )
 
@@ -79,6 +81,7 @@ var _ = BeforeEach(func() {
 	conf.EndpointDialTimeout = 50 * time.Millisecond
 	fakeReporter = &fakes.FakeCombinedReporter{}
 	skipSanitization = func(*http.Request) bool { return false }
+	tracerProvider = nil
 })
 
 var _ = JustBeforeEach(func() {
@@ -130,7 +133,7 @@ var _ = JustBeforeEach(func() {
 
 	fakeRouteServicesClient = &sharedfakes.RoundTripper{}
 
-	p = proxy.NewProxy(testLogger, al, ew, conf, r, fakeReporter, routeServiceConfig, tlsConfig, tlsConfig, healthStatus, fakeRouteServicesClient)
+	p = proxy.NewProxy(testLogger, al, ew, conf, r, fakeReporter, routeServiceConfig, tlsConfig, tlsConfig, healthStatus, fakeRouteServicesClient, tracerProvider)
 
 	server := http.Server{Handler: p}
 	go server.Serve(proxyServer)
@@ -1103,6 +1103,69 @@ var _ = Describe("Proxy", func() {
 		})
 	})
 
+	Describe("HTTP/2 Backends", func() {
+		var (
+			ln            net.Listener
+			backendProtos chan int
+		)
+
+		BeforeEach(func() {
+			var err error
+			ln, err = net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+
+			backendProtos = make(chan int, 1)
+			handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				backendProtos <- req.ProtoMajor
+				w.Header().Set("Trailer", "Grpc-Status")
+				w.Header().Set("Content-Type", "application/grpc")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte("hello"))
+				w.Header().Set("Grpc-Status", "0")
+			})
+			server := &http.Server{Handler: handler, Protocols: new(http.Protocols)}
+			server.Protocols.SetUnencryptedHTTP2(true)
+			go server.Serve(ln)
+
+			test_util.RegisterAddr(r, "grpc", ln.Addr().String(), test_util.RegisterConfig{
+				InstanceId: "123",
+				AppId:      "456",
+				Protocol:   route.ProtocolHTTP2,
+			})
+		})
+
+		AfterEach(func() {
+			ln.Close()
+		})
+
+		It("proxies to the backend over h2c and relays trailers", func() {
+			conn := dialProxy(proxyServer)
+
+			req := test_util.NewRequest("POST", "grpc", "/helloworld.Greeter/SayHello", nil)
+			req.Header.Set("Content-Type", "application/grpc")
+			req.Header.Set("Te", "trailers")
+			conn.WriteRequest(req)
+
+			resp, body := conn.ReadResponse()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(body).To(Equal("hello"))
+			Expect(resp.Trailer.Get("Grpc-Status")).To(Equal("0"))
+			Expect(backendProtos).To(Receive(Equal(2)))
+
+			Eventually(func() (int64, error) {
+				fi, err := f.Stat()
+				if err != nil {
+					return 0, err
+				}
+				return fi.Size(), nil
+			}).ShouldNot(BeZero())
+
+			b, err := ioutil.ReadFile(f.Name())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(ContainSubstring(`grpc_status:"0"`))
+		})
+	})
+
 	Describe("Access Logging", func() {
 		It("logs a request", func() {
 			ln := test_util.RegisterHandler(r, "test", func(conn *test_util.HttpConn) {
@@ -1552,6 +1615,45 @@ var _ = Describe("Proxy", func() {
 			conn.Close()
 		})
 
+		It("sends WebSocket requests to the backend group of the traffic split", func() {
+			served := make(chan string, 10)
+			backend := func(app string) func(conn *test_util.HttpConn) {
+				return func(conn *test_util.HttpConn) {
+					_, err := http.ReadRequest(conn.Reader)
+					Expect(err).NotTo(HaveOccurred())
+					served <- app
+
+					resp := test_util.NewResponse(http.StatusSwitchingProtocols)
+					resp.Header.Set("Upgrade", "websocket")
+					resp.Header.Set("Connection", "Upgrade")
+					conn.WriteResponse(resp)
+					conn.Close()
+				}
+			}
+
+			canary, err := route.ParseTrafficMatch("header:X-Canary=true")
+			Expect(err).NotTo(HaveOccurred())
+			blue := test_util.RegisterHandler(r, "ws-split", backend("blue"), test_util.RegisterConfig{AppId: "blue"})
+			defer blue.Close()
+			green := test_util.RegisterHandler(r, "ws-split", backend("green"), test_util.RegisterConfig{AppId: "green", TrafficMatch: canary})
+			defer green.Close()
+
+			for _, want := range []string{"green", "blue", "green", "blue"} {
+				conn := dialProxy(proxyServer)
+
+				req := test_util.NewRequest("GET", "ws-split", "/chat", nil)
+				req.Header.Set("Upgrade", "websocket")
+				req.Header.Set("Connection", "Upgrade")
+				req.Header.Set("X-Canary", strconv.FormatBool(want == "green"))
+				conn.WriteRequest(req)
+
+				resp, _ := conn.ReadResponse()
+				Expect(resp.StatusCode).To(Equal(http.StatusSwitchingProtocols))
+				Expect(served).To(Receive(Equal(want)))
+				conn.Close()
+			}
+		})
+
 		It("upgrades for a WebSocket request with comma-separated Connection header", func() {
 			done := make(chan bool)
 
@@ -74,7 +74,7 @@ var _ = Describe("Proxy Unit tests", func() {
 
 			skipSanitization = func(req *http.Request) bool { return false }
 			proxyObj = proxy.NewProxy(logger, fakeAccessLogger, ew, conf, r, combinedReporter,
-				routeServiceConfig, tlsConfig, tlsConfig, &health.Health{}, rt)
+				routeServiceConfig, tlsConfig, tlsConfig, &health.Health{}, rt, nil)
 
 			r.Register(route.Uri("some-app"), &route.Endpoint{Stats: route.NewStats()})
 
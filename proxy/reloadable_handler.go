@@ -0,0 +1,33 @@
+package proxy
+
+import (
+	"net/http"
+	"sync"
+)
+
+// ReloadableHandler serves every request with the handler most recently
+// passed to Swap. It lets a proxy built from a reloaded configuration replace
+// the live one without restarting the listeners. Requests already in flight
+// finish on the handler they started on.
+type ReloadableHandler struct {
+	lock    sync.RWMutex
+	handler http.Handler
+}
+
+func NewReloadableHandler(handler http.Handler) *ReloadableHandler {
+	return &ReloadableHandler{handler: handler}
+}
+
+func (h *ReloadableHandler) Swap(handler http.Handler) {
+	h.lock.Lock()
+	h.handler = handler
+	h.lock.Unlock()
+}
+
+func (h *ReloadableHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	h.lock.RLock()
+	handler := h.handler
+	h.lock.RUnlock()
+
+	handler.ServeHTTP(w, req)
+}
@@ -0,0 +1,32 @@
+package proxy_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"code.cloudfoundry.org/gorouter/proxy"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ReloadableHandler", func() {
+	statusHandler := func(status int) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		})
+	}
+
+	serve := func(h http.Handler) int {
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+		return rw.Code
+	}
+
+	It("serves requests with the latest handler", func() {
+		h := proxy.NewReloadableHandler(statusHandler(http.StatusTeapot))
+		Expect(serve(h)).To(Equal(http.StatusTeapot))
+
+		h.Swap(statusHandler(http.StatusAccepted))
+		Expect(serve(h)).To(Equal(http.StatusAccepted))
+	})
+})
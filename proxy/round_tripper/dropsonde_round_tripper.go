@@ -1,7 +1,9 @@
 package round_tripper
 
 import (
+	"context"
 	"net/http"
+	"sync"
 
 	"code.cloudfoundry.org/gorouter/proxy/utils"
 	"github.com/cloudfoundry/dropsonde"
@@ -33,7 +35,7 @@ type FactoryImpl struct {
 	IsInstrumented       bool
 }
 
-func (t *FactoryImpl) New(expectedServerName string, isRouteService bool) ProxyRoundTripper {
+func (t *FactoryImpl) New(expectedServerName string, isRouteService bool, isHTTP2 bool) ProxyRoundTripper {
 	var template *http.Transport
 	if isRouteService {
 		template = t.RouteServiceTemplate
@@ -43,7 +45,7 @@ func (t *FactoryImpl) New(expectedServerName string, isRouteService bool) ProxyR
 
 	customTLSConfig := utils.TLSConfigWithServerName(expectedServerName, template.TLSClientConfig)
 
-	newTransport := &http.Transport{
+	transport := &http.Transport{
 		Dial:                template.Dial,
 		DisableKeepAlives:   template.DisableKeepAlives,
 		MaxIdleConns:        template.MaxIdleConns,
@@ -53,6 +55,28 @@ func (t *FactoryImpl) New(expectedServerName string, isRouteService bool) ProxyR
 		TLSClientConfig:     customTLSConfig,
 		TLSHandshakeTimeout: template.TLSHandshakeTimeout,
 	}
+
+	var newTransport ProxyRoundTripper = transport
+	if isHTTP2 {
+		// setting a custom Dial disables HTTP/2 on the transport unless it is forced
+		transport.ForceAttemptHTTP2 = true
+
+		h2cProtocols := new(http.Protocols)
+		h2cProtocols.SetUnencryptedHTTP2(true)
+		newTransport = &http2RoundTripper{
+			tls: transport,
+			h2c: &http.Transport{
+				Dial:                template.Dial,
+				DisableKeepAlives:   template.DisableKeepAlives,
+				MaxIdleConns:        template.MaxIdleConns,
+				IdleConnTimeout:     template.IdleConnTimeout,
+				MaxIdleConnsPerHost: template.MaxIdleConnsPerHost,
+				DisableCompression:  template.DisableCompression,
+				Protocols:           h2cProtocols,
+			},
+		}
+	}
+
 	if t.IsInstrumented {
 		return NewDropsondeRoundTripper(newTransport)
 	} else {
@@ -60,3 +84,53 @@ func (t *FactoryImpl) New(expectedServerName string, isRouteService bool) ProxyR
 	}
 
 }
+
+// http2RoundTripper speaks h2 to TLS backends, negotiated via ALPN, and h2c
+// with prior knowledge to cleartext backends.
+type http2RoundTripper struct {
+	tls *http.Transport
+	h2c *http.Transport
+
+	// cancels are the cancel functions of the contexts of the requests in
+	// flight, since the transports cannot cancel HTTP/2 requests otherwise
+	cancelsMutex sync.Mutex
+	cancels      map[*http.Request]context.CancelFunc
+}
+
+func (t *http2RoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	transport := t.h2c
+	if r.URL.Scheme == "https" {
+		transport = t.tls
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	t.cancelsMutex.Lock()
+	if t.cancels == nil {
+		t.cancels = map[*http.Request]context.CancelFunc{}
+	}
+	t.cancels[r] = cancel
+	t.cancelsMutex.Unlock()
+
+	resp, err := transport.RoundTrip(r.WithContext(ctx))
+	if err != nil {
+		t.CancelRequest(r)
+		return nil, err
+	}
+
+	// the request is in flight until its response is read
+	cancelOnClose(resp, func() { t.CancelRequest(r) })
+	return resp, nil
+}
+
+// CancelRequest cancels a request in flight through its context, the only
+// way HTTP/2 requests can be canceled.
+func (t *http2RoundTripper) CancelRequest(r *http.Request) {
+	t.cancelsMutex.Lock()
+	cancel := t.cancels[r]
+	delete(t.cancels, r)
+	t.cancelsMutex.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+}
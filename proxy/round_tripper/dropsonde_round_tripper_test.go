@@ -0,0 +1,62 @@
+package round_tripper_test
+
+import (
+	"crypto/tls"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"code.cloudfoundry.org/gorouter/proxy/round_tripper"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FactoryImpl", func() {
+	var factory *round_tripper.FactoryImpl
+
+	BeforeEach(func() {
+		factory = &round_tripper.FactoryImpl{
+			BackendTemplate:      &http.Transport{TLSClientConfig: &tls.Config{}},
+			RouteServiceTemplate: &http.Transport{TLSClientConfig: &tls.Config{}},
+		}
+	})
+
+	Context("when the backend speaks h2c", func() {
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, "first")
+				w.(http.Flusher).Flush()
+				<-r.Context().Done()
+			}))
+			server.Config.Protocols = new(http.Protocols)
+			server.Config.Protocols.SetUnencryptedHTTP2(true)
+			server.Start()
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("cancels requests in flight", func() {
+			transport := factory.New("", false, true)
+			req, err := http.NewRequest("GET", server.URL, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			resp, err := transport.RoundTrip(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.Proto).To(Equal("HTTP/2.0"))
+
+			first := make([]byte, len("first"))
+			_, err = io.ReadFull(resp.Body, first)
+			Expect(err).NotTo(HaveOccurred())
+
+			transport.CancelRequest(req)
+			_, err = ioutil.ReadAll(resp.Body)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -35,8 +35,13 @@ var DefaultErrorSpecs = []ErrorSpec{
 	{fails.RemoteFailedCertCheck, SSLCertRequiredMessage, 496, nil},
 	{fails.ContextCancelled, ContextCancelledMessage, 499, nil},
 	{fails.RemoteHandshakeFailure, SSLHandshakeMessage, 525, handleSSLHandshake},
+	{perTryTimeout, GatewayTimeoutMessage, http.StatusGatewayTimeout, nil},
 }
 
+var perTryTimeout = fails.ClassifierFunc(func(err error) bool {
+	return err == PerTryTimeoutExceeded
+})
+
 type ErrorHandler struct {
 	MetricReporter metrics.ProxyReporter
 	ErrorSpecs     []ErrorSpec
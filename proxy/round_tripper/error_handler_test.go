@@ -193,5 +193,16 @@ var _ = Describe("HandleError", func() {
 				Expect(responseWriter.Status()).To(Equal(499))
 			})
 		})
+
+		Context("Per-try timeout exceeded", func() {
+			BeforeEach(func() {
+				err = round_tripper.PerTryTimeoutExceeded
+				errorHandler.HandleError(responseWriter, err)
+			})
+
+			It("has a 504 Status Code", func() {
+				Expect(responseWriter.Status()).To(Equal(504))
+			})
+		})
 	})
 })
@@ -3,9 +3,12 @@ package round_tripper
 import (
 	"context"
 	"errors"
+	"io"
 	"io/ioutil"
+	"math/rand"
 	"net/http"
 	"net/url"
+	"sync/atomic"
 	"time"
 
 	"code.cloudfoundry.org/gorouter/routeservice"
@@ -20,6 +23,7 @@ import (
 	"code.cloudfoundry.org/gorouter/proxy/handler"
 	"code.cloudfoundry.org/gorouter/proxy/utils"
 	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/gorouter/tracing"
 )
 
 const (
@@ -31,8 +35,20 @@ const (
 	SSLHandshakeMessage       = "525 SSL Handshake Failed"
 	SSLCertRequiredMessage    = "496 SSL Certificate Required"
 	ContextCancelledMessage   = "499 Request Cancelled"
+	GatewayTimeoutMessage     = "504 Gateway Timeout"
 )
 
+// Attempts of a hedged request that can win, as recorded in the access log
+const (
+	HedgeWinnerPrimary = "primary"
+	HedgeWinnerHedge   = "hedge"
+	HedgeWinnerNone    = "none"
+)
+
+// PerTryTimeoutExceeded is returned when a backend does not start its
+// response within the per-try timeout of the retry policy.
+var PerTryTimeoutExceeded = errors.New("backend did not respond within the per-try timeout")
+
 //go:generate counterfeiter -o fakes/fake_proxy_round_tripper.go . ProxyRoundTripper
 type ProxyRoundTripper interface {
 	http.RoundTripper
@@ -40,13 +56,13 @@ type ProxyRoundTripper interface {
 }
 
 type RoundTripperFactory interface {
-	New(expectedServerName string, isRouteService bool) ProxyRoundTripper
+	New(expectedServerName string, isRouteService bool, isHTTP2 bool) ProxyRoundTripper
 }
 
 func GetRoundTripper(endpoint *route.Endpoint, roundTripperFactory RoundTripperFactory, isRouteService bool) ProxyRoundTripper {
 	endpoint.RoundTripperInit.Do(func() {
 		endpoint.SetRoundTripperIfNil(func() route.ProxyRoundTripper {
-			return roundTripperFactory.New(endpoint.ServerCertDomainSAN, isRouteService)
+			return roundTripperFactory.New(endpoint.ServerCertDomainSAN, isRouteService, endpoint.IsHTTP2())
 		})
 	})
 
@@ -79,6 +95,9 @@ func NewProxyRoundTripper(
 		routeServicesTransport:   routeServicesTransport,
 		endpointTimeout:          cfg.EndpointTimeout,
 		stickySessionCookieNames: cfg.StickySessionCookieNames,
+		retryPolicy:              cfg.RetryPolicy,
+		retryBudget:              cfg.RetryBudget,
+		hedging:                  cfg.Hedging,
 	}
 }
 
@@ -93,6 +112,9 @@ type roundTripper struct {
 	routeServicesTransport   http.RoundTripper
 	endpointTimeout          time.Duration
 	stickySessionCookieNames config.StringSet
+	retryPolicy              config.RetryPolicyConfig
+	retryBudget              config.RetryBudgetConfig
+	hedging                  config.HedgingConfig
 }
 
 func (rt *roundTripper) RoundTrip(originalRequest *http.Request) (*http.Response, error) {
@@ -123,37 +145,69 @@ func (rt *roundTripper) RoundTrip(originalRequest *http.Request) (*http.Response
 	}
 
 	stickyEndpointID := getStickySession(request, rt.stickySessionCookieNames)
-	iter := reqInfo.RoutePool.Endpoints(rt.defaultLoadBalance, stickyEndpointID)
+	hashKey := reqInfo.RoutePool.HashKey().Value(request)
+	iter := reqInfo.RoutePool.GroupEndpoints(rt.defaultLoadBalance, stickyEndpointID, hashKey, reqInfo.BackendGroup)
+
+	policy := rt.routeRetryPolicy(reqInfo.RoutePool)
+	// requests that may have reached the backend are only retried when
+	// sending them again is safe
+	replayable := isIdempotent(originalRequest) && hasNoBody(originalRequest)
+	hedgeable := rt.hedging.Enabled && isRead(originalRequest) && hasNoBody(originalRequest)
+	if rt.retryBudget.Enabled {
+		reqInfo.RoutePool.RecordRequest()
+	}
 
 	var selectEndpointErr error
-	for retry := 0; retry < handler.MaxRetries; retry++ {
+	// next is the endpoint picked for a retry on a status code
+	var next *route.Endpoint
+	for retry := 0; retry < policy.MaxAttempts; retry++ {
 		logger := rt.logger
 
 		if reqInfo.RouteServiceURL == nil {
-			endpoint, selectEndpointErr = rt.selectEndpoint(iter, request)
-			if selectEndpointErr != nil {
-				logger.Error("select-endpoint-failed", zap.String("host", reqInfo.RoutePool.Host()), zap.Error(selectEndpointErr))
-				break
+			if next == nil {
+				next, selectEndpointErr = rt.selectEndpoint(iter, request)
+				if selectEndpointErr != nil {
+					logger.Error("select-endpoint-failed", zap.String("host", reqInfo.RoutePool.Host()), zap.Error(selectEndpointErr))
+					break
+				}
 			}
+			endpoint, next = next, nil
 			logger = logger.With(zap.Nest("route-endpoint", endpoint.ToLogData()...))
 			reqInfo.RouteEndpoint = endpoint
 
 			logger.Debug("backend", zap.Int("attempt", retry+1))
-			if endpoint.IsTLS() {
-				request.URL.Scheme = "https"
+			request.URL.Scheme = backendScheme(endpoint)
+			if hedgeable {
+				var winner *route.Endpoint
+				winner, res, err = rt.hedgedRoundTrip(request, endpoint, iter, reqInfo, hashKey, policy.PerTryTimeout, logger, retry+1)
+				if winner != endpoint {
+					endpoint = winner
+					logger = rt.logger.With(zap.Nest("route-endpoint", endpoint.ToLogData()...))
+					reqInfo.RouteEndpoint = endpoint
+				}
 			} else {
-				request.URL.Scheme = "http"
+				res, err = rt.backendRoundTrip(request, endpoint, iter, policy.PerTryTimeout, logger, retry+1)
 			}
-			res, err = rt.backendRoundTrip(request, endpoint, iter, logger)
+			reqInfo.RoutePool.ObserveResponse(endpoint, res, err)
 
 			if err != nil {
 				iter.EndpointFailed(err)
 				logger.Error("backend-endpoint-failed", zap.Error(err), zap.Int("attempt", retry+1), zap.String("vcap_request_id", request.Header.Get(handlers.VcapRequestIdHeader)))
 
-				if rt.retriableClassifier.Classify(err) {
+				retriable := rt.retriableClassifier.Classify(err) || (replayable && err == PerTryTimeoutExceeded)
+				if retriable && rt.retry(request, reqInfo.RoutePool, policy, retry, logger) {
 					logger.Debug("retriable-error", zap.Object("error", err))
 					continue
 				}
+			} else if replayable && retriesOn(policy, res.StatusCode) && retry+1 < policy.MaxAttempts {
+				// the response is returned when there is no other endpoint
+				// to retry on
+				next = rt.retryEndpoint(iter, reqInfo.RoutePool, endpoint, res.StatusCode)
+				if next != nil && rt.retry(request, reqInfo.RoutePool, policy, retry, logger) {
+					logger.Debug("retriable-status-code", zap.Int("status-code", res.StatusCode), zap.Int("attempt", retry+1))
+					res.Body.Close()
+					continue
+				}
 			}
 
 			break
@@ -178,11 +232,11 @@ func (rt *roundTripper) RoundTrip(originalRequest *http.Request) (*http.Response
 				roundTripper = rt.routeServicesTransport
 			}
 
-			res, err = rt.timedRoundTrip(roundTripper, request, logger)
+			res, err = rt.routeServiceRoundTrip(roundTripper, request, logger, retry+1)
 			if err != nil {
 				logger.Error("route-service-connection-failed", zap.Error(err))
 
-				if rt.retriableClassifier.Classify(err) {
+				if rt.retriableClassifier.Classify(err) && rt.retry(request, reqInfo.RoutePool, policy, retry, logger) {
 					continue
 				}
 			}
@@ -234,25 +288,71 @@ func (rt *roundTripper) backendRoundTrip(
 	request *http.Request,
 	endpoint *route.Endpoint,
 	iter route.EndpointIterator,
+	perTryTimeout time.Duration,
 	logger logger.Logger,
+	attempt int,
 ) (*http.Response, error) {
 	request.URL.Host = endpoint.CanonicalAddr()
 	request.Header.Set("X-CF-ApplicationID", endpoint.ApplicationId)
 	request.Header.Set("X-CF-InstanceIndex", endpoint.PrivateInstanceIndex)
 	handler.SetRequestXCfInstanceId(request, endpoint)
 
+	ctx, span := tracing.StartSpan(request.Context(), "backend",
+		tracing.WithSpanKind(tracing.SpanKindClient),
+		tracing.WithAttributes(
+			tracing.Int("attempt", attempt),
+			tracing.String("net.peer.name", endpoint.CanonicalAddr()),
+			tracing.String("cf.app_id", endpoint.ApplicationId),
+			tracing.String("cf.instance_index", endpoint.PrivateInstanceIndex),
+		),
+	)
+	defer span.End()
+	request = request.WithContext(ctx)
+	tracing.Inject(ctx, request.Header)
+
 	// increment connection stats
 	iter.PreRequest(endpoint)
 
 	rt.combinedReporter.CaptureRoutingRequest(endpoint)
 	tr := GetRoundTripper(endpoint, rt.roundTripperFactory, false)
-	res, err := rt.timedRoundTrip(tr, request, logger)
+	res, err := rt.perTryRoundTrip(tr, request, perTryTimeout, logger)
+	recordResult(span, res, err)
 
 	// decrement connection stats
 	iter.PostRequest(endpoint)
 	return res, err
 }
 
+func (rt *roundTripper) routeServiceRoundTrip(
+	tr http.RoundTripper,
+	request *http.Request,
+	logger logger.Logger,
+	attempt int,
+) (*http.Response, error) {
+	ctx, span := tracing.StartSpan(request.Context(), "route service",
+		tracing.WithSpanKind(tracing.SpanKindClient),
+		tracing.WithAttributes(
+			tracing.Int("attempt", attempt),
+			tracing.String("net.peer.name", request.URL.Host),
+		),
+	)
+	defer span.End()
+	request = request.WithContext(ctx)
+	tracing.Inject(ctx, request.Header)
+
+	res, err := rt.timedRoundTrip(tr, request, logger)
+	recordResult(span, res, err)
+	return res, err
+}
+
+func recordResult(span *tracing.Span, res *http.Response, err error) {
+	if err != nil {
+		tracing.SetError(span, err)
+		return
+	}
+	tracing.SetStatusCode(span, res.StatusCode)
+}
+
 func (rt *roundTripper) timedRoundTrip(tr http.RoundTripper, request *http.Request, logger logger.Logger) (*http.Response, error) {
 	if rt.endpointTimeout <= 0 {
 		return tr.RoundTrip(request)
@@ -282,6 +382,334 @@ func (rt *roundTripper) timedRoundTrip(tr http.RoundTripper, request *http.Reque
 	return resp, err
 }
 
+// perTryRoundTrip is timedRoundTrip with the backend given perTryTimeout to
+// start its response. Once the response started, only the endpoint timeout
+// applies to it.
+func (rt *roundTripper) perTryRoundTrip(tr http.RoundTripper, request *http.Request, perTryTimeout time.Duration, logger logger.Logger) (*http.Response, error) {
+	if perTryTimeout <= 0 {
+		return rt.timedRoundTrip(tr, request, logger)
+	}
+
+	// the timer and the response race to settle the attempt, so that a
+	// response that arrived in time is not cancelled by the timer
+	var settled int32
+	ctx, cancel := context.WithCancel(request.Context())
+	timer := time.AfterFunc(perTryTimeout, func() {
+		if atomic.CompareAndSwapInt32(&settled, 0, 1) {
+			cancel()
+		}
+	})
+
+	resp, err := rt.timedRoundTrip(tr, request.WithContext(ctx), logger)
+	timer.Stop()
+	if !atomic.CompareAndSwapInt32(&settled, 0, 1) {
+		if resp != nil && resp.Body != nil {
+			resp.Body.Close()
+		}
+		logger.Error("backend-request-per-try-timeout", zap.Duration("per-try-timeout", perTryTimeout), zap.String("vcap_request_id", request.Header.Get(handlers.VcapRequestIdHeader)))
+		return nil, PerTryTimeoutExceeded
+	}
+
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	cancelOnClose(resp, cancel)
+	return resp, nil
+}
+
+// cancelOnClose calls cancel, which ends the context the body of the
+// response is read with, once the body is closed.
+func cancelOnClose(resp *http.Response, cancel context.CancelFunc) {
+	switch body := resp.Body.(type) {
+	case nil:
+		cancel()
+	case io.ReadWriteCloser:
+		// the body of a 101 Switching Protocols response is the
+		// connection, which has to stay writable
+		resp.Body = &cancelOnCloseReadWriter{ReadWriteCloser: body, cancel: cancel}
+	default:
+		resp.Body = &cancelOnCloseReader{ReadCloser: body, cancel: cancel}
+	}
+}
+
+type cancelOnCloseReader struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseReader) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+type cancelOnCloseReadWriter struct {
+	io.ReadWriteCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseReadWriter) Close() error {
+	err := b.ReadWriteCloser.Close()
+	b.cancel()
+	return err
+}
+
+// hedgedAttempt is one of the copies of a hedged request, sent to its own
+// endpoint.
+type hedgedAttempt struct {
+	name     string
+	endpoint *route.Endpoint
+	iter     route.EndpointIterator
+	logger   logger.Logger
+	request  *http.Request
+	cancel   context.CancelFunc
+	res      *http.Response
+	err      error
+}
+
+// hedgedRoundTrip is backendRoundTrip for requests that are safe to send
+// twice. When the endpoint does not respond within the hedging delay, a copy
+// of the request is sent to another endpoint of the route, picked by an
+// iterator of its own. The first attempt to get a response wins and the
+// other one is canceled. When both attempts fail, the failure of the first
+// attempt is returned and the failure of the hedge is reported here.
+//
+// The endpoint of the returned attempt is returned with its response, and
+// the winning attempt is recorded in the request info: the first attempt
+// when no hedge is sent.
+func (rt *roundTripper) hedgedRoundTrip(
+	request *http.Request,
+	endpoint *route.Endpoint,
+	iter route.EndpointIterator,
+	reqInfo *handlers.RequestInfo,
+	hashKey string,
+	perTryTimeout time.Duration,
+	logger logger.Logger,
+	attempt int,
+) (*route.Endpoint, *http.Response, error) {
+	results := make(chan *hedgedAttempt, 2)
+	start := func(a *hedgedAttempt) {
+		// each attempt gets a copy of the request, since backendRoundTrip
+		// sets the endpoint on it
+		ctx, cancel := context.WithCancel(request.Context())
+		a.request, a.cancel = request.Clone(ctx), cancel
+		a.request.URL.Scheme = backendScheme(a.endpoint)
+		go func() {
+			a.res, a.err = rt.backendRoundTrip(a.request, a.endpoint, a.iter, perTryTimeout, a.logger, attempt)
+			if a.err != nil {
+				a.cancel()
+			} else {
+				cancelOnClose(a.res, a.cancel)
+			}
+			results <- a
+		}()
+	}
+
+	primary := &hedgedAttempt{name: HedgeWinnerPrimary, endpoint: endpoint, iter: iter, logger: logger}
+	start(primary)
+
+	timer := time.NewTimer(rt.hedging.Delay)
+	defer timer.Stop()
+	select {
+	case a := <-results:
+		reqInfo.HedgeWinner = hedgeWinner(a)
+		return a.endpoint, a.res, a.err
+	case <-timer.C:
+	}
+
+	hedgeIter := reqInfo.RoutePool.GroupEndpoints(rt.defaultLoadBalance, "", hashKey, reqInfo.BackendGroup)
+	hedgeEndpoint := hedgeIter.Next()
+	if hedgeEndpoint == nil || hedgeEndpoint.CanonicalAddr() == endpoint.CanonicalAddr() {
+		// there is no other endpoint to send the hedge to
+		a := <-results
+		reqInfo.HedgeWinner = hedgeWinner(a)
+		return a.endpoint, a.res, a.err
+	}
+
+	hedge := &hedgedAttempt{
+		name:     HedgeWinnerHedge,
+		endpoint: hedgeEndpoint,
+		iter:     hedgeIter,
+		logger:   rt.logger.With(zap.Nest("route-endpoint", hedgeEndpoint.ToLogData()...)),
+	}
+	hedge.logger.Info("backend-request-hedged", zap.Duration("hedging-delay", rt.hedging.Delay), zap.Int("attempt", attempt))
+	start(hedge)
+
+	first := <-results
+	if first.err == nil {
+		reqInfo.HedgeWinner = first.name
+		rt.cancelAttempt(other(first, primary, hedge), results)
+		return first.endpoint, first.res, first.err
+	}
+
+	second := <-results
+	if second.err == nil {
+		reqInfo.HedgeWinner = second.name
+		rt.reportHedgedFailure(first, reqInfo.RoutePool)
+		return second.endpoint, second.res, second.err
+	}
+
+	reqInfo.HedgeWinner = HedgeWinnerNone
+	rt.reportHedgedFailure(hedge, reqInfo.RoutePool)
+	return primary.endpoint, primary.res, primary.err
+}
+
+// hedgeWinner returns the name of the attempt when it got a response, and
+// HedgeWinnerNone when it failed.
+func hedgeWinner(a *hedgedAttempt) string {
+	if a.err != nil {
+		return HedgeWinnerNone
+	}
+	return a.name
+}
+
+func other(a, primary, hedge *hedgedAttempt) *hedgedAttempt {
+	if a == primary {
+		return hedge
+	}
+	return primary
+}
+
+// cancelAttempt cancels the attempt of a hedged request that lost through
+// its context, and closes its response should it still get one.
+func (rt *roundTripper) cancelAttempt(a *hedgedAttempt, results <-chan *hedgedAttempt) {
+	a.cancel()
+
+	go func() {
+		if a := <-results; a.res != nil {
+			a.res.Body.Close()
+		}
+	}()
+}
+
+// reportHedgedFailure reports the failure of an attempt of a hedged request
+// that is not returned to the route pool, like RoundTrip does for the
+// failures it returns.
+func (rt *roundTripper) reportHedgedFailure(a *hedgedAttempt, pool *route.EndpointPool) {
+	pool.ObserveResponse(a.endpoint, nil, a.err)
+	a.iter.EndpointFailed(a.err)
+	a.logger.Error("backend-endpoint-failed",
+		zap.Error(a.err),
+		zap.String("hedged-attempt", a.name),
+		zap.String("vcap_request_id", a.request.Header.Get(handlers.VcapRequestIdHeader)),
+	)
+}
+
+// routeRetryPolicy returns the retry policy of the router with the settings
+// the route overrides.
+func (rt *roundTripper) routeRetryPolicy(pool *route.EndpointPool) config.RetryPolicyConfig {
+	policy := rt.retryPolicy
+
+	override := pool.RetryPolicy()
+	if override == nil {
+		return policy
+	}
+	if override.MaxAttempts > 0 {
+		policy.MaxAttempts = override.MaxAttempts
+	}
+	if override.PerTryTimeout > 0 {
+		policy.PerTryTimeout = override.PerTryTimeout
+	}
+	if override.RetryOnStatusCodes != nil {
+		policy.RetryOnStatusCodes = override.RetryOnStatusCodes
+	}
+	if override.BaseBackoff > 0 {
+		policy.BaseBackoff = override.BaseBackoff
+	}
+	if override.MaxBackoff > 0 {
+		policy.MaxBackoff = override.MaxBackoff
+	}
+	return policy
+}
+
+// retry reports whether the request gets another attempt after the failed
+// attempt number retry, counting from 0: when the retry policy allows
+// another attempt and the retry budget of the route is not exhausted. It
+// waits for the backoff of the retry policy before returning, and returns
+// false when the request is canceled while waiting.
+func (rt *roundTripper) retry(request *http.Request, pool *route.EndpointPool, policy config.RetryPolicyConfig, retry int, logger logger.Logger) bool {
+	if retry+1 >= policy.MaxAttempts {
+		return false
+	}
+
+	if !pool.RetryAllowed(rt.retryBudget) {
+		logger.Info("retry-budget-exhausted", zap.String("host", pool.Host()))
+		rt.combinedReporter.CaptureRetryBudgetExhausted()
+		return false
+	}
+
+	d := backoff(policy, retry)
+	if d <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-request.Context().Done():
+		return false
+	}
+}
+
+// backoff returns the time to wait before the attempt after the failed
+// attempt number retry: the base backoff doubled for every earlier retry,
+// up to the maximum backoff, of which a random half is waited so that
+// retries of concurrent requests spread out.
+func backoff(policy config.RetryPolicyConfig, retry int) time.Duration {
+	d := policy.BaseBackoff
+	for i := 0; i < retry && (policy.MaxBackoff <= 0 || d < policy.MaxBackoff); i++ {
+		d *= 2
+	}
+	if policy.MaxBackoff > 0 && d > policy.MaxBackoff {
+		d = policy.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func retriesOn(policy config.RetryPolicyConfig, statusCode int) bool {
+	for _, code := range policy.RetryOnStatusCodes {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+func isIdempotent(request *http.Request) bool {
+	switch request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// isRead reports whether the request only reads from the backend, which
+// makes it safe to send to two backends at the same time.
+func isRead(request *http.Request) bool {
+	return request.Method == http.MethodGet || request.Method == http.MethodHead
+}
+
+// hasNoBody reports whether the request has no body that would be gone when
+// it is sent again.
+func hasNoBody(request *http.Request) bool {
+	return request.Body == nil || request.Body == http.NoBody
+}
+
+func backendScheme(endpoint *route.Endpoint) string {
+	if endpoint.IsTLS() {
+		return "https"
+	}
+	return "http"
+}
+
 func (rt *roundTripper) selectEndpoint(iter route.EndpointIterator, request *http.Request) (*route.Endpoint, error) {
 	endpoint := iter.Next()
 	if endpoint == nil {
@@ -291,6 +719,20 @@ func (rt *roundTripper) selectEndpoint(iter route.EndpointIterator, request *htt
 	return endpoint, nil
 }
 
+// retryEndpoint marks the endpoint that answered with a retriable status code
+// as failed and picks the endpoint for the retry. It returns nil when the
+// iterator picks the same endpoint again, because no other one is left.
+func (rt *roundTripper) retryEndpoint(iter route.EndpointIterator, pool *route.EndpointPool, endpoint *route.Endpoint, statusCode int) *route.Endpoint {
+	pool.EndpointResponseFailed(endpoint, statusCode)
+
+	next := iter.Next()
+	if next == nil || next.CanonicalAddr() == endpoint.CanonicalAddr() {
+		return nil
+	}
+
+	return next
+}
+
 func setupStickySession(
 	response *http.Response,
 	endpoint *route.Endpoint,
@@ -2,8 +2,10 @@ package round_tripper_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -48,10 +50,12 @@ func (t *testBody) Close() error {
 type FakeRoundTripperFactory struct {
 	ReturnValue                round_tripper.ProxyRoundTripper
 	RequestedRoundTripperTypes []bool
+	RequestedHTTP2             []bool
 }
 
-func (f *FakeRoundTripperFactory) New(expectedServerName string, isRouteService bool) round_tripper.ProxyRoundTripper {
+func (f *FakeRoundTripperFactory) New(expectedServerName string, isRouteService bool, isHTTP2 bool) round_tripper.ProxyRoundTripper {
 	f.RequestedRoundTripperTypes = append(f.RequestedRoundTripperTypes, isRouteService)
+	f.RequestedHTTP2 = append(f.RequestedHTTP2, isHTTP2)
 	return f.ReturnValue
 }
 
@@ -181,6 +185,44 @@ var _ = Describe("ProxyRoundTripper", func() {
 				})
 			})
 
+			Context("when the load balancing algorithm is consistent-hash", func() {
+				BeforeEach(func() {
+					cfg.LoadBalance = config.LOAD_BALANCE_CH
+					transport.RoundTripReturns(resp.Result(), nil)
+
+					routePool = route.NewPool(&route.PoolOpts{
+						Logger:            logger,
+						RetryAfterFailure: 1 * time.Second,
+						Host:              "myapp.com",
+					})
+					for i := 1; i <= 5; i++ {
+						routePool.Put(route.NewEndpoint(&route.EndpointOpts{
+							Host:    fmt.Sprintf("1.1.1.%d", i),
+							Port:    9090,
+							HashKey: route.HashKey{Source: route.HashOnHeader, Name: "X-User"},
+						}))
+					}
+					reqInfo.RoutePool = routePool
+				})
+
+				It("sends requests with the same key to the same endpoint", func() {
+					endpoints := map[string]string{}
+					for i := 0; i < 3; i++ {
+						for _, user := range []string{"alice", "bob", "carol"} {
+							req.Header.Set("X-User", user)
+							_, err := proxyRoundTripper.RoundTrip(req)
+							Expect(err).ToNot(HaveOccurred())
+
+							addr := reqInfo.RouteEndpoint.CanonicalAddr()
+							if i == 0 {
+								endpoints[user] = addr
+							}
+							Expect(addr).To(Equal(endpoints[user]))
+						}
+					}
+				})
+			})
+
 			Context("HTTP headers", func() {
 				BeforeEach(func() {
 					transport.RoundTripReturns(resp.Result(), nil)
@@ -445,6 +487,372 @@ var _ = Describe("ProxyRoundTripper", func() {
 
 			})
 
+			Context("when outlier detection is enabled and a backend keeps answering with 5xx", func() {
+				var other *route.Endpoint
+
+				BeforeEach(func() {
+					transport.RoundTripStub = func(r *http.Request) (*http.Response, error) {
+						if r.URL.Host == endpoint.CanonicalAddr() {
+							return &http.Response{StatusCode: http.StatusServiceUnavailable}, nil
+						}
+						return &http.Response{StatusCode: http.StatusOK}, nil
+					}
+
+					routePool = route.NewPool(&route.PoolOpts{
+						Logger:            logger,
+						RetryAfterFailure: 1 * time.Second,
+						Host:              "myapp.com",
+						OutlierDetection: config.OutlierDetectionConfig{
+							Enabled:            true,
+							Consecutive5xx:     2,
+							BaseEjectionTime:   time.Minute,
+							MaxEjectionTime:    time.Minute,
+							MaxEjectionPercent: 50,
+						},
+					})
+					routePool.Put(endpoint)
+					other = route.NewEndpoint(&route.EndpointOpts{Host: "2.2.2.2", Port: 9090})
+					routePool.Put(other)
+					reqInfo.RoutePool = routePool
+				})
+
+				It("ejects the backend from the pool", func() {
+					for i := 0; i < 4; i++ {
+						_, err := proxyRoundTripper.RoundTrip(req)
+						Expect(err).ToNot(HaveOccurred())
+					}
+
+					Expect(logger.Buffer()).To(gbytes.Say(`endpoint-ejected`))
+					Expect(routePool.Endpoints(cfg.LoadBalance, "").Next()).To(Equal(other))
+				})
+			})
+
+			Context("when the retry policy retries on status codes", func() {
+				var (
+					other *route.Endpoint
+					hosts []string
+				)
+
+				BeforeEach(func() {
+					cfg.RetryPolicy.RetryOnStatusCodes = []int{http.StatusServiceUnavailable}
+					req.Body = http.NoBody
+
+					other = route.NewEndpoint(&route.EndpointOpts{Host: "2.2.2.2", Port: 9090, PrivateInstanceId: "otherInstanceId"})
+					routePool.Put(other)
+
+					hosts = nil
+					transport.RoundTripStub = func(r *http.Request) (*http.Response, error) {
+						hosts = append(hosts, r.URL.Host)
+						if transport.RoundTripCallCount() == 1 {
+							return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+						}
+						return &http.Response{StatusCode: http.StatusOK}, nil
+					}
+				})
+
+				It("retries requests that got one of the status codes on another endpoint", func() {
+					res, err := proxyRoundTripper.RoundTrip(req)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(res.StatusCode).To(Equal(http.StatusOK))
+					Expect(transport.RoundTripCallCount()).To(Equal(2))
+					Expect(hosts).To(ConsistOf(endpoint.CanonicalAddr(), other.CanonicalAddr()))
+					Expect(logger.Buffer()).To(gbytes.Say(`endpoint-marked-as-ineligible.*"status-code":503`))
+				})
+
+				It("retries sticky requests on another endpoint", func() {
+					req.AddCookie(&http.Cookie{Name: StickyCookieKey, Value: "abc"})
+					req.AddCookie(&http.Cookie{Name: round_tripper.VcapCookieId, Value: "instanceId"})
+
+					res, err := proxyRoundTripper.RoundTrip(req)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(res.StatusCode).To(Equal(http.StatusOK))
+					Expect(transport.RoundTripCallCount()).To(Equal(2))
+					Expect(hosts).To(Equal([]string{endpoint.CanonicalAddr(), other.CanonicalAddr()}))
+				})
+
+				It("does not retry when no other endpoint is left", func() {
+					routePool.Remove(other)
+
+					res, err := proxyRoundTripper.RoundTrip(req)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(res.StatusCode).To(Equal(http.StatusServiceUnavailable))
+					Expect(transport.RoundTripCallCount()).To(Equal(1))
+				})
+
+				It("does not retry requests with a body", func() {
+					req.Body = reqBody
+
+					res, err := proxyRoundTripper.RoundTrip(req)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(res.StatusCode).To(Equal(http.StatusServiceUnavailable))
+					Expect(transport.RoundTripCallCount()).To(Equal(1))
+				})
+
+				It("does not retry requests that are not idempotent", func() {
+					req.Method = http.MethodPost
+
+					res, err := proxyRoundTripper.RoundTrip(req)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(res.StatusCode).To(Equal(http.StatusServiceUnavailable))
+					Expect(transport.RoundTripCallCount()).To(Equal(1))
+				})
+
+				Context("when every attempt gets one of the status codes", func() {
+					BeforeEach(func() {
+						cfg.RetryPolicy.MaxAttempts = 2
+						transport.RoundTripStub = func(*http.Request) (*http.Response, error) {
+							return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+						}
+					})
+
+					It("returns the response of the last attempt", func() {
+						res, err := proxyRoundTripper.RoundTrip(req)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(res.StatusCode).To(Equal(http.StatusServiceUnavailable))
+						Expect(transport.RoundTripCallCount()).To(Equal(2))
+					})
+
+					It("uses the retry policy of the route over the one of the router", func() {
+						routePool.Put(route.NewEndpoint(&route.EndpointOpts{
+							Host:        "1.1.1.1",
+							Port:        9090,
+							RetryPolicy: &config.RetryPolicyConfig{MaxAttempts: 4},
+						}))
+
+						_, err := proxyRoundTripper.RoundTrip(req)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(transport.RoundTripCallCount()).To(Equal(4))
+					})
+				})
+			})
+
+			Context("when a backend does not respond within the per-try timeout", func() {
+				BeforeEach(func() {
+					cfg.RetryPolicy.PerTryTimeout = 50 * time.Millisecond
+
+					transport.RoundTripStub = func(r *http.Request) (*http.Response, error) {
+						if transport.RoundTripCallCount() == 1 {
+							<-r.Context().Done()
+							return nil, r.Context().Err()
+						}
+						return &http.Response{StatusCode: http.StatusOK}, nil
+					}
+				})
+
+				It("retries requests that can be sent again", func() {
+					req.Body = http.NoBody
+
+					res, err := proxyRoundTripper.RoundTrip(req)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(res.StatusCode).To(Equal(http.StatusOK))
+					Expect(transport.RoundTripCallCount()).To(Equal(2))
+					Expect(logger.Buffer()).To(gbytes.Say(`backend-request-per-try-timeout`))
+				})
+
+				It("calls the error handler for requests that cannot be sent again", func() {
+					_, err := proxyRoundTripper.RoundTrip(req)
+					Expect(err).To(Equal(round_tripper.PerTryTimeoutExceeded))
+					Expect(transport.RoundTripCallCount()).To(Equal(1))
+
+					Expect(errorHandler.HandleErrorCallCount()).To(Equal(1))
+					_, err = errorHandler.HandleErrorArgsForCall(0)
+					Expect(err).To(Equal(round_tripper.PerTryTimeoutExceeded))
+				})
+
+				Context("when the backend responds in time", func() {
+					var ctx context.Context
+
+					BeforeEach(func() {
+						transport.RoundTripStub = func(r *http.Request) (*http.Response, error) {
+							ctx = r.Context()
+							return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader("body"))}, nil
+						}
+					})
+
+					It("keeps the response readable after the per-try timeout until its body is closed", func() {
+						res, err := proxyRoundTripper.RoundTrip(req)
+						Expect(err).NotTo(HaveOccurred())
+
+						Consistently(ctx.Done(), 100*time.Millisecond).ShouldNot(BeClosed())
+						Expect(res.Body.Close()).To(Succeed())
+						Expect(ctx.Err()).To(Equal(context.Canceled))
+					})
+				})
+			})
+
+			Context("when the retry budget of the route is exhausted", func() {
+				BeforeEach(func() {
+					cfg.RetryBudget = config.RetryBudgetConfig{Enabled: true}
+					transport.RoundTripReturns(nil, dialError)
+					retriableClassifier.ClassifyReturns(true)
+				})
+
+				It("does not retry and reports the exhausted budget", func() {
+					_, err := proxyRoundTripper.RoundTrip(req)
+					Expect(err).To(Equal(dialError))
+					Expect(transport.RoundTripCallCount()).To(Equal(1))
+
+					Expect(combinedReporter.CaptureRetryBudgetExhaustedCallCount()).To(Equal(1))
+					Expect(logger.Buffer()).To(gbytes.Say(`retry-budget-exhausted`))
+				})
+			})
+
+			Context("when hedging is enabled", func() {
+				var other *route.Endpoint
+
+				BeforeEach(func() {
+					cfg.Hedging = config.HedgingConfig{Enabled: true, Delay: 20 * time.Millisecond}
+					req.Body = http.NoBody
+
+					other = route.NewEndpoint(&route.EndpointOpts{Host: "2.2.2.2", Port: 9090})
+					routePool.Put(other)
+				})
+
+				Context("when the endpoint does not respond within the hedging delay", func() {
+					BeforeEach(func() {
+						transport.RoundTripStub = func(r *http.Request) (*http.Response, error) {
+							if transport.RoundTripCallCount() == 1 {
+								<-r.Context().Done()
+								return nil, r.Context().Err()
+							}
+							return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+						}
+					})
+
+					It("returns the response of a copy of the request sent to another endpoint", func() {
+						res, err := proxyRoundTripper.RoundTrip(req)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(res.StatusCode).To(Equal(http.StatusOK))
+						Expect(transport.RoundTripCallCount()).To(Equal(2))
+
+						first, hedge := transport.RoundTripArgsForCall(0), transport.RoundTripArgsForCall(1)
+						Expect(hedge.URL.Host).NotTo(Equal(first.URL.Host))
+						Expect(reqInfo.RouteEndpoint.CanonicalAddr()).To(Equal(hedge.URL.Host))
+						Expect(reqInfo.HedgeWinner).To(Equal(round_tripper.HedgeWinnerHedge))
+						Expect(logger.Buffer()).To(gbytes.Say(`backend-request-hedged`))
+					})
+
+					It("cancels the first request", func() {
+						_, err := proxyRoundTripper.RoundTrip(req)
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(transport.RoundTripArgsForCall(0).Context().Err()).To(Equal(context.Canceled))
+					})
+
+					It("cancels the request of the winner once its body is closed", func() {
+						res, err := proxyRoundTripper.RoundTrip(req)
+						Expect(err).NotTo(HaveOccurred())
+
+						hedge := transport.RoundTripArgsForCall(1)
+						Expect(hedge.Context().Err()).NotTo(HaveOccurred())
+						Expect(res.Body.Close()).To(Succeed())
+						Expect(hedge.Context().Err()).To(Equal(context.Canceled))
+					})
+
+					It("releases the connections of both requests", func() {
+						_, err := proxyRoundTripper.RoundTrip(req)
+						Expect(err).NotTo(HaveOccurred())
+
+						Eventually(endpoint.Stats.NumberConnections.Count).Should(BeZero())
+						Eventually(other.Stats.NumberConnections.Count).Should(BeZero())
+					})
+
+					It("does not hedge requests with a body", func() {
+						req.Body = reqBody
+						ctx, cancel := context.WithTimeout(req.Context(), 100*time.Millisecond)
+						defer cancel()
+
+						_, err := proxyRoundTripper.RoundTrip(req.WithContext(ctx))
+						Expect(err).To(HaveOccurred())
+						Expect(transport.RoundTripCallCount()).To(Equal(1))
+						Expect(reqInfo.HedgeWinner).To(BeEmpty())
+					})
+
+					It("does not hedge requests that do not only read", func() {
+						req.Method = http.MethodDelete
+						ctx, cancel := context.WithTimeout(req.Context(), 100*time.Millisecond)
+						defer cancel()
+
+						_, err := proxyRoundTripper.RoundTrip(req.WithContext(ctx))
+						Expect(err).To(HaveOccurred())
+						Expect(transport.RoundTripCallCount()).To(Equal(1))
+					})
+				})
+
+				Context("when the endpoint responds within the hedging delay", func() {
+					BeforeEach(func() {
+						transport.RoundTripReturns(&http.Response{StatusCode: http.StatusTeapot}, nil)
+					})
+
+					It("does not hedge the request", func() {
+						res, err := proxyRoundTripper.RoundTrip(req)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(res.StatusCode).To(Equal(http.StatusTeapot))
+						Expect(transport.RoundTripCallCount()).To(Equal(1))
+						Expect(reqInfo.HedgeWinner).To(Equal(round_tripper.HedgeWinnerPrimary))
+					})
+				})
+
+				Context("when the hedge fails before the endpoint responds", func() {
+					BeforeEach(func() {
+						transport.RoundTripStub = func(r *http.Request) (*http.Response, error) {
+							if transport.RoundTripCallCount() == 1 {
+								time.Sleep(100 * time.Millisecond)
+								return &http.Response{StatusCode: http.StatusTeapot}, nil
+							}
+							return nil, dialError
+						}
+					})
+
+					It("returns the response of the first request and reports the failed hedge", func() {
+						res, err := proxyRoundTripper.RoundTrip(req)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(res.StatusCode).To(Equal(http.StatusTeapot))
+						Expect(reqInfo.HedgeWinner).To(Equal(round_tripper.HedgeWinnerPrimary))
+						Expect(logger.Buffer()).To(gbytes.Say(`backend-endpoint-failed.*"hedged-attempt":"hedge"`))
+					})
+				})
+
+				Context("when the route has a single endpoint", func() {
+					BeforeEach(func() {
+						routePool.Remove(other)
+						transport.RoundTripStub = func(r *http.Request) (*http.Response, error) {
+							time.Sleep(50 * time.Millisecond)
+							return &http.Response{StatusCode: http.StatusTeapot}, nil
+						}
+					})
+
+					It("does not hedge the request", func() {
+						res, err := proxyRoundTripper.RoundTrip(req)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(res.StatusCode).To(Equal(http.StatusTeapot))
+						Expect(transport.RoundTripCallCount()).To(Equal(1))
+						Expect(reqInfo.HedgeWinner).To(Equal(round_tripper.HedgeWinnerPrimary))
+					})
+				})
+			})
+
+			Context("when the lookup picked a backend group", func() {
+				var green *route.Endpoint
+
+				BeforeEach(func() {
+					green = route.NewEndpoint(&route.EndpointOpts{AppId: "green", Host: "2.2.2.2", Port: 9090})
+					routePool.Put(green)
+					reqInfo.BackendGroup = "green"
+
+					transport.RoundTripReturns(&http.Response{StatusCode: http.StatusTeapot}, nil)
+				})
+
+				It("sends the requests to the endpoints of the group", func() {
+					for i := 0; i < 3; i++ {
+						_, err := proxyRoundTripper.RoundTrip(req)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(transport.RoundTripArgsForCall(i).URL.Host).To(Equal(green.CanonicalAddr()))
+					}
+					Expect(reqInfo.RouteEndpoint).To(Equal(green))
+				})
+			})
+
 			Context("when there are a mixture of tls and non-tls backends", func() {
 				BeforeEach(func() {
 					tlsEndpoint := route.NewEndpoint(&route.EndpointOpts{
@@ -583,6 +991,18 @@ var _ = Describe("ProxyRoundTripper", func() {
 					Expect(err).ToNot(HaveOccurred())
 					Expect(roundTripperFactory.RequestedRoundTripperTypes).To(Equal([]bool{false, false}))
 				})
+
+				It("requests an HTTP/2 transport for http2 endpoints", func() {
+					endpoint = route.NewEndpoint(&route.EndpointOpts{
+						Host: "1.1.1.1", Port: 9090, PrivateInstanceId: "instanceId", Protocol: route.ProtocolHTTP2,
+					})
+					added := routePool.Put(endpoint)
+					Expect(added).To(Equal(route.UPDATED))
+
+					_, err := proxyRoundTripper.RoundTrip(req)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(roundTripperFactory.RequestedHTTP2).To(Equal([]bool{true}))
+				})
 			})
 
 			Context("when the request context contains a Route Service URL", func() {
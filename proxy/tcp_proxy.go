@@ -0,0 +1,156 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/accesslog"
+	"code.cloudfoundry.org/gorouter/accesslog/schema"
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/logger"
+	"code.cloudfoundry.org/gorouter/metrics"
+	"code.cloudfoundry.org/gorouter/proxy/handler"
+	"code.cloudfoundry.org/gorouter/registry"
+	"code.cloudfoundry.org/gorouter/route"
+	"github.com/uber-go/zap"
+)
+
+// TCPProxy forwards connections accepted on the TCP routing ports to the
+// endpoints registered for the port they arrived on, and TLS connections for
+// passthrough routes to the endpoints of the route named by their SNI.
+type TCPProxy struct {
+	logger                 logger.Logger
+	accessLogger           accesslog.AccessLogger
+	registry               registry.Registry
+	reporter               metrics.ProxyReporter
+	forwarder              *handler.Forwarder
+	endpointDialer         handler.EndpointDialer
+	passthroughDialer      handler.EndpointDialer
+	disableSourceIPLogging bool
+
+	lock               sync.RWMutex
+	defaultLoadBalance string
+}
+
+func NewTCPProxy(
+	logger logger.Logger,
+	accessLogger accesslog.AccessLogger,
+	cfg *config.Config,
+	registry registry.Registry,
+	reporter metrics.ProxyReporter,
+	backendTLSConfig *tls.Config,
+) *TCPProxy {
+	tcpLogger := logger.Session("tcp-proxy")
+	return &TCPProxy{
+		logger:                 tcpLogger,
+		accessLogger:           accessLogger,
+		registry:               registry,
+		reporter:               reporter,
+		forwarder:              &handler.Forwarder{BackendReadTimeout: cfg.EndpointDialTimeout, IdleTimeout: cfg.TCPRouting.IdleTimeout, Logger: tcpLogger},
+		endpointDialer:         handler.NewEndpointDialer(cfg.EndpointDialTimeout, backendTLSConfig),
+		passthroughDialer:      handler.NewRawEndpointDialer(cfg.EndpointDialTimeout),
+		defaultLoadBalance:     cfg.LoadBalance,
+		disableSourceIPLogging: cfg.Logging.DisableLogSourceIP,
+	}
+}
+
+// Reload applies the reloadable settings of a configuration the TCP proxy
+// uses to the sessions that start afterwards. Sessions in flight keep the
+// endpoint they were forwarded to.
+func (p *TCPProxy) Reload(cfg *config.Config) {
+	p.lock.Lock()
+	p.defaultLoadBalance = cfg.LoadBalance
+	p.lock.Unlock()
+}
+
+// ServeTCP forwards the client connection to an endpoint of the TCP route of
+// the given router port and writes one access log record for the session. It
+// returns when the session is over and closes the client connection.
+func (p *TCPProxy) ServeTCP(clientConn net.Conn, port uint16) {
+	p.serve(clientConn, route.TCPUri(port), p.endpointDialer)
+}
+
+// ServeTLSPassthrough forwards a TLS connection, whose ClientHello has not
+// been consumed, to an endpoint of the passthrough route for serverName. TLS
+// is terminated by the endpoint, so the backend is always dialed in plain
+// TCP, even when it was registered with a TLS port.
+func (p *TCPProxy) ServeTLSPassthrough(clientConn net.Conn, serverName string) {
+	p.serve(clientConn, route.Uri(serverName), p.passthroughDialer)
+}
+
+func (p *TCPProxy) serve(clientConn net.Conn, uri route.Uri, dial handler.EndpointDialer) {
+	defer clientConn.Close()
+
+	remoteAddr := clientConn.RemoteAddr().String()
+	if p.disableSourceIPLogging {
+		remoteAddr = "-"
+	}
+	logger := p.logger.With(zap.String("RemoteAddr", remoteAddr), zap.Stringer("uri", uri))
+
+	alr := &schema.AccessLogRecord{
+		Request:                tcpAccessLogRequest(clientConn, uri),
+		RoundtripStartedAt:     time.Now(),
+		DisableSourceIPLogging: p.disableSourceIPLogging,
+	}
+	defer func() {
+		alr.RoundtripFinishedAt = time.Now()
+		p.accessLogger.Log(*alr)
+	}()
+
+	pool := p.registry.Lookup(uri)
+	if pool == nil {
+		logger.Info("unknown-tcp-route")
+		alr.RouterError = "unknown_route"
+		p.reporter.CaptureTCPConnectionFailure()
+		return
+	}
+
+	// TCP connections carry no headers, cookies or query parameters, so the
+	// consistent hash algorithm always hashes on the source IP
+	hashKey := route.HashKey{}.Value(alr.Request)
+
+	p.lock.RLock()
+	defaultLoadBalance := p.defaultLoadBalance
+	p.lock.RUnlock()
+
+	onConnectionFailed := func(err error) { logger.Error("tcp-connection-failed", zap.Error(err)) }
+	backendConn, endpoint, err := handler.DialEndpoint(
+		pool.HashedEndpoints(defaultLoadBalance, "", hashKey),
+		dial,
+		onConnectionFailed,
+	)
+	alr.RouteEndpoint = endpoint
+	if err != nil {
+		logger.Error("tcp-request-failed", zap.Error(err))
+		alr.RouterError = "endpoint_failure"
+		p.reporter.CaptureTCPConnectionFailure()
+		return
+	}
+	defer backendConn.Close()
+
+	logger.Debug("tcp-session-started", zap.String("backend", endpoint.CanonicalAddr()))
+	p.reporter.CaptureTCPConnection()
+
+	alr.AppRequestStartedAt = time.Now()
+	received, sent := p.forwarder.ForwardTCP(clientConn, backendConn)
+	alr.AppRequestFinishedAt = time.Now()
+	alr.RequestBytesReceived = int(received)
+	alr.BodyBytesSent = int(sent)
+}
+
+// tcpAccessLogRequest describes a TCP session in the shape the access log
+// expects of an HTTP request: `<uri> - [...] "TCP / TCP"`.
+func tcpAccessLogRequest(conn net.Conn, uri route.Uri) *http.Request {
+	return &http.Request{
+		Method:     "TCP",
+		URL:        &url.URL{Path: "/"},
+		Proto:      "TCP",
+		Header:     http.Header{},
+		Host:       uri.String(),
+		RemoteAddr: conn.RemoteAddr().String(),
+	}
+}
@@ -0,0 +1,266 @@
+package proxy_test
+
+import (
+	"crypto/tls"
+	"io"
+	"io/ioutil"
+	"net"
+
+	fakelogger "code.cloudfoundry.org/gorouter/accesslog/fakes"
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/proxy"
+	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/gorouter/test_util"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TCPProxy", func() {
+	const routerPort = uint16(1024)
+
+	var (
+		tcpProxy         *proxy.TCPProxy
+		fakeAccessLogger *fakelogger.FakeAccessLogger
+		frontend         net.Listener
+		serveConn        func(conn net.Conn)
+	)
+
+	dialTCPProxy := func() *net.TCPConn {
+		conn, err := net.Dial("tcp", frontend.Addr().String())
+		Expect(err).NotTo(HaveOccurred())
+		return conn.(*net.TCPConn)
+	}
+
+	BeforeEach(func() {
+		serveConn = func(conn net.Conn) { tcpProxy.ServeTCP(conn, routerPort) }
+	})
+
+	JustBeforeEach(func() {
+		fakeAccessLogger = &fakelogger.FakeAccessLogger{}
+		tcpProxy = proxy.NewTCPProxy(testLogger, fakeAccessLogger, conf, r, fakeReporter, nil)
+
+		var err error
+		frontend, err = net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+
+		go func() {
+			for {
+				conn, err := frontend.Accept()
+				if err != nil {
+					return
+				}
+				go serveConn(conn)
+			}
+		}()
+	})
+
+	AfterEach(func() {
+		frontend.Close()
+	})
+
+	Context("when the port has a registered backend", func() {
+		var backend net.Listener
+
+		JustBeforeEach(func() {
+			var err error
+			backend, err = net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+
+			go func() {
+				conn, err := backend.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+
+			test_util.RegisterAddr(r, string(route.TCPUri(routerPort)), backend.Addr().String(), test_util.RegisterConfig{
+				AppId:         "some-app-id",
+				InstanceIndex: "1",
+			})
+		})
+
+		AfterEach(func() {
+			backend.Close()
+		})
+
+		It("forwards the bytes of the session", func() {
+			conn := dialTCPProxy()
+			defer conn.Close()
+
+			_, err := conn.Write([]byte("ping"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(conn.CloseWrite()).To(Succeed())
+
+			b, err := ioutil.ReadAll(conn)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal("ping"))
+
+			Eventually(fakeReporter.CaptureTCPConnectionCallCount).Should(Equal(1))
+		})
+
+		It("logs an access log record for the session", func() {
+			conn := dialTCPProxy()
+			defer conn.Close()
+
+			_, err := conn.Write([]byte("ping"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(conn.CloseWrite()).To(Succeed())
+			_, err = ioutil.ReadAll(conn)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(fakeAccessLogger.LogCallCount).Should(Equal(1))
+			alr := fakeAccessLogger.LogArgsForCall(0)
+			Expect(alr.ApplicationID()).To(Equal("some-app-id"))
+			Expect(alr.RequestBytesReceived).To(Equal(4))
+			Expect(alr.BodyBytesSent).To(Equal(4))
+			Expect(alr.RouterError).To(BeEmpty())
+			Expect(alr.LogMessage()).To(HavePrefix(`tcp:1024 - [`))
+			Expect(alr.LogMessage()).To(ContainSubstring(`"TCP / TCP" "-" 4 4`))
+			Expect(alr.LogMessage()).To(ContainSubstring(backend.Addr().String()))
+		})
+	})
+
+	Context("when the port has no registered backend", func() {
+		It("closes the connection and logs an unknown route", func() {
+			conn := dialTCPProxy()
+			defer conn.Close()
+
+			b, err := ioutil.ReadAll(conn)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(b).To(BeEmpty())
+
+			Eventually(fakeAccessLogger.LogCallCount).Should(Equal(1))
+			Expect(fakeAccessLogger.LogArgsForCall(0).RouterError).To(Equal("unknown_route"))
+			Expect(fakeReporter.CaptureTCPConnectionFailureCallCount()).To(Equal(1))
+		})
+	})
+
+	Context("when the configuration is reloaded", func() {
+		var backends []net.Listener
+
+		servedBy := func() string {
+			conn := dialTCPProxy()
+			defer conn.Close()
+
+			b, err := ioutil.ReadAll(conn)
+			Expect(err).NotTo(HaveOccurred())
+			return string(b)
+		}
+
+		JustBeforeEach(func() {
+			backends = nil
+			for _, name := range []string{"backend-1", "backend-2"} {
+				backend, err := net.Listen("tcp", "127.0.0.1:0")
+				Expect(err).NotTo(HaveOccurred())
+				backends = append(backends, backend)
+
+				go func(name string) {
+					for {
+						conn, err := backend.Accept()
+						if err != nil {
+							return
+						}
+						_, _ = conn.Write([]byte(name))
+						conn.Close()
+					}
+				}(name)
+
+				test_util.RegisterAddr(r, string(route.TCPUri(routerPort)), backend.Addr().String(), test_util.RegisterConfig{InstanceId: name})
+			}
+		})
+
+		AfterEach(func() {
+			for _, backend := range backends {
+				backend.Close()
+			}
+		})
+
+		It("picks the endpoints of new sessions with the reloaded balancing algorithm", func() {
+			Expect([]string{servedBy(), servedBy()}).To(ConsistOf("backend-1", "backend-2"))
+
+			reloaded := *conf
+			reloaded.LoadBalance = config.LOAD_BALANCE_CH
+			tcpProxy.Reload(&reloaded)
+
+			first := servedBy()
+			for i := 0; i < 4; i++ {
+				Expect(servedBy()).To(Equal(first))
+			}
+		})
+	})
+
+	Context("when the registered backend cannot be reached", func() {
+		JustBeforeEach(func() {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			addr := ln.Addr().String()
+			ln.Close()
+
+			test_util.RegisterAddr(r, string(route.TCPUri(routerPort)), addr, test_util.RegisterConfig{AppId: "some-app-id"})
+		})
+
+		It("closes the connection and logs an endpoint failure", func() {
+			conn := dialTCPProxy()
+			defer conn.Close()
+
+			_, err := ioutil.ReadAll(conn)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(fakeAccessLogger.LogCallCount).Should(Equal(1))
+			Expect(fakeAccessLogger.LogArgsForCall(0).RouterError).To(Equal("endpoint_failure"))
+			Expect(fakeReporter.CaptureTCPConnectionFailureCallCount()).To(Equal(1))
+		})
+	})
+
+	Context("when serving a tls passthrough connection", func() {
+		var backend net.Listener
+
+		BeforeEach(func() {
+			serveConn = func(conn net.Conn) { tcpProxy.ServeTLSPassthrough(conn, "passthrough.example.com") }
+		})
+
+		JustBeforeEach(func() {
+			var err error
+			backend, err = net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+
+			go func() {
+				conn, err := backend.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+
+			test_util.RegisterAddr(r, "passthrough.example.com", backend.Addr().String(), test_util.RegisterConfig{
+				AppId:          "some-app-id",
+				TLSConfig:      &tls.Config{},
+				TLSPassthrough: true,
+			})
+		})
+
+		AfterEach(func() {
+			backend.Close()
+		})
+
+		It("forwards the bytes to the endpoint of the route without speaking tls to it", func() {
+			conn := dialTCPProxy()
+			defer conn.Close()
+
+			_, err := conn.Write([]byte("ping"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(conn.CloseWrite()).To(Succeed())
+
+			b, err := ioutil.ReadAll(conn)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal("ping"))
+
+			Eventually(fakeAccessLogger.LogCallCount).Should(Equal(1))
+			alr := fakeAccessLogger.LogArgsForCall(0)
+			Expect(alr.LogMessage()).To(HavePrefix(`passthrough.example.com - [`))
+		})
+	})
+})
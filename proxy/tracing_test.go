@@ -0,0 +1,99 @@
+package proxy_test
+
+import (
+	"net/http"
+
+	"code.cloudfoundry.org/gorouter/test_util"
+	"code.cloudfoundry.org/gorouter/tracing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OpenTelemetry Tracing", func() {
+	const (
+		traceID      = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentSpanID = "00f067aa0ba902b7"
+	)
+
+	var spanRecorder *tracing.SpanRecorder
+
+	spanNamed := func(name string) *tracing.Span {
+		for _, span := range spanRecorder.Ended() {
+			if span.Name() == name {
+				return span
+			}
+		}
+		return nil
+	}
+
+	BeforeEach(func() {
+		spanRecorder = tracing.NewSpanRecorder()
+		tracerProvider = tracing.NewTracerProviderWithProcessor(spanRecorder)
+	})
+
+	It("records the request, the route lookup and the backend request in the trace of the request", func() {
+		traceparents := make(chan string, 1)
+		ln := test_util.RegisterHandler(r, "app", func(conn *test_util.HttpConn) {
+			req, err := http.ReadRequest(conn.Reader)
+			Expect(err).NotTo(HaveOccurred())
+			traceparents <- req.Header.Get("traceparent")
+
+			conn.WriteResponse(test_util.NewResponse(http.StatusOK))
+			conn.Close()
+		})
+		defer ln.Close()
+
+		conn := dialProxy(proxyServer)
+		req := test_util.NewRequest("GET", "app", "/", nil)
+		req.Header.Set("traceparent", "00-"+traceID+"-"+parentSpanID+"-01")
+		conn.WriteRequest(req)
+		resp, _ := conn.ReadResponse()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+		Eventually(spanRecorder.Ended).Should(HaveLen(3))
+
+		request := spanNamed("GET")
+		Expect(request).NotTo(BeNil())
+		Expect(request.SpanContext().TraceID().String()).To(Equal(traceID))
+		Expect(request.Parent().SpanID().String()).To(Equal(parentSpanID))
+		Expect(request.Attribute("http.host")).To(Equal("app"))
+		Expect(request.Attribute("http.status_code")).To(Equal(int64(http.StatusOK)))
+
+		lookup := spanNamed("route lookup")
+		Expect(lookup).NotTo(BeNil())
+		Expect(lookup.Parent().SpanID()).To(Equal(request.SpanContext().SpanID()))
+		Expect(lookup.Attribute("route.host")).To(Equal("app"))
+
+		backend := spanNamed("backend")
+		Expect(backend).NotTo(BeNil())
+		Expect(backend.Parent().SpanID()).To(Equal(request.SpanContext().SpanID()))
+		Expect(backend.Attribute("net.peer.name")).To(Equal(ln.Addr().String()))
+		Expect(backend.Attribute("http.status_code")).To(Equal(int64(http.StatusOK)))
+
+		var traceparent string
+		Eventually(traceparents).Should(Receive(&traceparent))
+		Expect(traceparent).To(Equal("00-" + traceID + "-" + backend.SpanContext().SpanID().String() + "-01"))
+	})
+
+	It("records every backend attempt", func() {
+		test_util.RegisterAddr(r, "retries", "localhost:81", test_util.RegisterConfig{})
+
+		conn := dialProxy(proxyServer)
+		conn.WriteRequest(test_util.NewRequest("GET", "retries", "/", nil))
+		resp, _ := conn.ReadResponse()
+		Expect(resp.StatusCode).To(Equal(http.StatusBadGateway))
+
+		Eventually(func() *tracing.Span { return spanNamed("GET") }).ShouldNot(BeNil())
+
+		var attempts []int64
+		for _, span := range spanRecorder.Ended() {
+			if span.Name() == "backend" {
+				attempts = append(attempts, span.Attribute("attempt").(int64))
+				Expect(span.Status().Code).To(Equal(tracing.StatusError))
+			}
+		}
+		Expect(attempts).To(ConsistOf(int64(1), int64(2), int64(3)))
+		Expect(spanNamed("GET").Status().Code).To(Equal(tracing.StatusError))
+	})
+})
@@ -1,6 +1,7 @@
 package container
 
 import (
+	"sort"
 	"strings"
 
 	"code.cloudfoundry.org/gorouter/route"
@@ -12,6 +13,12 @@ type Trie struct {
 	Pool       *route.EndpointPool
 	ChildNodes map[string]*Trie
 	Parent     *Trie
+
+	// PatternNodes are the children whose segment is a pattern of a path
+	// pattern rather than literal, ordered by precedence. Pattern matches the
+	// segment of such nodes.
+	Pattern      route.SegmentPattern
+	PatternNodes []*Trie
 }
 
 // Find returns a *route.EndpointPool that matches exactly the URI parameter, nil if no match was found.
@@ -44,43 +51,42 @@ func (r *Trie) Find(uri route.Uri) *route.EndpointPool {
 	return nil
 }
 
-// MatchUri returns the longest route that matches the URI parameter, nil if nothing matches.
+// MatchUri returns the route that matches the most segments of the URI
+// parameter, nil if nothing matches. At every segment the literal child is
+// tried before path patterns, so a literal route wins over a path pattern
+// that matches as many segments, and of two path patterns that match as many
+// segments the one whose first differing segment takes precedence wins.
 func (r *Trie) MatchUri(uri route.Uri) *route.EndpointPool {
 	key := strings.TrimPrefix(uri.String(), "/")
-	node := r
-	var lastPool *route.EndpointPool
+	pool, _ := r.match(strings.Split(key, "/"))
+	return pool
+}
 
-	for {
-		pathParts := parts(key)
-		SegmentValue := pathParts[0]
+// match returns the route of the node or of its descendants that matches the
+// most of the segments, and how many segments it matches. Every node is
+// visited at most once, so lookups never cost more than walking the subtree
+// of the host.
+func (r *Trie) match(segments []string) (*route.EndpointPool, int) {
+	pool, matched := r.Pool, 0
+	if len(segments) == 0 {
+		return pool, matched
+	}
 
-		matchingChild, ok := node.ChildNodes[SegmentValue]
-		if !ok {
-			break
+	if child, ok := r.ChildNodes[segments[0]]; ok {
+		if p, m := child.match(segments[1:]); p != nil && m+1 > matched {
+			pool, matched = p, m+1
 		}
-
-		node = matchingChild
-
-		if nil != node.Pool {
-			lastPool = node.Pool
+	}
+	for _, child := range r.PatternNodes {
+		if !child.Pattern.Match(segments[0]) {
+			continue
 		}
-
-		if len(pathParts) <= 1 {
-			break
+		if p, m := child.match(segments[1:]); p != nil && m+1 > matched {
+			pool, matched = p, m+1
 		}
-
-		key = pathParts[1]
 	}
 
-	if nil != node.Pool {
-		return node.Pool
-	}
-
-	if nil != lastPool {
-		return lastPool
-	}
-
-	return nil
+	return pool, matched
 }
 
 func (r *Trie) Insert(uri route.Uri, value *route.EndpointPool) *Trie {
@@ -140,6 +146,115 @@ func (r *Trie) Delete(uri route.Uri) bool {
 	return true
 }
 
+// FindPattern returns the *route.EndpointPool of the path pattern under the
+// URI parameter, nil if there is none.
+func (r *Trie) FindPattern(uri route.Uri, pattern route.PathPattern) *route.EndpointPool {
+	node := r.patternNode(uri, pattern, false)
+	if node == nil {
+		return nil
+	}
+	return node.Pool
+}
+
+// InsertPattern adds the route of the path pattern under the URI parameter,
+// which MatchUri matches after the literal routes.
+func (r *Trie) InsertPattern(uri route.Uri, pattern route.PathPattern, value *route.EndpointPool) *Trie {
+	node := r.patternNode(uri, pattern, true)
+	node.Pool = value
+	return node
+}
+
+// DeletePattern removes the route of the path pattern under the URI
+// parameter and the nodes that are left empty.
+func (r *Trie) DeletePattern(uri route.Uri, pattern route.PathPattern) bool {
+	node := r.patternNode(uri, pattern, false)
+	if node == nil || node.Pool == nil {
+		return false
+	}
+	node.Pool = nil
+	node.Snip()
+	return true
+}
+
+// patternNode returns the node of the path pattern under the URI parameter,
+// creating the missing nodes if create is set, nil if it does not exist.
+func (r *Trie) patternNode(uri route.Uri, pattern route.PathPattern, create bool) *Trie {
+	node := r
+	for _, segment := range strings.Split(strings.TrimPrefix(uri.String(), "/"), "/") {
+		child, ok := node.ChildNodes[segment]
+		if !ok {
+			if !create {
+				return nil
+			}
+			child = NewTrie()
+			child.Segment = segment
+			child.Parent = node
+			node.ChildNodes[segment] = child
+		}
+		node = child
+	}
+
+	for _, sp := range pattern.Segments() {
+		var child *Trie
+		if sp.Literal() {
+			child = node.ChildNodes[sp.String()]
+		} else {
+			for _, c := range node.PatternNodes {
+				if c.Segment == sp.String() {
+					child = c
+					break
+				}
+			}
+		}
+		if child == nil {
+			if !create {
+				return nil
+			}
+			child = NewTrie()
+			child.Segment = sp.String()
+			child.Parent = node
+			if sp.Literal() {
+				node.ChildNodes[child.Segment] = child
+			} else {
+				child.Pattern = sp
+				node.addPatternNode(child)
+			}
+		}
+		node = child
+	}
+
+	return node
+}
+
+// addPatternNode adds a child to the pattern nodes, keeping them ordered by
+// precedence.
+func (r *Trie) addPatternNode(child *Trie) {
+	i := sort.Search(len(r.PatternNodes), func(i int) bool {
+		return child.Pattern.Before(r.PatternNodes[i].Pattern)
+	})
+	r.PatternNodes = append(r.PatternNodes, nil)
+	copy(r.PatternNodes[i+1:], r.PatternNodes[i:])
+	r.PatternNodes[i] = child
+}
+
+// removeChild removes a child node, whether literal or a pattern.
+func (r *Trie) removeChild(child *Trie) {
+	if child.Pattern.Literal() {
+		delete(r.ChildNodes, child.Segment)
+		return
+	}
+	for i, c := range r.PatternNodes {
+		if c == child {
+			r.PatternNodes = append(r.PatternNodes[:i], r.PatternNodes[i+1:]...)
+			return
+		}
+	}
+}
+
+func (r *Trie) childCount() int {
+	return len(r.ChildNodes) + len(r.PatternNodes)
+}
+
 func (r *Trie) deleteEmptyNodes(key string) {
 	node := r
 	nodeToKeep := r
@@ -151,9 +266,9 @@ func (r *Trie) deleteEmptyNodes(key string) {
 
 		matchingChild, _ := node.ChildNodes[SegmentValue]
 
-		if nil == nodeToRemove && nil == matchingChild.Pool && len(matchingChild.ChildNodes) < 2 {
+		if nil == nodeToRemove && nil == matchingChild.Pool && matchingChild.childCount() < 2 {
 			nodeToRemove = matchingChild
-		} else if nil != matchingChild.Pool || len(matchingChild.ChildNodes) > 1 {
+		} else if nil != matchingChild.Pool || matchingChild.childCount() > 1 {
 			nodeToKeep = matchingChild
 			nodeToRemove = nil
 		}
@@ -193,6 +308,10 @@ func (r *Trie) EachNodeWithPool(f func(*Trie)) {
 	for _, child := range r.ChildNodes {
 		child.EachNodeWithPool(f)
 	}
+	// f may snip the node it is given, which removes it from PatternNodes
+	for _, child := range append([]*Trie(nil), r.PatternNodes...) {
+		child.EachNodeWithPool(f)
+	}
 }
 
 func (r *Trie) EndpointCount() int {
@@ -213,6 +332,9 @@ func (r *Trie) endpointCount(m map[string]struct{}) map[string]struct{} {
 	for _, child := range r.ChildNodes {
 		child.endpointCount(m)
 	}
+	for _, child := range r.PatternNodes {
+		child.endpointCount(m)
+	}
 
 	return m
 }
@@ -224,6 +346,9 @@ func (r *Trie) PruneDeadLeaves() {
 	for _, child := range r.ChildNodes {
 		child.PruneDeadLeaves()
 	}
+	for _, child := range append([]*Trie(nil), r.PatternNodes...) {
+		child.PruneDeadLeaves()
+	}
 }
 
 func NewTrie() *Trie {
@@ -238,7 +363,7 @@ func (r *Trie) Snip() {
 	if (r.Pool != nil && !r.Pool.IsEmpty()) || r.isRoot() || !r.isLeaf() {
 		return
 	}
-	delete(r.Parent.ChildNodes, r.Segment)
+	r.Parent.removeChild(r)
 	r.Parent.Snip()
 }
 
@@ -259,13 +384,10 @@ func (r *Trie) toMap(segment string, m map[route.Uri]*route.EndpointPool) map[ro
 	}
 
 	for _, child := range r.ChildNodes {
-		var newseg string
-		if len(segment) == 0 {
-			newseg = segment + child.Segment
-		} else {
-			newseg = segment + "/" + child.Segment
-		}
-		child.toMap(newseg, m)
+		child.toMap(joinSegment(segment, child.Segment), m)
+	}
+	for _, child := range r.PatternNodes {
+		child.toMap(joinSegment(segment, child.Segment), m)
 	}
 
 	return m
@@ -276,7 +398,14 @@ func (r *Trie) isRoot() bool {
 }
 
 func (r *Trie) isLeaf() bool {
-	return len(r.ChildNodes) == 0
+	return r.childCount() == 0
+}
+
+func joinSegment(path, segment string) string {
+	if len(path) == 0 {
+		return segment
+	}
+	return path + "/" + segment
 }
 
 func parts(key string) []string {
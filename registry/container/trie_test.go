@@ -109,6 +109,108 @@ var _ = Describe("Trie", func() {
 		})
 	})
 
+	Describe("path patterns", func() {
+		var p3 *route.EndpointPool
+
+		BeforeEach(func() {
+			p3 = route.NewPool(&route.PoolOpts{
+				Logger:            new(fakes.FakeLogger),
+				RetryAfterFailure: 42,
+			})
+		})
+
+		pattern := func(s string) route.PathPattern {
+			pp, err := route.ParsePathPattern(s)
+			Expect(err).NotTo(HaveOccurred())
+			return pp
+		}
+
+		It("finds the route of a matching pattern", func() {
+			r.InsertPattern("foo.com", pattern("/api/v*/users/{id}"), p)
+
+			Expect(r.MatchUri("foo.com/api/v1/users/42")).To(BeIdenticalTo(p))
+			Expect(r.MatchUri("foo.com/api/v2/users/42/photos")).To(BeIdenticalTo(p))
+			Expect(r.MatchUri("foo.com/api/beta/users/42")).To(BeNil())
+			Expect(r.MatchUri("foo.com/api/v1/users")).To(BeNil())
+		})
+
+		It("anchors regular expressions to the segment", func() {
+			r.InsertPattern("foo.com", pattern("/users/{id:[0-9]+}"), p)
+
+			Expect(r.MatchUri("foo.com/users/42")).To(BeIdenticalTo(p))
+			Expect(r.MatchUri("foo.com/users/42abc")).To(BeNil())
+			Expect(r.MatchUri("foo.com/users/abc42")).To(BeNil())
+		})
+
+		It("prefers the literal route that matches as many segments", func() {
+			r.InsertPattern("foo.com", pattern("/users/{id}"), p)
+			r.Insert("foo.com/users/me", p1)
+
+			Expect(r.MatchUri("foo.com/users/me")).To(BeIdenticalTo(p1))
+			Expect(r.MatchUri("foo.com/users/42")).To(BeIdenticalTo(p))
+		})
+
+		It("prefers the pattern that matches more segments than a literal route", func() {
+			r.Insert("foo.com/users", p1)
+			r.InsertPattern("foo.com", pattern("/users/{id}"), p)
+
+			Expect(r.MatchUri("foo.com/users/42")).To(BeIdenticalTo(p))
+			Expect(r.MatchUri("foo.com/users")).To(BeIdenticalTo(p1))
+		})
+
+		It("prefers regular expressions to globs and globs to named parameters", func() {
+			r.InsertPattern("foo.com", pattern("/users/{name}"), p)
+			r.InsertPattern("foo.com", pattern("/users/4*"), p1)
+			r.InsertPattern("foo.com", pattern("/users/{id:[0-9]+}"), p2)
+
+			Expect(r.MatchUri("foo.com/users/42")).To(BeIdenticalTo(p2))
+			Expect(r.MatchUri("foo.com/users/4b")).To(BeIdenticalTo(p1))
+			Expect(r.MatchUri("foo.com/users/bob")).To(BeIdenticalTo(p))
+		})
+
+		It("prefers the more specific pattern at the first segment the patterns differ", func() {
+			r.InsertPattern("foo.com", pattern("/{tenant}/users/{id:[0-9]+}"), p)
+			r.InsertPattern("foo.com", pattern("/{id:[a-z]+}/{resource}/{rest}"), p1)
+
+			Expect(r.MatchUri("foo.com/acme/users/42")).To(BeIdenticalTo(p1))
+			Expect(r.MatchUri("foo.com/acme-1/users/42")).To(BeIdenticalTo(p))
+		})
+
+		It("backtracks to another pattern when the preferred one does not match", func() {
+			r.InsertPattern("foo.com", pattern("/{id:[a-z]+}/orders"), p1)
+			r.InsertPattern("foo.com", pattern("/{tenant}/users"), p3)
+
+			Expect(r.MatchUri("foo.com/acme/users")).To(BeIdenticalTo(p3))
+		})
+
+		It("finds, lists and deletes pattern routes", func() {
+			e := route.NewEndpoint(&route.EndpointOpts{})
+			p.Put(e)
+			r.Insert("foo.com/api", p1)
+			r.InsertPattern("foo.com", pattern("/api/v*/users/{id}"), p)
+
+			Expect(r.FindPattern("foo.com", pattern("/api/v*/users/{id}"))).To(BeIdenticalTo(p))
+			Expect(r.FindPattern("foo.com", pattern("/api/v*/orders/{id}"))).To(BeNil())
+			Expect(r.Find("foo.com/api/v*/users/{id}")).To(BeNil())
+			Expect(r.ToMap()).To(HaveKey(route.Uri("foo.com/api/v*/users/{id}")))
+			Expect(r.PoolCount()).To(Equal(2))
+
+			Expect(r.DeletePattern("foo.com", pattern("/api/v*/users/{id}"))).To(BeTrue())
+			Expect(r.DeletePattern("foo.com", pattern("/api/v*/users/{id}"))).To(BeFalse())
+			Expect(r.MatchUri("foo.com/api/v1/users/42")).To(BeIdenticalTo(p1))
+			Expect(r.ChildNodes["foo.com"].ChildNodes["api"].PatternNodes).To(BeEmpty())
+		})
+
+		It("keeps the pattern routes below a deleted literal route", func() {
+			r.Insert("foo.com/api", p1)
+			r.InsertPattern("foo.com", pattern("/api/{version}"), p)
+
+			r.Delete("foo.com/api")
+
+			Expect(r.MatchUri("foo.com/api/v1")).To(BeIdenticalTo(p))
+		})
+	})
+
 	Describe(".Insert", func() {
 		It("adds a non-existing key", func() {
 			childBar := r.Insert("/foo/bar", p)
@@ -54,6 +54,25 @@ type RouteRegistry struct {
 	isolationSegments        []string
 
 	maxConnsPerBackend int64
+
+	localZone         string
+	minHealthyPercent int
+
+	outlierDetection config.OutlierDetectionConfig
+	slowStart        config.SlowStartConfig
+
+	// staticLock serializes the updates of the static routes
+	staticLock      sync.Mutex
+	staticEndpoints map[staticRoute]*route.Endpoint
+
+	snapshotLoaded bool
+}
+
+// staticRoute is an endpoint of a uri of the static routes of the
+// configuration.
+type staticRoute struct {
+	uri  route.Uri
+	addr string
 }
 
 func NewRouteRegistry(logger logger.Logger, c *config.Config, reporter metrics.RouteRegistryReporter) *RouteRegistry {
@@ -72,6 +91,14 @@ func NewRouteRegistry(logger logger.Logger, c *config.Config, reporter metrics.R
 
 	r.maxConnsPerBackend = c.Backends.MaxConns
 
+	if c.LocalityAwareRouting.Enabled {
+		r.localZone = c.Zone
+		r.minHealthyPercent = c.LocalityAwareRouting.MinHealthyPercent
+	}
+
+	r.outlierDetection = c.OutlierDetection
+	r.slowStart = c.SlowStart
+
 	return r
 }
 
@@ -88,13 +115,14 @@ func (r *RouteRegistry) Register(uri route.Uri, endpoint *route.Endpoint) {
 		r.reporter.CaptureRouteRegistrationLatency(time.Since(endpoint.UpdatedAt))
 	}
 
+	key := patternKey(uri, endpoint.PathPattern)
 	switch endpointAdded {
 	case route.ADDED:
-		r.logger.Info("endpoint-registered", zapData(uri, endpoint)...)
+		r.logger.Info("endpoint-registered", zapData(key, endpoint)...)
 	case route.UPDATED:
-		r.logger.Debug("endpoint-registered", zapData(uri, endpoint)...)
+		r.logger.Debug("endpoint-registered", zapData(key, endpoint)...)
 	default:
-		r.logger.Debug("endpoint-not-registered", zapData(uri, endpoint)...)
+		r.logger.Debug("endpoint-not-registered", zapData(key, endpoint)...)
 	}
 }
 
@@ -104,33 +132,100 @@ func (r *RouteRegistry) register(uri route.Uri, endpoint *route.Endpoint) route.
 
 	t := time.Now()
 
+	pool := r.pool(uri, endpoint)
+
+	endpoint.StaleThreshold = r.staleThreshold(endpoint)
+
+	endpointAdded := pool.Put(endpoint)
+
+	r.timeOfLastUpdate = t
+
+	return endpointAdded
+}
+
+// pool returns the pool of the route of the endpoint, and adds the route to
+// the routing table when it is not in it yet.
+func (r *RouteRegistry) pool(uri route.Uri, endpoint *route.Endpoint) *route.EndpointPool {
 	routekey := uri.RouteKey()
 
-	pool := r.byURI.Find(routekey)
+	pool := r.find(routekey, endpoint.PathPattern)
 	if pool == nil {
 		host, contextPath := splitHostAndContextPath(uri)
+		if !endpoint.PathPattern.IsZero() {
+			contextPath = strings.TrimSuffix(contextPath, "/") + endpoint.PathPattern.Prefix()
+			if contextPath == "" {
+				contextPath = "/"
+			}
+		}
 		pool = route.NewPool(&route.PoolOpts{
 			Logger:             r.logger,
 			RetryAfterFailure:  r.dropletStaleThreshold / 4,
 			Host:               host,
 			ContextPath:        contextPath,
 			MaxConnsPerBackend: r.maxConnsPerBackend,
+			LocalZone:          r.localZone,
+			MinHealthyPercent:  r.minHealthyPercent,
+			OutlierDetection:   r.outlierDetection,
+			OutlierReporter:    r.reporter,
+			SlowStart:          r.slowStart,
 		})
-		r.byURI.Insert(routekey, pool)
-		r.logger.Info("route-registered", zap.Stringer("uri", routekey))
+		if endpoint.PathPattern.IsZero() {
+			r.byURI.Insert(routekey, pool)
+		} else {
+			r.byURI.InsertPattern(routekey, endpoint.PathPattern, pool)
+		}
+		r.logger.Info("route-registered", zap.Stringer("uri", patternKey(routekey, endpoint.PathPattern)))
 		// for backward compatibility:
-		r.logger.Debug("uri-added", zap.Stringer("uri", routekey))
+		r.logger.Debug("uri-added", zap.Stringer("uri", patternKey(routekey, endpoint.PathPattern)))
 	}
 
+	return pool
+}
+
+// staleThreshold returns the time after its last registration the endpoint
+// is pruned, which is at most the droplet stale threshold.
+func (r *RouteRegistry) staleThreshold(endpoint *route.Endpoint) time.Duration {
 	if endpoint.StaleThreshold > r.dropletStaleThreshold || endpoint.StaleThreshold == 0 {
-		endpoint.StaleThreshold = r.dropletStaleThreshold
+		return r.dropletStaleThreshold
 	}
+	return endpoint.StaleThreshold
+}
 
-	endpointAdded := pool.Put(endpoint)
-
-	r.timeOfLastUpdate = t
+// SetStaticRoutes registers the endpoints of the static routes of the
+// configuration and unregisters the ones of the previous static routes that
+// are no longer in them. Static endpoints are never pruned, and registration
+// messages for their addresses neither update nor remove them.
+func (r *RouteRegistry) SetStaticRoutes(staticRoutes []config.StaticRouteConfig) {
+	r.staticLock.Lock()
+	defer r.staticLock.Unlock()
+
+	endpoints := map[staticRoute]*route.Endpoint{}
+	for _, sr := range staticRoutes {
+		for _, uri := range sr.URIs {
+			for _, e := range sr.Endpoints {
+				endpoint := route.NewEndpoint(&route.EndpointOpts{
+					Host:                e.Host,
+					Port:                e.Port,
+					UseTLS:              e.TLS,
+					ServerCertDomainSAN: e.ServerCertDomainSAN,
+					Static:              true,
+				})
+				endpoints[staticRoute{uri: route.Uri(uri), addr: endpoint.CanonicalAddr()}] = endpoint
+			}
+		}
+	}
 
-	return endpointAdded
+	for sr, endpoint := range endpoints {
+		if r.register(sr.uri, endpoint) == route.ADDED {
+			r.logger.Info("static-endpoint-registered", zapData(sr.uri, endpoint)...)
+		}
+	}
+	for sr, endpoint := range r.staticEndpoints {
+		if _, ok := endpoints[sr]; !ok {
+			r.unregister(sr.uri, endpoint)
+		}
+	}
+	r.staticEndpoints = endpoints
 }
 
 func (r *RouteRegistry) Unregister(uri route.Uri, endpoint *route.Endpoint) {
@@ -149,22 +244,44 @@ func (r *RouteRegistry) unregister(uri route.Uri, endpoint *route.Endpoint) {
 
 	uri = uri.RouteKey()
 
-	pool := r.byURI.Find(uri)
+	pool := r.find(uri, endpoint.PathPattern)
 	if pool != nil {
+		key := patternKey(uri, endpoint.PathPattern)
 		endpointRemoved := pool.Remove(endpoint)
 		if endpointRemoved {
-			r.logger.Info("endpoint-unregistered", zapData(uri, endpoint)...)
+			r.logger.Info("endpoint-unregistered", zapData(key, endpoint)...)
 		} else {
-			r.logger.Debug("endpoint-not-unregistered", zapData(uri, endpoint)...)
+			r.logger.Debug("endpoint-not-unregistered", zapData(key, endpoint)...)
 		}
 
 		if pool.IsEmpty() {
-			r.byURI.Delete(uri)
-			r.logger.Info("route-unregistered", zap.Stringer("uri", uri))
+			if endpoint.PathPattern.IsZero() {
+				r.byURI.Delete(uri)
+			} else {
+				r.byURI.DeletePattern(uri, endpoint.PathPattern)
+			}
+			r.logger.Info("route-unregistered", zap.Stringer("uri", key))
 		}
 	}
 }
 
+// find returns the pool of the route, which is the route of the path pattern
+// under the uri when the pattern is set.
+func (r *RouteRegistry) find(uri route.Uri, pattern route.PathPattern) *route.EndpointPool {
+	if pattern.IsZero() {
+		return r.byURI.Find(uri)
+	}
+	return r.byURI.FindPattern(uri, pattern)
+}
+
+// patternKey returns the uri the route of a path pattern is listed under.
+func patternKey(uri route.Uri, pattern route.PathPattern) route.Uri {
+	if pattern.IsZero() {
+		return uri
+	}
+	return route.Uri(uri.String() + pattern.String())
+}
+
 func (r *RouteRegistry) Lookup(uri route.Uri) *route.EndpointPool {
 	started := time.Now()
 
@@ -284,6 +401,18 @@ func (r *RouteRegistry) TimeOfLastUpdate() time.Time {
 	return r.timeOfLastUpdate
 }
 
+// Pools returns the pools of every route in the routing table.
+func (r *RouteRegistry) Pools() []*route.EndpointPool {
+	r.RLock()
+	defer r.RUnlock()
+
+	var pools []*route.EndpointPool
+	r.byURI.EachNodeWithPool(func(t *container.Trie) {
+		pools = append(pools, t.Pool)
+	})
+	return pools
+}
+
 func (r *RouteRegistry) NumEndpoints() int {
 	r.RLock()
 	defer r.RUnlock()
@@ -82,3 +82,70 @@ func BenchmarkRegisterWithOneRoute(b *testing.B) {
 		r.Register("foo.example.com", fooEndpoint)
 	}
 }
+
+func BenchmarkLookupWith100KRoutes(b *testing.B) {
+	r := registry.NewRouteRegistry(testLogger, configObj, reporter)
+
+	for i := 0; i < 100000; i++ {
+		r.Register(route.Uri(fmt.Sprintf("foo%d.example.com", i)), fooEndpoint)
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		r.Lookup("foo50000.example.com/api/v1/users/42")
+	}
+}
+
+func BenchmarkLookupPathPatternWith100KRoutes(b *testing.B) {
+	r := registry.NewRouteRegistry(testLogger, configObj, reporter)
+
+	for i := 0; i < 100000; i++ {
+		r.Register(route.Uri(fmt.Sprintf("foo%d.example.com", i)), fooEndpoint)
+	}
+	r.Register("foo50000.example.com", patternEndpoint(b, "/api/v*/users/{id:[0-9]+}"))
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		r.Lookup("foo50000.example.com/api/v1/users/42")
+	}
+}
+
+func BenchmarkLookupWith1KPathPatternsOfOneRoute(b *testing.B) {
+	r := registry.NewRouteRegistry(testLogger, configObj, reporter)
+
+	r.Register("foo.example.com", fooEndpoint)
+	for i := 0; i < 1000; i++ {
+		r.Register("foo.example.com", patternEndpoint(b, fmt.Sprintf("/api/v%d*/users/{id:[0-9]+}", i)))
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		r.Lookup("foo.example.com/api/v999/users/42")
+	}
+}
+
+func BenchmarkLookupWith1KPathPatternsOfOtherRoutes(b *testing.B) {
+	r := registry.NewRouteRegistry(testLogger, configObj, reporter)
+
+	r.Register("foo.example.com", fooEndpoint)
+	for i := 0; i < 1000; i++ {
+		r.Register(route.Uri(fmt.Sprintf("foo%d.example.com", i)), patternEndpoint(b, "/api/v*/users/{id:[0-9]+}"))
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		r.Lookup("foo.example.com/api/v999/users/42")
+	}
+}
+
+func patternEndpoint(b *testing.B, pattern string) *route.Endpoint {
+	p, err := route.ParsePathPattern(pattern)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return route.NewEndpoint(&route.EndpointOpts{PathPattern: p})
+}
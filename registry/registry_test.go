@@ -820,6 +820,61 @@ var _ = Describe("RouteRegistry", func() {
 			})
 		})
 
+		Context("has a path pattern", func() {
+			var literal, users, wildcard *route.Endpoint
+
+			BeforeEach(func() {
+				pattern, err := route.ParsePathPattern("/api/v*/users/{id:[0-9]+}")
+				Expect(err).NotTo(HaveOccurred())
+				literal = route.NewEndpoint(&route.EndpointOpts{Host: "192.168.1.1", Port: 1234})
+				users = route.NewEndpoint(&route.EndpointOpts{Host: "192.168.1.2", Port: 1234, PathPattern: pattern})
+				wildcard = route.NewEndpoint(&route.EndpointOpts{Host: "192.168.1.3", Port: 1234, PathPattern: pattern})
+
+				r.Register("dora.app.com/api", literal)
+				r.Register("dora.app.com", users)
+				r.Register("*.app.com", wildcard)
+			})
+
+			lookup := func(uri route.Uri) string {
+				p := r.Lookup(uri)
+				Expect(p).ToNot(BeNil())
+				return p.Endpoints("", "").Next().CanonicalAddr()
+			}
+
+			It("routes the requests that match the pattern to it", func() {
+				Expect(lookup("dora.app.com/api/v1/users/42?foo=bar")).To(Equal("192.168.1.2:1234"))
+				Expect(lookup("DORA.app.com/API/V2/users/42/photos")).To(Equal("192.168.1.2:1234"))
+			})
+
+			It("routes other requests to the longest literal route", func() {
+				Expect(lookup("dora.app.com/api/v1/users/me")).To(Equal("192.168.1.1:1234"))
+				Expect(lookup("dora.app.com/api/beta/users/42")).To(Equal("192.168.1.1:1234"))
+			})
+
+			It("matches patterns of wildcard routes", func() {
+				Expect(lookup("other.app.com/api/v1/users/42")).To(Equal("192.168.1.3:1234"))
+				Expect(r.Lookup("other.app.com/api")).To(BeNil())
+			})
+
+			It("sets the context path of the pool to the literal prefix of the pattern", func() {
+				Expect(r.Lookup("dora.app.com/api/v1/users/42").ContextPath()).To(Equal("/api"))
+			})
+
+			It("lists the route under the uri and the pattern", func() {
+				marshalled, err := json.Marshal(r)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(marshalled)).To(ContainSubstring(`"dora.app.com/api/v*/users/{id:[0-9]+}":[{"address":"192.168.1.2:1234"`))
+				Expect(r.NumUris()).To(Equal(3))
+			})
+
+			It("removes the route when its last endpoint is unregistered", func() {
+				r.Unregister("dora.app.com", users)
+
+				Expect(lookup("dora.app.com/api/v1/users/42")).To(Equal("192.168.1.1:1234"))
+				Expect(r.NumUris()).To(Equal(2))
+			})
+		})
+
 		Context("when lookup fails to find any routes", func() {
 			It("returns nil", func() {
 				p := r.Lookup("non-existent")
@@ -827,6 +882,25 @@ var _ = Describe("RouteRegistry", func() {
 			})
 		})
 
+		Context("when locality aware routing is enabled", func() {
+			BeforeEach(func() {
+				configObj.Zone = "z1"
+				configObj.LocalityAwareRouting.Enabled = true
+				r = NewRouteRegistry(logger, configObj, reporter)
+			})
+
+			It("prefers the endpoints in the zone of the router", func() {
+				r.Register("foo", route.NewEndpoint(&route.EndpointOpts{Host: "192.168.1.1", Port: 1234, AvailabilityZone: "z2"}))
+				r.Register("foo", route.NewEndpoint(&route.EndpointOpts{Host: "192.168.1.2", Port: 1234, AvailabilityZone: "z1"}))
+
+				p := r.Lookup("foo")
+				Expect(p).ToNot(BeNil())
+				for i := 0; i < 5; i++ {
+					Expect(p.Endpoints("", "").Next().CanonicalAddr()).To(Equal("192.168.1.2:1234"))
+				}
+			})
+		})
+
 		It("selects a route even with extra paths in the lookup argument", func() {
 			m := route.NewEndpoint(&route.EndpointOpts{Host: "192.168.1.1", Port: 1234})
 
@@ -1178,6 +1252,82 @@ var _ = Describe("RouteRegistry", func() {
 
 	})
 
+	Context("SetStaticRoutes", func() {
+		var staticRoutes []config.StaticRouteConfig
+
+		BeforeEach(func() {
+			staticRoutes = []config.StaticRouteConfig{{
+				URIs: []string{"status.example.com", "status.example.com/internal"},
+				Endpoints: []config.StaticEndpointConfig{
+					{Host: "10.0.0.5", Port: 8080},
+					{Host: "10.0.0.6", Port: 8443, TLS: true, ServerCertDomainSAN: "status.internal"},
+				},
+			}}
+			r.SetStaticRoutes(staticRoutes)
+		})
+
+		AfterEach(func() {
+			r.StopPruningCycle()
+		})
+
+		It("registers the endpoints of the static routes", func() {
+			Expect(r.NumUris()).To(Equal(2))
+			Expect(r.NumEndpoints()).To(Equal(2))
+
+			p := r.Lookup("status.example.com/internal/health")
+			Expect(p).NotTo(BeNil())
+			addrs := []string{}
+			p.Each(func(e *route.Endpoint) {
+				Expect(e.Static).To(BeTrue())
+				addrs = append(addrs, e.CanonicalAddr())
+			})
+			Expect(addrs).To(ConsistOf("10.0.0.5:8080", "10.0.0.6:8443"))
+			Expect(logger).To(gbytes.Say(`static-endpoint-registered`))
+		})
+
+		It("marks the endpoints as static in the routing table", func() {
+			marshalled, err := json.Marshal(r)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(marshalled)).To(MatchRegexp(`"address":"10.0.0.5:8080"[^}]*"static":true`))
+		})
+
+		It("does not prune the static endpoints", func() {
+			r.Register("status.example.com", fooEndpoint)
+
+			r.StartPruningCycle()
+			time.Sleep(configObj.PruneStaleDropletsInterval + configObj.DropletStaleThreshold)
+
+			Expect(r.NumUris()).To(Equal(2))
+			Expect(r.NumEndpoints()).To(Equal(2))
+		})
+
+		It("does not let registration messages update or remove the static endpoints", func() {
+			nats := route.NewEndpoint(&route.EndpointOpts{Host: "10.0.0.5", Port: 8080})
+
+			r.Register("status.example.com", nats)
+			r.Unregister("status.example.com", nats)
+
+			p := r.Lookup("status.example.com")
+			Expect(p).NotTo(BeNil())
+			Expect(p.Endpoints("", "").Next().Static).To(BeTrue())
+			Expect(r.NumEndpoints()).To(Equal(2))
+		})
+
+		It("unregisters the endpoints that are no longer in the static routes", func() {
+			staticRoutes[0].URIs = []string{"status.example.com"}
+			staticRoutes[0].Endpoints = staticRoutes[0].Endpoints[:1]
+			r.SetStaticRoutes(staticRoutes)
+
+			Expect(r.NumUris()).To(Equal(1))
+			Expect(r.NumEndpoints()).To(Equal(1))
+			Expect(r.Lookup("status.example.com/internal").Endpoints("", "").Next().CanonicalAddr()).To(Equal("10.0.0.5:8080"))
+
+			r.SetStaticRoutes(nil)
+
+			Expect(r.NumUris()).To(Equal(0))
+		})
+	})
+
 	Context("Varz data", func() {
 		It("NumUris", func() {
 			r.Register("bar", barEndpoint)
@@ -0,0 +1,305 @@
+package registry
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"github.com/uber-go/zap"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/logger"
+	"code.cloudfoundry.org/gorouter/registry/container"
+	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/routing-api/models"
+)
+
+// routingTableSnapshot is the format of the file the routing table is saved
+// to.
+type routingTableSnapshot struct {
+	Endpoints []snapshotEndpoint `json:"endpoints"`
+}
+
+// snapshotEndpoint is an endpoint of a route of the routing table together
+// with the time it was last registered.
+type snapshotEndpoint struct {
+	URI                     string                    `json:"uri"`
+	PathPattern             string                    `json:"path_pattern,omitempty"`
+	Host                    string                    `json:"host"`
+	Port                    uint16                    `json:"port"`
+	TLS                     bool                      `json:"tls,omitempty"`
+	App                     string                    `json:"app,omitempty"`
+	PrivateInstanceID       string                    `json:"private_instance_id,omitempty"`
+	PrivateInstanceIndex    string                    `json:"private_instance_index,omitempty"`
+	ServerCertDomainSAN     string                    `json:"server_cert_domain_san,omitempty"`
+	Tags                    map[string]string         `json:"tags,omitempty"`
+	StaleThresholdInSeconds int                       `json:"stale_threshold_in_seconds"`
+	RouteServiceURL         string                    `json:"route_service_url,omitempty"`
+	ModificationTag         models.ModificationTag    `json:"modification_tag"`
+	IsolationSegment        string                    `json:"isolation_segment,omitempty"`
+	Protocol                string                    `json:"protocol,omitempty"`
+	TLSPassthrough          bool                      `json:"tls_passthrough,omitempty"`
+	Weight                  int                       `json:"weight,omitempty"`
+	AvailabilityZone        string                    `json:"availability_zone,omitempty"`
+	HashKey                 string                    `json:"hash_key,omitempty"`
+	LoadBalancingAlgorithm  string                    `json:"load_balancing_algorithm,omitempty"`
+	HealthCheckPath         string                    `json:"health_check_path,omitempty"`
+	RetryPolicy             *config.RetryPolicyConfig `json:"retry_policy,omitempty"`
+	TrafficPercent          int                       `json:"traffic_percent,omitempty"`
+	TrafficMatch            string                    `json:"traffic_match,omitempty"`
+	Updated                 time.Time                 `json:"updated"`
+}
+
+func newSnapshotEndpoint(uri string, e *route.Endpoint, updated time.Time) (snapshotEndpoint, error) {
+	host, port, err := net.SplitHostPort(e.CanonicalAddr())
+	if err != nil {
+		return snapshotEndpoint{}, err
+	}
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return snapshotEndpoint{}, err
+	}
+
+	return snapshotEndpoint{
+		URI:                     uri,
+		PathPattern:             e.PathPattern.String(),
+		Host:                    host,
+		Port:                    uint16(p),
+		TLS:                     e.IsTLS(),
+		App:                     e.ApplicationId,
+		PrivateInstanceID:       e.PrivateInstanceId,
+		PrivateInstanceIndex:    e.PrivateInstanceIndex,
+		ServerCertDomainSAN:     e.ServerCertDomainSAN,
+		Tags:                    e.Tags,
+		StaleThresholdInSeconds: int(e.StaleThreshold.Seconds()),
+		RouteServiceURL:         e.RouteServiceUrl,
+		ModificationTag:         e.ModificationTag,
+		IsolationSegment:        e.IsolationSegment,
+		Protocol:                e.Protocol,
+		TLSPassthrough:          e.TLSPassthrough,
+		Weight:                  e.Weight,
+		AvailabilityZone:        e.AvailabilityZone,
+		HashKey:                 e.HashKey.String(),
+		LoadBalancingAlgorithm:  e.LoadBalancingAlgorithm,
+		HealthCheckPath:         e.HealthCheckPath,
+		RetryPolicy:             e.RetryPolicy,
+		TrafficPercent:          e.TrafficPercent,
+		TrafficMatch:            e.TrafficMatch.String(),
+		Updated:                 updated,
+	}, nil
+}
+
+func (s snapshotEndpoint) endpoint() (*route.Endpoint, error) {
+	pathPattern, err := route.ParsePathPattern(s.PathPattern)
+	if err != nil {
+		return nil, err
+	}
+	hashKey, err := route.ParseHashKey(s.HashKey)
+	if err != nil {
+		return nil, err
+	}
+	trafficMatch, err := route.ParseTrafficMatch(s.TrafficMatch)
+	if err != nil {
+		return nil, err
+	}
+
+	return route.NewEndpoint(&route.EndpointOpts{
+		AppId:                   s.App,
+		Host:                    s.Host,
+		Port:                    s.Port,
+		ServerCertDomainSAN:     s.ServerCertDomainSAN,
+		PrivateInstanceId:       s.PrivateInstanceID,
+		PrivateInstanceIndex:    s.PrivateInstanceIndex,
+		Tags:                    s.Tags,
+		StaleThresholdInSeconds: s.StaleThresholdInSeconds,
+		RouteServiceUrl:         s.RouteServiceURL,
+		ModificationTag:         s.ModificationTag,
+		IsolationSegment:        s.IsolationSegment,
+		UseTLS:                  s.TLS,
+		Protocol:                s.Protocol,
+		TLSPassthrough:          s.TLSPassthrough,
+		Weight:                  s.Weight,
+		AvailabilityZone:        s.AvailabilityZone,
+		HashKey:                 hashKey,
+		LoadBalancingAlgorithm:  s.LoadBalancingAlgorithm,
+		HealthCheckPath:         s.HealthCheckPath,
+		RetryPolicy:             s.RetryPolicy,
+		TrafficPercent:          s.TrafficPercent,
+		TrafficMatch:            trafficMatch,
+		PathPattern:             pathPattern,
+	}), nil
+}
+
+// SaveSnapshot writes the routes of the routing table to a file. The file is
+// replaced at once, so that a router starting while it is written reads the
+// previous snapshot. Static endpoints are left out as they come from the
+// configuration.
+func (r *RouteRegistry) SaveSnapshot(path string) error {
+	data, err := json.Marshal(r.snapshot())
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+func (r *RouteRegistry) snapshot() routingTableSnapshot {
+	r.RLock()
+	defer r.RUnlock()
+
+	snapshot := routingTableSnapshot{Endpoints: []snapshotEndpoint{}}
+	r.byURI.EachNodeWithPool(func(t *container.Trie) {
+		t.Pool.EachUpdated(func(e *route.Endpoint, updated time.Time) {
+			if e.Static {
+				return
+			}
+			uri := strings.TrimSuffix(t.ToPath(), e.PathPattern.String())
+			se, err := newSnapshotEndpoint(uri, e, updated)
+			if err != nil {
+				r.logger.Error("failed-to-snapshot-endpoint", zap.String("uri", t.ToPath()), zap.Error(err))
+				return
+			}
+			snapshot.Endpoints = append(snapshot.Endpoints, se)
+		})
+	})
+	return snapshot
+}
+
+// LoadSnapshot registers the routes of a routing table snapshot with the
+// times they were last registered before the restart, so that the ones that
+// are not registered again are pruned when they would have been without it.
+// Endpoints that would have been pruned already are skipped.
+func (r *RouteRegistry) LoadSnapshot(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var snapshot routingTableSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	loaded, skipped := 0, 0
+	for _, se := range snapshot.Endpoints {
+		endpoint, err := se.endpoint()
+		if err != nil {
+			r.logger.Error("invalid-snapshot-endpoint", zap.String("uri", se.URI), zap.Error(err))
+			continue
+		}
+		if !r.endpointInRouterShard(endpoint) {
+			continue
+		}
+		if !endpoint.IsTLS() && se.Updated.Before(now.Add(-r.staleThreshold(endpoint))) {
+			skipped++
+			continue
+		}
+
+		if r.restore(route.Uri(se.URI), endpoint, se.Updated) {
+			loaded++
+		}
+	}
+
+	r.Lock()
+	r.snapshotLoaded = r.snapshotLoaded || loaded > 0
+	r.Unlock()
+
+	r.logger.Info("snapshot-loaded",
+		zap.String("path", path),
+		zap.Int("endpoints", loaded),
+		zap.Int("stale_endpoints", skipped),
+	)
+	return nil
+}
+
+// restore adds an endpoint of a snapshot with the time it was last
+// registered, unless an endpoint with its address was registered since.
+func (r *RouteRegistry) restore(uri route.Uri, endpoint *route.Endpoint, updated time.Time) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	endpoint.StaleThreshold = r.staleThreshold(endpoint)
+	if !r.pool(uri, endpoint).Restore(endpoint, updated) {
+		return false
+	}
+
+	r.timeOfLastUpdate = time.Now()
+	return true
+}
+
+// SnapshotLoaded reports whether routes were loaded from a routing table
+// snapshot.
+func (r *RouteRegistry) SnapshotLoaded() bool {
+	r.RLock()
+	defer r.RUnlock()
+
+	return r.snapshotLoaded
+}
+
+// Snapshotter saves the routing table of a registry to a file every
+// interval, and once more when it stops.
+type Snapshotter struct {
+	registry *RouteRegistry
+	path     string
+	interval time.Duration
+	logger   logger.Logger
+	clock    clock.Clock
+}
+
+func NewSnapshotter(logger logger.Logger, registry *RouteRegistry, c *config.Config, clock clock.Clock) *Snapshotter {
+	return &Snapshotter{
+		registry: registry,
+		path:     c.RoutingTableSnapshot.Path,
+		interval: c.RoutingTableSnapshot.Interval,
+		logger:   logger,
+		clock:    clock,
+	}
+}
+
+func (s *Snapshotter) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	ticker := s.clock.NewTicker(s.interval)
+	s.logger.Info("snapshotter-started", zap.String("path", s.path))
+
+	close(ready)
+	for {
+		select {
+		case <-ticker.C():
+			s.save()
+		case <-signals:
+			s.logger.Info("stopping")
+			ticker.Stop()
+			s.save()
+			return nil
+		}
+	}
+}
+
+func (s *Snapshotter) save() {
+	if err := s.registry.SaveSnapshot(s.path); err != nil {
+		s.logger.Error("failed-to-save-snapshot", zap.String("path", s.path), zap.Error(err))
+		return
+	}
+	s.logger.Debug("snapshot-saved", zap.String("path", s.path))
+}
@@ -0,0 +1,224 @@
+package registry_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/logger"
+	"code.cloudfoundry.org/gorouter/metrics/fakes"
+	. "code.cloudfoundry.org/gorouter/registry"
+	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/gorouter/test_util"
+	"code.cloudfoundry.org/routing-api/models"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/tedsuo/ifrit"
+)
+
+var _ = Describe("Routing table snapshots", func() {
+	var (
+		r, restarted *RouteRegistry
+		configObj    *config.Config
+		logger       logger.Logger
+		dir, path    string
+	)
+
+	newRegistry := func() *RouteRegistry {
+		return NewRouteRegistry(logger, configObj, new(fakes.FakeRouteRegistryReporter))
+	}
+
+	updatedTimes := func(reg *RouteRegistry, uri route.Uri) []time.Time {
+		var times []time.Time
+		reg.Lookup(uri).EachUpdated(func(_ *route.Endpoint, updated time.Time) {
+			times = append(times, updated)
+		})
+		return times
+	}
+
+	BeforeEach(func() {
+		logger = test_util.NewTestZapLogger("test")
+		var err error
+		configObj, err = config.DefaultConfig()
+		Expect(err).ToNot(HaveOccurred())
+		configObj.DropletStaleThreshold = 2 * time.Minute
+
+		dir, err = ioutil.TempDir("", "routing-table-snapshot")
+		Expect(err).NotTo(HaveOccurred())
+		path = filepath.Join(dir, "routes.json")
+
+		r = newRegistry()
+		restarted = newRegistry()
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("restores the routes and their endpoints", func() {
+		hashKey, err := route.ParseHashKey("header:X-User")
+		Expect(err).NotTo(HaveOccurred())
+		pattern, err := route.ParsePathPattern("/users/{id}")
+		Expect(err).NotTo(HaveOccurred())
+
+		r.Register("app.example.com", route.NewEndpoint(&route.EndpointOpts{
+			AppId:                "app-guid",
+			Host:                 "10.0.0.1",
+			Port:                 8080,
+			PrivateInstanceId:    "instance-guid",
+			PrivateInstanceIndex: "2",
+			Tags:                 map[string]string{"component": "app"},
+			ModificationTag:      models.ModificationTag{Guid: "abc", Index: 3},
+			Weight:               5,
+			HashKey:              hashKey,
+		}))
+		r.Register("app.example.com/api", route.NewEndpoint(&route.EndpointOpts{
+			Host:                "10.0.0.2",
+			Port:                8443,
+			UseTLS:              true,
+			ServerCertDomainSAN: "api.internal",
+		}))
+		r.Register("app.example.com/api", route.NewEndpoint(&route.EndpointOpts{
+			Host:        "10.0.0.3",
+			Port:        8080,
+			PathPattern: pattern,
+		}))
+		r.SetStaticRoutes([]config.StaticRouteConfig{{
+			URIs:      []string{"status.example.com"},
+			Endpoints: []config.StaticEndpointConfig{{Host: "10.0.0.5", Port: 8080}},
+		}})
+
+		Expect(r.SaveSnapshot(path)).To(Succeed())
+		Expect(restarted.LoadSnapshot(path)).To(Succeed())
+
+		Expect(restarted.NumUris()).To(Equal(3))
+		Expect(restarted.NumEndpoints()).To(Equal(3))
+		Expect(restarted.SnapshotLoaded()).To(BeTrue())
+		Expect(restarted.Lookup("status.example.com")).To(BeNil())
+
+		e := restarted.Lookup("app.example.com").Endpoints("", "").Next()
+		Expect(e.CanonicalAddr()).To(Equal("10.0.0.1:8080"))
+		Expect(e.ApplicationId).To(Equal("app-guid"))
+		Expect(e.PrivateInstanceId).To(Equal("instance-guid"))
+		Expect(e.PrivateInstanceIndex).To(Equal("2"))
+		Expect(e.Tags).To(Equal(map[string]string{"component": "app"}))
+		Expect(e.ModificationTag).To(Equal(models.ModificationTag{Guid: "abc", Index: 3}))
+		Expect(e.Weight).To(Equal(5))
+		Expect(e.HashKey).To(Equal(hashKey))
+		Expect(e.StaleThreshold).To(Equal(configObj.DropletStaleThreshold))
+
+		e = restarted.Lookup("app.example.com/api/orders").Endpoints("", "").Next()
+		Expect(e.CanonicalAddr()).To(Equal("10.0.0.2:8443"))
+		Expect(e.IsTLS()).To(BeTrue())
+		Expect(e.ServerCertDomainSAN).To(Equal("api.internal"))
+
+		p := restarted.Lookup("app.example.com/api/users/42")
+		Expect(p.ContextPath()).To(Equal("/api/users"))
+		e = p.Endpoints("", "").Next()
+		Expect(e.CanonicalAddr()).To(Equal("10.0.0.3:8080"))
+		Expect(e.PathPattern).To(Equal(pattern))
+
+		Expect(logger).To(gbytes.Say(`snapshot-loaded.*"endpoints":3`))
+	})
+
+	It("keeps the times the endpoints were last registered", func() {
+		r.Register("app.example.com", route.NewEndpoint(&route.EndpointOpts{Host: "10.0.0.1", Port: 8080}))
+		r.Lookup("app.example.com").MarkUpdated(time.Now().Add(-time.Minute))
+
+		Expect(r.SaveSnapshot(path)).To(Succeed())
+		Expect(restarted.LoadSnapshot(path)).To(Succeed())
+
+		Expect(updatedTimes(restarted, "app.example.com")).To(HaveLen(1))
+		Expect(updatedTimes(restarted, "app.example.com")[0]).To(BeTemporally("==", updatedTimes(r, "app.example.com")[0]))
+	})
+
+	It("skips the endpoints that would have been pruned", func() {
+		r.Register("app.example.com", route.NewEndpoint(&route.EndpointOpts{Host: "10.0.0.1", Port: 8080}))
+		r.Register("stale.example.com", route.NewEndpoint(&route.EndpointOpts{Host: "10.0.0.2", Port: 8080}))
+		r.Lookup("stale.example.com").MarkUpdated(time.Now().Add(-3 * time.Minute))
+
+		Expect(r.SaveSnapshot(path)).To(Succeed())
+		Expect(restarted.LoadSnapshot(path)).To(Succeed())
+
+		Expect(restarted.NumEndpoints()).To(Equal(1))
+		Expect(restarted.Lookup("stale.example.com")).To(BeNil())
+	})
+
+	It("does not set back the times of endpoints registered since the restart", func() {
+		r.Register("app.example.com", route.NewEndpoint(&route.EndpointOpts{Host: "10.0.0.1", Port: 8080}))
+		r.Lookup("app.example.com").MarkUpdated(time.Now().Add(-time.Minute))
+		Expect(r.SaveSnapshot(path)).To(Succeed())
+
+		restarted.Register("app.example.com", route.NewEndpoint(&route.EndpointOpts{Host: "10.0.0.1", Port: 8080}))
+		Expect(restarted.LoadSnapshot(path)).To(Succeed())
+
+		Expect(updatedTimes(restarted, "app.example.com")[0]).To(BeTemporally("~", time.Now(), time.Second))
+		Expect(restarted.SnapshotLoaded()).To(BeFalse())
+	})
+
+	It("replaces the previous snapshot", func() {
+		r.Register("app.example.com", route.NewEndpoint(&route.EndpointOpts{Host: "10.0.0.1", Port: 8080}))
+		Expect(r.SaveSnapshot(path)).To(Succeed())
+		r.Unregister("app.example.com", route.NewEndpoint(&route.EndpointOpts{Host: "10.0.0.1", Port: 8080}))
+		Expect(r.SaveSnapshot(path)).To(Succeed())
+
+		Expect(restarted.LoadSnapshot(path)).To(Succeed())
+		Expect(restarted.NumUris()).To(BeZero())
+
+		files, err := ioutil.ReadDir(dir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(HaveLen(1))
+	})
+
+	It("returns an error when there is no snapshot", func() {
+		err := restarted.LoadSnapshot(path)
+		Expect(os.IsNotExist(err)).To(BeTrue())
+		Expect(restarted.SnapshotLoaded()).To(BeFalse())
+	})
+
+	It("returns an error when the snapshot cannot be parsed", func() {
+		Expect(ioutil.WriteFile(path, []byte(`{"endpoints": [`), 0644)).To(Succeed())
+
+		Expect(restarted.LoadSnapshot(path)).NotTo(Succeed())
+	})
+
+	Describe("Snapshotter", func() {
+		var (
+			clock   *fakeclock.FakeClock
+			process ifrit.Process
+		)
+
+		BeforeEach(func() {
+			configObj.RoutingTableSnapshot.Path = path
+			configObj.RoutingTableSnapshot.Interval = 10 * time.Second
+			clock = fakeclock.NewFakeClock(time.Now())
+
+			r.Register("app.example.com", route.NewEndpoint(&route.EndpointOpts{Host: "10.0.0.1", Port: 8080}))
+			process = ifrit.Invoke(NewSnapshotter(logger, r, configObj, clock))
+		})
+
+		It("saves the routing table every interval", func() {
+			Consistently(func() error { _, err := os.Stat(path); return err }).ShouldNot(Succeed())
+
+			clock.WaitForWatcherAndIncrement(configObj.RoutingTableSnapshot.Interval)
+
+			Eventually(func() error { _, err := os.Stat(path); return err }).Should(Succeed())
+			process.Signal(os.Interrupt)
+			Eventually(process.Wait()).Should(Receive(BeNil()))
+		})
+
+		It("saves the routing table when it stops", func() {
+			r.Register("api.example.com", route.NewEndpoint(&route.EndpointOpts{Host: "10.0.0.2", Port: 8080}))
+
+			process.Signal(os.Interrupt)
+			Eventually(process.Wait()).Should(Receive(BeNil()))
+
+			Expect(restarted.LoadSnapshot(path)).To(Succeed())
+			Expect(restarted.NumUris()).To(Equal(2))
+		})
+	})
+})
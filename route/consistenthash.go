@@ -0,0 +1,263 @@
+package route
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sources of the key the consistent hash algorithm maps to an endpoint
+const (
+	HashOnHeader   = "header"
+	HashOnCookie   = "cookie"
+	HashOnQuery    = "query"
+	HashOnSourceIP = "source_ip"
+)
+
+// replicasPerEndpoint is the number of points every endpoint gets on the
+// hash ring. More points spread keys more evenly over the endpoints.
+const replicasPerEndpoint = 160
+
+// HashKey says which part of a request the consistent hash algorithm uses as
+// the key. The zero value hashes on the source IP of the request.
+type HashKey struct {
+	Source string
+	Name   string
+}
+
+// ParseHashKey parses a hash key in the format of a registration message:
+// "header:<name>", "cookie:<name>", "query:<name>" or "source_ip".
+func ParseHashKey(s string) (HashKey, error) {
+	if s == "" || s == HashOnSourceIP {
+		return HashKey{}, nil
+	}
+
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) == 2 && parts[1] != "" {
+		switch parts[0] {
+		case HashOnHeader, HashOnCookie, HashOnQuery:
+			return HashKey{Source: parts[0], Name: parts[1]}, nil
+		}
+	}
+
+	return HashKey{}, fmt.Errorf("invalid hash key %q, must be one of %q, %q, %q or %q", s, HashOnHeader+":<name>", HashOnCookie+":<name>", HashOnQuery+":<name>", HashOnSourceIP)
+}
+
+func (k HashKey) String() string {
+	if k.Source == "" {
+		return ""
+	}
+	return k.Source + ":" + k.Name
+}
+
+// Value returns the key of the request. It is empty when the request does
+// not carry the header, cookie or query parameter.
+func (k HashKey) Value(req *http.Request) string {
+	switch k.Source {
+	case HashOnHeader:
+		return req.Header.Get(k.Name)
+	case HashOnCookie:
+		if cookie, err := req.Cookie(k.Name); err == nil {
+			return cookie.Value
+		}
+		return ""
+	case HashOnQuery:
+		return req.URL.Query().Get(k.Name)
+	default:
+		host, _, err := net.SplitHostPort(req.RemoteAddr)
+		if err != nil {
+			return req.RemoteAddr
+		}
+		return host
+	}
+}
+
+// ConsistentHash maps the hash key of a request to an endpoint on a hash
+// ring, so that requests with the same key keep going to the same endpoint
+// and only the keys of an added or removed endpoint move to other endpoints.
+// When the endpoint of a key is overloaded or failed, the next endpoint on the
+// ring is used. Requests without a key start at a random point of the ring.
+type ConsistentHash struct {
+	pool            *EndpointPool
+	initialEndpoint string
+	group           string
+	lastEndpoint    *Endpoint
+	key             string
+}
+
+func NewConsistentHash(p *EndpointPool, initial, key string) EndpointIterator {
+	return &ConsistentHash{
+		pool:            p,
+		initialEndpoint: initial,
+		key:             key,
+	}
+}
+
+func (r *ConsistentHash) Next() *Endpoint {
+	var e *endpointElem
+	if r.initialEndpoint != "" {
+		e = r.pool.findInitial(r.initialEndpoint, r.group)
+		r.initialEndpoint = ""
+	}
+
+	if e != nil {
+		e.RLock()
+		defer e.RUnlock()
+		r.lastEndpoint = e.endpoint
+		return e.endpoint
+	}
+
+	e = r.next()
+	if e != nil {
+		e.RLock()
+		defer e.RUnlock()
+		r.lastEndpoint = e.endpoint
+		return e.endpoint
+	}
+
+	r.lastEndpoint = nil
+	return nil
+}
+
+func (r *ConsistentHash) next() *endpointElem {
+	r.pool.Lock()
+	defer r.pool.Unlock()
+
+	ring := r.pool.hashRing()
+	if len(ring) == 0 {
+		return nil
+	}
+
+	var start int
+	if r.key == "" {
+		start = r.pool.random.Intn(len(ring))
+	} else {
+		start = ring.search(hash(r.key))
+	}
+
+	sel := r.pool.selection(r.group)
+	e, failed := r.walk(ring, start, sel)
+	if e == nil && failed {
+		// all available endpoints are marked failed so reset everything to available
+		for _, e2 := range r.pool.endpoints {
+			e2.failedAt = nil
+		}
+		e, _ = r.walk(ring, start, sel)
+	}
+
+	return e
+}
+
+// walk returns the first endpoint on the ring from start that is selectable
+// and neither overloaded nor failed, and reports whether any endpoint was
+// skipped because it is failed.
+func (r *ConsistentHash) walk(ring hashRing, start int, sel selection) (*endpointElem, bool) {
+	failed := false
+
+	for i := 0; i < len(ring); i++ {
+		e := ring[(start+i)%len(ring)].elem
+
+		if e.isOverloaded() || !e.selectable(sel) {
+			continue
+		}
+
+		if e.failedAt != nil {
+			curTime := time.Now()
+			if curTime.Sub(*e.failedAt) > r.pool.retryAfterFailure {
+				// exipired failure window
+				e.failedAt = nil
+			}
+		}
+
+		if e.failedAt != nil {
+			failed = true
+			continue
+		}
+
+		return e, failed
+	}
+
+	return nil, failed
+}
+
+func (r *ConsistentHash) EndpointFailed(err error) {
+	if r.lastEndpoint != nil {
+		r.pool.EndpointFailed(r.lastEndpoint, err)
+	}
+}
+
+func (r *ConsistentHash) PreRequest(e *Endpoint) {
+	e.Stats.NumberConnections.Increment()
+}
+
+func (r *ConsistentHash) PostRequest(e *Endpoint) {
+	e.Stats.NumberConnections.Decrement()
+}
+
+type ringPoint struct {
+	hash uint64
+	elem *endpointElem
+}
+
+// hashRing holds replicasPerEndpoint points for every endpoint of a pool,
+// sorted by hash.
+type hashRing []ringPoint
+
+func newHashRing(endpoints []*endpointElem) hashRing {
+	ring := make(hashRing, 0, len(endpoints)*replicasPerEndpoint)
+	for _, e := range endpoints {
+		addr := e.endpoint.CanonicalAddr()
+		for i := 0; i < replicasPerEndpoint; i++ {
+			ring = append(ring, ringPoint{
+				hash: hash(addr + "-" + strconv.Itoa(i)),
+				elem: e,
+			})
+		}
+	}
+
+	sort.Slice(ring, func(i, j int) bool {
+		return ring[i].hash < ring[j].hash
+	})
+	return ring
+}
+
+// search returns the index of the first point at or after h, wrapping around
+// to the start of the ring.
+func (ring hashRing) search(h uint64) int {
+	i := sort.Search(len(ring), func(i int) bool {
+		return ring[i].hash >= h
+	})
+	if i == len(ring) {
+		return 0
+	}
+	return i
+}
+
+// hashRing returns the hash ring of the pool, building it when the endpoints
+// changed since it was last built. It must be called with the pool locked.
+func (p *EndpointPool) hashRing() hashRing {
+	if p.ring == nil {
+		p.ring = newHashRing(p.endpoints)
+	}
+	return p.ring
+}
+
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+
+	// FNV hashes of similar strings are close to each other, so mix the bits
+	// to spread them over the ring (the splitmix64 finalizer)
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
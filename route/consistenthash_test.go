@@ -0,0 +1,238 @@
+package route_test
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/gorouter/test_util"
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HashKey", func() {
+	table.DescribeTable("ParseHashKey",
+		func(s string, expected route.HashKey) {
+			key, err := route.ParseHashKey(s)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(key).To(Equal(expected))
+			Expect(key.String()).To(Equal(expected.String()))
+		},
+		table.Entry("empty", "", route.HashKey{}),
+		table.Entry("source ip", "source_ip", route.HashKey{}),
+		table.Entry("header", "header:X-User", route.HashKey{Source: route.HashOnHeader, Name: "X-User"}),
+		table.Entry("cookie", "cookie:session", route.HashKey{Source: route.HashOnCookie, Name: "session"}),
+		table.Entry("query", "query:user", route.HashKey{Source: route.HashOnQuery, Name: "user"}),
+	)
+
+	table.DescribeTable("ParseHashKey with an invalid key",
+		func(s string) {
+			_, err := route.ParseHashKey(s)
+			Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("invalid hash key %q", s))))
+		},
+		table.Entry("unknown source", "body:user"),
+		table.Entry("missing name", "header:"),
+		table.Entry("missing separator", "header"),
+	)
+
+	Describe("Value", func() {
+		var req *http.Request
+
+		BeforeEach(func() {
+			req = test_util.NewRequest("GET", "example.com", "/?user=query-user", nil)
+			req.RemoteAddr = "10.0.0.1:51234"
+			req.Header.Set("X-User", "header-user")
+			req.AddCookie(&http.Cookie{Name: "session", Value: "cookie-user"})
+		})
+
+		It("returns the key of the request", func() {
+			Expect(route.HashKey{Source: route.HashOnHeader, Name: "X-User"}.Value(req)).To(Equal("header-user"))
+			Expect(route.HashKey{Source: route.HashOnCookie, Name: "session"}.Value(req)).To(Equal("cookie-user"))
+			Expect(route.HashKey{Source: route.HashOnQuery, Name: "user"}.Value(req)).To(Equal("query-user"))
+			Expect(route.HashKey{}.Value(req)).To(Equal("10.0.0.1"))
+		})
+
+		It("returns an empty key when the request does not carry it", func() {
+			Expect(route.HashKey{Source: route.HashOnHeader, Name: "X-Missing"}.Value(req)).To(BeEmpty())
+			Expect(route.HashKey{Source: route.HashOnCookie, Name: "missing"}.Value(req)).To(BeEmpty())
+			Expect(route.HashKey{Source: route.HashOnQuery, Name: "missing"}.Value(req)).To(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("ConsistentHash", func() {
+	var (
+		pool      *route.EndpointPool
+		endpoints []*route.Endpoint
+	)
+
+	newEndpoint := func(i int) *route.Endpoint {
+		return route.NewEndpoint(&route.EndpointOpts{Host: fmt.Sprintf("10.0.0.%d", i), Port: 8080, PrivateInstanceId: fmt.Sprintf("instance-%d", i)})
+	}
+
+	mapKeys := func(n int) map[string]*route.Endpoint {
+		mapping := map[string]*route.Endpoint{}
+		for i := 0; i < n; i++ {
+			key := fmt.Sprintf("key-%d", i)
+			mapping[key] = route.NewConsistentHash(pool, "", key).Next()
+		}
+		return mapping
+	}
+
+	BeforeEach(func() {
+		pool = route.NewPool(&route.PoolOpts{
+			Logger:             test_util.NewTestZapLogger("test"),
+			RetryAfterFailure:  2 * time.Minute,
+			MaxConnsPerBackend: 1,
+		})
+
+		endpoints = nil
+		for i := 1; i <= 3; i++ {
+			e := newEndpoint(i)
+			endpoints = append(endpoints, e)
+			pool.Put(e)
+		}
+	})
+
+	Describe("Next", func() {
+		It("returns nil when no endpoints exist", func() {
+			pool = route.NewPool(&route.PoolOpts{Logger: test_util.NewTestZapLogger("test")})
+			Expect(route.NewConsistentHash(pool, "", "key").Next()).To(BeNil())
+		})
+
+		It("sends the same key to the same endpoint", func() {
+			e := route.NewConsistentHash(pool, "", "some-key").Next()
+			Expect(e).NotTo(BeNil())
+
+			for i := 0; i < 10; i++ {
+				Expect(route.NewConsistentHash(pool, "", "some-key").Next()).To(Equal(e))
+			}
+		})
+
+		It("spreads keys over the endpoints", func() {
+			counts := map[*route.Endpoint]int{}
+			for _, e := range mapKeys(3000) {
+				counts[e]++
+			}
+
+			Expect(counts).To(HaveLen(3))
+			for _, count := range counts {
+				Expect(count).To(BeNumerically(">", 700))
+			}
+		})
+
+		It("spreads requests without a key over the endpoints", func() {
+			counts := map[*route.Endpoint]int{}
+			for i := 0; i < 100; i++ {
+				counts[route.NewConsistentHash(pool, "", "").Next()]++
+			}
+
+			Expect(counts).To(HaveLen(3))
+		})
+
+		It("only moves keys to an added endpoint", func() {
+			before := mapKeys(3000)
+
+			added := newEndpoint(4)
+			pool.Put(added)
+			after := mapKeys(3000)
+
+			moved := 0
+			for key, e := range after {
+				if e != before[key] {
+					Expect(e).To(Equal(added))
+					moved++
+				}
+			}
+			Expect(moved).To(BeNumerically("~", 750, 250))
+		})
+
+		It("only moves the keys of a removed endpoint", func() {
+			before := mapKeys(3000)
+
+			removed := endpoints[0]
+			Expect(pool.Remove(removed)).To(BeTrue())
+			after := mapKeys(3000)
+
+			for key, e := range after {
+				if before[key] != removed {
+					Expect(e).To(Equal(before[key]))
+				} else {
+					Expect(e).NotTo(Equal(removed))
+				}
+			}
+		})
+
+		It("uses the next endpoint on the ring when the endpoint of the key has failed", func() {
+			iter := route.NewConsistentHash(pool, "", "some-key")
+			first := iter.Next()
+			iter.EndpointFailed(&net.OpError{Op: "dial"})
+
+			second := iter.Next()
+			Expect(second).NotTo(BeNil())
+			Expect(second).NotTo(Equal(first))
+
+			Expect(route.NewConsistentHash(pool, "", "some-key").Next()).To(Equal(second))
+		})
+
+		It("resets when all endpoints are failed", func() {
+			iter := route.NewConsistentHash(pool, "", "some-key")
+			for i := 0; i < 3; i++ {
+				Expect(iter.Next()).NotTo(BeNil())
+				iter.EndpointFailed(&net.OpError{Op: "dial"})
+			}
+
+			Expect(iter.Next()).NotTo(BeNil())
+		})
+
+		It("uses the next endpoint on the ring when the endpoint of the key is overloaded", func() {
+			first := route.NewConsistentHash(pool, "", "some-key").Next()
+			first.Stats.NumberConnections.Increment()
+
+			second := route.NewConsistentHash(pool, "", "some-key").Next()
+			Expect(second).NotTo(BeNil())
+			Expect(second).NotTo(Equal(first))
+		})
+
+		It("returns nil when all endpoints are overloaded", func() {
+			for _, e := range endpoints {
+				e.Stats.NumberConnections.Increment()
+			}
+
+			Expect(route.NewConsistentHash(pool, "", "some-key").Next()).To(BeNil())
+		})
+
+		It("finds the initial endpoint", func() {
+			e := route.NewConsistentHash(pool, "", "some-key").Next()
+			var other *route.Endpoint
+			for _, candidate := range endpoints {
+				if candidate != e {
+					other = candidate
+				}
+			}
+
+			Expect(route.NewConsistentHash(pool, other.PrivateInstanceId, "some-key").Next()).To(Equal(other))
+		})
+
+		It("is selected by the pool for the consistent hash algorithm", func() {
+			e := route.NewConsistentHash(pool, "", "some-key").Next()
+			for i := 0; i < 10; i++ {
+				Expect(pool.HashedEndpoints(config.LOAD_BALANCE_CH, "", "some-key").Next()).To(Equal(e))
+			}
+		})
+	})
+
+	Describe("HashKey", func() {
+		It("returns the hash key of the route", func() {
+			Expect(pool.HashKey()).To(Equal(route.HashKey{}))
+
+			pool = route.NewPool(&route.PoolOpts{Logger: test_util.NewTestZapLogger("test")})
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "10.0.0.1", Port: 8080, HashKey: route.HashKey{Source: route.HashOnCookie, Name: "session"}}))
+			Expect(pool.HashKey()).To(Equal(route.HashKey{Source: route.HashOnCookie, Name: "session"}))
+		})
+	})
+})
@@ -0,0 +1,90 @@
+package route
+
+import (
+	"time"
+)
+
+// Health of an endpoint as reported by its active health checks
+const (
+	HealthHealthy   = "healthy"
+	HealthUnhealthy = "unhealthy"
+)
+
+// healthCheckState is the result of the active health checks of an endpoint.
+// Endpoints are healthy until they fail enough consecutive checks, so that
+// routes keep working before their endpoints are first checked.
+type healthCheckState struct {
+	checked   bool
+	unhealthy bool
+	passes    int
+	fails     int
+	checkedAt time.Time
+	lastError string
+}
+
+type healthCheckJSON struct {
+	Status    string    `json:"status"`
+	CheckedAt time.Time `json:"checked_at"`
+	Error     string    `json:"error,omitempty"`
+}
+
+func (s *healthCheckState) toJSON() *healthCheckJSON {
+	if !s.checked {
+		return nil
+	}
+
+	status := HealthHealthy
+	if s.unhealthy {
+		status = HealthUnhealthy
+	}
+	return &healthCheckJSON{
+		Status:    status,
+		CheckedAt: s.checkedAt,
+		Error:     s.lastError,
+	}
+}
+
+// HealthCheckResult records the result of an active health check of the
+// endpoint, where err is nil when the check passed. The endpoint is marked
+// unhealthy after unhealthyThreshold consecutive failed checks, and healthy
+// again after healthyThreshold consecutive passed checks. Iterators skip
+// unhealthy endpoints unless every endpoint of the pool is unhealthy.
+// HealthCheckResult returns the health of the endpoint and whether the check
+// changed it, or "" when the endpoint is not in the pool.
+func (p *EndpointPool) HealthCheckResult(endpoint *Endpoint, err error, healthyThreshold, unhealthyThreshold int) (string, bool) {
+	p.Lock()
+	defer p.Unlock()
+
+	e := p.index[endpoint.CanonicalAddr()]
+	if e == nil {
+		return "", false
+	}
+
+	s := &e.health
+	s.checked = true
+	s.checkedAt = time.Now()
+
+	changed := false
+	if err == nil {
+		s.passes++
+		s.fails = 0
+		s.lastError = ""
+		if s.unhealthy && s.passes >= healthyThreshold {
+			s.unhealthy = false
+			changed = true
+		}
+	} else {
+		s.fails++
+		s.passes = 0
+		s.lastError = err.Error()
+		if !s.unhealthy && s.fails >= unhealthyThreshold {
+			s.unhealthy = true
+			changed = true
+		}
+	}
+
+	if s.unhealthy {
+		return HealthUnhealthy, changed
+	}
+	return HealthHealthy, changed
+}
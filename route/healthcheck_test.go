@@ -0,0 +1,136 @@
+package route_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/gorouter/test_util"
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Health checks", func() {
+	var (
+		pool   *route.EndpointPool
+		e1, e2 *route.Endpoint
+	)
+
+	checkFailed := errors.New("connection refused")
+
+	BeforeEach(func() {
+		pool = route.NewPool(&route.PoolOpts{
+			Logger:            test_util.NewTestZapLogger("test"),
+			RetryAfterFailure: 2 * time.Minute,
+		})
+
+		e1 = route.NewEndpoint(&route.EndpointOpts{Host: "1.1.1.1", Port: 1111, StaleThresholdInSeconds: -1, HealthCheckPath: "/health"})
+		e2 = route.NewEndpoint(&route.EndpointOpts{Host: "2.2.2.2", Port: 2222, StaleThresholdInSeconds: -1})
+		pool.Put(e1)
+		pool.Put(e2)
+	})
+
+	next := func(algorithm string, n int) map[*route.Endpoint]int {
+		counts := map[*route.Endpoint]int{}
+		for i := 0; i < n; i++ {
+			counts[pool.HashedEndpoints(algorithm, "", "some-key").Next()]++
+		}
+		return counts
+	}
+
+	Describe("HealthCheckResult", func() {
+		It("marks the endpoint unhealthy after the unhealthy threshold of failed checks", func() {
+			health, changed := pool.HealthCheckResult(e1, checkFailed, 2, 2)
+			Expect(health).To(Equal(route.HealthHealthy))
+			Expect(changed).To(BeFalse())
+
+			health, changed = pool.HealthCheckResult(e1, checkFailed, 2, 2)
+			Expect(health).To(Equal(route.HealthUnhealthy))
+			Expect(changed).To(BeTrue())
+
+			health, changed = pool.HealthCheckResult(e1, checkFailed, 2, 2)
+			Expect(health).To(Equal(route.HealthUnhealthy))
+			Expect(changed).To(BeFalse())
+		})
+
+		It("marks the endpoint healthy again after the healthy threshold of passed checks", func() {
+			pool.HealthCheckResult(e1, checkFailed, 2, 1)
+
+			health, changed := pool.HealthCheckResult(e1, nil, 2, 1)
+			Expect(health).To(Equal(route.HealthUnhealthy))
+			Expect(changed).To(BeFalse())
+
+			health, changed = pool.HealthCheckResult(e1, nil, 2, 1)
+			Expect(health).To(Equal(route.HealthHealthy))
+			Expect(changed).To(BeTrue())
+		})
+
+		It("only counts consecutive failed checks", func() {
+			pool.HealthCheckResult(e1, checkFailed, 1, 2)
+			pool.HealthCheckResult(e1, nil, 1, 2)
+
+			health, _ := pool.HealthCheckResult(e1, checkFailed, 1, 2)
+			Expect(health).To(Equal(route.HealthHealthy))
+		})
+
+		It("ignores endpoints that are not in the pool", func() {
+			other := route.NewEndpoint(&route.EndpointOpts{Host: "3.3.3.3", Port: 3333})
+			health, changed := pool.HealthCheckResult(other, checkFailed, 1, 1)
+			Expect(health).To(BeEmpty())
+			Expect(changed).To(BeFalse())
+		})
+
+		It("keeps the health of the endpoint when it registers again", func() {
+			pool.HealthCheckResult(e1, checkFailed, 1, 1)
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.1.1.1", Port: 1111, HealthCheckPath: "/health"}))
+
+			Expect(next(config.LOAD_BALANCE_RR, 4)).To(Equal(map[*route.Endpoint]int{e2: 4}))
+		})
+	})
+
+	table.DescribeTable("skips unhealthy endpoints",
+		func(algorithm string) {
+			pool.HealthCheckResult(e1, checkFailed, 1, 1)
+
+			Expect(next(algorithm, 10)).To(Equal(map[*route.Endpoint]int{e2: 10}))
+		},
+		table.Entry("round-robin", config.LOAD_BALANCE_RR),
+		table.Entry("least-connection", config.LOAD_BALANCE_LC),
+		table.Entry("weighted-round-robin", config.LOAD_BALANCE_WRR),
+		table.Entry("power-of-two-choices", config.LOAD_BALANCE_P2C),
+		table.Entry("consistent-hash", config.LOAD_BALANCE_CH),
+	)
+
+	table.DescribeTable("skips an unhealthy initial endpoint",
+		func(algorithm string) {
+			pool.HealthCheckResult(e1, checkFailed, 1, 1)
+
+			Expect(pool.Endpoints(algorithm, e1.CanonicalAddr()).Next()).To(Equal(e2))
+		},
+		table.Entry("round-robin", config.LOAD_BALANCE_RR),
+		table.Entry("least-connection", config.LOAD_BALANCE_LC),
+		table.Entry("weighted-round-robin", config.LOAD_BALANCE_WRR),
+		table.Entry("power-of-two-choices", config.LOAD_BALANCE_P2C),
+		table.Entry("consistent-hash", config.LOAD_BALANCE_CH),
+	)
+
+	It("picks unhealthy endpoints when every endpoint is unhealthy", func() {
+		pool.HealthCheckResult(e1, checkFailed, 1, 1)
+		pool.HealthCheckResult(e2, checkFailed, 1, 1)
+
+		Expect(next(config.LOAD_BALANCE_RR, 10)).To(Equal(map[*route.Endpoint]int{e1: 5, e2: 5}))
+	})
+
+	Describe("MarshalJSON", func() {
+		It("includes the health check result of checked endpoints", func() {
+			pool.HealthCheckResult(e1, checkFailed, 1, 1)
+
+			json, err := pool.MarshalJSON()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(json)).To(MatchRegexp(`^\[\{"address":"1.1.1.1:1111","tls":false,"ttl":-1,"tags":null,"health_check_path":"/health","health_check":\{"status":"unhealthy","checked_at":"[^"]+","error":"connection refused"\}\},`))
+			Expect(string(json)).To(HaveSuffix(`{"address":"2.2.2.2:2222","tls":false,"ttl":-1,"tags":null}]`))
+		})
+	})
+})
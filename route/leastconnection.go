@@ -10,6 +10,7 @@ var randomize = rand.New(rand.NewSource(time.Now().UnixNano()))
 type LeastConnection struct {
 	pool            *EndpointPool
 	initialEndpoint string
+	group           string
 	lastEndpoint    *Endpoint
 }
 
@@ -23,12 +24,8 @@ func NewLeastConnection(p *EndpointPool, initial string) EndpointIterator {
 func (r *LeastConnection) Next() *Endpoint {
 	var e *endpointElem
 	if r.initialEndpoint != "" {
-		e = r.pool.findById(r.initialEndpoint)
+		e = r.pool.findInitial(r.initialEndpoint, r.group)
 		r.initialEndpoint = ""
-
-		if e != nil && e.isOverloaded() {
-			e = nil
-		}
 	}
 
 	if e != nil {
@@ -70,10 +67,12 @@ func (r *LeastConnection) next() *endpointElem {
 		return nil
 	}
 
+	sel := r.pool.selection(r.group)
+
 	// single endpoint
 	if total == 1 {
 		e := r.pool.endpoints[0]
-		if e.isOverloaded() {
+		if e.isOverloaded() || !e.selectable(sel) {
 			return nil
 		}
 
@@ -85,10 +84,21 @@ func (r *LeastConnection) next() *endpointElem {
 	// random one within the least connection endpoints
 	randIndices := randomize.Perm(total)
 
+	// an endpoint passed over while warming up, to fall back on when no
+	// other endpoint can be picked
+	var warming *endpointElem
+
 	for i := 0; i < total; i++ {
 		randIdx := randIndices[i]
 		cur := r.pool.endpoints[randIdx]
-		if cur.isOverloaded() {
+		if cur.isOverloaded() || !cur.selectable(sel) {
+			continue
+		}
+
+		if r.pool.skipWarmingUp(cur, sel) {
+			if warming == nil {
+				warming = cur
+			}
 			continue
 		}
 
@@ -102,6 +112,10 @@ func (r *LeastConnection) next() *endpointElem {
 			selected = cur
 		}
 	}
+
+	if selected == nil {
+		return warming
+	}
 	return selected
 }
 
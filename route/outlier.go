@@ -0,0 +1,184 @@
+package route
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/uber-go/zap"
+)
+
+// Reasons for outlier detection to eject an endpoint
+const (
+	EjectionConsecutive5xx = "consecutive_5xx"
+	EjectionErrorRate      = "error_rate"
+)
+
+//go:generate counterfeiter -o fakes/fake_outlier_reporter.go . OutlierReporter
+type OutlierReporter interface {
+	CaptureOutlierEjection(reason string)
+	CaptureOutlierRecovery()
+}
+
+// outlierState is what outlier detection knows about the responses of an
+// endpoint. Errors are 5xx responses and requests that failed without a
+// response.
+type outlierState struct {
+	consecutiveErrors int
+
+	windowStart    time.Time
+	windowRequests int
+	windowErrors   int
+
+	ejected      bool
+	ejections    int
+	ejectedUntil time.Time
+	reason       string
+}
+
+type ejectionJSON struct {
+	Reason string    `json:"reason"`
+	Until  time.Time `json:"until"`
+}
+
+func (s *outlierState) isEjected(now time.Time) bool {
+	return s.ejected && now.Before(s.ejectedUntil)
+}
+
+func (s *outlierState) toJSON(now time.Time) *ejectionJSON {
+	if !s.isEjected(now) {
+		return nil
+	}
+	return &ejectionJSON{Reason: s.reason, Until: s.ejectedUntil}
+}
+
+// ObserveResponse records the outcome of a request to the endpoint for
+// outlier detection, where err is the error of a request that failed
+// without a response. An endpoint is ejected from the pool after the
+// configured number of consecutive errors, or when the share of errors among
+// its requests in the current interval reaches the configured error rate.
+// Iterators skip ejected endpoints unless every endpoint of the pool is
+// ejected or unhealthy. Requests canceled by the client do not count.
+func (p *EndpointPool) ObserveResponse(endpoint *Endpoint, res *http.Response, err error) {
+	c := p.outlierDetection
+	if !c.Enabled || errors.Is(err, context.Canceled) {
+		return
+	}
+	failed := err != nil || (res != nil && res.StatusCode >= 500)
+
+	p.Lock()
+	defer p.Unlock()
+
+	e := p.index[endpoint.CanonicalAddr()]
+	if e == nil {
+		return
+	}
+
+	now := time.Now()
+	p.recoverOutlier(e, now)
+
+	s := &e.outlier
+	if s.ejected {
+		// the pool fails open while every endpoint is ejected, so requests
+		// may still reach it
+		return
+	}
+
+	if now.Sub(s.windowStart) >= c.Interval {
+		s.windowStart = now
+		s.windowRequests, s.windowErrors = 0, 0
+	}
+	s.windowRequests++
+	if failed {
+		s.windowErrors++
+		s.consecutiveErrors++
+	} else {
+		s.consecutiveErrors = 0
+	}
+
+	var reason string
+	switch {
+	case c.Consecutive5xx > 0 && s.consecutiveErrors >= c.Consecutive5xx:
+		reason = EjectionConsecutive5xx
+	case c.ErrorRatePercent > 0 && s.windowRequests >= c.MinRequests &&
+		s.windowErrors*100 >= s.windowRequests*c.ErrorRatePercent:
+		reason = EjectionErrorRate
+	default:
+		return
+	}
+
+	if p.canEject(now) {
+		p.eject(e, reason, now)
+	}
+}
+
+// canEject reports whether ejecting one more endpoint keeps the ejected
+// endpoints of the pool within the maximum ejection percentage. One endpoint
+// may always be ejected, so that small pools are protected too. It must be
+// called with the pool locked.
+func (p *EndpointPool) canEject(now time.Time) bool {
+	ejected := 0
+	for _, e := range p.endpoints {
+		if e.outlier.isEjected(now) {
+			ejected++
+		}
+	}
+	return ejected == 0 || (ejected+1)*100 <= len(p.endpoints)*p.outlierDetection.MaxEjectionPercent
+}
+
+// eject ejects the endpoint for the base ejection time, doubled for every
+// time it was ejected before, up to the maximum ejection time. An endpoint
+// that stayed in the pool for longer than the maximum ejection time starts
+// over at the base ejection time. It must be called with the pool locked.
+func (p *EndpointPool) eject(e *endpointElem, reason string, now time.Time) {
+	c := p.outlierDetection
+	s := &e.outlier
+
+	if s.ejections > 0 && now.Sub(s.ejectedUntil) > c.MaxEjectionTime {
+		s.ejections = 0
+	}
+	s.ejections++
+
+	d := c.BaseEjectionTime
+	for i := 1; i < s.ejections && d < c.MaxEjectionTime; i++ {
+		d *= 2
+	}
+	if d > c.MaxEjectionTime {
+		d = c.MaxEjectionTime
+	}
+
+	s.ejected = true
+	s.ejectedUntil = now.Add(d)
+	s.reason = reason
+	s.consecutiveErrors = 0
+	s.windowStart = time.Time{}
+
+	p.logger.Error("endpoint-ejected",
+		zap.Nest("route-endpoint", e.endpoint.ToLogData()...),
+		zap.String("reason", reason),
+		zap.Duration("ejection-time", d),
+		zap.Int("ejections", s.ejections),
+	)
+	if p.outlierReporter != nil {
+		p.outlierReporter.CaptureOutlierEjection(reason)
+	}
+}
+
+// recoverOutlier returns the endpoint to the pool once its ejection time is
+// over. It must be called with the pool locked.
+func (p *EndpointPool) recoverOutlier(e *endpointElem, now time.Time) {
+	s := &e.outlier
+	if !s.ejected || s.isEjected(now) {
+		return
+	}
+	s.ejected = false
+
+	p.logger.Info("endpoint-recovered",
+		zap.Nest("route-endpoint", e.endpoint.ToLogData()...),
+		zap.String("reason", s.reason),
+	)
+	if p.outlierReporter != nil {
+		p.outlierReporter.CaptureOutlierRecovery()
+	}
+}
@@ -0,0 +1,201 @@
+package route_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/gorouter/route/fakes"
+	"code.cloudfoundry.org/gorouter/test_util"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Outlier detection", func() {
+	var (
+		pool       *route.EndpointPool
+		reporter   *fakes.FakeOutlierReporter
+		detection  config.OutlierDetectionConfig
+		e1, e2, e3 *route.Endpoint
+	)
+
+	BeforeEach(func() {
+		detection = config.OutlierDetectionConfig{
+			Enabled:            true,
+			Consecutive5xx:     3,
+			ErrorRatePercent:   50,
+			MinRequests:        10,
+			Interval:           time.Minute,
+			BaseEjectionTime:   time.Minute,
+			MaxEjectionTime:    10 * time.Minute,
+			MaxEjectionPercent: 50,
+		}
+		reporter = new(fakes.FakeOutlierReporter)
+
+		e1 = route.NewEndpoint(&route.EndpointOpts{Host: "1.1.1.1", Port: 1111, StaleThresholdInSeconds: -1})
+		e2 = route.NewEndpoint(&route.EndpointOpts{Host: "2.2.2.2", Port: 2222, StaleThresholdInSeconds: -1})
+		e3 = route.NewEndpoint(&route.EndpointOpts{Host: "3.3.3.3", Port: 3333, StaleThresholdInSeconds: -1})
+	})
+
+	JustBeforeEach(func() {
+		pool = route.NewPool(&route.PoolOpts{
+			Logger:            test_util.NewTestZapLogger("test"),
+			RetryAfterFailure: 2 * time.Minute,
+			OutlierDetection:  detection,
+			OutlierReporter:   reporter,
+		})
+		pool.Put(e1)
+		pool.Put(e2)
+		pool.Put(e3)
+	})
+
+	respond := func(e *route.Endpoint, statusCode, n int) {
+		for i := 0; i < n; i++ {
+			pool.ObserveResponse(e, &http.Response{StatusCode: statusCode}, nil)
+		}
+	}
+
+	next := func(n int) map[*route.Endpoint]int {
+		counts := map[*route.Endpoint]int{}
+		for i := 0; i < n; i++ {
+			counts[pool.Endpoints(config.LOAD_BALANCE_RR, "").Next()]++
+		}
+		return counts
+	}
+
+	ejectedUntil := func(e *route.Endpoint) time.Time {
+		b, err := pool.MarshalJSON()
+		Expect(err).NotTo(HaveOccurred())
+
+		var endpoints []struct {
+			Address  string `json:"address"`
+			Ejection *struct {
+				Until time.Time `json:"until"`
+			} `json:"outlier_ejection"`
+		}
+		Expect(json.Unmarshal(b, &endpoints)).To(Succeed())
+		for _, j := range endpoints {
+			if j.Address == e.CanonicalAddr() && j.Ejection != nil {
+				return j.Ejection.Until
+			}
+		}
+		return time.Time{}
+	}
+
+	It("ejects an endpoint after consecutive 5xx responses", func() {
+		respond(e1, http.StatusServiceUnavailable, 3)
+
+		Expect(next(10)).To(Equal(map[*route.Endpoint]int{e2: 5, e3: 5}))
+		Expect(reporter.CaptureOutlierEjectionCallCount()).To(Equal(1))
+		Expect(reporter.CaptureOutlierEjectionArgsForCall(0)).To(Equal(route.EjectionConsecutive5xx))
+
+		json, err := pool.MarshalJSON()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(json)).To(MatchRegexp(`"address":"1.1.1.1:1111",.*"outlier_ejection":\{"reason":"consecutive_5xx","until":"[^"]+"\}`))
+	})
+
+	It("counts requests that failed without a response as errors", func() {
+		for i := 0; i < 3; i++ {
+			pool.ObserveResponse(e1, nil, errors.New("dial tcp: connection refused"))
+		}
+
+		Expect(next(10)).To(Equal(map[*route.Endpoint]int{e2: 5, e3: 5}))
+	})
+
+	It("does not count requests canceled by the client", func() {
+		for i := 0; i < 3; i++ {
+			pool.ObserveResponse(e1, nil, context.Canceled)
+		}
+
+		Expect(next(9)).To(HaveKeyWithValue(e1, 3))
+		Expect(reporter.CaptureOutlierEjectionCallCount()).To(Equal(0))
+	})
+
+	It("only counts consecutive 5xx responses", func() {
+		respond(e1, http.StatusBadGateway, 2)
+		respond(e1, http.StatusOK, 1)
+		respond(e1, http.StatusBadGateway, 2)
+
+		Expect(reporter.CaptureOutlierEjectionCallCount()).To(Equal(0))
+	})
+
+	It("ejects an endpoint when its error rate reaches the threshold", func() {
+		for i := 0; i < 5; i++ {
+			respond(e1, http.StatusInternalServerError, 1)
+			respond(e1, http.StatusOK, 1)
+		}
+
+		Expect(next(10)).To(Equal(map[*route.Endpoint]int{e2: 5, e3: 5}))
+		Expect(reporter.CaptureOutlierEjectionArgsForCall(0)).To(Equal(route.EjectionErrorRate))
+	})
+
+	It("does not eject more than the maximum ejection percentage of the pool", func() {
+		respond(e1, http.StatusServiceUnavailable, 3)
+		respond(e2, http.StatusServiceUnavailable, 3)
+
+		Expect(next(10)).To(Equal(map[*route.Endpoint]int{e2: 5, e3: 5}))
+		Expect(reporter.CaptureOutlierEjectionCallCount()).To(Equal(1))
+	})
+
+	It("keeps the ejection of the endpoint when it registers again", func() {
+		respond(e1, http.StatusServiceUnavailable, 3)
+		pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.1.1.1", Port: 1111}))
+
+		Expect(next(10)).To(Equal(map[*route.Endpoint]int{e2: 5, e3: 5}))
+	})
+
+	Context("when every endpoint is ejected", func() {
+		BeforeEach(func() {
+			detection.MaxEjectionPercent = 100
+		})
+
+		It("picks ejected endpoints", func() {
+			respond(e1, http.StatusServiceUnavailable, 3)
+			respond(e2, http.StatusServiceUnavailable, 3)
+			respond(e3, http.StatusServiceUnavailable, 3)
+
+			Expect(next(9)).To(Equal(map[*route.Endpoint]int{e1: 3, e2: 3, e3: 3}))
+		})
+	})
+
+	Context("when the ejection time is over", func() {
+		BeforeEach(func() {
+			detection.BaseEjectionTime = 100 * time.Millisecond
+			detection.MaxEjectionTime = time.Second
+		})
+
+		It("returns the endpoint to the pool", func() {
+			respond(e1, http.StatusServiceUnavailable, 3)
+			Expect(next(10)).NotTo(HaveKey(e1))
+
+			Eventually(func() map[*route.Endpoint]int { return next(3) }).Should(HaveKey(e1))
+			Expect(reporter.CaptureOutlierRecoveryCallCount()).To(Equal(1))
+		})
+
+		It("doubles the ejection time every time the endpoint is ejected again", func() {
+			respond(e1, http.StatusServiceUnavailable, 3)
+			Expect(ejectedUntil(e1)).To(BeTemporally("<=", time.Now().Add(100*time.Millisecond)))
+
+			Eventually(func() map[*route.Endpoint]int { return next(3) }).Should(HaveKey(e1))
+			respond(e1, http.StatusServiceUnavailable, 3)
+			Expect(ejectedUntil(e1)).To(BeTemporally(">", time.Now().Add(150*time.Millisecond)))
+		})
+	})
+
+	Context("when outlier detection is disabled", func() {
+		BeforeEach(func() {
+			detection.Enabled = false
+		})
+
+		It("does not eject endpoints", func() {
+			respond(e1, http.StatusServiceUnavailable, 10)
+
+			Expect(next(9)).To(HaveKeyWithValue(e1, 3))
+			Expect(reporter.CaptureOutlierEjectionCallCount()).To(Equal(0))
+		})
+	})
+})
@@ -0,0 +1,165 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+type segmentKind int
+
+// Kinds of the segments of a path pattern, from the most to the least
+// specific.
+const (
+	literalSegment segmentKind = iota
+	regexSegment
+	globSegment
+	paramSegment
+)
+
+// SegmentPattern matches one segment of a request path.
+type SegmentPattern struct {
+	raw  string
+	kind segmentKind
+	re   *regexp.Regexp
+}
+
+func parseSegmentPattern(s string) (SegmentPattern, error) {
+	if s == "" {
+		return SegmentPattern{}, errors.New("empty segment")
+	}
+
+	if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+		parts := strings.SplitN(s[1:len(s)-1], ":", 2)
+		if parts[0] == "" {
+			return SegmentPattern{}, fmt.Errorf("segment %q has no name", s)
+		}
+		if len(parts) == 1 {
+			return SegmentPattern{raw: s, kind: paramSegment}, nil
+		}
+		re, err := regexp.Compile("(?i)^(?:" + parts[1] + ")$")
+		if err != nil {
+			return SegmentPattern{}, fmt.Errorf("segment %q: %s", s, err)
+		}
+		return SegmentPattern{raw: s, kind: regexSegment, re: re}, nil
+	}
+
+	s = strings.ToLower(s)
+	if strings.ContainsAny(s, "{}") {
+		return SegmentPattern{}, fmt.Errorf("segment %q must be a single {name} or {name:regex}", s)
+	}
+	if strings.ContainsAny(s, `*?[\`) {
+		if _, err := path.Match(s, ""); err != nil {
+			return SegmentPattern{}, fmt.Errorf("segment %q: %s", s, err)
+		}
+		return SegmentPattern{raw: s, kind: globSegment}, nil
+	}
+	return SegmentPattern{raw: s, kind: literalSegment}, nil
+}
+
+// Match reports whether the segment of a lowercased request path matches.
+func (s SegmentPattern) Match(segment string) bool {
+	switch s.kind {
+	case literalSegment:
+		return segment == s.raw
+	case regexSegment:
+		return segment != "" && s.re.MatchString(segment)
+	case globSegment:
+		matched, _ := path.Match(s.raw, segment)
+		return segment != "" && matched
+	default:
+		return segment != ""
+	}
+}
+
+// Literal reports whether the segment only matches itself.
+func (s SegmentPattern) Literal() bool {
+	return s.kind == literalSegment
+}
+
+// Before reports whether the segment takes precedence over another one that
+// matches the same request segment: literal segments come first, then
+// regular expressions, globs and last named parameters. Segments of the same
+// kind are ordered by their text so that lookups are deterministic.
+func (s SegmentPattern) Before(other SegmentPattern) bool {
+	if s.kind != other.kind {
+		return s.kind < other.kind
+	}
+	return s.raw < other.raw
+}
+
+func (s SegmentPattern) String() string {
+	return s.raw
+}
+
+// PathPattern matches request paths segment by segment. The zero value is no
+// pattern.
+type PathPattern struct {
+	segments []SegmentPattern
+}
+
+// ParsePathPattern parses a path pattern in the format of a registration
+// message: a path starting with / whose segments are either literal,
+// globs such as "v*", named parameters such as "{id}" that match any
+// segment, or named parameters with a regular expression such as
+// "{id:[0-9]+}" that is anchored to the segment. Patterns need at least one
+// segment that is not literal; literal paths belong in the uris of a route.
+func ParsePathPattern(s string) (PathPattern, error) {
+	if s == "" {
+		return PathPattern{}, nil
+	}
+
+	if !strings.HasPrefix(s, "/") {
+		return PathPattern{}, fmt.Errorf("invalid path pattern %q, must start with /", s)
+	}
+
+	var p PathPattern
+	literal := true
+	for _, segment := range strings.Split(strings.TrimSuffix(s[1:], "/"), "/") {
+		sp, err := parseSegmentPattern(segment)
+		if err != nil {
+			return PathPattern{}, fmt.Errorf("invalid path pattern %q: %s", s, err)
+		}
+		literal = literal && sp.Literal()
+		p.segments = append(p.segments, sp)
+	}
+	if literal {
+		return PathPattern{}, fmt.Errorf("invalid path pattern %q, must have a glob, {name} or {name:regex} segment", s)
+	}
+
+	return p, nil
+}
+
+// Segments returns the patterns of the segments of the path.
+func (p PathPattern) Segments() []SegmentPattern {
+	return p.segments
+}
+
+// IsZero reports whether p is no pattern.
+func (p PathPattern) IsZero() bool {
+	return len(p.segments) == 0
+}
+
+// Prefix returns the literal segments the pattern starts with, which is the
+// part of the path that every request it matches shares.
+func (p PathPattern) Prefix() string {
+	prefix := ""
+	for _, s := range p.segments {
+		if !s.Literal() {
+			break
+		}
+		prefix += "/" + s.raw
+	}
+	return prefix
+}
+
+func (p PathPattern) String() string {
+	var b strings.Builder
+	for _, s := range p.segments {
+		b.WriteString("/")
+		b.WriteString(s.raw)
+	}
+	return b.String()
+}
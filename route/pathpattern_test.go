@@ -0,0 +1,69 @@
+package route_test
+
+import (
+	"code.cloudfoundry.org/gorouter/route"
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PathPattern", func() {
+	Describe("ParsePathPattern", func() {
+		table.DescribeTable("parses valid path patterns",
+			func(s, expected, prefix string) {
+				p, err := route.ParsePathPattern(s)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(p.String()).To(Equal(expected))
+				Expect(p.Prefix()).To(Equal(prefix))
+			},
+			table.Entry("empty", "", "", ""),
+			table.Entry("glob", "/api/v*/users", "/api/v*/users", "/api"),
+			table.Entry("named parameter", "/users/{id}", "/users/{id}", "/users"),
+			table.Entry("regular expression", "/users/{id:[0-9]{3}}", "/users/{id:[0-9]{3}}", "/users"),
+			table.Entry("leading pattern", "/{tenant}/users", "/{tenant}/users", ""),
+			table.Entry("trailing slash", "/users/{id}/", "/users/{id}", "/users"),
+			table.Entry("upper case", "/API/V*/{ID:[A-Z]+}", "/api/v*/{ID:[A-Z]+}", "/api"),
+		)
+
+		table.DescribeTable("rejects invalid path patterns",
+			func(s string) {
+				_, err := route.ParsePathPattern(s)
+				Expect(err).To(MatchError(ContainSubstring("invalid path pattern")))
+			},
+			table.Entry("relative", "users/{id}"),
+			table.Entry("literal", "/users/me"),
+			table.Entry("empty segment", "/users//{id}"),
+			table.Entry("unnamed parameter", "/users/{}"),
+			table.Entry("partial parameter", "/users/id-{id}"),
+			table.Entry("invalid regular expression", "/users/{id:[0-9}"),
+			table.Entry("invalid glob", "/users/[a-"),
+		)
+	})
+
+	Describe("Segments", func() {
+		segment := func(pattern string) route.SegmentPattern {
+			p, err := route.ParsePathPattern("/" + pattern)
+			Expect(err).NotTo(HaveOccurred())
+			return p.Segments()[0]
+		}
+
+		table.DescribeTable("match segments",
+			func(pattern, s string, matches bool) {
+				Expect(segment(pattern).Match(s)).To(Equal(matches))
+			},
+			table.Entry("glob", "v*", "v1", true),
+			table.Entry("glob mismatch", "v*", "beta", false),
+			table.Entry("named parameter", "{id}", "42", true),
+			table.Entry("named parameter on empty segment", "{id}", "", false),
+			table.Entry("regular expression", "{id:[0-9]+}", "42", true),
+			table.Entry("regular expression is anchored", "{id:[0-9]+}", "a42b", false),
+			table.Entry("regular expression ignores case", "{id:[A-Z]+}", "abc", true),
+		)
+
+		It("orders regular expressions before globs before named parameters", func() {
+			Expect(segment("{id:[0-9]+}").Before(segment("4*"))).To(BeTrue())
+			Expect(segment("4*").Before(segment("{id}"))).To(BeTrue())
+			Expect(segment("{id}").Before(segment("{id:[0-9]+}"))).To(BeFalse())
+		})
+	})
+})
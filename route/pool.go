@@ -29,6 +29,12 @@ const (
 	ADDED
 )
 
+// Protocols that gorouter can speak to a backend endpoint
+const (
+	ProtocolHTTP1 = "http1"
+	ProtocolHTTP2 = "http2"
+)
+
 func NewCounter(initial int64) *Counter {
 	return &Counter{initial}
 }
@@ -59,22 +65,34 @@ type ProxyRoundTripper interface {
 }
 
 type Endpoint struct {
-	ApplicationId        string
-	addr                 string
-	Tags                 map[string]string
-	ServerCertDomainSAN  string
-	PrivateInstanceId    string
-	StaleThreshold       time.Duration
-	RouteServiceUrl      string
-	PrivateInstanceIndex string
-	ModificationTag      models.ModificationTag
-	Stats                *Stats
-	IsolationSegment     string
-	useTls               bool
-	roundTripper         ProxyRoundTripper
-	roundTripperMutex    sync.RWMutex
-	UpdatedAt            time.Time
-	RoundTripperInit     sync.Once
+	ApplicationId          string
+	addr                   string
+	Tags                   map[string]string
+	ServerCertDomainSAN    string
+	PrivateInstanceId      string
+	StaleThreshold         time.Duration
+	RouteServiceUrl        string
+	PrivateInstanceIndex   string
+	ModificationTag        models.ModificationTag
+	Stats                  *Stats
+	IsolationSegment       string
+	Protocol               string
+	TLSPassthrough         bool
+	Weight                 int
+	AvailabilityZone       string
+	HashKey                HashKey
+	LoadBalancingAlgorithm string
+	HealthCheckPath        string
+	RetryPolicy            *config.RetryPolicyConfig
+	TrafficPercent         int
+	TrafficMatch           TrafficMatch
+	PathPattern            PathPattern
+	Static                 bool
+	useTls                 bool
+	roundTripper           ProxyRoundTripper
+	roundTripperMutex      sync.RWMutex
+	UpdatedAt              time.Time
+	RoundTripperInit       sync.Once
 }
 
 func (e *Endpoint) RoundTripper() ProxyRoundTripper {
@@ -113,8 +131,15 @@ type endpointElem struct {
 	endpoint           *Endpoint
 	index              int
 	updated            time.Time
+	addedAt            time.Time
 	failedAt           *time.Time
 	maxConnsPerBackend int64
+	currentWeight      int
+	latency            movingAverage
+	requests           decayingCounter
+	failures           decayingCounter
+	health             healthCheckState
+	outlier            outlierState
 }
 
 type EndpointPool struct {
@@ -130,6 +155,19 @@ type EndpointPool struct {
 	nextIdx            int
 	maxConnsPerBackend int64
 
+	retryBudget retryBudget
+
+	localZone         string
+	minHealthyPercent int
+
+	outlierDetection config.OutlierDetectionConfig
+	outlierReporter  OutlierReporter
+
+	slowStart config.SlowStartConfig
+
+	ring   hashRing
+	groups []backendGroup
+
 	random *rand.Rand
 	logger logger.Logger
 }
@@ -148,36 +186,98 @@ type EndpointOpts struct {
 	IsolationSegment        string
 	UseTLS                  bool
 	UpdatedAt               time.Time
+	Protocol                string
+	TLSPassthrough          bool
+	Weight                  int
+	AvailabilityZone        string
+	HashKey                 HashKey
+	LoadBalancingAlgorithm  string
+	HealthCheckPath         string
+	RetryPolicy             *config.RetryPolicyConfig
+	TrafficPercent          int
+	TrafficMatch            TrafficMatch
+	PathPattern             PathPattern
+	Static                  bool
 }
 
 func NewEndpoint(opts *EndpointOpts) *Endpoint {
 	return &Endpoint{
-		ApplicationId:        opts.AppId,
-		addr:                 fmt.Sprintf("%s:%d", opts.Host, opts.Port),
-		Tags:                 opts.Tags,
-		useTls:               opts.UseTLS,
-		ServerCertDomainSAN:  opts.ServerCertDomainSAN,
-		PrivateInstanceId:    opts.PrivateInstanceId,
-		PrivateInstanceIndex: opts.PrivateInstanceIndex,
-		StaleThreshold:       time.Duration(opts.StaleThresholdInSeconds) * time.Second,
-		RouteServiceUrl:      opts.RouteServiceUrl,
-		ModificationTag:      opts.ModificationTag,
-		Stats:                NewStats(),
-		IsolationSegment:     opts.IsolationSegment,
-		UpdatedAt:            opts.UpdatedAt,
+		ApplicationId:          opts.AppId,
+		addr:                   fmt.Sprintf("%s:%d", opts.Host, opts.Port),
+		Tags:                   opts.Tags,
+		useTls:                 opts.UseTLS,
+		ServerCertDomainSAN:    opts.ServerCertDomainSAN,
+		PrivateInstanceId:      opts.PrivateInstanceId,
+		PrivateInstanceIndex:   opts.PrivateInstanceIndex,
+		StaleThreshold:         time.Duration(opts.StaleThresholdInSeconds) * time.Second,
+		RouteServiceUrl:        opts.RouteServiceUrl,
+		ModificationTag:        opts.ModificationTag,
+		Stats:                  NewStats(),
+		IsolationSegment:       opts.IsolationSegment,
+		UpdatedAt:              opts.UpdatedAt,
+		Protocol:               opts.Protocol,
+		TLSPassthrough:         opts.TLSPassthrough,
+		Weight:                 opts.Weight,
+		AvailabilityZone:       opts.AvailabilityZone,
+		HashKey:                opts.HashKey,
+		LoadBalancingAlgorithm: opts.LoadBalancingAlgorithm,
+		HealthCheckPath:        opts.HealthCheckPath,
+		RetryPolicy:            opts.RetryPolicy,
+		TrafficPercent:         opts.TrafficPercent,
+		TrafficMatch:           opts.TrafficMatch,
+		PathPattern:            opts.PathPattern,
+		Static:                 opts.Static,
+	}
+}
+
+// replaceableBy reports whether other may update or remove the endpoint.
+// Static endpoints are only changed by the static routes of the
+// configuration, not by registration messages for the same address.
+func (e *Endpoint) replaceableBy(other *Endpoint) bool {
+	return !e.Static || other.Static
+}
+
+// weight returns the share of traffic the endpoint gets relative to the other
+// endpoints of its pool. Endpoints registered without a weight count as 1.
+func (e *Endpoint) weight() int {
+	if e.Weight <= 0 {
+		return 1
 	}
+	return e.Weight
 }
 
 func (e *Endpoint) IsTLS() bool {
 	return e.useTls
 }
 
+// IsHTTP2 returns true when the endpoint was registered as speaking HTTP/2,
+// either over TLS (h2) or in cleartext (h2c).
+func (e *Endpoint) IsHTTP2() bool {
+	return e.Protocol == ProtocolHTTP2
+}
+
 type PoolOpts struct {
 	RetryAfterFailure  time.Duration
 	Host               string
 	ContextPath        string
 	MaxConnsPerBackend int64
 	Logger             logger.Logger
+
+	// LocalZone is the availability zone of the router. When it is set,
+	// requests prefer the endpoints in that zone.
+	LocalZone string
+	// MinHealthyPercent is the percentage of the endpoints in the local zone
+	// that must be healthy for requests to stay in the zone.
+	MinHealthyPercent int
+
+	// OutlierDetection configures the ejection of endpoints that keep
+	// answering with errors, and OutlierReporter reports the ejections.
+	OutlierDetection config.OutlierDetectionConfig
+	OutlierReporter  OutlierReporter
+
+	// SlowStart configures the ramp up of the traffic to endpoints added
+	// to the pool.
+	SlowStart config.SlowStartConfig
 }
 
 func NewPool(opts *PoolOpts) *EndpointPool {
@@ -191,6 +291,11 @@ func NewPool(opts *PoolOpts) *EndpointPool {
 		contextPath:        opts.ContextPath,
 		random:             rand.New(rand.NewSource(time.Now().UnixNano())),
 		logger:             opts.Logger,
+		localZone:          opts.LocalZone,
+		minHealthyPercent:  opts.MinHealthyPercent,
+		outlierDetection:   opts.OutlierDetection,
+		outlierReporter:    opts.OutlierReporter,
+		slowStart:          opts.SlowStart,
 	}
 }
 
@@ -222,7 +327,7 @@ func (p *EndpointPool) Put(endpoint *Endpoint) PoolPutResult {
 			e.Lock()
 			defer e.Unlock()
 
-			if !e.endpoint.ModificationTag.SucceededBy(&endpoint.ModificationTag) {
+			if !e.endpoint.replaceableBy(endpoint) || !e.endpoint.ModificationTag.SucceededBy(&endpoint.ModificationTag) {
 				return UNMODIFIED
 			}
 
@@ -234,22 +339,19 @@ func (p *EndpointPool) Put(endpoint *Endpoint) PoolPutResult {
 				p.index[endpoint.PrivateInstanceId] = e
 			}
 
-			if oldEndpoint.ServerCertDomainSAN == endpoint.ServerCertDomainSAN {
+			if oldEndpoint.ApplicationId != endpoint.ApplicationId || oldEndpoint.TrafficPercent != endpoint.TrafficPercent || oldEndpoint.TrafficMatch != endpoint.TrafficMatch {
+				p.groups = nil
+				p.checkTrafficPercent(endpoint)
+			}
+
+			if oldEndpoint.ServerCertDomainSAN == endpoint.ServerCertDomainSAN && oldEndpoint.Protocol == endpoint.Protocol {
 				endpoint.SetRoundTripper(oldEndpoint.RoundTripper())
 			}
 		}
 	} else {
 		result = ADDED
-		e = &endpointElem{
-			endpoint:           endpoint,
-			index:              len(p.endpoints),
-			maxConnsPerBackend: p.maxConnsPerBackend,
-		}
-
-		p.endpoints = append(p.endpoints, e)
-
-		p.index[endpoint.CanonicalAddr()] = e
-		p.index[endpoint.PrivateInstanceId] = e
+		e = p.add(endpoint)
+		p.checkTrafficPercent(endpoint)
 	}
 
 	e.updated = time.Now()
@@ -257,6 +359,42 @@ func (p *EndpointPool) Put(endpoint *Endpoint) PoolPutResult {
 	return result
 }
 
+// Restore adds an endpoint that was last registered at the given time, before
+// the router restarted, so that it is pruned when it would have been without
+// the restart. It reports whether the endpoint was added; endpoints
+// registered since the restart are kept.
+func (p *EndpointPool) Restore(endpoint *Endpoint, updated time.Time) bool {
+	p.Lock()
+	defer p.Unlock()
+
+	if _, found := p.index[endpoint.CanonicalAddr()]; found {
+		return false
+	}
+
+	e := p.add(endpoint)
+	e.updated = updated
+
+	return true
+}
+
+func (p *EndpointPool) add(endpoint *Endpoint) *endpointElem {
+	e := &endpointElem{
+		endpoint:           endpoint,
+		index:              len(p.endpoints),
+		maxConnsPerBackend: p.maxConnsPerBackend,
+		addedAt:            time.Now(),
+	}
+
+	p.endpoints = append(p.endpoints, e)
+
+	p.index[endpoint.CanonicalAddr()] = e
+	p.index[endpoint.PrivateInstanceId] = e
+	p.ring = nil
+	p.groups = nil
+
+	return e
+}
+
 func (p *EndpointPool) RouteServiceUrl() string {
 	p.Lock()
 	defer p.Unlock()
@@ -269,6 +407,19 @@ func (p *EndpointPool) RouteServiceUrl() string {
 	}
 }
 
+// IsTLSPassthrough reports whether the route terminates TLS at its endpoints
+// rather than at the router. Like the route service URL, it is a property of
+// the route that every endpoint carries, so the first one decides.
+func (p *EndpointPool) IsTLSPassthrough() bool {
+	p.Lock()
+	defer p.Unlock()
+
+	if len(p.endpoints) > 0 {
+		return p.endpoints[0].endpoint.TLSPassthrough
+	}
+	return false
+}
+
 func (p *EndpointPool) PruneEndpoints() []*Endpoint {
 	p.Lock()
 
@@ -280,7 +431,7 @@ func (p *EndpointPool) PruneEndpoints() []*Endpoint {
 	for i := 0; i < last; {
 		e := p.endpoints[i]
 
-		if e.endpoint.useTls {
+		if e.endpoint.useTls || e.endpoint.Static {
 			i++
 			continue
 		}
@@ -309,7 +460,7 @@ func (p *EndpointPool) Remove(endpoint *Endpoint) bool {
 	l := len(p.endpoints)
 	if l > 0 {
 		e = p.index[endpoint.CanonicalAddr()]
-		if e != nil && e.endpoint.modificationTagSameOrNewer(endpoint) {
+		if e != nil && e.endpoint.replaceableBy(endpoint) && e.endpoint.modificationTagSameOrNewer(endpoint) {
 			p.removeEndpoint(e)
 			return true
 		}
@@ -331,21 +482,182 @@ func (p *EndpointPool) removeEndpoint(e *endpointElem) {
 
 	delete(p.index, e.endpoint.CanonicalAddr())
 	delete(p.index, e.endpoint.PrivateInstanceId)
+	p.ring = nil
+	p.groups = nil
+}
+
+// HashKey returns the part of a request the consistent hash algorithm uses
+// to pick an endpoint. Like the route service URL, it is a property of the
+// route that every endpoint carries, so the first one decides.
+func (p *EndpointPool) HashKey() HashKey {
+	p.Lock()
+	defer p.Unlock()
+
+	if len(p.endpoints) > 0 {
+		return p.endpoints[0].endpoint.HashKey
+	}
+	return HashKey{}
 }
 
+// LoadBalancingAlgorithm returns the load balancing algorithm of the route,
+// or "" when it uses the default algorithm of the router. Like the hash key,
+// it is a property of the route that every endpoint carries, so the first one
+// decides.
+func (p *EndpointPool) LoadBalancingAlgorithm() string {
+	p.Lock()
+	defer p.Unlock()
+
+	return p.loadBalancingAlgorithm()
+}
+
+func (p *EndpointPool) loadBalancingAlgorithm() string {
+	if len(p.endpoints) > 0 {
+		return p.endpoints[0].endpoint.LoadBalancingAlgorithm
+	}
+	return ""
+}
+
+// RetryPolicy returns the retry policy of the route, or nil when it uses the
+// retry policy of the router. Like the load balancing algorithm, the first
+// endpoint decides.
+func (p *EndpointPool) RetryPolicy() *config.RetryPolicyConfig {
+	p.Lock()
+	defer p.Unlock()
+
+	if len(p.endpoints) > 0 {
+		return p.endpoints[0].endpoint.RetryPolicy
+	}
+	return nil
+}
+
+// Endpoints returns an iterator over the endpoints of the pool that uses the
+// load balancing algorithm of the route, or defaultLoadBalance when the route
+// was registered without one.
 func (p *EndpointPool) Endpoints(defaultLoadBalance, initial string) EndpointIterator {
-	switch defaultLoadBalance {
+	return p.HashedEndpoints(defaultLoadBalance, initial, "")
+}
+
+// HashedEndpoints is like Endpoints, with the key the consistent hash
+// algorithm maps to an endpoint. The other algorithms ignore the key.
+func (p *EndpointPool) HashedEndpoints(defaultLoadBalance, initial, hashKey string) EndpointIterator {
+	return p.GroupEndpoints(defaultLoadBalance, initial, hashKey, "")
+}
+
+// GroupEndpoints is like HashedEndpoints, with the iterator only picking the
+// endpoints of the backend group, the app, returned by BackendGroup. The
+// initial endpoint is picked whatever its group. An empty group, or a group
+// without endpoints, picks from every endpoint.
+func (p *EndpointPool) GroupEndpoints(defaultLoadBalance, initial, hashKey, group string) EndpointIterator {
+	algorithm := p.LoadBalancingAlgorithm()
+	if algorithm == "" {
+		algorithm = defaultLoadBalance
+	}
+
+	switch algorithm {
 	case config.LOAD_BALANCE_LC:
-		return NewLeastConnection(p, initial)
+		return &LeastConnection{pool: p, initialEndpoint: initial, group: group}
+	case config.LOAD_BALANCE_WRR:
+		return &WeightedRoundRobin{pool: p, initialEndpoint: initial, group: group}
+	case config.LOAD_BALANCE_P2C:
+		return &PowerOfTwoChoices{pool: p, initialEndpoint: initial, group: group}
+	case config.LOAD_BALANCE_CH:
+		return &ConsistentHash{pool: p, initialEndpoint: initial, key: hashKey, group: group}
 	default:
-		return NewRoundRobin(p, initial)
+		return &RoundRobin{pool: p, initialEndpoint: initial, group: group}
+	}
+}
+
+// selection says which endpoints of a pool the iterators may pick.
+type selection struct {
+	// group is the app to pick endpoints of, or "" for every app.
+	group string
+	// zone is the zone to pick endpoints from, or "" for every zone.
+	zone string
+	// skipUnavailable is false when every endpoint failed its health checks
+	// or was ejected by outlier detection, so that requests still reach the
+	// route rather than no endpoint at all.
+	skipUnavailable bool
+	// slowStart is true when endpoints in their slow start window should
+	// get less traffic, which is when the pool has endpoints past theirs.
+	slowStart bool
+	now       time.Time
+}
+
+// selection returns the endpoints of the backend group the iterators should
+// pick from. It must be called with the pool locked.
+func (p *EndpointPool) selection(group string) selection {
+	now := time.Now()
+	if !p.hasGroup(group) {
+		group = ""
+	}
+
+	skipUnavailable, slowStart := false, false
+	for _, e := range p.endpoints {
+		p.recoverOutlier(e, now)
+		if !e.inGroup(group) {
+			continue
+		}
+		if !e.unavailable(now) {
+			skipUnavailable = true
+		}
+		if p.slowStart.Window > 0 && now.Sub(e.addedAt) >= p.slowStart.Window {
+			slowStart = true
+		}
+	}
+
+	return selection{
+		group:           group,
+		zone:            p.preferredZone(group, now, skipUnavailable),
+		skipUnavailable: skipUnavailable,
+		slowStart:       slowStart,
+		now:             now,
 	}
 }
 
-func (p *EndpointPool) findById(id string) *endpointElem {
+// preferredZone returns the zone the iterators should pick endpoints of the
+// group from: the local zone when enough of the endpoints in it are neither
+// overloaded, failed nor unavailable, or "" to pick from every zone. It must
+// be called with the pool locked.
+func (p *EndpointPool) preferredZone(group string, now time.Time, skipUnavailable bool) string {
+	if p.localZone == "" {
+		return ""
+	}
+
+	local, healthy := 0, 0
+	for _, e := range p.endpoints {
+		if !e.inGroup(group) || e.endpoint.AvailabilityZone != p.localZone {
+			continue
+		}
+		local++
+		if !e.isOverloaded() && !e.isFailed(now, p.retryAfterFailure) && !(skipUnavailable && e.unavailable(now)) {
+			healthy++
+		}
+	}
+
+	if healthy == 0 || healthy*100 < local*p.minHealthyPercent {
+		return ""
+	}
+	return p.localZone
+}
+
+// findInitial returns the endpoint with the id of a sticky session, unless it
+// is overloaded or the iterators of the group would skip it as unavailable or
+// outside the preferred zone. It is picked whatever its group.
+func (p *EndpointPool) findInitial(id, group string) *endpointElem {
 	p.Lock()
 	defer p.Unlock()
-	return p.index[id]
+
+	e := p.index[id]
+	if e == nil || e.isOverloaded() {
+		return nil
+	}
+
+	sel := p.selection(group)
+	sel.group = ""
+	if !e.selectable(sel) {
+		return nil
+	}
+	return e
 }
 
 func (p *EndpointPool) IsEmpty() bool {
@@ -411,6 +723,24 @@ func (p *EndpointPool) EndpointFailed(endpoint *Endpoint, err error) {
 	return
 }
 
+// EndpointResponseFailed marks the endpoint as ineligible after it answered
+// with a status code that the retry policy retries on, so that the iterators
+// pick another endpoint for the retry.
+func (p *EndpointPool) EndpointResponseFailed(endpoint *Endpoint, statusCode int) {
+	p.Lock()
+	defer p.Unlock()
+	e := p.index[endpoint.CanonicalAddr()]
+	if e == nil {
+		return
+	}
+
+	p.logger.Error("endpoint-marked-as-ineligible",
+		zap.Nest("route-endpoint", endpoint.ToLogData()...),
+		zap.Int("status-code", statusCode),
+	)
+	e.failed()
+}
+
 func (p *EndpointPool) Each(f func(endpoint *Endpoint)) {
 	p.Lock()
 	for _, e := range p.endpoints {
@@ -419,15 +749,48 @@ func (p *EndpointPool) Each(f func(endpoint *Endpoint)) {
 	p.Unlock()
 }
 
+// EachUpdated calls f with every endpoint of the pool and the time it was
+// last registered.
+func (p *EndpointPool) EachUpdated(f func(endpoint *Endpoint, updated time.Time)) {
+	p.Lock()
+	for _, e := range p.endpoints {
+		f(e.endpoint, e.updated)
+	}
+	p.Unlock()
+}
+
 func (p *EndpointPool) MarshalJSON() ([]byte, error) {
 	p.Lock()
+	now := time.Now()
+	loadBalancingAlgorithm := p.loadBalancingAlgorithm()
 	endpoints := make([]*Endpoint, 0, len(p.endpoints))
+	statuses := make([]*endpointStatus, 0, len(p.endpoints))
 	for _, e := range p.endpoints {
 		endpoints = append(endpoints, e.endpoint)
+
+		status := &endpointStatus{
+			loadBalancingAlgorithm: loadBalancingAlgorithm,
+			healthCheck:            e.health.toJSON(),
+			ejection:               e.outlier.toJSON(now),
+		}
+		if e.hasScore() {
+			s := e.score(now)
+			status.score = &s
+		}
+		statuses = append(statuses, status)
 	}
 	p.Unlock()
 
-	return json.Marshal(endpoints)
+	jsonEndpoints := make([]json.RawMessage, 0, len(endpoints))
+	for i, e := range endpoints {
+		b, err := e.marshalJSON(statuses[i])
+		if err != nil {
+			return nil, err
+		}
+		jsonEndpoints = append(jsonEndpoints, b)
+	}
+
+	return json.Marshal(jsonEndpoints)
 }
 
 func (e *endpointElem) failed() {
@@ -435,6 +798,27 @@ func (e *endpointElem) failed() {
 	e.failedAt = &t
 }
 
+func (e *endpointElem) isFailed(now time.Time, retryAfterFailure time.Duration) bool {
+	return e.failedAt != nil && now.Sub(*e.failedAt) <= retryAfterFailure
+}
+
+// selectable reports whether the iterators may pick the endpoint.
+func (e *endpointElem) selectable(s selection) bool {
+	if !e.inGroup(s.group) {
+		return false
+	}
+	if s.zone != "" && e.endpoint.AvailabilityZone != s.zone {
+		return false
+	}
+	return !(s.skipUnavailable && e.unavailable(s.now))
+}
+
+// unavailable reports whether the endpoint failed its health checks or is
+// ejected by outlier detection.
+func (e *endpointElem) unavailable(now time.Time) bool {
+	return e.health.unhealthy || e.outlier.isEjected(now)
+}
+
 func (e *endpointElem) isOverloaded() bool {
 	if e.maxConnsPerBackend == 0 {
 		return false
@@ -444,15 +828,45 @@ func (e *endpointElem) isOverloaded() bool {
 }
 
 func (e *Endpoint) MarshalJSON() ([]byte, error) {
+	return e.marshalJSON(&endpointStatus{})
+}
+
+// endpointStatus is what the pool an endpoint is in knows about it beyond
+// its registration.
+type endpointStatus struct {
+	score                  *float64
+	loadBalancingAlgorithm string
+	healthCheck            *healthCheckJSON
+	ejection               *ejectionJSON
+}
+
+// marshalJSON marshals the endpoint together with its load balancing score,
+// the load balancing algorithm of the route of the pool it is in, the result
+// of its health checks and its ejection by outlier detection, if they are
+// set.
+func (e *Endpoint) marshalJSON(status *endpointStatus) ([]byte, error) {
 	var jsonObj struct {
-		Address             string            `json:"address"`
-		TLS                 bool              `json:"tls"`
-		TTL                 int               `json:"ttl"`
-		RouteServiceUrl     string            `json:"route_service_url,omitempty"`
-		Tags                map[string]string `json:"tags"`
-		IsolationSegment    string            `json:"isolation_segment,omitempty"`
-		PrivateInstanceId   string            `json:"private_instance_id,omitempty"`
-		ServerCertDomainSAN string            `json:"server_cert_domain_san,omitempty"`
+		Address                string            `json:"address"`
+		TLS                    bool              `json:"tls"`
+		TTL                    int               `json:"ttl"`
+		RouteServiceUrl        string            `json:"route_service_url,omitempty"`
+		Tags                   map[string]string `json:"tags"`
+		IsolationSegment       string            `json:"isolation_segment,omitempty"`
+		PrivateInstanceId      string            `json:"private_instance_id,omitempty"`
+		ServerCertDomainSAN    string            `json:"server_cert_domain_san,omitempty"`
+		Protocol               string            `json:"protocol,omitempty"`
+		TLSPassthrough         bool              `json:"tls_passthrough,omitempty"`
+		Weight                 int               `json:"weight,omitempty"`
+		Score                  *float64          `json:"score,omitempty"`
+		AvailabilityZone       string            `json:"availability_zone,omitempty"`
+		HashKey                string            `json:"hash_key,omitempty"`
+		LoadBalancingAlgorithm string            `json:"load_balancing_algorithm,omitempty"`
+		HealthCheckPath        string            `json:"health_check_path,omitempty"`
+		TrafficPercent         int               `json:"traffic_percent,omitempty"`
+		TrafficMatch           string            `json:"traffic_match,omitempty"`
+		Static                 bool              `json:"static,omitempty"`
+		HealthCheck            *healthCheckJSON  `json:"health_check,omitempty"`
+		Ejection               *ejectionJSON     `json:"outlier_ejection,omitempty"`
 	}
 
 	jsonObj.Address = e.addr
@@ -463,6 +877,19 @@ func (e *Endpoint) MarshalJSON() ([]byte, error) {
 	jsonObj.IsolationSegment = e.IsolationSegment
 	jsonObj.PrivateInstanceId = e.PrivateInstanceId
 	jsonObj.ServerCertDomainSAN = e.ServerCertDomainSAN
+	jsonObj.Protocol = e.Protocol
+	jsonObj.TLSPassthrough = e.TLSPassthrough
+	jsonObj.Weight = e.Weight
+	jsonObj.Score = status.score
+	jsonObj.AvailabilityZone = e.AvailabilityZone
+	jsonObj.HashKey = e.HashKey.String()
+	jsonObj.LoadBalancingAlgorithm = status.loadBalancingAlgorithm
+	jsonObj.HealthCheckPath = e.HealthCheckPath
+	jsonObj.TrafficPercent = e.TrafficPercent
+	jsonObj.TrafficMatch = e.TrafficMatch.String()
+	jsonObj.Static = e.Static
+	jsonObj.HealthCheck = status.healthCheck
+	jsonObj.Ejection = status.ejection
 	return json.Marshal(jsonObj)
 }
 
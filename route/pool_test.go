@@ -11,6 +11,7 @@ import (
 
 	"net"
 
+	"code.cloudfoundry.org/gorouter/config"
 	"code.cloudfoundry.org/gorouter/route"
 	"code.cloudfoundry.org/gorouter/test_util"
 	"code.cloudfoundry.org/routing-api/models"
@@ -226,6 +227,14 @@ var _ = Describe("EndpointPool", func() {
 				})
 			})
 
+			It("clears roundTrippers if the protocol changes", func() {
+				endpointWithSameAddressButDifferentProtocol := route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 5678, Protocol: route.ProtocolHTTP2})
+				pool.Put(endpointWithSameAddressButDifferentProtocol)
+				pool.Each(func(e *route.Endpoint) {
+					Expect(e.RoundTripper()).To(BeNil())
+				})
+			})
+
 		})
 	})
 
@@ -253,6 +262,71 @@ var _ = Describe("EndpointPool", func() {
 		})
 	})
 
+	Context("IsTLSPassthrough", func() {
+		It("returns whether the endpoints of the pool terminate TLS", func() {
+			endpoint := route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 5678})
+			Expect(pool.Put(endpoint)).To(Equal(route.ADDED))
+			Expect(pool.IsTLSPassthrough()).To(BeFalse())
+
+			passthroughEndpoint := route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 5678, TLSPassthrough: true})
+			Expect(pool.Put(passthroughEndpoint)).To(Equal(route.UPDATED))
+			Expect(pool.IsTLSPassthrough()).To(BeTrue())
+		})
+
+		Context("when there are no endpoints in the pool", func() {
+			It("returns false", func() {
+				Expect(pool.IsTLSPassthrough()).To(BeFalse())
+			})
+		})
+	})
+
+	Context("LoadBalancingAlgorithm", func() {
+		It("returns the load balancing algorithm of the first endpoint", func() {
+			Expect(pool.LoadBalancingAlgorithm()).To(BeEmpty())
+
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 5678, LoadBalancingAlgorithm: config.LOAD_BALANCE_LC}))
+			Expect(pool.LoadBalancingAlgorithm()).To(Equal(config.LOAD_BALANCE_LC))
+
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.5", Port: 5678, LoadBalancingAlgorithm: config.LOAD_BALANCE_WRR}))
+			Expect(pool.LoadBalancingAlgorithm()).To(Equal(config.LOAD_BALANCE_LC))
+
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 5678, LoadBalancingAlgorithm: config.LOAD_BALANCE_P2C}))
+			Expect(pool.LoadBalancingAlgorithm()).To(Equal(config.LOAD_BALANCE_P2C))
+		})
+
+		It("is cleared when the endpoints are registered again without one", func() {
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 5678, LoadBalancingAlgorithm: config.LOAD_BALANCE_LC}))
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 5678}))
+
+			Expect(pool.LoadBalancingAlgorithm()).To(BeEmpty())
+		})
+
+		It("is the one of the remaining endpoints when an endpoint is removed", func() {
+			e1 := route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 5678, LoadBalancingAlgorithm: config.LOAD_BALANCE_LC})
+			pool.Put(e1)
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.5", Port: 5678}))
+
+			pool.Remove(e1)
+			Expect(pool.LoadBalancingAlgorithm()).To(BeEmpty())
+		})
+	})
+
+	Context("Endpoints", func() {
+		It("uses the default load balancing algorithm", func() {
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 5678}))
+
+			Expect(pool.Endpoints(config.LOAD_BALANCE_RR, "")).To(BeAssignableToTypeOf(&route.RoundRobin{}))
+			Expect(pool.Endpoints(config.LOAD_BALANCE_LC, "")).To(BeAssignableToTypeOf(&route.LeastConnection{}))
+		})
+
+		It("uses the load balancing algorithm of the route over the default", func() {
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 5678, LoadBalancingAlgorithm: config.LOAD_BALANCE_LC}))
+
+			Expect(pool.Endpoints(config.LOAD_BALANCE_RR, "")).To(BeAssignableToTypeOf(&route.LeastConnection{}))
+			Expect(pool.HashedEndpoints(config.LOAD_BALANCE_RR, "", "key")).To(BeAssignableToTypeOf(&route.LeastConnection{}))
+		})
+	})
+
 	Context("EndpointFailed", func() {
 		Context("non-tls endpoints", func() {
 			var failedEndpoint, fineEndpoint *route.Endpoint
@@ -616,6 +690,36 @@ var _ = Describe("EndpointPool", func() {
 		})
 	})
 
+	Context("Restore", func() {
+		It("adds the endpoint with the time it was registered", func() {
+			e1 := route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 5678, StaleThresholdInSeconds: 120})
+			e2 := route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 1234, StaleThresholdInSeconds: 120})
+			pool.Put(e2)
+
+			Expect(pool.Restore(e1, time.Now().Add(-120*time.Second))).To(BeTrue())
+
+			Expect(pool.PruneEndpoints()).To(ConsistOf(e1))
+			Expect(pool.IsEmpty()).To(BeFalse())
+		})
+
+		It("keeps an endpoint with the same address", func() {
+			e1 := route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 5678, StaleThresholdInSeconds: 120})
+			pool.Put(e1)
+
+			restored := route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 5678, StaleThresholdInSeconds: 120})
+			Expect(pool.Restore(restored, time.Now().Add(-120*time.Second))).To(BeFalse())
+
+			Expect(pool.PruneEndpoints()).To(BeEmpty())
+			Expect(pool.Endpoints("", "").Next()).To(BeIdenticalTo(e1))
+		})
+
+		It("applies the options of the route", func() {
+			pool.Restore(route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 5678, LoadBalancingAlgorithm: config.LOAD_BALANCE_LC}), time.Now())
+
+			Expect(pool.LoadBalancingAlgorithm()).To(Equal(config.LOAD_BALANCE_LC))
+		})
+	})
+
 	Context("Each", func() {
 		It("applies a function to each endpoint", func() {
 			e1 := route.NewEndpoint(&route.EndpointOpts{Port: 5678})
@@ -633,6 +737,22 @@ var _ = Describe("EndpointPool", func() {
 		})
 	})
 
+	Context("EachUpdated", func() {
+		It("applies a function to each endpoint and the time it was registered", func() {
+			e1 := route.NewEndpoint(&route.EndpointOpts{Port: 5678})
+			pool.Put(e1)
+			updated := time.Now().Add(-time.Minute)
+			pool.MarkUpdated(updated)
+
+			var endpoints []*route.Endpoint
+			pool.EachUpdated(func(e *route.Endpoint, t time.Time) {
+				endpoints = append(endpoints, e)
+				Expect(t).To(Equal(updated))
+			})
+			Expect(endpoints).To(ConsistOf(e1))
+		})
+	})
+
 	Context("Stats", func() {
 		Context("NumberConnections", func() {
 			It("increments number of connections", func() {
@@ -710,6 +830,51 @@ var _ = Describe("EndpointPool", func() {
 		})
 	})
 
+	Context("when endpoints have a protocol", func() {
+		It("marshals json ", func() {
+			e := route.NewEndpoint(&route.EndpointOpts{
+				Host:                    "1.2.3.4",
+				Port:                    5678,
+				StaleThresholdInSeconds: -1,
+				Protocol:                route.ProtocolHTTP2,
+			})
+			pool.Put(e)
+			json, err := pool.MarshalJSON()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(json)).To(Equal(`[{"address":"1.2.3.4:5678","tls":false,"ttl":-1,"tags":null,"protocol":"http2"}]`))
+		})
+	})
+
+	Context("when endpoints have a weight", func() {
+		It("marshals json ", func() {
+			e := route.NewEndpoint(&route.EndpointOpts{
+				Host:                    "1.2.3.4",
+				Port:                    5678,
+				StaleThresholdInSeconds: -1,
+				Weight:                  3,
+			})
+			pool.Put(e)
+			json, err := pool.MarshalJSON()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(json)).To(Equal(`[{"address":"1.2.3.4:5678","tls":false,"ttl":-1,"tags":null,"weight":3}]`))
+		})
+	})
+
+	Context("when the route has a load balancing algorithm", func() {
+		It("marshals json ", func() {
+			e := route.NewEndpoint(&route.EndpointOpts{
+				Host:                    "1.2.3.4",
+				Port:                    5678,
+				StaleThresholdInSeconds: -1,
+				LoadBalancingAlgorithm:  config.LOAD_BALANCE_LC,
+			})
+			pool.Put(e)
+			json, err := pool.MarshalJSON()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(json)).To(Equal(`[{"address":"1.2.3.4:5678","tls":false,"ttl":-1,"tags":null,"load_balancing_algorithm":"least-connection"}]`))
+		})
+	})
+
 	Context("when endpoints have empty tags", func() {
 		var e *route.Endpoint
 		BeforeEach(func() {
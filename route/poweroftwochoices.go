@@ -0,0 +1,246 @@
+package route
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// scoreDecay is how quickly the latency and error rate of an endpoint
+	// forget old requests. Observations lose about two thirds of their
+	// weight after this long.
+	scoreDecay = 10 * time.Second
+
+	// defaultLatency is assumed for endpoints that have not served a request
+	// yet, so that new endpoints are tried without receiving every request.
+	defaultLatency = 10 * time.Millisecond
+
+	// errorPenalty is how much a 100% error rate multiplies the score of an
+	// endpoint.
+	errorPenalty = 10
+)
+
+// PowerOfTwoChoices picks two random endpoints and sends the request to the
+// one with the lower score. The score of an endpoint is its moving average
+// response latency, scaled by its number of requests in flight and its
+// recent error rate, so slow or failing endpoints receive less traffic.
+type PowerOfTwoChoices struct {
+	pool            *EndpointPool
+	initialEndpoint string
+	group           string
+	lastEndpoint    *Endpoint
+	requestStarted  time.Time
+}
+
+func NewPowerOfTwoChoices(p *EndpointPool, initial string) EndpointIterator {
+	return &PowerOfTwoChoices{
+		pool:            p,
+		initialEndpoint: initial,
+	}
+}
+
+func (r *PowerOfTwoChoices) Next() *Endpoint {
+	var e *endpointElem
+	if r.initialEndpoint != "" {
+		e = r.pool.findInitial(r.initialEndpoint, r.group)
+		r.initialEndpoint = ""
+	}
+
+	if e != nil {
+		e.RLock()
+		defer e.RUnlock()
+		r.lastEndpoint = e.endpoint
+		return e.endpoint
+	}
+
+	e = r.next()
+	if e != nil {
+		e.RLock()
+		defer e.RUnlock()
+		r.lastEndpoint = e.endpoint
+		return e.endpoint
+	}
+
+	r.lastEndpoint = nil
+	return nil
+}
+
+func (r *PowerOfTwoChoices) next() *endpointElem {
+	r.pool.Lock()
+	defer r.pool.Unlock()
+
+	if len(r.pool.endpoints) == 0 {
+		return nil
+	}
+
+	sel := r.pool.selection(r.group)
+	candidates, failed := r.candidates(sel)
+	if len(candidates) == 0 && failed {
+		// all available endpoints are marked failed so reset everything to available
+		for _, e := range r.pool.endpoints {
+			e.failedAt = nil
+		}
+		candidates, _ = r.candidates(sel)
+	}
+
+	switch len(candidates) {
+	case 0:
+		return nil
+	case 1:
+		return candidates[0]
+	}
+
+	i := r.pool.random.Intn(len(candidates))
+	j := r.pool.random.Intn(len(candidates) - 1)
+	if j >= i {
+		j++
+	}
+
+	now := time.Now()
+	if candidates[j].score(now) < candidates[i].score(now) {
+		return candidates[j]
+	}
+	return candidates[i]
+}
+
+// candidates returns the selectable endpoints that are neither overloaded
+// nor failed, and reports whether any endpoint was left out because it is
+// failed.
+func (r *PowerOfTwoChoices) candidates(sel selection) ([]*endpointElem, bool) {
+	candidates := make([]*endpointElem, 0, len(r.pool.endpoints))
+	failed := false
+
+	for _, e := range r.pool.endpoints {
+		if e.isOverloaded() || !e.selectable(sel) {
+			continue
+		}
+
+		if e.failedAt != nil {
+			curTime := time.Now()
+			if curTime.Sub(*e.failedAt) > r.pool.retryAfterFailure {
+				// exipired failure window
+				e.failedAt = nil
+			}
+		}
+
+		if e.failedAt != nil {
+			failed = true
+			continue
+		}
+
+		candidates = append(candidates, e)
+	}
+
+	return candidates, failed
+}
+
+func (r *PowerOfTwoChoices) EndpointFailed(err error) {
+	if r.lastEndpoint != nil {
+		r.pool.observeFailure(r.lastEndpoint)
+		r.pool.EndpointFailed(r.lastEndpoint, err)
+	}
+}
+
+func (r *PowerOfTwoChoices) PreRequest(e *Endpoint) {
+	e.Stats.NumberConnections.Increment()
+	r.requestStarted = time.Now()
+}
+
+func (r *PowerOfTwoChoices) PostRequest(e *Endpoint) {
+	e.Stats.NumberConnections.Decrement()
+	if !r.requestStarted.IsZero() {
+		r.pool.observeRequest(e, time.Since(r.requestStarted))
+	}
+}
+
+func (p *EndpointPool) observeRequest(endpoint *Endpoint, latency time.Duration) {
+	p.Lock()
+	defer p.Unlock()
+
+	e := p.index[endpoint.CanonicalAddr()]
+	if e == nil {
+		return
+	}
+
+	now := time.Now()
+	e.latency.observe(latency.Seconds(), now)
+	e.requests.add(now)
+}
+
+func (p *EndpointPool) observeFailure(endpoint *Endpoint) {
+	p.Lock()
+	defer p.Unlock()
+
+	e := p.index[endpoint.CanonicalAddr()]
+	if e == nil {
+		return
+	}
+
+	e.failures.add(time.Now())
+}
+
+// score returns the cost of sending the next request to the endpoint; lower
+// is better. It must be called with the pool locked.
+func (e *endpointElem) score(now time.Time) float64 {
+	latency := defaultLatency.Seconds()
+	if !e.latency.updated.IsZero() {
+		latency = e.latency.value
+	}
+
+	errorRate := 0.0
+	if requests := e.requests.at(now); requests > 0 {
+		errorRate = math.Min(e.failures.at(now)/requests, 1)
+	}
+
+	inFlight := float64(e.endpoint.Stats.NumberConnections.Count())
+	return latency * (inFlight + 1) * (1 + errorPenalty*errorRate)
+}
+
+// hasScore reports whether the endpoint has served requests through a
+// PowerOfTwoChoices iterator. It must be called with the pool locked.
+func (e *endpointElem) hasScore() bool {
+	return !e.latency.updated.IsZero()
+}
+
+// movingAverage is an exponentially weighted moving average whose
+// observations decay with time rather than with the number of observations.
+type movingAverage struct {
+	value   float64
+	updated time.Time
+}
+
+func (a *movingAverage) observe(value float64, now time.Time) {
+	if a.updated.IsZero() {
+		a.value = value
+	} else {
+		w := decay(now.Sub(a.updated))
+		a.value = a.value*w + value*(1-w)
+	}
+	a.updated = now
+}
+
+// decayingCounter counts events, with every event counting for less as it
+// gets older.
+type decayingCounter struct {
+	value   float64
+	updated time.Time
+}
+
+func (c *decayingCounter) add(now time.Time) {
+	c.value = c.at(now) + 1
+	c.updated = now
+}
+
+func (c *decayingCounter) at(now time.Time) float64 {
+	if c.updated.IsZero() {
+		return 0
+	}
+	return c.value * decay(now.Sub(c.updated))
+}
+
+func decay(elapsed time.Duration) float64 {
+	if elapsed < 0 {
+		return 1
+	}
+	return math.Exp(-float64(elapsed) / float64(scoreDecay))
+}
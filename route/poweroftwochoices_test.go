@@ -0,0 +1,220 @@
+package route_test
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/gorouter/test_util"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PowerOfTwoChoices", func() {
+	var (
+		pool   *route.EndpointPool
+		e1, e2 *route.Endpoint
+	)
+
+	request := func(iter route.EndpointIterator, e *route.Endpoint, latency time.Duration) {
+		iter.PreRequest(e)
+		time.Sleep(latency)
+		iter.PostRequest(e)
+	}
+
+	BeforeEach(func() {
+		pool = route.NewPool(&route.PoolOpts{
+			Logger:             test_util.NewTestZapLogger("test"),
+			RetryAfterFailure:  2 * time.Minute,
+			Host:               "",
+			ContextPath:        "",
+			MaxConnsPerBackend: 0,
+		})
+
+		e1 = route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 5678, PrivateInstanceId: "first"})
+		e2 = route.NewEndpoint(&route.EndpointOpts{Host: "5.6.7.8", Port: 1234, PrivateInstanceId: "second"})
+	})
+
+	Describe("Next", func() {
+		It("returns nil when no endpoints exist", func() {
+			iter := route.NewPowerOfTwoChoices(pool, "")
+			Expect(iter.Next()).To(BeNil())
+		})
+
+		It("returns the only endpoint", func() {
+			pool.Put(e1)
+
+			iter := route.NewPowerOfTwoChoices(pool, "")
+			Expect(iter.Next()).To(Equal(e1))
+		})
+
+		It("finds the initial endpoint", func() {
+			pool.Put(e1)
+			pool.Put(e2)
+
+			iter := route.NewPowerOfTwoChoices(pool, "second")
+			request(iter, e2, 20*time.Millisecond)
+
+			for i := 0; i < 10; i++ {
+				iter := route.NewPowerOfTwoChoices(pool, "second")
+				Expect(iter.Next()).To(Equal(e2))
+			}
+		})
+
+		It("prefers the endpoint with the lower latency", func() {
+			pool.Put(e1)
+			pool.Put(e2)
+
+			iter := route.NewPowerOfTwoChoices(pool, "")
+			request(iter, e1, 20*time.Millisecond)
+			request(iter, e2, time.Millisecond)
+
+			for i := 0; i < 10; i++ {
+				Expect(iter.Next()).To(Equal(e2))
+			}
+		})
+
+		It("prefers the endpoint with fewer requests in flight", func() {
+			pool.Put(e1)
+			pool.Put(e2)
+
+			e1.Stats.NumberConnections.Increment()
+
+			iter := route.NewPowerOfTwoChoices(pool, "")
+			for i := 0; i < 10; i++ {
+				Expect(iter.Next()).To(Equal(e2))
+			}
+		})
+
+		It("prefers the endpoint with fewer errors", func() {
+			pool.Put(e1)
+			pool.Put(e2)
+
+			iter := route.NewPowerOfTwoChoices(pool, "")
+			for i := 0; i < 3; i++ {
+				request(iter, e1, 5*time.Millisecond)
+				request(iter, e2, 5*time.Millisecond)
+			}
+
+			for i := 0; i < 3; i++ {
+				iter = route.NewPowerOfTwoChoices(pool, "first")
+				Expect(iter.Next()).To(Equal(e1))
+				iter.EndpointFailed(errors.New("some-error"))
+			}
+
+			for i := 0; i < 10; i++ {
+				Expect(iter.Next()).To(Equal(e2))
+			}
+		})
+
+		It("is selected by the pool for the power of two choices algorithm", func() {
+			Expect(pool.Endpoints(config.LOAD_BALANCE_P2C, "")).To(BeAssignableToTypeOf(&route.PowerOfTwoChoices{}))
+		})
+
+		Context("when some endpoints are overloaded", func() {
+			BeforeEach(func() {
+				pool = route.NewPool(&route.PoolOpts{
+					Logger:             test_util.NewTestZapLogger("test"),
+					RetryAfterFailure:  2 * time.Minute,
+					Host:               "",
+					ContextPath:        "",
+					MaxConnsPerBackend: 1,
+				})
+				pool.Put(e1)
+				pool.Put(e2)
+			})
+
+			It("returns an unencumbered endpoint", func() {
+				iter := route.NewPowerOfTwoChoices(pool, "")
+				request(iter, e1, 0)
+				request(iter, e2, 20*time.Millisecond)
+
+				e1.Stats.NumberConnections.Increment()
+				Expect(iter.Next()).To(Equal(e2))
+			})
+
+			It("returns nil when all endpoints are overloaded", func() {
+				e1.Stats.NumberConnections.Increment()
+				e2.Stats.NumberConnections.Increment()
+
+				iter := route.NewPowerOfTwoChoices(pool, "")
+				Expect(iter.Next()).To(BeNil())
+			})
+		})
+	})
+
+	Describe("Failed", func() {
+		It("skips failed endpoints", func() {
+			pool.Put(e1)
+			pool.Put(e2)
+
+			iter := route.NewPowerOfTwoChoices(pool, "")
+			n := iter.Next()
+			Expect(n).ToNot(BeNil())
+
+			iter.EndpointFailed(&net.OpError{Op: "dial"})
+
+			for i := 0; i < 5; i++ {
+				nn := iter.Next()
+				Expect(nn).ToNot(BeNil())
+				Expect(nn).ToNot(Equal(n))
+			}
+		})
+
+		It("resets when all endpoints are failed", func() {
+			pool.Put(e1)
+			pool.Put(e2)
+
+			iter := route.NewPowerOfTwoChoices(pool, "")
+			n1 := iter.Next()
+			iter.EndpointFailed(&net.OpError{Op: "dial"})
+			n2 := iter.Next()
+			iter.EndpointFailed(&net.OpError{Op: "dial"})
+			Expect(n1).ToNot(Equal(n2))
+
+			Expect(iter.Next()).ToNot(BeNil())
+		})
+	})
+
+	Context("PreRequest", func() {
+		It("increments the NumberConnections counter", func() {
+			pool.Put(e1)
+			iter := route.NewPowerOfTwoChoices(pool, "")
+			iter.PreRequest(e1)
+			Expect(e1.Stats.NumberConnections.Count()).To(Equal(int64(1)))
+		})
+	})
+
+	Context("PostRequest", func() {
+		It("decrements the NumberConnections counter", func() {
+			e1.Stats = &route.Stats{
+				NumberConnections: route.NewCounter(int64(1)),
+			}
+			pool.Put(e1)
+			iter := route.NewPowerOfTwoChoices(pool, "")
+			iter.PostRequest(e1)
+			Expect(e1.Stats.NumberConnections.Count()).To(Equal(int64(0)))
+		})
+	})
+
+	Describe("MarshalJSON", func() {
+		It("includes the score of endpoints that served requests", func() {
+			pool.Put(e1)
+			pool.Put(e2)
+
+			json, err := pool.MarshalJSON()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(json)).ToNot(ContainSubstring(`"score"`))
+
+			iter := route.NewPowerOfTwoChoices(pool, "")
+			request(iter, e1, 0)
+
+			json, err = pool.MarshalJSON()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(json)).To(MatchRegexp(`"address":"1.2.3.4:5678".*"score":[0-9.e-]+\}`))
+			Expect(string(json)).To(MatchRegexp(`"address":"5.6.7.8:1234"[^}]*"private_instance_id":"second"\}`))
+		})
+	})
+})
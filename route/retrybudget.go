@@ -0,0 +1,71 @@
+package route
+
+import (
+	"math"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/config"
+)
+
+// retryBudgetWindow is how quickly the retry budget of a route forgets old
+// requests and retries. They lose about two thirds of their weight after this
+// long, so the budget holds about this long worth of them.
+const retryBudgetWindow = 10 * time.Second
+
+// retryBudget counts the recent requests to a route and the retries of them,
+// with every request counting for less as it gets older.
+type retryBudget struct {
+	requests recentCount
+	retries  recentCount
+}
+
+// recentCount counts events over the retry budget window.
+type recentCount struct {
+	value   float64
+	updated time.Time
+}
+
+func (c *recentCount) add(now time.Time) {
+	c.value = c.at(now) + 1
+	c.updated = now
+}
+
+func (c *recentCount) at(now time.Time) float64 {
+	elapsed := now.Sub(c.updated)
+	if c.updated.IsZero() || elapsed < 0 {
+		return c.value
+	}
+	return c.value * math.Exp(-float64(elapsed)/float64(retryBudgetWindow))
+}
+
+// RecordRequest counts a request to the route for its retry budget.
+func (p *EndpointPool) RecordRequest() {
+	p.Lock()
+	defer p.Unlock()
+
+	p.retryBudget.requests.add(time.Now())
+}
+
+// RetryAllowed reports whether a request to the route may be retried
+// without going over the retry budget, which allows the configured
+// percentage of the recent requests to be retried plus a minimum number of
+// retries per second. Allowed retries count against the budget.
+func (p *EndpointPool) RetryAllowed(budget config.RetryBudgetConfig) bool {
+	if !budget.Enabled {
+		return true
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	now := time.Now()
+	b := &p.retryBudget
+	allowed := b.requests.at(now)*float64(budget.Percent)/100 +
+		float64(budget.MinRetriesPerSecond)*retryBudgetWindow.Seconds()
+	if b.retries.at(now)+1 > allowed {
+		return false
+	}
+
+	b.retries.add(now)
+	return true
+}
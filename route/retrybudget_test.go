@@ -0,0 +1,75 @@
+package route_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/gorouter/test_util"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Retry budget", func() {
+	var (
+		pool   *route.EndpointPool
+		budget config.RetryBudgetConfig
+	)
+
+	BeforeEach(func() {
+		pool = route.NewPool(&route.PoolOpts{
+			Logger:            test_util.NewTestZapLogger("test"),
+			RetryAfterFailure: 2 * time.Minute,
+		})
+		budget = config.RetryBudgetConfig{Enabled: true, Percent: 20}
+	})
+
+	retries := func(n int) int {
+		allowed := 0
+		for i := 0; i < n; i++ {
+			if pool.RetryAllowed(budget) {
+				allowed++
+			}
+		}
+		return allowed
+	}
+
+	It("allows retrying the configured percentage of the requests", func() {
+		for i := 0; i < 100; i++ {
+			pool.RecordRequest()
+		}
+
+		Expect(retries(100)).To(BeNumerically("~", 20, 1))
+	})
+
+	It("allows the minimum number of retries without requests", func() {
+		budget.Percent = 0
+		budget.MinRetriesPerSecond = 1
+
+		Expect(retries(100)).To(BeNumerically(">", 0))
+		Expect(pool.RetryAllowed(budget)).To(BeFalse())
+	})
+
+	It("allows every retry when the budget is disabled", func() {
+		budget.Enabled = false
+
+		Expect(retries(100)).To(Equal(100))
+	})
+
+	It("uses the retry policy of the first endpoint", func() {
+		Expect(pool.RetryPolicy()).To(BeNil())
+
+		policy := &config.RetryPolicyConfig{MaxAttempts: 5}
+		pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.1.1.1", Port: 1111, RetryPolicy: policy}))
+		pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "2.2.2.2", Port: 2222}))
+
+		Expect(pool.RetryPolicy()).To(Equal(policy))
+	})
+
+	It("uses the retry policy of the router once the route is registered again without one", func() {
+		pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.1.1.1", Port: 1111, RetryPolicy: &config.RetryPolicyConfig{MaxAttempts: 5}}))
+		pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.1.1.1", Port: 1111}))
+
+		Expect(pool.RetryPolicy()).To(BeNil())
+	})
+})
@@ -8,6 +8,7 @@ type RoundRobin struct {
 	pool *EndpointPool
 
 	initialEndpoint string
+	group           string
 	lastEndpoint    *Endpoint
 }
 
@@ -21,12 +22,8 @@ func NewRoundRobin(p *EndpointPool, initial string) EndpointIterator {
 func (r *RoundRobin) Next() *Endpoint {
 	var e *endpointElem
 	if r.initialEndpoint != "" {
-		e = r.pool.findById(r.initialEndpoint)
+		e = r.pool.findInitial(r.initialEndpoint, r.group)
 		r.initialEndpoint = ""
-
-		if e != nil && e.isOverloaded() {
-			e = nil
-		}
 	}
 
 	if e != nil {
@@ -57,12 +54,19 @@ func (r *RoundRobin) next() *endpointElem {
 		return nil
 	}
 
+	sel := r.pool.selection(r.group)
+
 	if r.pool.nextIdx == -1 {
 		r.pool.nextIdx = r.pool.random.Intn(last)
 	} else if r.pool.nextIdx >= last {
 		r.pool.nextIdx = 0
 	}
 
+	// an endpoint passed over while warming up, to fall back on when no
+	// other endpoint can be picked
+	var warming *endpointElem
+	failed := false
+
 	startIdx := r.pool.nextIdx
 	curIdx := startIdx
 	for {
@@ -73,32 +77,45 @@ func (r *RoundRobin) next() *endpointElem {
 			curIdx = 0
 		}
 
-		if e.isOverloaded() {
-			if curIdx == startIdx {
-				return nil
+		if !e.isOverloaded() && e.selectable(sel) {
+			if e.failedAt != nil {
+				curTime := time.Now()
+				if curTime.Sub(*e.failedAt) > r.pool.retryAfterFailure {
+					// exipired failure window
+					e.failedAt = nil
+				}
 			}
-			continue
-		}
 
-		if e.failedAt != nil {
-			curTime := time.Now()
-			if curTime.Sub(*e.failedAt) > r.pool.retryAfterFailure {
-				// exipired failure window
-				e.failedAt = nil
+			switch {
+			case e.failedAt != nil:
+				failed = true
+			case r.pool.skipWarmingUp(e, sel):
+				if warming == nil {
+					warming = e
+				}
+			default:
+				r.pool.nextIdx = curIdx
+				return e
 			}
 		}
 
-		if e.failedAt == nil {
+		if curIdx != startIdx {
+			continue
+		}
+
+		if warming != nil {
 			r.pool.nextIdx = curIdx
-			return e
+			return warming
+		}
+		if !failed {
+			return nil
 		}
 
-		if curIdx == startIdx {
-			// all endpoints are marked failed so reset everything to available
-			for _, e2 := range r.pool.endpoints {
-				e2.failedAt = nil
-			}
+		// all available endpoints are marked failed so reset everything to available
+		for _, e2 := range r.pool.endpoints {
+			e2.failedAt = nil
 		}
+		failed = false
 	}
 }
 
@@ -299,6 +299,25 @@ var _ = Describe("RoundRobin", func() {
 			Expect(n1).ToNot(Equal(n2))
 		})
 
+		It("resets when all endpoints that can be picked are failed", func() {
+			e1 := route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 1234})
+			e2 := route.NewEndpoint(&route.EndpointOpts{Host: "5.6.7.8", Port: 5678, HealthCheckPath: "/health"})
+			pool.Put(e1)
+			pool.Put(e2)
+
+			// start the next iteration at e1, right after e2
+			Eventually(func() *route.Endpoint {
+				return route.NewRoundRobin(pool, "").Next()
+			}).Should(Equal(e2))
+
+			pool.EndpointFailed(e1, &net.OpError{Op: "dial"})
+			pool.HealthCheckResult(e2, errors.New("connection refused"), 1, 1)
+
+			iter := route.NewRoundRobin(pool, "")
+			Expect(iter.Next()).To(Equal(e1))
+			Expect(iter.Next()).To(Equal(e1))
+		})
+
 		It("resets failed endpoints after exceeding failure duration", func() {
 			pool = route.NewPool(&route.PoolOpts{
 				Logger:             test_util.NewTestZapLogger("test"),
@@ -0,0 +1,31 @@
+package route
+
+import (
+	"time"
+)
+
+// slowStartFactor returns the share of its full traffic the endpoint gets
+// during the slow start window after it was added to the pool, which grows
+// linearly from the minimum weight to 1 over the window.
+func (p *EndpointPool) slowStartFactor(e *endpointElem, now time.Time) float64 {
+	window := p.slowStart.Window
+	elapsed := now.Sub(e.addedAt)
+	if window <= 0 || elapsed >= window {
+		return 1
+	}
+
+	min := float64(p.slowStart.MinWeightPercent) / 100
+	return min + (1-min)*float64(elapsed)/float64(window)
+}
+
+// skipWarmingUp reports whether an iterator should pass over the endpoint,
+// which it does with a probability that falls from 1 minus the minimum
+// weight to 0 during the slow start window of the endpoint. Endpoints only
+// warm up while the pool has endpoints that are past their window to take
+// their traffic. It must be called with the pool locked.
+func (p *EndpointPool) skipWarmingUp(e *endpointElem, s selection) bool {
+	if !s.slowStart {
+		return false
+	}
+	return p.random.Float64() >= p.slowStartFactor(e, s.now)
+}
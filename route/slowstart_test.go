@@ -0,0 +1,87 @@
+package route_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/gorouter/test_util"
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Slow start", func() {
+	const window = 200 * time.Millisecond
+
+	var (
+		pool   *route.EndpointPool
+		e1, e2 *route.Endpoint
+	)
+
+	BeforeEach(func() {
+		pool = route.NewPool(&route.PoolOpts{
+			Logger:             test_util.NewTestZapLogger("test"),
+			RetryAfterFailure:  2 * time.Minute,
+			MaxConnsPerBackend: 1,
+			SlowStart: config.SlowStartConfig{
+				Window:           window,
+				MinWeightPercent: 10,
+			},
+		})
+
+		e1 = route.NewEndpoint(&route.EndpointOpts{Host: "1.1.1.1", Port: 1111})
+		e2 = route.NewEndpoint(&route.EndpointOpts{Host: "2.2.2.2", Port: 2222})
+	})
+
+	next := func(algorithm string, n int) map[*route.Endpoint]int {
+		counts := map[*route.Endpoint]int{}
+		for i := 0; i < n; i++ {
+			counts[pool.Endpoints(algorithm, "").Next()]++
+		}
+		return counts
+	}
+
+	Context("when an endpoint is added to a pool with endpoints past their slow start window", func() {
+		BeforeEach(func() {
+			pool.Put(e1)
+			time.Sleep(window)
+			pool.Put(e2)
+		})
+
+		table.DescribeTable("sends less traffic to the new endpoint until its window is over",
+			func(algorithm string) {
+				counts := next(algorithm, 1000)
+				Expect(counts[e2]).To(BeNumerically(">", 0))
+				Expect(counts[e2]).To(BeNumerically("<", 250))
+
+				time.Sleep(window)
+				counts = next(algorithm, 1000)
+				Expect(counts[e2]).To(BeNumerically(">", 350))
+			},
+			table.Entry("round-robin", config.LOAD_BALANCE_RR),
+			table.Entry("least-connection", config.LOAD_BALANCE_LC),
+		)
+
+		table.DescribeTable("picks the new endpoint when no other endpoint can be picked",
+			func(algorithm string) {
+				e1.Stats.NumberConnections.Increment()
+
+				Expect(next(algorithm, 10)).To(Equal(map[*route.Endpoint]int{e2: 10}))
+			},
+			table.Entry("round-robin", config.LOAD_BALANCE_RR),
+			table.Entry("least-connection", config.LOAD_BALANCE_LC),
+		)
+	})
+
+	Context("when every endpoint of the pool is in its slow start window", func() {
+		BeforeEach(func() {
+			pool.Put(e1)
+			pool.Put(e2)
+		})
+
+		It("sends every endpoint its full traffic", func() {
+			Expect(next(config.LOAD_BALANCE_RR, 10)).To(Equal(map[*route.Endpoint]int{e1: 5, e2: 5}))
+		})
+	})
+})
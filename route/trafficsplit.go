@@ -0,0 +1,215 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/uber-go/zap"
+)
+
+// Parts of a request a traffic match compares
+const (
+	MatchOnHeader = "header"
+	MatchOnCookie = "cookie"
+)
+
+// TrafficMatch sends the requests that carry a header or cookie with a value
+// to the backend group of the endpoint. The zero value matches no request.
+type TrafficMatch struct {
+	Source string
+	Name   string
+	Value  string
+}
+
+// ParseTrafficMatch parses a traffic match in the format of a registration
+// message: "header:<name>=<value>" or "cookie:<name>=<value>".
+func ParseTrafficMatch(s string) (TrafficMatch, error) {
+	if s == "" {
+		return TrafficMatch{}, nil
+	}
+
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) == 2 && (parts[0] == MatchOnHeader || parts[0] == MatchOnCookie) {
+		nameValue := strings.SplitN(parts[1], "=", 2)
+		if len(nameValue) == 2 && nameValue[0] != "" && nameValue[1] != "" {
+			return TrafficMatch{Source: parts[0], Name: nameValue[0], Value: nameValue[1]}, nil
+		}
+	}
+
+	return TrafficMatch{}, fmt.Errorf("invalid traffic match %q, must be one of %q or %q", s, MatchOnHeader+":<name>=<value>", MatchOnCookie+":<name>=<value>")
+}
+
+func (m TrafficMatch) String() string {
+	if m.Source == "" {
+		return ""
+	}
+	return m.Source + ":" + m.Name + "=" + m.Value
+}
+
+// Matches reports whether the request carries the header or cookie of the
+// match with its value.
+func (m TrafficMatch) Matches(req *http.Request) bool {
+	switch m.Source {
+	case MatchOnHeader:
+		return req.Header.Get(m.Name) == m.Value
+	case MatchOnCookie:
+		cookie, err := req.Cookie(m.Name)
+		return err == nil && cookie.Value == m.Value
+	default:
+		return false
+	}
+}
+
+// backendGroup is the share of the traffic of a route that goes to the
+// endpoints of one app.
+type backendGroup struct {
+	app     string
+	percent int
+	match   TrafficMatch
+	// weight is the share of the requests without a match that go to the app
+	weight float64
+}
+
+// split reports whether the group asks for a share of the traffic.
+func (g backendGroup) split() bool {
+	return g.percent > 0 || g.match.Source != ""
+}
+
+// BackendGroup picks the app whose endpoints serve the request, when the
+// endpoints of the route belong to apps that split its traffic. Requests that
+// match the traffic match of an app go to that app. Other requests go to an
+// app at random, with apps that registered a traffic percentage getting that
+// percentage, and the rest of the traffic shared evenly by the apps that
+// registered neither a percentage nor a match. It returns "" to pick from
+// every endpoint of the route, which is what routes without traffic splits
+// do.
+func (p *EndpointPool) BackendGroup(req *http.Request) string {
+	p.Lock()
+	defer p.Unlock()
+
+	groups := p.backendGroups()
+	if len(groups) == 0 {
+		return ""
+	}
+
+	total := 0.0
+	for _, g := range groups {
+		if g.match.Matches(req) {
+			return g.app
+		}
+		total += g.weight
+	}
+	if total == 0 {
+		return ""
+	}
+
+	r := p.random.Float64() * total
+	for _, g := range groups {
+		if r < g.weight {
+			return g.app
+		}
+		r -= g.weight
+	}
+	return groups[len(groups)-1].app
+}
+
+// backendGroups returns the backend groups of the pool, building them when
+// the endpoints changed since they were last built. It must be called with
+// the pool locked.
+func (p *EndpointPool) backendGroups() []backendGroup {
+	if p.groups == nil {
+		p.groups = newBackendGroups(p.endpoints)
+	}
+	return p.groups
+}
+
+// newBackendGroups returns the apps of the endpoints, ordered by app, with the
+// traffic split of their endpoints, which every endpoint of an app is
+// expected to register alike. It returns no groups when the endpoints do not
+// split the traffic between apps.
+func newBackendGroups(endpoints []*endpointElem) []backendGroup {
+	byApp := map[string]backendGroup{}
+	for _, e := range endpoints {
+		byApp[e.endpoint.ApplicationId] = backendGroup{
+			app:     e.endpoint.ApplicationId,
+			percent: e.endpoint.TrafficPercent,
+			match:   e.endpoint.TrafficMatch,
+		}
+	}
+
+	groups := make([]backendGroup, 0, len(byApp))
+	split := false
+	percent, shared := 0, 0
+	for _, g := range byApp {
+		groups = append(groups, g)
+		split = split || g.split()
+		percent += g.percent
+		if !g.split() {
+			shared++
+		}
+	}
+	if len(groups) < 2 || !split {
+		return []backendGroup{}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].app < groups[j].app })
+
+	rest := 0.0
+	if shared > 0 && percent < 100 {
+		rest = float64(100-percent) / float64(shared)
+	}
+	for i := range groups {
+		if groups[i].split() {
+			groups[i].weight = float64(groups[i].percent)
+		} else {
+			groups[i].weight = rest
+		}
+	}
+	return groups
+}
+
+// checkTrafficPercent logs an error when the traffic percentages of the apps
+// of the pool add up to more than 100 after the endpoint registered its
+// percentage. It must be called with the pool locked.
+func (p *EndpointPool) checkTrafficPercent(endpoint *Endpoint) {
+	if endpoint.TrafficPercent == 0 {
+		return
+	}
+
+	byApp := map[string]int{}
+	for _, e := range p.endpoints {
+		byApp[e.endpoint.ApplicationId] = e.endpoint.TrafficPercent
+	}
+	total := 0
+	for _, percent := range byApp {
+		total += percent
+	}
+
+	if total > 100 {
+		p.logger.Error("traffic-percent-exceeds-100",
+			zap.String("host", p.host),
+			zap.String("app", endpoint.ApplicationId),
+			zap.Int("traffic-percent", endpoint.TrafficPercent),
+			zap.Int("total-traffic-percent", total),
+		)
+	}
+}
+
+// hasGroup reports whether the pool has endpoints of the group. It must be
+// called with the pool locked.
+func (p *EndpointPool) hasGroup(group string) bool {
+	if group == "" {
+		return false
+	}
+	for _, e := range p.endpoints {
+		if e.inGroup(group) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *endpointElem) inGroup(group string) bool {
+	return group == "" || e.endpoint.ApplicationId == group
+}
@@ -0,0 +1,204 @@
+package route_test
+
+import (
+	"net/http"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/gorouter/test_util"
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Traffic splits", func() {
+	Describe("ParseTrafficMatch", func() {
+		table.DescribeTable("parses valid traffic matches",
+			func(s string, expected route.TrafficMatch) {
+				m, err := route.ParseTrafficMatch(s)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(m).To(Equal(expected))
+				Expect(m.String()).To(Equal(s))
+			},
+			table.Entry("empty", "", route.TrafficMatch{}),
+			table.Entry("header", "header:X-Canary=true", route.TrafficMatch{Source: "header", Name: "X-Canary", Value: "true"}),
+			table.Entry("cookie", "cookie:canary=always", route.TrafficMatch{Source: "cookie", Name: "canary", Value: "always"}),
+		)
+
+		table.DescribeTable("rejects invalid traffic matches",
+			func(s string) {
+				_, err := route.ParseTrafficMatch(s)
+				Expect(err).To(MatchError(ContainSubstring("invalid traffic match")))
+			},
+			table.Entry("unknown source", "query:canary=true"),
+			table.Entry("missing value", "header:X-Canary"),
+			table.Entry("empty value", "header:X-Canary="),
+			table.Entry("empty name", "cookie:=true"),
+		)
+	})
+
+	Describe("BackendGroup", func() {
+		var (
+			pool   *route.EndpointPool
+			req    *http.Request
+			logger *test_util.TestZapLogger
+		)
+
+		BeforeEach(func() {
+			logger = test_util.NewTestZapLogger("test")
+			pool = route.NewPool(&route.PoolOpts{
+				Logger:            logger,
+				RetryAfterFailure: 2 * time.Minute,
+			})
+			req = test_util.NewRequest("GET", "example.com", "/", nil)
+		})
+
+		groups := func(n int) map[string]int {
+			counts := map[string]int{}
+			for i := 0; i < n; i++ {
+				counts[pool.BackendGroup(req)]++
+			}
+			return counts
+		}
+
+		It("does not pick a group for routes without traffic splits", func() {
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{AppId: "blue", Host: "1.1.1.1", Port: 1111}))
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{AppId: "green", Host: "2.2.2.2", Port: 2222}))
+
+			Expect(groups(10)).To(Equal(map[string]int{"": 10}))
+		})
+
+		It("does not pick a group for routes of a single app", func() {
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{AppId: "blue", Host: "1.1.1.1", Port: 1111, TrafficPercent: 10}))
+
+			Expect(groups(10)).To(Equal(map[string]int{"": 10}))
+		})
+
+		Context("when an app registered a traffic percentage", func() {
+			BeforeEach(func() {
+				pool.Put(route.NewEndpoint(&route.EndpointOpts{AppId: "blue", Host: "1.1.1.1", Port: 1111}))
+				pool.Put(route.NewEndpoint(&route.EndpointOpts{AppId: "blue", Host: "1.1.1.2", Port: 1111}))
+				pool.Put(route.NewEndpoint(&route.EndpointOpts{AppId: "green", Host: "2.2.2.2", Port: 2222, TrafficPercent: 10}))
+			})
+
+			It("sends that percentage of the requests to the app and the rest to the other apps", func() {
+				counts := groups(10000)
+				Expect(counts["green"]).To(BeNumerically("~", 1000, 200))
+				Expect(counts["blue"]).To(BeNumerically("~", 9000, 200))
+			})
+
+			It("follows the traffic split as the endpoints change", func() {
+				Expect(groups(10000)["green"]).To(BeNumerically("~", 1000, 200))
+
+				green := route.NewEndpoint(&route.EndpointOpts{AppId: "green", Host: "2.2.2.2", Port: 2222, TrafficPercent: 50})
+				pool.Put(green)
+				Expect(groups(10000)["green"]).To(BeNumerically("~", 5000, 200))
+
+				pool.Remove(green)
+				Expect(groups(10)).To(Equal(map[string]int{"": 10}))
+			})
+		})
+
+		Context("when every app registered a traffic percentage", func() {
+			It("scales up percentages that add up to less than 100", func() {
+				pool.Put(route.NewEndpoint(&route.EndpointOpts{AppId: "blue", Host: "1.1.1.1", Port: 1111, TrafficPercent: 30}))
+				pool.Put(route.NewEndpoint(&route.EndpointOpts{AppId: "green", Host: "2.2.2.2", Port: 2222, TrafficPercent: 10}))
+
+				counts := groups(10000)
+				Expect(counts["green"]).To(BeNumerically("~", 2500, 200))
+				Expect(counts["blue"]).To(BeNumerically("~", 7500, 200))
+			})
+
+			It("logs and scales down percentages that add up to more than 100", func() {
+				pool.Put(route.NewEndpoint(&route.EndpointOpts{AppId: "blue", Host: "1.1.1.1", Port: 1111, TrafficPercent: 90}))
+				Expect(logger).NotTo(gbytes.Say("traffic-percent-exceeds-100"))
+
+				pool.Put(route.NewEndpoint(&route.EndpointOpts{AppId: "green", Host: "2.2.2.2", Port: 2222, TrafficPercent: 60}))
+				Expect(logger).To(gbytes.Say(`traffic-percent-exceeds-100.*"app":"green".*"total-traffic-percent":150`))
+
+				counts := groups(10000)
+				Expect(counts["green"]).To(BeNumerically("~", 4000, 200))
+				Expect(counts["blue"]).To(BeNumerically("~", 6000, 200))
+			})
+		})
+
+		Context("when an app registered a traffic match", func() {
+			BeforeEach(func() {
+				pool.Put(route.NewEndpoint(&route.EndpointOpts{AppId: "blue", Host: "1.1.1.1", Port: 1111}))
+				canary, err := route.ParseTrafficMatch("header:X-Canary=true")
+				Expect(err).NotTo(HaveOccurred())
+				pool.Put(route.NewEndpoint(&route.EndpointOpts{AppId: "green", Host: "2.2.2.2", Port: 2222, TrafficMatch: canary}))
+			})
+
+			It("sends the matching requests to the app", func() {
+				req.Header.Set("X-Canary", "true")
+				Expect(groups(10)).To(Equal(map[string]int{"green": 10}))
+			})
+
+			It("sends no other requests to the app", func() {
+				req.Header.Set("X-Canary", "false")
+				Expect(groups(10)).To(Equal(map[string]int{"blue": 10}))
+			})
+
+			It("matches cookies", func() {
+				canary, err := route.ParseTrafficMatch("cookie:canary=always")
+				Expect(err).NotTo(HaveOccurred())
+				pool.Put(route.NewEndpoint(&route.EndpointOpts{AppId: "green", Host: "2.2.2.2", Port: 2222, TrafficMatch: canary}))
+				req.AddCookie(&http.Cookie{Name: "canary", Value: "always"})
+
+				Expect(groups(10)).To(Equal(map[string]int{"green": 10}))
+			})
+		})
+	})
+
+	Describe("GroupEndpoints", func() {
+		var (
+			pool         *route.EndpointPool
+			blue1, blue2 *route.Endpoint
+			green        *route.Endpoint
+		)
+
+		BeforeEach(func() {
+			pool = route.NewPool(&route.PoolOpts{
+				Logger:            test_util.NewTestZapLogger("test"),
+				RetryAfterFailure: 2 * time.Minute,
+			})
+			blue1 = route.NewEndpoint(&route.EndpointOpts{AppId: "blue", Host: "1.1.1.1", Port: 1111})
+			blue2 = route.NewEndpoint(&route.EndpointOpts{AppId: "blue", Host: "1.1.1.2", Port: 1111})
+			green = route.NewEndpoint(&route.EndpointOpts{AppId: "green", Host: "2.2.2.2", Port: 2222, PrivateInstanceId: "green-0"})
+			pool.Put(blue1)
+			pool.Put(blue2)
+			pool.Put(green)
+		})
+
+		next := func(algorithm, initial, group string, n int) map[*route.Endpoint]int {
+			counts := map[*route.Endpoint]int{}
+			for i := 0; i < n; i++ {
+				counts[pool.GroupEndpoints(algorithm, initial, "", group).Next()]++
+			}
+			return counts
+		}
+
+		table.DescribeTable("only picks the endpoints of the group",
+			func(algorithm string) {
+				Expect(next(algorithm, "", "blue", 10)).NotTo(HaveKey(green))
+				Expect(next(algorithm, "", "green", 10)).To(Equal(map[*route.Endpoint]int{green: 10}))
+			},
+			table.Entry("round-robin", config.LOAD_BALANCE_RR),
+			table.Entry("least-connection", config.LOAD_BALANCE_LC),
+			table.Entry("weighted-round-robin", config.LOAD_BALANCE_WRR),
+			table.Entry("power-of-two-choices", config.LOAD_BALANCE_P2C),
+			table.Entry("consistent-hash", config.LOAD_BALANCE_CH),
+		)
+
+		It("picks the initial endpoint whatever its group", func() {
+			Expect(next(config.LOAD_BALANCE_RR, "green-0", "blue", 1)).To(Equal(map[*route.Endpoint]int{green: 1}))
+		})
+
+		It("picks from every endpoint when the group has no endpoints", func() {
+			Expect(next(config.LOAD_BALANCE_RR, "", "purple", 9)).To(Equal(map[*route.Endpoint]int{blue1: 3, blue2: 3, green: 3}))
+		})
+	})
+})
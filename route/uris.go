@@ -2,11 +2,19 @@ package route
 
 import (
 	"errors"
+	"strconv"
 	"strings"
 )
 
 type Uri string
 
+// TCPUri returns the key under which the TCP route for a router port is
+// registered. HTTP lookups drop everything after the first colon of the Host
+// header, so these keys are never reachable over HTTP.
+func TCPUri(port uint16) Uri {
+	return Uri("tcp:" + strconv.Itoa(int(port)))
+}
+
 func (u Uri) ToLower() Uri {
 	return Uri(strings.ToLower(u.String()))
 }
@@ -73,4 +73,14 @@ var _ = Describe("URIs", func() {
 		})
 
 	})
+
+	Describe("TCPUri", func() {
+		It("keys the route by the router port", func() {
+			Expect(route.TCPUri(1024)).To(Equal(route.Uri("tcp:1024")))
+		})
+
+		It("is unaffected by RouteKey", func() {
+			Expect(route.TCPUri(1024).RouteKey()).To(Equal(route.TCPUri(1024)))
+		})
+	})
 })
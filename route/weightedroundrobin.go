@@ -0,0 +1,129 @@
+package route
+
+import (
+	"time"
+)
+
+// WeightedRoundRobin spreads requests over the endpoints of a pool in
+// proportion to their weights, using the smooth weighted round-robin
+// algorithm so that heavier endpoints are interleaved with lighter ones
+// rather than picked in bursts.
+type WeightedRoundRobin struct {
+	pool *EndpointPool
+
+	initialEndpoint string
+	group           string
+	lastEndpoint    *Endpoint
+}
+
+func NewWeightedRoundRobin(p *EndpointPool, initial string) EndpointIterator {
+	return &WeightedRoundRobin{
+		pool:            p,
+		initialEndpoint: initial,
+	}
+}
+
+func (r *WeightedRoundRobin) Next() *Endpoint {
+	var e *endpointElem
+	if r.initialEndpoint != "" {
+		e = r.pool.findInitial(r.initialEndpoint, r.group)
+		r.initialEndpoint = ""
+	}
+
+	if e != nil {
+		e.RLock()
+		defer e.RUnlock()
+		r.lastEndpoint = e.endpoint
+		return e.endpoint
+	}
+
+	e = r.next()
+	if e != nil {
+		e.RLock()
+		defer e.RUnlock()
+		r.lastEndpoint = e.endpoint
+		return e.endpoint
+	}
+
+	r.lastEndpoint = nil
+	return nil
+}
+
+func (r *WeightedRoundRobin) next() *endpointElem {
+	r.pool.Lock()
+	defer r.pool.Unlock()
+
+	if len(r.pool.endpoints) == 0 {
+		return nil
+	}
+
+	sel := r.pool.selection(r.group)
+	e, failed := r.selectEndpoint(sel)
+	if e == nil && failed {
+		// all available endpoints are marked failed so reset everything to available
+		for _, e2 := range r.pool.endpoints {
+			e2.failedAt = nil
+		}
+		e, _ = r.selectEndpoint(sel)
+	}
+
+	return e
+}
+
+// selectEndpoint picks the endpoint with the highest current weight among
+// the selectable ones that are neither overloaded nor failed, and reports
+// whether any endpoint was skipped because it is failed.
+func (r *WeightedRoundRobin) selectEndpoint(sel selection) (*endpointElem, bool) {
+	var (
+		selected    *endpointElem
+		totalWeight int
+		failed      bool
+	)
+
+	for _, e := range r.pool.endpoints {
+		if e.isOverloaded() || !e.selectable(sel) {
+			continue
+		}
+
+		if e.failedAt != nil {
+			curTime := time.Now()
+			if curTime.Sub(*e.failedAt) > r.pool.retryAfterFailure {
+				// exipired failure window
+				e.failedAt = nil
+			}
+		}
+
+		if e.failedAt != nil {
+			failed = true
+			continue
+		}
+
+		weight := e.endpoint.weight()
+		e.currentWeight += weight
+		totalWeight += weight
+
+		if selected == nil || e.currentWeight > selected.currentWeight {
+			selected = e
+		}
+	}
+
+	if selected != nil {
+		selected.currentWeight -= totalWeight
+	}
+
+	return selected, failed
+}
+
+func (r *WeightedRoundRobin) EndpointFailed(err error) {
+	if r.lastEndpoint != nil {
+		r.pool.EndpointFailed(r.lastEndpoint, err)
+	}
+}
+
+func (r *WeightedRoundRobin) PreRequest(e *Endpoint) {
+	e.Stats.NumberConnections.Increment()
+}
+
+func (r *WeightedRoundRobin) PostRequest(e *Endpoint) {
+	e.Stats.NumberConnections.Decrement()
+}
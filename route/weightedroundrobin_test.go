@@ -0,0 +1,273 @@
+package route_test
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/gorouter/test_util"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WeightedRoundRobin", func() {
+	var pool *route.EndpointPool
+
+	BeforeEach(func() {
+		pool = route.NewPool(&route.PoolOpts{
+			Logger:             test_util.NewTestZapLogger("test"),
+			RetryAfterFailure:  2 * time.Minute,
+			Host:               "",
+			ContextPath:        "",
+			MaxConnsPerBackend: 0,
+		})
+	})
+
+	Describe("Next", func() {
+		It("distributes requests in proportion to the endpoint weights", func() {
+			e1 := route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 5678, Weight: 5})
+			e2 := route.NewEndpoint(&route.EndpointOpts{Host: "5.6.7.8", Port: 1234, Weight: 1})
+			e3 := route.NewEndpoint(&route.EndpointOpts{Host: "1.2.7.8", Port: 1234, Weight: 1})
+			endpoints := []*route.Endpoint{e1, e2, e3}
+
+			for _, e := range endpoints {
+				pool.Put(e)
+			}
+
+			iter := route.NewWeightedRoundRobin(pool, "")
+
+			loops := 20
+			for i := 0; i < loops; i++ {
+				counts := map[*route.Endpoint]int{}
+				for j := 0; j < 7; j++ {
+					counts[iter.Next()]++
+				}
+
+				Expect(counts).To(Equal(map[*route.Endpoint]int{e1: 5, e2: 1, e3: 1}))
+			}
+		})
+
+		It("interleaves the heavier endpoint with the lighter ones", func() {
+			e1 := route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 5678, Weight: 5})
+			e2 := route.NewEndpoint(&route.EndpointOpts{Host: "5.6.7.8", Port: 1234, Weight: 1})
+			e3 := route.NewEndpoint(&route.EndpointOpts{Host: "1.2.7.8", Port: 1234, Weight: 1})
+			pool.Put(e1)
+			pool.Put(e2)
+			pool.Put(e3)
+
+			iter := route.NewWeightedRoundRobin(pool, "")
+
+			var picked []*route.Endpoint
+			for i := 0; i < 7; i++ {
+				picked = append(picked, iter.Next())
+			}
+
+			Expect(picked).To(Equal([]*route.Endpoint{e1, e1, e2, e1, e3, e1, e1}))
+		})
+
+		It("treats endpoints without a weight as weight 1", func() {
+			e1 := route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 5678})
+			e2 := route.NewEndpoint(&route.EndpointOpts{Host: "5.6.7.8", Port: 1234})
+			pool.Put(e1)
+			pool.Put(e2)
+
+			iter := route.NewWeightedRoundRobin(pool, "")
+
+			n1 := iter.Next()
+			n2 := iter.Next()
+			Expect(n1).ToNot(Equal(n2))
+			Expect(iter.Next()).To(Equal(n1))
+			Expect(iter.Next()).To(Equal(n2))
+		})
+
+		It("returns nil when no endpoints exist", func() {
+			iter := route.NewWeightedRoundRobin(pool, "")
+			e := iter.Next()
+			Expect(e).To(BeNil())
+		})
+
+		It("finds the initial endpoint by private id", func() {
+			b := route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 1235, PrivateInstanceId: "light", Weight: 1})
+			pool.Put(route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 1234, Weight: 10}))
+			pool.Put(b)
+
+			for i := 0; i < 10; i++ {
+				iter := route.NewWeightedRoundRobin(pool, b.PrivateInstanceId)
+				e := iter.Next()
+				Expect(e).ToNot(BeNil())
+				Expect(e.PrivateInstanceId).To(Equal(b.PrivateInstanceId))
+			}
+		})
+
+		It("is selected by the pool for the weighted round-robin algorithm", func() {
+			e1 := route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 5678, Weight: 3})
+			e2 := route.NewEndpoint(&route.EndpointOpts{Host: "5.6.7.8", Port: 1234, Weight: 1})
+			pool.Put(e1)
+			pool.Put(e2)
+
+			counts := map[*route.Endpoint]int{}
+			for i := 0; i < 40; i++ {
+				counts[pool.Endpoints(config.LOAD_BALANCE_WRR, "").Next()]++
+			}
+
+			Expect(counts).To(Equal(map[*route.Endpoint]int{e1: 30, e2: 10}))
+		})
+
+		Context("when some endpoints are overloaded", func() {
+			var (
+				epOne, epTwo *route.Endpoint
+			)
+
+			BeforeEach(func() {
+				pool = route.NewPool(&route.PoolOpts{
+					Logger:             test_util.NewTestZapLogger("test"),
+					RetryAfterFailure:  2 * time.Minute,
+					Host:               "",
+					ContextPath:        "",
+					MaxConnsPerBackend: 2,
+				})
+
+				epOne = route.NewEndpoint(&route.EndpointOpts{Host: "5.5.5.5", Port: 5555, PrivateInstanceId: "private-label-1", Weight: 1})
+				pool.Put(epOne)
+				epTwo = route.NewEndpoint(&route.EndpointOpts{Host: "2.2.2.2", Port: 2222, PrivateInstanceId: "private-label-2", Weight: 10})
+				pool.Put(epTwo)
+			})
+
+			It("returns an unencumbered endpoint", func() {
+				epTwo.Stats.NumberConnections.Increment()
+				epTwo.Stats.NumberConnections.Increment()
+				iter := route.NewWeightedRoundRobin(pool, "")
+
+				Expect(iter.Next()).To(Equal(epOne))
+				Expect(iter.Next()).To(Equal(epOne))
+			})
+
+			Context("when all endpoints are overloaded", func() {
+				It("returns nil", func() {
+					epOne.Stats.NumberConnections.Increment()
+					epOne.Stats.NumberConnections.Increment()
+					epTwo.Stats.NumberConnections.Increment()
+					epTwo.Stats.NumberConnections.Increment()
+					iter := route.NewWeightedRoundRobin(pool, "")
+
+					Consistently(func() *route.Endpoint {
+						return iter.Next()
+					}).Should(BeNil())
+				})
+			})
+
+			Context("when the initial endpoint is overloaded", func() {
+				It("returns another endpoint", func() {
+					epOne.Stats.NumberConnections.Increment()
+					epOne.Stats.NumberConnections.Increment()
+					iter := route.NewWeightedRoundRobin(pool, "private-label-1")
+
+					Expect(iter.Next()).To(Equal(epTwo))
+				})
+			})
+		})
+	})
+
+	Describe("Failed", func() {
+		It("skips failed endpoints", func() {
+			e1 := route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 1234, Weight: 3})
+			e2 := route.NewEndpoint(&route.EndpointOpts{Host: "5.6.7.8", Port: 5678, Weight: 1})
+
+			pool.Put(e1)
+			pool.Put(e2)
+
+			iter := route.NewWeightedRoundRobin(pool, "")
+			n := iter.Next()
+			Expect(n).To(Equal(e1))
+
+			iter.EndpointFailed(&net.OpError{Op: "dial"})
+
+			for i := 0; i < 5; i++ {
+				Expect(iter.Next()).To(Equal(e2))
+			}
+		})
+
+		It("resets when all endpoints are failed", func() {
+			e1 := route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 1234})
+			e2 := route.NewEndpoint(&route.EndpointOpts{Host: "5.6.7.8", Port: 5678})
+			pool.Put(e1)
+			pool.Put(e2)
+
+			iter := route.NewWeightedRoundRobin(pool, "")
+			n1 := iter.Next()
+			iter.EndpointFailed(&net.OpError{Op: "dial"})
+			n2 := iter.Next()
+			iter.EndpointFailed(&net.OpError{Op: "remote error", Err: errors.New("tls: bad certificate")})
+			Expect(n1).ToNot(Equal(n2))
+
+			n1 = iter.Next()
+			n2 = iter.Next()
+			Expect(n1).ToNot(BeNil())
+			Expect(n2).ToNot(BeNil())
+			Expect(n1).ToNot(Equal(n2))
+		})
+
+		It("resets failed endpoints after exceeding failure duration", func() {
+			pool = route.NewPool(&route.PoolOpts{
+				Logger:             test_util.NewTestZapLogger("test"),
+				RetryAfterFailure:  50 * time.Millisecond,
+				Host:               "",
+				ContextPath:        "",
+				MaxConnsPerBackend: 0,
+			})
+
+			e1 := route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 1234})
+			e2 := route.NewEndpoint(&route.EndpointOpts{Host: "5.6.7.8", Port: 5678})
+			pool.Put(e1)
+			pool.Put(e2)
+
+			iter := route.NewWeightedRoundRobin(pool, "")
+			n1 := iter.Next()
+			n2 := iter.Next()
+			Expect(n1).ToNot(Equal(n2))
+
+			iter.EndpointFailed(&net.OpError{Op: "read", Err: errors.New("read: connection reset by peer")})
+
+			n1 = iter.Next()
+			n2 = iter.Next()
+			Expect(n1).To(Equal(n2))
+
+			time.Sleep(50 * time.Millisecond)
+
+			n1 = iter.Next()
+			n2 = iter.Next()
+			Expect(n1).ToNot(Equal(n2))
+		})
+	})
+
+	Context("PreRequest", func() {
+		It("increments the NumberConnections counter", func() {
+			endpointFoo := route.NewEndpoint(&route.EndpointOpts{Host: "1.2.3.4", Port: 1234, PrivateInstanceId: "foo"})
+			Expect(endpointFoo.Stats.NumberConnections.Count()).To(Equal(int64(0)))
+			pool.Put(endpointFoo)
+			iter := route.NewWeightedRoundRobin(pool, "foo")
+			iter.PreRequest(endpointFoo)
+			Expect(endpointFoo.Stats.NumberConnections.Count()).To(Equal(int64(1)))
+		})
+	})
+
+	Context("PostRequest", func() {
+		It("decrements the NumberConnections counter", func() {
+			endpointFoo := route.NewEndpoint(&route.EndpointOpts{
+				Host:              "1.2.3.4",
+				Port:              1234,
+				PrivateInstanceId: "foo",
+			})
+			endpointFoo.Stats = &route.Stats{
+				NumberConnections: route.NewCounter(int64(1)),
+			}
+			Expect(endpointFoo.Stats.NumberConnections.Count()).To(Equal(int64(1)))
+			pool.Put(endpointFoo)
+			iter := route.NewWeightedRoundRobin(pool, "foo")
+			iter.PostRequest(endpointFoo)
+			Expect(endpointFoo.Stats.NumberConnections.Count()).To(Equal(int64(0)))
+		})
+	})
+})
@@ -0,0 +1,114 @@
+package route_test
+
+import (
+	"net"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/gorouter/test_util"
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Locality aware routing", func() {
+	var (
+		pool                   *route.EndpointPool
+		minHealthyPercent      int
+		local1, local2, remote *route.Endpoint
+	)
+
+	JustBeforeEach(func() {
+		pool = route.NewPool(&route.PoolOpts{
+			Logger:             test_util.NewTestZapLogger("test"),
+			RetryAfterFailure:  2 * time.Minute,
+			MaxConnsPerBackend: 1,
+			LocalZone:          "z1",
+			MinHealthyPercent:  minHealthyPercent,
+		})
+
+		local1 = route.NewEndpoint(&route.EndpointOpts{Host: "1.1.1.1", Port: 1111, AvailabilityZone: "z1"})
+		local2 = route.NewEndpoint(&route.EndpointOpts{Host: "1.1.1.2", Port: 1111, AvailabilityZone: "z1"})
+		remote = route.NewEndpoint(&route.EndpointOpts{Host: "2.2.2.2", Port: 2222, AvailabilityZone: "z2"})
+		pool.Put(remote)
+		pool.Put(local1)
+		pool.Put(local2)
+	})
+
+	BeforeEach(func() {
+		minHealthyPercent = 0
+	})
+
+	next := func(algorithm string, n int) map[*route.Endpoint]int {
+		counts := map[*route.Endpoint]int{}
+		for i := 0; i < n; i++ {
+			counts[pool.Endpoints(algorithm, "").Next()]++
+		}
+		return counts
+	}
+
+	table.DescribeTable("only picks endpoints in the local zone while they are healthy",
+		func(algorithm string) {
+			counts := next(algorithm, 20)
+			Expect(counts).NotTo(HaveKey(remote))
+			Expect(counts).To(HaveKey(local1))
+		},
+		table.Entry("round-robin", config.LOAD_BALANCE_RR),
+		table.Entry("least-connection", config.LOAD_BALANCE_LC),
+		table.Entry("weighted-round-robin", config.LOAD_BALANCE_WRR),
+		table.Entry("power-of-two-choices", config.LOAD_BALANCE_P2C),
+	)
+
+	table.DescribeTable("spills over to other zones when every local endpoint is overloaded",
+		func(algorithm string) {
+			local1.Stats.NumberConnections.Increment()
+			local2.Stats.NumberConnections.Increment()
+
+			Expect(next(algorithm, 5)).To(Equal(map[*route.Endpoint]int{remote: 5}))
+		},
+		table.Entry("round-robin", config.LOAD_BALANCE_RR),
+		table.Entry("least-connection", config.LOAD_BALANCE_LC),
+		table.Entry("weighted-round-robin", config.LOAD_BALANCE_WRR),
+		table.Entry("power-of-two-choices", config.LOAD_BALANCE_P2C),
+	)
+
+	It("spills over to other zones when every local endpoint is failed", func() {
+		for _, e := range []*route.Endpoint{local1, local2} {
+			pool.EndpointFailed(e, &net.OpError{Op: "dial"})
+		}
+
+		Expect(next(config.LOAD_BALANCE_RR, 5)).To(Equal(map[*route.Endpoint]int{remote: 5}))
+	})
+
+	It("picks the remaining healthy local endpoint", func() {
+		pool.EndpointFailed(local1, &net.OpError{Op: "dial"})
+
+		Expect(next(config.LOAD_BALANCE_RR, 5)).To(Equal(map[*route.Endpoint]int{local2: 5}))
+	})
+
+	Context("when fewer local endpoints than the minimum healthy percentage are healthy", func() {
+		BeforeEach(func() {
+			minHealthyPercent = 60
+		})
+
+		It("picks endpoints from every zone", func() {
+			pool.EndpointFailed(local1, &net.OpError{Op: "dial"})
+
+			Expect(next(config.LOAD_BALANCE_RR, 10)).To(Equal(map[*route.Endpoint]int{local2: 5, remote: 5}))
+		})
+	})
+
+	Context("when the pool has no local zone", func() {
+		It("picks endpoints from every zone", func() {
+			pool = route.NewPool(&route.PoolOpts{
+				Logger:            test_util.NewTestZapLogger("test"),
+				RetryAfterFailure: 2 * time.Minute,
+			})
+			pool.Put(remote)
+			pool.Put(local1)
+
+			Expect(next(config.LOAD_BALANCE_RR, 10)).To(Equal(map[*route.Endpoint]int{local1: 5, remote: 5}))
+		})
+	})
+})
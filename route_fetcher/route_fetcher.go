@@ -24,14 +24,21 @@ type RouteFetcher struct {
 	RouteRegistry             registry.Registry
 	FetchRoutesInterval       time.Duration
 	SubscriptionRetryInterval time.Duration
+	// TCPPorts are the TCP routing ports of the router. TCP route mappings
+	// are only fetched when there are any, and only for these ports.
+	TCPPorts map[uint16]struct{}
 
 	logger          logger.Logger
 	endpoints       []models.Route
+	tcpMappings     []models.TcpRouteMapping
 	endpointsMutex  sync.Mutex
+	tcpMutex        sync.Mutex
 	client          routing_api.Client
 	stopEventSource int32
 	eventSource     atomic.Value
+	tcpEventSource  atomic.Value
 	eventChannel    chan routing_api.Event
+	tcpEventChannel chan routing_api.TcpEvent
 
 	clock clock.Clock
 }
@@ -51,21 +58,31 @@ func NewRouteFetcher(
 	subscriptionRetryInterval time.Duration,
 	clock clock.Clock,
 ) *RouteFetcher {
+	tcpPorts := map[uint16]struct{}{}
+	for _, port := range cfg.TCPRouting.Ports {
+		tcpPorts[port] = struct{}{}
+	}
+
 	return &RouteFetcher{
 		UaaClient:                 uaaClient,
 		RouteRegistry:             routeRegistry,
 		FetchRoutesInterval:       cfg.PruneStaleDropletsInterval / 2,
 		SubscriptionRetryInterval: subscriptionRetryInterval,
+		TCPPorts:                  tcpPorts,
 
-		client:       client,
-		logger:       logger,
-		eventChannel: make(chan routing_api.Event, 1024),
-		clock:        clock,
+		client:          client,
+		logger:          logger,
+		eventChannel:    make(chan routing_api.Event, 1024),
+		tcpEventChannel: make(chan routing_api.TcpEvent, 1024),
+		clock:           clock,
 	}
 }
 
 func (r *RouteFetcher) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
-	r.startEventCycle()
+	r.startEventCycle(r.subscribeToEvents)
+	if r.tcpRoutingEnabled() {
+		r.startEventCycle(r.subscribeToTCPEvents)
+	}
 
 	ticker := r.clock.NewTicker(r.FetchRoutesInterval)
 	r.logger.Debug("created-ticker", zap.Duration("interval", r.FetchRoutesInterval))
@@ -81,6 +98,8 @@ func (r *RouteFetcher) Run(signals <-chan os.Signal, ready chan<- struct{}) erro
 			}
 		case e := <-r.eventChannel:
 			r.HandleEvent(e)
+		case e := <-r.tcpEventChannel:
+			r.HandleTCPEvent(e)
 
 		case <-signals:
 			r.logger.Info("stopping")
@@ -91,13 +110,19 @@ func (r *RouteFetcher) Run(signals <-chan os.Signal, ready chan<- struct{}) erro
 					r.logger.Error("failed-closing-routing-api-event-source", zap.Error(err))
 				}
 			}
+			if es := r.tcpEventSource.Load(); es != nil {
+				err := es.(routing_api.TcpEventSource).Close()
+				if err != nil {
+					r.logger.Error("failed-closing-routing-api-tcp-event-source", zap.Error(err))
+				}
+			}
 			ticker.Stop()
 			return nil
 		}
 	}
 }
 
-func (r *RouteFetcher) startEventCycle() {
+func (r *RouteFetcher) startEventCycle(subscribe func(token *schema.Token) error) {
 	go func() {
 		forceUpdate := false
 		for {
@@ -111,7 +136,7 @@ func (r *RouteFetcher) startEventCycle() {
 				if atomic.LoadInt32(&r.stopEventSource) == 1 {
 					return
 				}
-				err = r.subscribeToEvents(token)
+				err = subscribe(token)
 				if err != nil && err.Error() == "unauthorized" {
 					forceUpdate = true
 				} else {
@@ -164,6 +189,44 @@ func (r *RouteFetcher) subscribeToEvents(token *schema.Token) error {
 	return err
 }
 
+func (r *RouteFetcher) subscribeToTCPEvents(token *schema.Token) error {
+	r.client.SetToken(token.AccessToken)
+
+	r.logger.Info("subscribing-to-routing-api-tcp-event-stream")
+	source, err := r.client.SubscribeToTcpEventsWithMaxRetries(maxRetries)
+	if err != nil {
+		metrics.IncrementCounter(SubscribeEventsErrors)
+		r.logger.Error("failed-subscribing-to-routing-api-tcp-event-stream", zap.Error(err))
+		return err
+	}
+	r.logger.Info("successfully-subscribed-to-routing-api-tcp-event-stream")
+
+	err = r.FetchTCPRoutes()
+	if err != nil {
+		r.logger.Error("failed-to-refresh-tcp-routes", zap.Error(err))
+	}
+
+	r.tcpEventSource.Store(source)
+	var event routing_api.TcpEvent
+
+	for {
+		event, err = source.Next()
+		if err != nil {
+			metrics.IncrementCounter(SubscribeEventsErrors)
+			r.logger.Error("failed-getting-next-tcp-event", zap.Error(err))
+
+			closeErr := source.Close()
+			if closeErr != nil {
+				r.logger.Error("failed-closing-tcp-event-source", zap.Error(closeErr))
+			}
+			break
+		}
+		r.logger.Debug("received-tcp-event", zap.Object("event", event))
+		r.tcpEventChannel <- event
+	}
+	return err
+}
+
 func (r *RouteFetcher) HandleEvent(e routing_api.Event) {
 	eventRoute := e.Route
 	uri := route.Uri(eventRoute.Route)
@@ -185,6 +248,24 @@ func (r *RouteFetcher) HandleEvent(e routing_api.Event) {
 	}
 }
 
+// HandleTCPEvent registers or unregisters the endpoint of a TCP route
+// mapping of one of the router's TCP routing ports.
+func (r *RouteFetcher) HandleTCPEvent(e routing_api.TcpEvent) {
+	mapping := e.TcpRouteMapping
+	if !r.routerTCPPort(mapping) {
+		return
+	}
+
+	r.tcpMutex.Lock()
+	defer r.tcpMutex.Unlock()
+	switch e.Action {
+	case "Delete":
+		r.RouteRegistry.Unregister(route.TCPUri(mapping.ExternalPort), tcpEndpoint(mapping))
+	case "Upsert":
+		r.RouteRegistry.Register(route.TCPUri(mapping.ExternalPort), tcpEndpoint(mapping))
+	}
+}
+
 func (r *RouteFetcher) FetchRoutes() error {
 	r.logger.Debug("syncer-fetch-routes-started")
 
@@ -197,35 +278,75 @@ func (r *RouteFetcher) FetchRoutes() error {
 
 	r.logger.Debug("syncer-refreshing-endpoints", zap.Int("number-of-routes", len(routes)))
 	r.refreshEndpoints(routes)
+
+	if r.tcpRoutingEnabled() {
+		return r.FetchTCPRoutes()
+	}
+	return nil
+}
+
+// FetchTCPRoutes registers the endpoints of the TCP route mappings of the
+// router's TCP routing ports, and unregisters the ones that are gone.
+func (r *RouteFetcher) FetchTCPRoutes() error {
+	var mappings []models.TcpRouteMapping
+	err := r.withTokenRefresh(func() error {
+		var err error
+		r.logger.Debug("syncer-fetching-tcp-routes")
+		mappings, err = r.client.TcpRouteMappings()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	var routerMappings []models.TcpRouteMapping
+	for _, mapping := range mappings {
+		if r.routerTCPPort(mapping) {
+			routerMappings = append(routerMappings, mapping)
+		}
+	}
+
+	r.logger.Debug("syncer-refreshing-tcp-endpoints", zap.Int("number-of-routes", len(routerMappings)))
+	r.refreshTCPEndpoints(routerMappings)
 	return nil
 }
 
 func (r *RouteFetcher) fetchRoutesWithTokenRefresh() ([]models.Route, error) {
+	var routes []models.Route
+	err := r.withTokenRefresh(func() error {
+		var err error
+		r.logger.Debug("syncer-fetching-routes")
+		routes, err = r.client.Routes()
+		return err
+	})
+	if err != nil {
+		return []models.Route{}, err
+	}
+
+	return routes, nil
+}
+
+// withTokenRefresh calls fetch with the cached token of the client, and once
+// more with a new token when the routing API rejects the cached one.
+func (r *RouteFetcher) withTokenRefresh(fetch func() error) error {
 	forceUpdate := false
 	var err error
-	var routes []models.Route
 	for count := 0; count < 2; count++ {
 		r.logger.Debug("syncer-fetching-token")
 		token, tokenErr := r.UaaClient.FetchToken(forceUpdate)
 		if tokenErr != nil {
 			metrics.IncrementCounter(TokenFetchErrors)
-			return []models.Route{}, tokenErr
+			return tokenErr
 		}
 		r.client.SetToken(token.AccessToken)
-		r.logger.Debug("syncer-fetching-routes")
-		routes, err = r.client.Routes()
-		if err != nil {
-			if err.Error() == "unauthorized" {
-				forceUpdate = true
-			} else {
-				return []models.Route{}, err
-			}
-		} else {
-			break
+		err = fetch()
+		if err == nil || err.Error() != "unauthorized" {
+			return err
 		}
+		forceUpdate = true
 	}
 
-	return routes, err
+	return err
 }
 
 func (r *RouteFetcher) getEndpoints() []models.Route {
@@ -308,3 +429,58 @@ func routeEquals(current, desired models.Route) bool {
 
 	return false
 }
+
+func (r *RouteFetcher) tcpRoutingEnabled() bool {
+	return len(r.TCPPorts) > 0
+}
+
+func (r *RouteFetcher) routerTCPPort(mapping models.TcpRouteMapping) bool {
+	_, ok := r.TCPPorts[mapping.ExternalPort]
+	return ok
+}
+
+// refreshTCPEndpoints holds tcpMutex, like HandleTCPEvent, since the TCP
+// event cycle fetches the mappings while the events and the periodic fetch
+// are handled by Run.
+func (r *RouteFetcher) refreshTCPEndpoints(mappings []models.TcpRouteMapping) {
+	r.tcpMutex.Lock()
+	defer r.tcpMutex.Unlock()
+	previous := r.tcpMappings
+	r.tcpMappings = mappings
+
+	for _, current := range previous {
+		found := false
+		for _, mapping := range mappings {
+			if tcpMappingEquals(current, mapping) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			r.RouteRegistry.Unregister(route.TCPUri(current.ExternalPort), tcpEndpoint(current))
+		}
+	}
+
+	for _, mapping := range mappings {
+		r.RouteRegistry.Register(route.TCPUri(mapping.ExternalPort), tcpEndpoint(mapping))
+	}
+}
+
+func tcpEndpoint(mapping models.TcpRouteMapping) *route.Endpoint {
+	ttl := 0
+	if mapping.TTL != nil {
+		ttl = *mapping.TTL
+	}
+
+	return route.NewEndpoint(&route.EndpointOpts{
+		Host:                    mapping.HostIP,
+		Port:                    mapping.HostPort,
+		StaleThresholdInSeconds: ttl,
+		ModificationTag:         mapping.ModificationTag,
+		IsolationSegment:        mapping.IsolationSegment,
+	})
+}
+
+func tcpMappingEquals(current, desired models.TcpRouteMapping) bool {
+	return current.ExternalPort == desired.ExternalPort && current.HostIP == desired.HostIP && current.HostPort == desired.HostPort
+}
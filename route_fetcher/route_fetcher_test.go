@@ -293,6 +293,122 @@ var _ = Describe("RouteFetcher", func() {
 
 	})
 
+	Describe("FetchTCPRoutes", func() {
+		var mappings []models.TcpRouteMapping
+
+		tcpMapping := func(externalPort uint16, hostIP string, hostPort uint16) models.TcpRouteMapping {
+			ttl := 60
+			return models.TcpRouteMapping{TcpMappingEntity: models.TcpMappingEntity{
+				ExternalPort: externalPort,
+				HostIP:       hostIP,
+				HostPort:     hostPort,
+				TTL:          &ttl,
+			}}
+		}
+
+		BeforeEach(func() {
+			uaaClient.FetchTokenReturns(token, nil)
+			fetcher.TCPPorts = map[uint16]struct{}{1024: {}, 1025: {}}
+
+			mappings = []models.TcpRouteMapping{
+				tcpMapping(1024, "10.0.0.1", 8080),
+				tcpMapping(1025, "10.0.0.2", 8080),
+				tcpMapping(2000, "10.0.0.3", 8080),
+			}
+			client.TcpRouteMappingsReturns(mappings, nil)
+		})
+
+		It("registers the mappings of the router's TCP routing ports", func() {
+			Expect(fetcher.FetchTCPRoutes()).To(Succeed())
+
+			Expect(registry.RegisterCallCount()).To(Equal(2))
+			uri, endpoint := registry.RegisterArgsForCall(0)
+			Expect(uri).To(Equal(route.TCPUri(1024)))
+			Expect(endpoint).To(Equal(route.NewEndpoint(&route.EndpointOpts{
+				Host:                    "10.0.0.1",
+				Port:                    8080,
+				StaleThresholdInSeconds: 60,
+			})))
+			uri, _ = registry.RegisterArgsForCall(1)
+			Expect(uri).To(Equal(route.TCPUri(1025)))
+		})
+
+		It("unregisters the mappings that are gone", func() {
+			Expect(fetcher.FetchTCPRoutes()).To(Succeed())
+
+			client.TcpRouteMappingsReturns(mappings[:1], nil)
+			Expect(fetcher.FetchTCPRoutes()).To(Succeed())
+
+			Expect(registry.UnregisterCallCount()).To(Equal(1))
+			uri, endpoint := registry.UnregisterArgsForCall(0)
+			Expect(uri).To(Equal(route.TCPUri(1025)))
+			Expect(endpoint.CanonicalAddr()).To(Equal("10.0.0.2:8080"))
+		})
+
+		It("fetches a new token when the cached one is rejected", func() {
+			client.TcpRouteMappingsReturnsOnCall(0, nil, errors.New("unauthorized"))
+
+			Expect(fetcher.FetchTCPRoutes()).To(Succeed())
+			Expect(uaaClient.FetchTokenCallCount()).To(Equal(2))
+			Expect(uaaClient.FetchTokenArgsForCall(1)).To(BeTrue())
+		})
+
+		It("is part of FetchRoutes", func() {
+			client.RoutesReturns(nil, nil)
+
+			Expect(fetcher.FetchRoutes()).To(Succeed())
+			Expect(client.TcpRouteMappingsCallCount()).To(Equal(1))
+		})
+
+		It("is not part of FetchRoutes without TCP routing ports", func() {
+			fetcher.TCPPorts = map[uint16]struct{}{}
+			client.RoutesReturns(nil, nil)
+
+			Expect(fetcher.FetchRoutes()).To(Succeed())
+			Expect(client.TcpRouteMappingsCallCount()).To(BeZero())
+		})
+
+		Describe("HandleTCPEvent", func() {
+			It("registers and unregisters the mappings of the router's TCP routing ports", func() {
+				fetcher.HandleTCPEvent(routing_api.TcpEvent{Action: "Upsert", TcpRouteMapping: mappings[0]})
+				fetcher.HandleTCPEvent(routing_api.TcpEvent{Action: "Delete", TcpRouteMapping: mappings[1]})
+				fetcher.HandleTCPEvent(routing_api.TcpEvent{Action: "Upsert", TcpRouteMapping: mappings[2]})
+
+				Expect(registry.RegisterCallCount()).To(Equal(1))
+				uri, _ := registry.RegisterArgsForCall(0)
+				Expect(uri).To(Equal(route.TCPUri(1024)))
+				Expect(registry.UnregisterCallCount()).To(Equal(1))
+				uri, _ = registry.UnregisterArgsForCall(0)
+				Expect(uri).To(Equal(route.TCPUri(1025)))
+			})
+
+			It("waits for a fetch to finish registering the mappings", func() {
+				unblock := make(chan struct{})
+				registry.RegisterStub = func(uri route.Uri, _ *route.Endpoint) {
+					if uri == route.TCPUri(1024) {
+						<-unblock
+					}
+				}
+
+				go fetcher.FetchTCPRoutes()
+				Eventually(registry.RegisterCallCount).Should(Equal(1))
+
+				handled := make(chan struct{})
+				go func() {
+					fetcher.HandleTCPEvent(routing_api.TcpEvent{Action: "Delete", TcpRouteMapping: mappings[1]})
+					close(handled)
+				}()
+				Consistently(handled).ShouldNot(BeClosed())
+				Expect(registry.UnregisterCallCount()).To(BeZero())
+
+				close(unblock)
+				Eventually(handled).Should(BeClosed())
+				Expect(registry.RegisterCallCount()).To(Equal(2))
+				Expect(registry.UnregisterCallCount()).To(Equal(1))
+			})
+		})
+	})
+
 	Describe("Run", func() {
 		BeforeEach(func() {
 			uaaClient.FetchTokenReturns(token, nil)
@@ -313,6 +429,55 @@ var _ = Describe("RouteFetcher", func() {
 			Eventually(client.SubscribeToEventsWithMaxRetriesCallCount).Should(Equal(1))
 		})
 
+		Context("when the router has TCP routing ports", func() {
+			var tcpEvents chan routing_api.TcpEvent
+
+			BeforeEach(func() {
+				fetcher.TCPPorts = map[uint16]struct{}{1024: {}}
+				tcpEvents = make(chan routing_api.TcpEvent)
+				tcpEventSource := &fake_routing_api.FakeTcpEventSource{}
+				tcpEventSource.NextStub = func() (routing_api.TcpEvent, error) {
+					event, ok := <-tcpEvents
+					if !ok {
+						return routing_api.TcpEvent{}, errors.New("closed")
+					}
+					return event, nil
+				}
+				client.SubscribeToTcpEventsWithMaxRetriesReturns(tcpEventSource, nil)
+			})
+
+			AfterEach(func() {
+				close(tcpEvents)
+			})
+
+			It("subscribes for TCP events and responds to them", func() {
+				Eventually(client.SubscribeToTcpEventsWithMaxRetriesCallCount).Should(Equal(1))
+				Eventually(client.TcpRouteMappingsCallCount).Should(BeNumerically(">=", 1))
+
+				tcpEvents <- routing_api.TcpEvent{
+					Action: "Upsert",
+					TcpRouteMapping: models.TcpRouteMapping{TcpMappingEntity: models.TcpMappingEntity{
+						ExternalPort: 1024,
+						HostIP:       "10.0.0.1",
+						HostPort:     8080,
+					}},
+				}
+				Eventually(func() []route.Uri {
+					var uris []route.Uri
+					for i := 0; i < registry.RegisterCallCount(); i++ {
+						uri, _ := registry.RegisterArgsForCall(i)
+						uris = append(uris, uri)
+					}
+					return uris
+				}).Should(ContainElement(route.TCPUri(1024)))
+			})
+		})
+
+		It("does not subscribe for TCP events without TCP routing ports", func() {
+			Eventually(client.SubscribeToEventsWithMaxRetriesCallCount).Should(Equal(1))
+			Consistently(client.SubscribeToTcpEventsWithMaxRetriesCallCount).Should(BeZero())
+		})
+
 		Context("on specified interval", func() {
 			BeforeEach(func() {
 				client.SubscribeToEventsWithMaxRetriesReturns(&fake_routing_api.FakeEventSource{}, errors.New("not used"))
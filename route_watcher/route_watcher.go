@@ -0,0 +1,284 @@
+package route_watcher
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/logger"
+	"code.cloudfoundry.org/gorouter/mbus"
+	"code.cloudfoundry.org/gorouter/registry"
+	"code.cloudfoundry.org/gorouter/route"
+	"github.com/uber-go/zap"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// RouteWatcher registers the routes of the registration messages in the
+// files of a directory, and applies the changes to the files as they happen.
+// Files hold a registration message, or a list of them, as JSON (.json) or
+// YAML (.yml or .yaml).
+type RouteWatcher struct {
+	RouteRegistry   registry.Registry
+	Directory       string
+	SyncInterval    time.Duration
+	RefreshInterval time.Duration
+
+	logger logger.Logger
+	clock  clock.Clock
+
+	// files are the files of the last sync by name
+	files map[string]*routeFile
+	// routes are the routes of every file of the last sync
+	routes map[routeKey]fileRoute
+}
+
+type routeFile struct {
+	// sum is the hash of the content of the file, since writes within the
+	// resolution of its modification time that keep its size do not show
+	// in the file info
+	sum    [sha256.Size]byte
+	routes []fileRoute
+}
+
+type fileRoute struct {
+	uri      route.Uri
+	endpoint *route.Endpoint
+}
+
+// routeKey tells the routes of the registry apart: endpoints of a uri are
+// identified by their address, and path patterns make routes of their own.
+type routeKey struct {
+	uri     route.Uri
+	pattern string
+	addr    string
+}
+
+func (r fileRoute) key() routeKey {
+	return routeKey{uri: r.uri, pattern: r.endpoint.PathPattern.String(), addr: r.endpoint.CanonicalAddr()}
+}
+
+func NewRouteWatcher(
+	logger logger.Logger,
+	routeRegistry registry.Registry,
+	cfg *config.Config,
+	clock clock.Clock,
+) *RouteWatcher {
+	return &RouteWatcher{
+		RouteRegistry:   routeRegistry,
+		Directory:       cfg.RouteFiles.Directory,
+		SyncInterval:    cfg.RouteFiles.SyncInterval,
+		RefreshInterval: cfg.PruneStaleDropletsInterval / 2,
+
+		logger: logger,
+		clock:  clock,
+		files:  map[string]*routeFile{},
+		routes: map[routeKey]fileRoute{},
+	}
+}
+
+// Run registers the routes of the directory before it is ready, then checks
+// the directory for changes every SyncInterval and registers every route
+// again every RefreshInterval so that they are not pruned.
+func (w *RouteWatcher) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	w.Sync()
+
+	syncTicker := w.clock.NewTicker(w.SyncInterval)
+	refreshTicker := w.clock.NewTicker(w.RefreshInterval)
+	w.logger.Info("route-watcher-started", zap.String("directory", w.Directory))
+
+	close(ready)
+	for {
+		select {
+		case <-syncTicker.C():
+			w.Sync()
+		case <-refreshTicker.C():
+			w.refresh()
+		case <-signals:
+			w.logger.Info("stopping")
+			syncTicker.Stop()
+			refreshTicker.Stop()
+			return nil
+		}
+	}
+}
+
+// Sync reads the files of the directory, registers the routes of the ones
+// whose content changed since the last sync and unregisters the routes that
+// are gone. Files
+// that cannot be read or parsed keep the routes they had, so that a file
+// caught while it is written does not drop its routes.
+func (w *RouteWatcher) Sync() {
+	infos, err := ioutil.ReadDir(w.Directory)
+	if err != nil {
+		w.logger.Error("failed-to-read-route-directory", zap.Error(err))
+		return
+	}
+
+	files := map[string]*routeFile{}
+	changed := false
+	for _, info := range infos {
+		if info.IsDir() || !isRouteFile(info.Name()) {
+			continue
+		}
+
+		previous, ok := w.files[info.Name()]
+		data, err := ioutil.ReadFile(filepath.Join(w.Directory, info.Name()))
+		if err != nil {
+			w.logger.Error("failed-to-read-route-file", zap.String("file", info.Name()), zap.Error(err))
+			if ok {
+				files[info.Name()] = previous
+			}
+			continue
+		}
+
+		sum := sha256.Sum256(data)
+		if ok && previous.sum == sum {
+			files[info.Name()] = previous
+			continue
+		}
+
+		changed = true
+		f := &routeFile{sum: sum}
+		f.routes, err = w.parseRoutes(info.Name(), data)
+		if err != nil {
+			w.logger.Error("failed-to-read-route-file", zap.String("file", info.Name()), zap.Error(err))
+			if ok {
+				f.routes = previous.routes
+			}
+		}
+		files[info.Name()] = f
+	}
+	if !changed && len(files) == len(w.files) {
+		return
+	}
+
+	routes := map[routeKey]fileRoute{}
+	for _, name := range sortedNames(files) {
+		for _, r := range files[name].routes {
+			routes[r.key()] = r
+		}
+	}
+
+	for key, r := range w.routes {
+		if _, ok := routes[key]; !ok {
+			w.RouteRegistry.Unregister(r.uri, r.endpoint)
+		}
+	}
+	for _, r := range routes {
+		w.RouteRegistry.Register(r.uri, r.endpoint)
+	}
+
+	w.files = files
+	w.routes = routes
+	w.logger.Debug("route-files-synced", zap.Int("files", len(files)), zap.Int("routes", len(routes)))
+}
+
+func (w *RouteWatcher) refresh() {
+	for _, r := range w.routes {
+		w.RouteRegistry.Register(r.uri, r.endpoint)
+	}
+}
+
+// parseRoutes returns the routes of the registration messages of a file.
+// Invalid messages are logged and skipped like the ones received over NATS.
+func (w *RouteWatcher) parseRoutes(name string, data []byte) ([]fileRoute, error) {
+	var err error
+	if filepath.Ext(name) != ".json" {
+		data, err = yamlToJSON(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var msgs []mbus.RegistryMessage
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("[")) {
+		err = json.Unmarshal(data, &msgs)
+	} else {
+		msgs = make([]mbus.RegistryMessage, 1)
+		err = json.Unmarshal(data, &msgs[0])
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var routes []fileRoute
+	for i := range msgs {
+		msg := &msgs[i]
+		endpoint, err := msg.MakeEndpoint()
+		if err == nil && !msg.ValidateMessage() {
+			err = fmt.Errorf("invalid route service url %q, must be https", msg.RouteServiceURL)
+		}
+		if err != nil {
+			w.logger.Error("Unable to register route",
+				zap.String("file", name),
+				zap.Error(err),
+				zap.Object("message", msg),
+			)
+			continue
+		}
+
+		for _, uri := range msg.RouteUris() {
+			routes = append(routes, fileRoute{uri: uri, endpoint: endpoint})
+		}
+	}
+	return routes, nil
+}
+
+func isRouteFile(name string) bool {
+	if strings.HasPrefix(name, ".") {
+		return false
+	}
+	switch filepath.Ext(name) {
+	case ".json", ".yml", ".yaml":
+		return true
+	default:
+		return false
+	}
+}
+
+func sortedNames(files map[string]*routeFile) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// yamlToJSON converts a YAML document to JSON, so that files use the JSON
+// field names of registration messages either way.
+func yamlToJSON(data []byte) ([]byte, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonValue(doc))
+}
+
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, value := range v {
+			m[fmt.Sprint(k)] = jsonValue(value)
+		}
+		return m
+	case []interface{}:
+		for i := range v {
+			v[i] = jsonValue(v[i])
+		}
+		return v
+	default:
+		return v
+	}
+}
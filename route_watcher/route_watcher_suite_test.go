@@ -0,0 +1,14 @@
+package route_watcher_test
+
+import (
+	"code.cloudfoundry.org/gorouter/test_util"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestRouteWatcher(t *testing.T) {
+	RegisterFailHandler(Fail)
+	test_util.RunSpecWithHoneyCombReporter(t, "RouteWatcher Suite")
+}
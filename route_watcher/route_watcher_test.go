@@ -0,0 +1,240 @@
+package route_watcher_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/logger"
+	testRegistry "code.cloudfoundry.org/gorouter/registry/fakes"
+	. "code.cloudfoundry.org/gorouter/route_watcher"
+	"code.cloudfoundry.org/gorouter/test_util"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/tedsuo/ifrit"
+)
+
+var _ = Describe("RouteWatcher", func() {
+	var (
+		cfg      *config.Config
+		registry *testRegistry.FakeRegistry
+		watcher  *RouteWatcher
+		logger   logger.Logger
+		dir      string
+		clock    *fakeclock.FakeClock
+	)
+
+	writeFile := func(name, content string) {
+		Expect(ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)).To(Succeed())
+	}
+
+	registered := func() []string {
+		var routes []string
+		for i := 0; i < registry.RegisterCallCount(); i++ {
+			uri, endpoint := registry.RegisterArgsForCall(i)
+			routes = append(routes, string(uri)+" "+endpoint.CanonicalAddr())
+		}
+		return routes
+	}
+
+	unregistered := func() []string {
+		var routes []string
+		for i := 0; i < registry.UnregisterCallCount(); i++ {
+			uri, endpoint := registry.UnregisterArgsForCall(i)
+			routes = append(routes, string(uri)+" "+endpoint.CanonicalAddr())
+		}
+		return routes
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "route-files")
+		Expect(err).NotTo(HaveOccurred())
+
+		logger = test_util.NewTestZapLogger("test")
+		cfg, err = config.DefaultConfig()
+		Expect(err).NotTo(HaveOccurred())
+		cfg.RouteFiles.Directory = dir
+		cfg.PruneStaleDropletsInterval = 20 * time.Second
+
+		registry = &testRegistry.FakeRegistry{}
+		clock = fakeclock.NewFakeClock(time.Now())
+		watcher = NewRouteWatcher(logger, registry, cfg, clock)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Describe("Sync", func() {
+		It("registers the routes of JSON and YAML files", func() {
+			writeFile("app.json", `{"host": "10.0.0.1", "port": 8080, "uris": ["app.example.com", "www.example.com"]}`)
+			writeFile("tools.yml", `
+- host: 10.0.0.2
+  port: 8080
+  uris: [tools.example.com]
+  tags: {component: tools}
+- host: 10.0.0.3
+  port: 8080
+  uris: [tools.example.com]
+`)
+
+			watcher.Sync()
+
+			Expect(registered()).To(ConsistOf(
+				"app.example.com 10.0.0.1:8080",
+				"www.example.com 10.0.0.1:8080",
+				"tools.example.com 10.0.0.2:8080",
+				"tools.example.com 10.0.0.3:8080",
+			))
+		})
+
+		It("reads the fields of registration messages", func() {
+			writeFile("app.yaml", `
+host: 10.0.0.1
+tls_port: 8443
+server_cert_domain_san: app.internal
+uris: [app.example.com]
+path_pattern: /users/{id}
+`)
+
+			watcher.Sync()
+
+			Expect(registry.RegisterCallCount()).To(Equal(1))
+			_, endpoint := registry.RegisterArgsForCall(0)
+			Expect(endpoint.IsTLS()).To(BeTrue())
+			Expect(endpoint.ServerCertDomainSAN).To(Equal("app.internal"))
+			Expect(endpoint.PathPattern.String()).To(Equal("/users/{id}"))
+		})
+
+		It("ignores other and hidden files", func() {
+			writeFile("README.md", `{"host": "10.0.0.1", "port": 8080, "uris": ["app.example.com"]}`)
+			writeFile(".app.json.swp", `{"host": "10.0.0.1", "port": 8080, "uris": ["app.example.com"]}`)
+
+			watcher.Sync()
+
+			Expect(registry.RegisterCallCount()).To(BeZero())
+		})
+
+		It("skips invalid registration messages", func() {
+			writeFile("app.json", `[
+				{"host": "10.0.0.1", "port": 8080, "uris": ["app.example.com"], "weight": -1},
+				{"host": "10.0.0.2", "port": 8080, "uris": ["app.example.com"], "route_service_url": "http://rs.example.com"},
+				{"host": "10.0.0.3", "port": 8080, "uris": ["app.example.com"]}
+			]`)
+
+			watcher.Sync()
+
+			Expect(registered()).To(ConsistOf("app.example.com 10.0.0.3:8080"))
+			Expect(logger).To(gbytes.Say(`Unable to register route.*app.json.*invalid weight`))
+		})
+
+		Context("when the files change", func() {
+			BeforeEach(func() {
+				writeFile("app.json", `{"host": "10.0.0.1", "port": 8080, "uris": ["app.example.com", "www.example.com"]}`)
+				writeFile("tools.json", `{"host": "10.0.0.2", "port": 8080, "uris": ["tools.example.com"]}`)
+				watcher.Sync()
+				registry = &testRegistry.FakeRegistry{}
+				watcher.RouteRegistry = registry
+			})
+
+			It("does nothing when no file changed", func() {
+				watcher.Sync()
+
+				Expect(registry.RegisterCallCount()).To(BeZero())
+				Expect(registry.UnregisterCallCount()).To(BeZero())
+			})
+
+			It("registers the new routes and unregisters the ones that are gone", func() {
+				writeFile("app.json", `{"host": "10.0.0.1", "port": 8080, "uris": ["app.example.com", "api.example.com"]}`)
+
+				watcher.Sync()
+
+				Expect(registered()).To(ContainElement("api.example.com 10.0.0.1:8080"))
+				Expect(unregistered()).To(ConsistOf("www.example.com 10.0.0.1:8080"))
+			})
+
+			It("applies changes that keep the size and modification time of a file", func() {
+				path := filepath.Join(dir, "tools.json")
+				info, err := os.Stat(path)
+				Expect(err).NotTo(HaveOccurred())
+
+				writeFile("tools.json", `{"host": "10.0.0.3", "port": 8080, "uris": ["tools.example.com"]}`)
+				Expect(os.Chtimes(path, info.ModTime(), info.ModTime())).To(Succeed())
+
+				watcher.Sync()
+
+				Expect(registered()).To(ContainElement("tools.example.com 10.0.0.3:8080"))
+				Expect(unregistered()).To(ConsistOf("tools.example.com 10.0.0.2:8080"))
+			})
+
+			It("unregisters the routes of removed files", func() {
+				Expect(os.Remove(filepath.Join(dir, "tools.json"))).To(Succeed())
+
+				watcher.Sync()
+
+				Expect(unregistered()).To(ConsistOf("tools.example.com 10.0.0.2:8080"))
+			})
+
+			It("keeps the routes of files that cannot be parsed", func() {
+				writeFile("tools.json", `{"host": "10.0.0.2", "port": 80`)
+
+				watcher.Sync()
+
+				Expect(registry.UnregisterCallCount()).To(BeZero())
+				Expect(logger).To(gbytes.Say(`failed-to-read-route-file.*tools.json`))
+			})
+		})
+
+		Context("when the directory cannot be read", func() {
+			It("logs an error", func() {
+				watcher.Directory = filepath.Join(dir, "missing")
+
+				watcher.Sync()
+
+				Expect(registry.RegisterCallCount()).To(BeZero())
+				Expect(logger).To(gbytes.Say(`failed-to-read-route-directory`))
+			})
+		})
+	})
+
+	Describe("Run", func() {
+		var process ifrit.Process
+
+		BeforeEach(func() {
+			writeFile("app.json", `{"host": "10.0.0.1", "port": 8080, "uris": ["app.example.com"]}`)
+			process = ifrit.Invoke(watcher)
+		})
+
+		AfterEach(func() {
+			process.Signal(os.Interrupt)
+			Eventually(process.Wait()).Should(Receive(BeNil()))
+		})
+
+		It("registers the routes of the directory before it is ready", func() {
+			Eventually(process.Ready()).Should(BeClosed())
+			Expect(registered()).To(ConsistOf("app.example.com 10.0.0.1:8080"))
+		})
+
+		It("applies the changes to the files every sync interval", func() {
+			Eventually(process.Ready()).Should(BeClosed())
+			writeFile("tools.json", `{"host": "10.0.0.2", "port": 8080, "uris": ["tools.example.com"]}`)
+
+			clock.WaitForNWatchersAndIncrement(cfg.RouteFiles.SyncInterval, 2)
+
+			Eventually(registered).Should(ContainElement("tools.example.com 10.0.0.2:8080"))
+		})
+
+		It("registers the routes again every refresh interval", func() {
+			Eventually(process.Ready()).Should(BeClosed())
+
+			clock.WaitForNWatchersAndIncrement(cfg.PruneStaleDropletsInterval/2, 2)
+
+			Eventually(registry.RegisterCallCount).Should(Equal(2))
+		})
+	})
+})
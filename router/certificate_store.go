@@ -0,0 +1,178 @@
+package router
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/logger"
+	"github.com/uber-go/zap"
+)
+
+// CertificateStore holds the certificates served on the TLS listener. The
+// listener looks them up through GetCertificate on every handshake, so Reload
+// can replace them without restarting it.
+type CertificateStore struct {
+	logger logger.Logger
+	load   func() ([]tls.Certificate, error)
+
+	lock              sync.RWMutex
+	certificates      []tls.Certificate
+	nameToCertificate map[string]*tls.Certificate
+}
+
+// CertificateInfo describes a served certificate on the status server.
+type CertificateInfo struct {
+	Subject  string    `json:"subject"`
+	SANs     []string  `json:"sans"`
+	NotAfter time.Time `json:"not_after"`
+}
+
+// NewCertificateStore serves certificates until Reload replaces them with the
+// result of load.
+func NewCertificateStore(
+	logger logger.Logger,
+	certificates []tls.Certificate,
+	load func() ([]tls.Certificate, error),
+) (*CertificateStore, error) {
+	s := &CertificateStore{
+		logger: logger,
+		load:   load,
+	}
+	if err := s.set(certificates); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload loads the certificates again and swaps them in if all of them are
+// valid. The served certificates are left untouched otherwise.
+func (s *CertificateStore) Reload() error {
+	certificates, err := s.load()
+	if err != nil {
+		s.logger.Error("certificates-reload-failed", zap.Error(err))
+		return err
+	}
+
+	if s.unchanged(certificates) {
+		s.logger.Debug("certificates-unchanged")
+		return nil
+	}
+
+	if err := s.set(certificates); err != nil {
+		s.logger.Error("certificates-reload-failed", zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("certificates-reloaded", zap.Int("count", len(certificates)))
+	return nil
+}
+
+// GetCertificate picks the certificate for the SNI of the handshake the same
+// way crypto/tls does for tls.Config.NameToCertificate: an exact match first,
+// then a wildcard match, then the first certificate.
+func (s *CertificateStore) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	name := strings.ToLower(hello.ServerName)
+	if len(s.certificates) == 1 || name == "" {
+		return &s.certificates[0], nil
+	}
+
+	if cert, ok := s.nameToCertificate[name]; ok {
+		return cert, nil
+	}
+
+	labels := strings.Split(name, ".")
+	labels[0] = "*"
+	if cert, ok := s.nameToCertificate[strings.Join(labels, ".")]; ok {
+		return cert, nil
+	}
+
+	return &s.certificates[0], nil
+}
+
+// Certificates describes the certificates currently being served.
+func (s *CertificateStore) Certificates() []CertificateInfo {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	infos := make([]CertificateInfo, 0, len(s.certificates))
+	for _, cert := range s.certificates {
+		sans := append([]string{}, cert.Leaf.DNSNames...)
+		for _, ip := range cert.Leaf.IPAddresses {
+			sans = append(sans, ip.String())
+		}
+		infos = append(infos, CertificateInfo{
+			Subject:  cert.Leaf.Subject.String(),
+			SANs:     sans,
+			NotAfter: cert.Leaf.NotAfter,
+		})
+	}
+	return infos
+}
+
+func (s *CertificateStore) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Certificates())
+}
+
+func (s *CertificateStore) set(certificates []tls.Certificate) error {
+	if len(certificates) == 0 {
+		return errors.New("no certificates")
+	}
+
+	parsed := make([]tls.Certificate, len(certificates))
+	nameToCertificate := make(map[string]*tls.Certificate)
+	for i, cert := range certificates {
+		if len(cert.Certificate) == 0 {
+			return fmt.Errorf("certificate %d is empty", i)
+		}
+
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return fmt.Errorf("certificate %d: %s", i, err)
+		}
+		cert.Leaf = leaf
+		parsed[i] = cert
+
+		if len(leaf.Subject.CommonName) > 0 {
+			nameToCertificate[strings.ToLower(leaf.Subject.CommonName)] = &parsed[i]
+		}
+		for _, san := range leaf.DNSNames {
+			nameToCertificate[strings.ToLower(san)] = &parsed[i]
+		}
+	}
+
+	s.lock.Lock()
+	s.certificates = parsed
+	s.nameToCertificate = nameToCertificate
+	s.lock.Unlock()
+	return nil
+}
+
+func (s *CertificateStore) unchanged(certificates []tls.Certificate) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if len(certificates) != len(s.certificates) {
+		return false
+	}
+	for i := range certificates {
+		if len(certificates[i].Certificate) != len(s.certificates[i].Certificate) {
+			return false
+		}
+		for j := range certificates[i].Certificate {
+			if !bytes.Equal(certificates[i].Certificate[j], s.certificates[i].Certificate[j]) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,114 @@
+package router_test
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/router"
+	"code.cloudfoundry.org/gorouter/test_util"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CertificateStore", func() {
+	var (
+		store   *router.CertificateStore
+		fooCert tls.Certificate
+		loaded  []tls.Certificate
+		loadErr error
+	)
+
+	certificate := func(names test_util.CertNames) tls.Certificate {
+		certChain := test_util.CreateSignedCertWithRootCA(names)
+		return certChain.TLSCert()
+	}
+
+	served := func(serverName string) string {
+		cert, err := store.GetCertificate(&tls.ClientHelloInfo{ServerName: serverName})
+		Expect(err).NotTo(HaveOccurred())
+		return cert.Leaf.Subject.CommonName
+	}
+
+	BeforeEach(func() {
+		fooCert = certificate(test_util.CertNames{CommonName: "foo.com"})
+		barCert := certificate(test_util.CertNames{
+			CommonName: "bar.com",
+			SANs:       test_util.SubjectAltNames{DNS: "*.bar.com", IP: "10.0.0.1"},
+		})
+		loaded = nil
+		loadErr = nil
+
+		var err error
+		store, err = router.NewCertificateStore(
+			test_util.NewTestZapLogger("certificates"),
+			[]tls.Certificate{fooCert, barCert},
+			func() ([]tls.Certificate, error) { return loaded, loadErr },
+		)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("requires at least one certificate", func() {
+		_, err := router.NewCertificateStore(test_util.NewTestZapLogger("certificates"), nil, nil)
+		Expect(err).To(HaveOccurred())
+	})
+
+	Describe("GetCertificate", func() {
+		It("selects the certificate by server name", func() {
+			Expect(served("foo.com")).To(Equal("foo.com"))
+			Expect(served("BAR.com")).To(Equal("bar.com"))
+			Expect(served("www.bar.com")).To(Equal("bar.com"))
+		})
+
+		It("falls back to the first certificate", func() {
+			Expect(served("")).To(Equal("foo.com"))
+			Expect(served("unknown.com")).To(Equal("foo.com"))
+		})
+	})
+
+	Describe("Reload", func() {
+		It("swaps in the loaded certificates", func() {
+			loaded = []tls.Certificate{certificate(test_util.CertNames{CommonName: "baz.com"})}
+
+			Expect(store.Reload()).To(Succeed())
+			Expect(served("foo.com")).To(Equal("baz.com"))
+			Expect(served("bar.com")).To(Equal("baz.com"))
+		})
+
+		It("keeps serving the certificates when loading fails", func() {
+			loadErr = errors.New("boom")
+
+			Expect(store.Reload()).To(MatchError("boom"))
+			Expect(served("foo.com")).To(Equal("foo.com"))
+			Expect(served("bar.com")).To(Equal("bar.com"))
+		})
+
+		It("keeps serving the certificates when no certificate is loaded", func() {
+			Expect(store.Reload()).To(HaveOccurred())
+			Expect(served("bar.com")).To(Equal("bar.com"))
+		})
+
+		It("keeps serving the certificates when a loaded certificate is invalid", func() {
+			loaded = []tls.Certificate{fooCert, {Certificate: [][]byte{[]byte("garbage")}}}
+
+			Expect(store.Reload()).To(HaveOccurred())
+			Expect(served("bar.com")).To(Equal("bar.com"))
+		})
+	})
+
+	Describe("MarshalJSON", func() {
+		It("describes the served certificates", func() {
+			b, err := json.Marshal(store)
+			Expect(err).NotTo(HaveOccurred())
+
+			var infos []router.CertificateInfo
+			Expect(json.Unmarshal(b, &infos)).To(Succeed())
+			Expect(infos).To(HaveLen(2))
+			Expect(infos[0].Subject).To(ContainSubstring("CN=foo.com"))
+			Expect(infos[1].SANs).To(ConsistOf("*.bar.com", "10.0.0.1"))
+			Expect(infos[1].NotAfter).To(BeTemporally("~", time.Now().Add(time.Hour), time.Minute))
+		})
+	})
+})
@@ -23,6 +23,7 @@ import (
 	"code.cloudfoundry.org/gorouter/logger"
 	"code.cloudfoundry.org/gorouter/metrics/monitor"
 	"code.cloudfoundry.org/gorouter/registry"
+	"code.cloudfoundry.org/gorouter/route"
 	"code.cloudfoundry.org/gorouter/varz"
 	"github.com/armon/go-proxyproto"
 	"github.com/nats-io/nats.go"
@@ -43,16 +44,29 @@ type rss interface {
 	Serve(handler http.Handler, errChan chan error) error
 	Stop()
 }
+
+// TCPHandler serves the connections accepted on the TCP routing ports and the
+// TLS connections for passthrough routes.
+type TCPHandler interface {
+	ServeTCP(conn net.Conn, port uint16)
+	ServeTLSPassthrough(conn net.Conn, serverName string)
+}
+
 type Router struct {
 	config     *config.Config
 	handler    http.Handler
+	tcpHandler TCPHandler
 	mbusClient *nats.Conn
 	registry   *registry.RouteRegistry
 	varz       varz.Varz
 	component  *common.VcapComponent
 
+	certificates        *CertificateStore
+	stopReload          chan struct{}
+	stopReloadOnce      sync.Once
 	listener            net.Listener
 	tlsListener         net.Listener
+	tcpListeners        []net.Listener
 	closeConnections    bool
 	connLock            sync.Mutex
 	idleConns           map[net.Conn]struct{}
@@ -60,6 +74,7 @@ type Router struct {
 	drainDone           chan struct{}
 	serveDone           chan struct{}
 	tlsServeDone        chan struct{}
+	tcpServeDone        sync.WaitGroup
 	stopping            bool
 	stopLock            sync.Mutex
 	uptimeMonitor       *monitor.Uptime
@@ -73,6 +88,7 @@ func NewRouter(
 	logger logger.Logger,
 	cfg *config.Config,
 	handler http.Handler,
+	tcpHandler TCPHandler,
 	mbusClient *nats.Conn,
 	r *registry.RouteRegistry,
 	v varz.Varz,
@@ -108,6 +124,16 @@ func NewRouter(
 		Logger: logger,
 	}
 
+	var certificates *CertificateStore
+	if cfg.EnableSSL {
+		var err error
+		certificates, err = NewCertificateStore(logger.Session("certificates"), cfg.SSLCertificates, cfg.LoadSSLCertificates)
+		if err != nil {
+			return nil, err
+		}
+		component.InfoRoutes["/certificates"] = certificates
+	}
+
 	routerErrChan := errChan
 	if routerErrChan == nil {
 		routerErrChan = make(chan error, 3)
@@ -116,10 +142,13 @@ func NewRouter(
 	router := &Router{
 		config:              cfg,
 		handler:             handler,
+		tcpHandler:          tcpHandler,
 		mbusClient:          mbusClient,
 		registry:            r,
 		varz:                v,
 		component:           component,
+		certificates:        certificates,
+		stopReload:          make(chan struct{}),
 		serveDone:           make(chan struct{}),
 		tlsServeDone:        make(chan struct{}),
 		idleConns:           make(map[net.Conn]struct{}),
@@ -147,8 +176,14 @@ func (r *Router) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
 	// Schedule flushing active app's app_id
 	r.ScheduleFlushApps()
 
-	r.logger.Debug("Sleeping before returning success on /health endpoint to preload routing table", zap.Float64("sleep_time_seconds", r.config.StartResponseDelayInterval.Seconds()))
-	time.Sleep(r.config.StartResponseDelayInterval)
+	r.ScheduleCertificateReload()
+
+	if r.registry.SnapshotLoaded() {
+		r.logger.Debug("Not sleeping before returning success on /health endpoint, routing table loaded from snapshot")
+	} else {
+		r.logger.Debug("Sleeping before returning success on /health endpoint to preload routing table", zap.Float64("sleep_time_seconds", r.config.StartResponseDelayInterval.Seconds()))
+		time.Sleep(r.config.StartResponseDelayInterval)
+	}
 
 	server := &http.Server{
 		Handler:     r.handler,
@@ -166,6 +201,11 @@ func (r *Router) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
 		r.errChan <- err
 		return err
 	}
+	err = r.serveTCP(r.errChan)
+	if err != nil {
+		r.errChan <- err
+		return err
+	}
 	err = r.routeServicesServer.Serve(r.handler, r.errChan)
 	if err != nil {
 		r.errChan <- err
@@ -228,15 +268,17 @@ func (r *Router) serveHTTPS(server *http.Server, errChan chan error) error {
 	}
 
 	tlsConfig := &tls.Config{
-		Certificates: r.config.SSLCertificates,
-		CipherSuites: r.config.CipherSuites,
-		MinVersion:   r.config.MinTLSVersion,
-		MaxVersion:   r.config.MaxTLSVersion,
-		ClientCAs:    r.config.ClientCAPool,
-		ClientAuth:   r.config.ClientCertificateValidation,
+		GetCertificate: r.certificates.GetCertificate,
+		CipherSuites:   r.config.CipherSuites,
+		MinVersion:     r.config.MinTLSVersion,
+		MaxVersion:     r.config.MaxTLSVersion,
+		ClientCAs:      r.config.ClientCAPool,
+		ClientAuth:     r.config.ClientCertificateValidation,
 	}
 
-	tlsConfig.BuildNameToCertificate()
+	if r.config.EnableHTTP2 {
+		r.advertiseHTTP2(tlsConfig)
+	}
 
 	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", r.config.SSLPort))
 	if err != nil {
@@ -251,6 +293,10 @@ func (r *Router) serveHTTPS(server *http.Server, errChan chan error) error {
 		}
 	}
 
+	if r.config.EnableTLSPassthrough {
+		listener = newPassthroughListener(listener, r.isTLSPassthrough, r.serveTLSPassthrough, r.logger)
+	}
+
 	r.tlsListener = tls.NewListener(listener, tlsConfig)
 
 	r.logger.Info("tls-listener-started", zap.Object("address", r.tlsListener.Addr()))
@@ -267,6 +313,53 @@ func (r *Router) serveHTTPS(server *http.Server, errChan chan error) error {
 	return nil
 }
 
+var http2NextProtos = []string{"h2", "http/1.1"}
+
+// advertiseHTTP2 advertises h2 on the TLS listener to the clients that
+// negotiate a TLS version and cipher suite HTTP/2 can be used with.
+func (r *Router) advertiseHTTP2(tlsConfig *tls.Config) {
+	if supportsHTTP2(tlsConfig.MinVersion, tlsConfig.CipherSuites) {
+		tlsConfig.NextProtos = http2NextProtos
+		return
+	}
+
+	if tlsConfig.MaxVersion < tls.VersionTLS13 {
+		r.logger.Info("http2-not-advertised", zap.String("reason", "no HTTP/2 compatible cipher suite configured"))
+		return
+	}
+
+	// every TLS 1.3 cipher suite is HTTP/2 compatible, so h2 is still
+	// advertised to the clients that negotiate TLS 1.3
+	r.logger.Info("http2-advertised-over-tls13-only", zap.String("reason", "no HTTP/2 compatible TLS 1.2 cipher suite configured"))
+	http2Config := tlsConfig.Clone()
+	http2Config.NextProtos = http2NextProtos
+	tlsConfig.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		for _, v := range hello.SupportedVersions {
+			if v >= tls.VersionTLS13 {
+				return http2Config, nil
+			}
+		}
+		return nil, nil
+	}
+}
+
+// supportsHTTP2 reports whether HTTP/2 can be used with every connection of a
+// TLS listener: either the connections use TLS 1.3, whose cipher suites are
+// all HTTP/2 compatible, or the configured cipher suites include one of the
+// suites that RFC 7540 requires of a TLS 1.2 HTTP/2 server. Negotiating h2
+// without one of them makes clients abort with INADEQUATE_SECURITY.
+func supportsHTTP2(minVersion uint16, cipherSuites []uint16) bool {
+	if minVersion >= tls.VersionTLS13 || len(cipherSuites) == 0 {
+		return true
+	}
+	for _, c := range cipherSuites {
+		if c == tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 || c == tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256 {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *Router) serveHTTP(server *http.Server, errChan chan error) error {
 	if r.config.DisableHTTP {
 		r.logger.Info("tcp-listener-disabled")
@@ -302,6 +395,83 @@ func (r *Router) serveHTTP(server *http.Server, errChan chan error) error {
 	return nil
 }
 
+func (r *Router) serveTCP(errChan chan error) error {
+	for _, port := range r.config.TCPRouting.Ports {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+		if err != nil {
+			r.logger.Fatal("tcp-routing-listener-error", zap.Error(err))
+			return err
+		}
+
+		if r.config.EnablePROXY {
+			listener = &proxyproto.Listener{
+				Listener:           listener,
+				ProxyHeaderTimeout: proxyProtocolHeaderTimeout,
+			}
+		}
+
+		r.tcpListeners = append(r.tcpListeners, listener)
+		r.logger.Info("tcp-routing-listener-started", zap.Object("address", listener.Addr()))
+
+		r.tcpServeDone.Add(1)
+		go r.acceptTCP(listener, port, errChan)
+	}
+	return nil
+}
+
+func (r *Router) acceptTCP(listener net.Listener, port uint16, errChan chan error) {
+	defer r.tcpServeDone.Done()
+
+	var tempDelay time.Duration
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			// back off on temporary errors the same way http.Server does
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				if tempDelay == 0 {
+					tempDelay = 5 * time.Millisecond
+				} else if tempDelay *= 2; tempDelay > time.Second {
+					tempDelay = time.Second
+				}
+				r.logger.Error("tcp-routing-accept-error", zap.Error(err))
+				time.Sleep(tempDelay)
+				continue
+			}
+
+			r.stopLock.Lock()
+			if !r.stopping {
+				errChan <- err
+			}
+			r.stopLock.Unlock()
+			return
+		}
+		tempDelay = 0
+
+		go r.serveTCPConn(conn, port)
+	}
+}
+
+// serveTCPConn tracks TCP sessions as active connections so that draining
+// waits for them like it does for in-flight HTTP requests
+func (r *Router) serveTCPConn(conn net.Conn, port uint16) {
+	r.HandleConnState(conn, http.StateActive)
+	defer r.HandleConnState(conn, http.StateClosed)
+
+	r.tcpHandler.ServeTCP(conn, port)
+}
+
+func (r *Router) isTLSPassthrough(serverName string) bool {
+	pool := r.registry.Lookup(route.Uri(serverName))
+	return pool != nil && pool.IsTLSPassthrough()
+}
+
+func (r *Router) serveTLSPassthrough(conn net.Conn, serverName string) {
+	r.HandleConnState(conn, http.StateActive)
+	defer r.HandleConnState(conn, http.StateClosed)
+
+	r.tcpHandler.ServeTLSPassthrough(conn, serverName)
+}
+
 func (r *Router) Drain(drainWait, drainTimeout time.Duration) error {
 	<-time.After(drainWait)
 
@@ -339,6 +509,7 @@ func (r *Router) Stop() {
 	r.logger.Info("gorouter.stopping")
 
 	r.stopListening()
+	r.stopReloadOnce.Do(func() { close(r.stopReload) })
 
 	r.connLock.Lock()
 	r.closeIdleConns()
@@ -376,6 +547,11 @@ func (r *Router) stopListening() {
 		<-r.tlsServeDone
 	}
 
+	for _, listener := range r.tcpListeners {
+		listener.Close()
+	}
+	r.tcpServeDone.Wait()
+
 	r.routeServicesServer.Stop()
 }
 
@@ -403,6 +579,36 @@ func (r *Router) ScheduleFlushApps() {
 	}()
 }
 
+// ReloadCertificates replaces the certificates served on the TLS listener with
+// the configured ones. The served certificates are kept when the configured
+// ones cannot be loaded.
+func (r *Router) ReloadCertificates() error {
+	if r.certificates == nil {
+		return nil
+	}
+	return r.certificates.Reload()
+}
+
+func (r *Router) ScheduleCertificateReload() {
+	if r.certificates == nil || r.config.TLSCertificateReloadInterval == 0 {
+		return
+	}
+
+	go func() {
+		t := time.NewTicker(r.config.TLSCertificateReloadInterval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-t.C:
+				_ = r.ReloadCertificates()
+			case <-r.stopReload:
+				return
+			}
+		}
+	}()
+}
+
 func (r *Router) HandleConnState(conn net.Conn, state http.ConnState) {
 	endpointTimeout := r.config.EndpointTimeout
 
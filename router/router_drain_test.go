@@ -186,12 +186,12 @@ var _ = Describe("Router", func() {
 
 		rt := &sharedfakes.RoundTripper{}
 		p = proxy.NewProxy(logger, &accesslog.NullAccessLogger{}, ew, config, registry, combinedReporter,
-			&routeservice.RouteServiceConfig{}, &tls.Config{}, &tls.Config{}, healthStatus, rt)
+			&routeservice.RouteServiceConfig{}, &tls.Config{}, &tls.Config{}, healthStatus, rt, nil)
 
 		errChan := make(chan error, 2)
 		var err error
 		rss := &sharedfakes.RouteServicesServer{}
-		rtr, err = router.NewRouter(logger, config, p, mbusClient, registry, varz, healthStatus, logcounter, errChan, rss)
+		rtr, err = router.NewRouter(logger, config, p, nil, mbusClient, registry, varz, healthStatus, logcounter, errChan, rss)
 		Expect(err).ToNot(HaveOccurred())
 
 		config.Index = 4321
@@ -418,12 +418,12 @@ var _ = Describe("Router", func() {
 				config.HealthCheckUserAgent = "HTTP-Monitor/1.1"
 				rt := &sharedfakes.RoundTripper{}
 				p := proxy.NewProxy(logger, &accesslog.NullAccessLogger{}, ew, config, registry, combinedReporter,
-					&routeservice.RouteServiceConfig{}, &tls.Config{}, &tls.Config{}, h, rt)
+					&routeservice.RouteServiceConfig{}, &tls.Config{}, &tls.Config{}, h, rt, nil)
 
 				errChan = make(chan error, 2)
 				var err error
 				rss := &sharedfakes.RouteServicesServer{}
-				rtr2, err = router.NewRouter(logger, config, p, mbusClient, registry, varz, h, logcounter, errChan, rss)
+				rtr2, err = router.NewRouter(logger, config, p, nil, mbusClient, registry, varz, h, logcounter, errChan, rss)
 				Expect(err).ToNot(HaveOccurred())
 				runRouter(rtr2)
 			})
@@ -20,6 +20,7 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"os"
+	"path/filepath"
 	"syscall"
 	"time"
 
@@ -239,6 +240,38 @@ var _ = Describe("Router", func() {
 		})
 	})
 
+	Context("when the routing table was loaded from a snapshot", func() {
+		It("is ready without waiting for StartResponseDelayInterval", func() {
+			dir, err := ioutil.TempDir("", "routing-table-snapshot")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(dir)
+			snapshot := filepath.Join(dir, "routes.json")
+
+			registry.Register("app."+test_util.LocalhostDNS, route.NewEndpoint(&route.EndpointOpts{Host: "10.0.0.1", Port: 8080}))
+			Expect(registry.SaveSnapshot(snapshot)).To(Succeed())
+
+			natsPort := test_util.NextAvailPort()
+			proxyPort := test_util.NextAvailPort()
+			statusPort = test_util.NextAvailPort()
+			c := test_util.SpecConfig(statusPort, proxyPort, natsPort)
+			c.StartResponseDelayInterval = 10 * time.Second
+
+			restarted := rregistry.NewRouteRegistry(logger, c, fakeReporter)
+			Expect(restarted.LoadSnapshot(snapshot)).To(Succeed())
+
+			rtr, err := initializeRouter(c, c.EndpointTimeout, c.EndpointTimeout, restarted, varz, mbusClient, logger, routeServicesServer)
+			Expect(err).ToNot(HaveOccurred())
+
+			signals := make(chan os.Signal)
+			readyChan := make(chan struct{})
+			go rtr.Run(signals, readyChan)
+
+			Eventually(readyChan, "3s").Should(BeClosed())
+			Expect(logger).To(gbytes.Say("Not sleeping before returning success on /health endpoint, routing table loaded from snapshot"))
+			signals <- syscall.SIGUSR1
+		})
+	})
+
 	It("registry contains last updated varz", func() {
 		app1 := test.NewGreetApp([]route.Uri{"test1." + test_util.LocalhostDNS}, config.Port, mbusClient, nil)
 		app1.RegisterAndListen()
@@ -1008,6 +1041,213 @@ var _ = Describe("Router", func() {
 		})
 	})
 
+	Describe("tcp routing", func() {
+		var (
+			tcpPort uint16
+			backend net.Listener
+		)
+
+		BeforeEach(func() {
+			tcpPort = test_util.NextAvailPort()
+			config.TCPRouting.Ports = []uint16{tcpPort}
+
+			backend, err = net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			go func() {
+				conn, err := backend.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+
+			test_util.RegisterAddr(registry, string(route.TCPUri(tcpPort)), backend.Addr().String(), test_util.RegisterConfig{})
+		})
+
+		AfterEach(func() {
+			backend.Close()
+		})
+
+		It("forwards connections on the tcp routing port to the registered backend", func() {
+			conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", tcpPort))
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+
+			_, err = conn.Write([]byte("ping"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(conn.(*net.TCPConn).CloseWrite()).To(Succeed())
+
+			b, err := ioutil.ReadAll(conn)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal("ping"))
+		})
+
+		It("stops listening on the tcp routing port when the router stops", func() {
+			router.Stop()
+			router = nil
+
+			_, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", tcpPort))
+			Expect(err).To(MatchError(ContainSubstring("connection refused")))
+		})
+	})
+
+	Describe("certificate reload", func() {
+		var certDir string
+
+		writeCert := func(commonName string) {
+			keyPEM, certPEM := test_util.CreateKeyPair(commonName)
+			Expect(ioutil.WriteFile(config.TLSPEM[0].CertChainPath, certPEM, 0600)).To(Succeed())
+			Expect(ioutil.WriteFile(config.TLSPEM[0].PrivateKeyPath, keyPEM, 0600)).To(Succeed())
+		}
+
+		servedCommonName := func() string {
+			conn, err := tls.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", config.SSLPort), &tls.Config{
+				InsecureSkipVerify: true,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+			return conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+		}
+
+		BeforeEach(func() {
+			certDir, err = ioutil.TempDir("", "certs")
+			Expect(err).NotTo(HaveOccurred())
+
+			config.TLSPEM = []cfg.TLSPem{{
+				CertChainPath:  filepath.Join(certDir, "cert.pem"),
+				PrivateKeyPath: filepath.Join(certDir, "key.pem"),
+			}}
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(certDir)
+		})
+
+		It("serves the reloaded certificates without restarting the listener", func() {
+			Expect(servedCommonName()).To(Equal("default"))
+
+			writeCert("rotated")
+			Expect(router.ReloadCertificates()).To(Succeed())
+
+			Expect(servedCommonName()).To(Equal("rotated"))
+		})
+
+		It("keeps serving the certificates when the reloaded ones are invalid", func() {
+			Expect(ioutil.WriteFile(config.TLSPEM[0].CertChainPath, []byte("garbage"), 0600)).To(Succeed())
+			Expect(ioutil.WriteFile(config.TLSPEM[0].PrivateKeyPath, []byte("garbage"), 0600)).To(Succeed())
+			Expect(router.ReloadCertificates()).NotTo(Succeed())
+
+			Expect(servedCommonName()).To(Equal("default"))
+		})
+
+		It("describes the served certificates on the status server", func() {
+			uri := fmt.Sprintf("http://%s:%s@localhost:%d/certificates", config.Status.User, config.Status.Pass, config.Status.Port)
+			resp, err := http.Get(uri)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			var infos []CertificateInfo
+			Expect(json.NewDecoder(resp.Body).Decode(&infos)).To(Succeed())
+			Expect(infos).To(HaveLen(1))
+			Expect(infos[0].Subject).To(ContainSubstring("CN=default"))
+		})
+
+		Context("when a reload interval is configured", func() {
+			BeforeEach(func() {
+				config.TLSCertificateReloadInterval = 50 * time.Millisecond
+			})
+
+			It("reloads the certificates periodically", func() {
+				writeCert("rotated")
+				Eventually(servedCommonName).Should(Equal("rotated"))
+			})
+
+			It("stops reloading the certificates when the router stops", func() {
+				router.Stop()
+				router = nil
+				writeCert("rotated")
+
+				Consistently(logger, 200*time.Millisecond).ShouldNot(gbytes.Say("certificates-reloaded"))
+			})
+		})
+	})
+
+	Describe("tls passthrough", func() {
+		var (
+			backend    net.Listener
+			serverName string
+		)
+
+		BeforeEach(func() {
+			config.EnableTLSPassthrough = true
+			serverName = "passthrough." + test_util.LocalhostDNS
+
+			certChain := test_util.CreateSignedCertWithRootCA(test_util.CertNames{CommonName: "passthrough-backend"})
+			backend, err = tls.Listen("tcp", "127.0.0.1:0", certChain.AsTLSConfig())
+			Expect(err).NotTo(HaveOccurred())
+			go func() {
+				conn, err := backend.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+
+			test_util.RegisterAddr(registry, serverName, backend.Addr().String(), test_util.RegisterConfig{TLSPassthrough: true})
+		})
+
+		AfterEach(func() {
+			backend.Close()
+		})
+
+		dialSSLPort := func(serverName string) *tls.Conn {
+			conn, err := tls.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", config.SSLPort), &tls.Config{
+				InsecureSkipVerify: true,
+				ServerName:         serverName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			return conn
+		}
+
+		It("forwards tls connections for the route to the backend without terminating them", func() {
+			conn := dialSSLPort(serverName)
+			defer conn.Close()
+
+			Expect(conn.ConnectionState().PeerCertificates[0].Subject.CommonName).To(Equal("passthrough-backend"))
+
+			_, err := conn.Write([]byte("ping"))
+			Expect(err).NotTo(HaveOccurred())
+
+			b := make([]byte, 4)
+			_, err = io.ReadFull(conn, b)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal("ping"))
+		})
+
+		It("terminates tls connections for other routes", func() {
+			conn := dialSSLPort("test." + test_util.LocalhostDNS)
+			defer conn.Close()
+
+			Expect(conn.ConnectionState().PeerCertificates[0].Subject.CommonName).To(Equal("default"))
+		})
+
+		Context("when tls passthrough is disabled", func() {
+			BeforeEach(func() {
+				config.EnableTLSPassthrough = false
+			})
+
+			It("terminates tls connections for the route", func() {
+				conn := dialSSLPort(serverName)
+				defer conn.Close()
+
+				Expect(conn.ConnectionState().PeerCertificates[0].Subject.CommonName).To(Equal("default"))
+			})
+		})
+	})
+
 	Context("when DisableHTTP is true", func() {
 		BeforeEach(func() {
 			config.DisableHTTP = true
@@ -1434,6 +1674,114 @@ var _ = Describe("Router", func() {
 			resp.Body.Close()
 		})
 
+		Context("when HTTP/2 is enabled", func() {
+			BeforeEach(func() {
+				config.EnableHTTP2 = true
+				config.CipherSuites = []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}
+				client.Transport.(*http.Transport).ForceAttemptHTTP2 = true
+			})
+
+			It("negotiates h2 and proxies to the HTTP/1.1 backend", func() {
+				app := test.NewGreetApp([]route.Uri{"test." + test_util.LocalhostDNS}, config.Port, mbusClient, nil)
+				app.RegisterAndListen()
+				Eventually(func() bool {
+					return appRegistered(registry, app)
+				}).Should(BeTrue())
+
+				uri := fmt.Sprintf("https://test.%s:%d/", test_util.LocalhostDNS, config.SSLPort)
+				req, _ := http.NewRequest("GET", uri, nil)
+
+				resp, err := client.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+				defer resp.Body.Close()
+
+				Expect(resp.Proto).To(Equal("HTTP/2.0"))
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				bytes, err := ioutil.ReadAll(resp.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(bytes).To(ContainSubstring("Hello"))
+			})
+
+			Context("when no HTTP/2 compatible cipher suite is configured", func() {
+				BeforeEach(func() {
+					config.CipherSuites = []uint16{tls.TLS_RSA_WITH_AES_256_CBC_SHA}
+				})
+
+				It("does not advertise h2", func() {
+					conn, err := tls.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", config.SSLPort), &tls.Config{
+						InsecureSkipVerify: true,
+						NextProtos:         []string{"h2", "http/1.1"},
+						MaxVersion:         tls.VersionTLS12,
+						CipherSuites:       config.CipherSuites,
+					})
+					Expect(err).ToNot(HaveOccurred())
+					defer conn.Close()
+
+					Expect(conn.ConnectionState().NegotiatedProtocol).ToNot(Equal("h2"))
+				})
+
+				It("logs that h2 is not advertised", func() {
+					Expect(logger).To(gbytes.Say("http2-not-advertised"))
+				})
+
+				Context("when TLS 1.3 is allowed", func() {
+					BeforeEach(func() {
+						config.MaxTLSVersion = tls.VersionTLS13
+					})
+
+					It("advertises h2 to the clients that negotiate TLS 1.3 only", func() {
+						Expect(logger).To(gbytes.Say("http2-advertised-over-tls13-only"))
+
+						conn, err := tls.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", config.SSLPort), &tls.Config{
+							InsecureSkipVerify: true,
+							NextProtos:         []string{"h2", "http/1.1"},
+							MinVersion:         tls.VersionTLS13,
+						})
+						Expect(err).ToNot(HaveOccurred())
+						defer conn.Close()
+						Expect(conn.ConnectionState().NegotiatedProtocol).To(Equal("h2"))
+
+						conn12, err := tls.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", config.SSLPort), &tls.Config{
+							InsecureSkipVerify: true,
+							NextProtos:         []string{"h2", "http/1.1"},
+							MaxVersion:         tls.VersionTLS12,
+							CipherSuites:       config.CipherSuites,
+						})
+						Expect(err).ToNot(HaveOccurred())
+						defer conn12.Close()
+						Expect(conn12.ConnectionState().NegotiatedProtocol).ToNot(Equal("h2"))
+					})
+				})
+			})
+		})
+
+		Context("when HTTP/2 is disabled", func() {
+			BeforeEach(func() {
+				config.EnableHTTP2 = false
+				config.CipherSuites = []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}
+				client.Transport.(*http.Transport).ForceAttemptHTTP2 = true
+			})
+
+			It("serves HTTP/1.1 to clients that offer h2", func() {
+				app := test.NewGreetApp([]route.Uri{"test." + test_util.LocalhostDNS}, config.Port, mbusClient, nil)
+				app.RegisterAndListen()
+				Eventually(func() bool {
+					return appRegistered(registry, app)
+				}).Should(BeTrue())
+
+				uri := fmt.Sprintf("https://test.%s:%d/", test_util.LocalhostDNS, config.SSLPort)
+				req, _ := http.NewRequest("GET", uri, nil)
+
+				resp, err := client.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+				defer resp.Body.Close()
+
+				Expect(resp.Proto).To(Equal("HTTP/1.1"))
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			})
+		})
+
 		Context("when a ca cert is provided", func() {
 			BeforeEach(func() {
 				config.CACerts = string(cert)
@@ -2003,12 +2351,13 @@ func initializeRouter(config *cfg.Config, backendIdleTimeout, requestTimeout tim
 	proxyConfig.EndpointTimeout = requestTimeout
 	routeServicesTransport := &sharedfakes.RoundTripper{}
 	p := proxy.NewProxy(logger, &accesslog.NullAccessLogger{}, ew, &proxyConfig, registry, combinedReporter,
-		routeServiceConfig, &tls.Config{}, &tls.Config{}, &health.Health{}, routeServicesTransport)
+		routeServiceConfig, &tls.Config{}, &tls.Config{}, &health.Health{}, routeServicesTransport, nil)
+	tcpProxy := proxy.NewTCPProxy(logger, &accesslog.NullAccessLogger{}, &proxyConfig, registry, combinedReporter, &tls.Config{})
 
 	h := &health.Health{}
 	logcounter := schema.NewLogCounter()
 	config.EndpointTimeout = backendIdleTimeout
-	router, e := NewRouter(logger, config, p, mbusClient, registry, varz, h, logcounter, nil, routeServicesServer)
+	router, e := NewRouter(logger, config, p, tcpProxy, mbusClient, registry, varz, h, logcounter, nil, routeServicesServer)
 
 	h.OnDegrade = router.DrainAndStop
 
@@ -0,0 +1,179 @@
+package router
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/logger"
+	"github.com/uber-go/zap"
+)
+
+const clientHelloTimeout = 5 * time.Second
+
+var errClientHelloRead = errors.New("client hello read")
+
+// passthroughListener reads the ClientHello of every connection accepted by
+// the wrapped listener. Connections whose SNI names a passthrough route are
+// handed to passthrough without TLS being terminated, all others are returned
+// from Accept with the ClientHello replayed so that they can be served by a
+// tls.Listener.
+//
+// Reading the ClientHello happens on a goroutine per connection so that a slow
+// client cannot hold up the accept loop.
+type passthroughListener struct {
+	net.Listener
+	isPassthrough func(serverName string) bool
+	passthrough   func(conn net.Conn, serverName string)
+	logger        logger.Logger
+
+	conns     chan net.Conn
+	closed    chan struct{}
+	closeOnce sync.Once
+	err       error
+}
+
+func newPassthroughListener(
+	listener net.Listener,
+	isPassthrough func(serverName string) bool,
+	passthrough func(conn net.Conn, serverName string),
+	logger logger.Logger,
+) *passthroughListener {
+	l := &passthroughListener{
+		Listener:      listener,
+		isPassthrough: isPassthrough,
+		passthrough:   passthrough,
+		logger:        logger,
+		conns:         make(chan net.Conn),
+		closed:        make(chan struct{}),
+	}
+	go l.acceptLoop()
+	return l
+}
+
+func (l *passthroughListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, l.err
+	}
+}
+
+func (l *passthroughListener) Close() error {
+	err := l.Listener.Close()
+	l.shutdown(err)
+	return err
+}
+
+func (l *passthroughListener) shutdown(err error) {
+	l.closeOnce.Do(func() {
+		l.err = err
+		close(l.closed)
+	})
+}
+
+func (l *passthroughListener) acceptLoop() {
+	var tempDelay time.Duration
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				if tempDelay == 0 {
+					tempDelay = 5 * time.Millisecond
+				} else if tempDelay *= 2; tempDelay > time.Second {
+					tempDelay = time.Second
+				}
+				time.Sleep(tempDelay)
+				continue
+			}
+			l.shutdown(err)
+			return
+		}
+		tempDelay = 0
+
+		go l.route(conn)
+	}
+}
+
+func (l *passthroughListener) route(conn net.Conn) {
+	_ = conn.SetReadDeadline(time.Now().Add(clientHelloTimeout))
+	serverName, peeked, err := peekServerName(conn)
+	_ = conn.SetReadDeadline(noDeadline)
+
+	replayConn := &peekedConn{Conn: conn, reader: io.MultiReader(bytes.NewReader(peeked), conn)}
+
+	if err == nil && serverName != "" && l.isPassthrough(serverName) {
+		l.logger.Debug("tls-passthrough", zap.String("server_name", serverName))
+		l.passthrough(replayConn, serverName)
+		return
+	}
+
+	// connections that are not a readable TLS handshake are left for the TLS
+	// listener to reject
+	select {
+	case l.conns <- replayConn:
+	case <-l.closed:
+		conn.Close()
+	}
+}
+
+// peekServerName reads the ClientHello from r and returns its SNI together
+// with all the bytes read, which have to be replayed to whoever serves the
+// connection.
+func peekServerName(r io.Reader) (string, []byte, error) {
+	peeked := new(bytes.Buffer)
+	var serverName string
+	var helloRead bool
+
+	err := tls.Server(readOnlyConn{reader: io.TeeReader(r, peeked)}, &tls.Config{
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			serverName = hello.ServerName
+			helloRead = true
+			return nil, errClientHelloRead
+		},
+	}).Handshake()
+
+	if !helloRead {
+		return "", peeked.Bytes(), err
+	}
+	return serverName, peeked.Bytes(), nil
+}
+
+// readOnlyConn lets tls.Server parse a ClientHello without being able to
+// answer it
+type readOnlyConn struct {
+	reader io.Reader
+}
+
+func (c readOnlyConn) Read(p []byte) (int, error)         { return c.reader.Read(p) }
+func (c readOnlyConn) Write(p []byte) (int, error)        { return 0, io.ErrClosedPipe }
+func (c readOnlyConn) Close() error                       { return nil }
+func (c readOnlyConn) LocalAddr() net.Addr                { return nil }
+func (c readOnlyConn) RemoteAddr() net.Addr               { return nil }
+func (c readOnlyConn) SetDeadline(t time.Time) error      { return nil }
+func (c readOnlyConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c readOnlyConn) SetWriteDeadline(t time.Time) error { return nil }
+
+// peekedConn replays the bytes read while peeking before reading from the
+// connection again
+type peekedConn struct {
+	net.Conn
+	reader io.Reader
+}
+
+func (c *peekedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+// CloseWrite lets the TCP proxy half-close passthrough connections
+func (c *peekedConn) CloseWrite() error {
+	if cw, ok := c.Conn.(interface{ CloseWrite() error }); ok {
+		return cw.CloseWrite()
+	}
+	return c.Conn.Close()
+}
@@ -45,6 +45,9 @@ func RegisterAddr(reg *registry.RouteRegistry, path string, addr string, cfg Reg
 			StaleThresholdInSeconds: cfg.StaleThreshold,
 			RouteServiceUrl:         cfg.RouteServiceUrl,
 			UseTLS:                  cfg.TLSConfig != nil,
+			Protocol:                cfg.Protocol,
+			TLSPassthrough:          cfg.TLSPassthrough,
+			TrafficMatch:            cfg.TrafficMatch,
 		}),
 	)
 }
@@ -89,6 +92,9 @@ type RegisterConfig struct {
 	StaleThreshold      int
 	TLSConfig           *tls.Config
 	IgnoreTLSConfig     bool
+	Protocol            string
+	TLSPassthrough      bool
+	TrafficMatch        route.TrafficMatch
 }
 
 func runBackendInstance(ln net.Listener, handler connHandler) {
@@ -0,0 +1,321 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/uber-go/zap"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/logger"
+)
+
+const (
+	defaultBatchTimeout = 5 * time.Second
+	maxQueueSize        = 2048
+	maxBatchSize        = 512
+	exportTimeout       = 10 * time.Second
+)
+
+// Exporter sends spans to an OpenTelemetry collector with OTLP/HTTP, encoded
+// as JSON.
+type Exporter struct {
+	url      string
+	headers  map[string]string
+	client   *http.Client
+	resource []Attribute
+}
+
+func NewExporter(c config.OpenTelemetryConfig, tlsConfig *tls.Config, resource []Attribute) (*Exporter, error) {
+	if c.Endpoint == "" {
+		return nil, fmt.Errorf("no collector endpoint")
+	}
+
+	scheme := "https"
+	transport := &http.Transport{TLSClientConfig: tlsConfig}
+	if c.Insecure {
+		scheme = "http"
+		transport.TLSClientConfig = nil
+	}
+
+	return &Exporter{
+		url:      scheme + "://" + c.Endpoint + c.URLPath,
+		headers:  c.Headers,
+		client:   &http.Client{Transport: transport, Timeout: exportTimeout},
+		resource: resource,
+	}, nil
+}
+
+// Export sends spans in a single request.
+func (e *Exporter) Export(ctx context.Context, spans []*Span) error {
+	body, err := json.Marshal(newExportTraceRequest(e.resource, spans))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("collector responded with %s", res.Status)
+	}
+	return nil
+}
+
+// The OTLP/JSON encoding of an ExportTraceServiceRequest. IDs are hex
+// strings and 64 bit integers are decimal strings.
+type exportTraceRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   otlpResource `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type scopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              SpanKind        `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Events            []otlpEvent     `json:"events,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano string          `json:"timeUnixNano"`
+	Name         string          `json:"name"`
+	Attributes   []otlpAttribute `json:"attributes,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    StatusCode `json:"code,omitempty"`
+	Message string     `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
+
+func newExportTraceRequest(resource []Attribute, spans []*Span) exportTraceRequest {
+	otlpSpans := make([]otlpSpan, 0, len(spans))
+	for _, span := range spans {
+		s := otlpSpan{
+			TraceID:           span.spanContext.traceID.String(),
+			SpanID:            span.spanContext.spanID.String(),
+			Name:              span.name,
+			Kind:              span.kind,
+			StartTimeUnixNano: unixNano(span.start),
+			EndTimeUnixNano:   unixNano(span.EndTime()),
+			Attributes:        otlpAttributes(span.Attributes()),
+			Status:            otlpStatus{Code: span.Status().Code, Message: span.Status().Description},
+		}
+		if span.parent.IsValid() {
+			s.ParentSpanID = span.parent.spanID.String()
+		}
+		for _, event := range span.Events() {
+			s.Events = append(s.Events, otlpEvent{
+				TimeUnixNano: unixNano(event.Time),
+				Name:         event.Name,
+				Attributes:   otlpAttributes(event.Attributes),
+			})
+		}
+		otlpSpans = append(otlpSpans, s)
+	}
+
+	return exportTraceRequest{ResourceSpans: []resourceSpans{{
+		Resource:   otlpResource{Attributes: otlpAttributes(resource)},
+		ScopeSpans: []scopeSpans{{Scope: otlpScope{Name: TracerName}, Spans: otlpSpans}},
+	}}}
+}
+
+func otlpAttributes(attributes []Attribute) []otlpAttribute {
+	otlp := make([]otlpAttribute, 0, len(attributes))
+	for _, a := range attributes {
+		var v otlpValue
+		switch value := a.Value.(type) {
+		case string:
+			v.StringValue = &value
+		case int64:
+			s := strconv.FormatInt(value, 10)
+			v.IntValue = &s
+		case bool:
+			v.BoolValue = &value
+		default:
+			s := fmt.Sprint(value)
+			v.StringValue = &s
+		}
+		otlp = append(otlp, otlpAttribute{Key: a.Key, Value: v})
+	}
+	return otlp
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// BatchSpanProcessor queues ended spans and exports them in batches, every
+// batchTimeout or once a batch is full. Spans are dropped while the queue is
+// full, so that a slow collector does not hold up requests.
+type BatchSpanProcessor struct {
+	logger   logger.Logger
+	exporter *Exporter
+	timeout  time.Duration
+
+	queue    chan *Span
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+func NewBatchSpanProcessor(logger logger.Logger, exporter *Exporter, batchTimeout time.Duration) *BatchSpanProcessor {
+	p := &BatchSpanProcessor{
+		logger:   logger,
+		exporter: exporter,
+		timeout:  batchTimeout,
+		queue:    make(chan *Span, maxQueueSize),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+func (p *BatchSpanProcessor) OnEnd(span *Span) {
+	select {
+	case p.queue <- span:
+	default:
+		p.logger.Debug("span-dropped", zap.String("reason", "queue full"))
+	}
+}
+
+// Shutdown exports the queued spans and stops the processor.
+func (p *BatchSpanProcessor) Shutdown(ctx context.Context) error {
+	p.stopOnce.Do(func() { close(p.stop) })
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *BatchSpanProcessor) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.timeout)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, maxBatchSize)
+	for {
+		select {
+		case span := <-p.queue:
+			batch = append(batch, span)
+			if len(batch) == maxBatchSize {
+				batch = p.export(batch)
+			}
+		case <-ticker.C:
+			batch = p.export(batch)
+		case <-p.stop:
+			for {
+				select {
+				case span := <-p.queue:
+					batch = append(batch, span)
+					if len(batch) == maxBatchSize {
+						batch = p.export(batch)
+					}
+				default:
+					p.export(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (p *BatchSpanProcessor) export(batch []*Span) []*Span {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+	if err := p.exporter.Export(ctx, batch); err != nil {
+		p.logger.Error("failed-to-export-spans", zap.Int("spans", len(batch)), zap.Error(err))
+	}
+	return batch[:0]
+}
+
+// SpanRecorder keeps the spans that ended in memory.
+type SpanRecorder struct {
+	lock  sync.Mutex
+	ended []*Span
+}
+
+func NewSpanRecorder() *SpanRecorder {
+	return &SpanRecorder{}
+}
+
+func (r *SpanRecorder) OnEnd(span *Span) {
+	r.lock.Lock()
+	r.ended = append(r.ended, span)
+	r.lock.Unlock()
+}
+
+func (r *SpanRecorder) Shutdown(context.Context) error {
+	return nil
+}
+
+// Ended returns the spans that ended, in the order they did.
+func (r *SpanRecorder) Ended() []*Span {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]*Span(nil), r.ended...)
+}
@@ -0,0 +1,270 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TraceID identifies a trace.
+type TraceID [16]byte
+
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// SpanID identifies a span within its trace.
+type SpanID [8]byte
+
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// SpanContext is the part of a span that is propagated to other processes.
+type SpanContext struct {
+	traceID TraceID
+	spanID  SpanID
+	sampled bool
+	remote  bool
+}
+
+func (sc SpanContext) TraceID() TraceID {
+	return sc.traceID
+}
+
+func (sc SpanContext) SpanID() SpanID {
+	return sc.spanID
+}
+
+// IsSampled reports whether the span is recorded and exported.
+func (sc SpanContext) IsSampled() bool {
+	return sc.sampled
+}
+
+// IsRemote reports whether the span context was received from another
+// process.
+func (sc SpanContext) IsRemote() bool {
+	return sc.remote
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.traceID.IsValid() && sc.spanID.IsValid()
+}
+
+// SpanKind is the role of a span in a request, as defined by OpenTelemetry.
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+// StatusCode is the outcome of the operation of a span.
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = 0
+	StatusError StatusCode = 2
+)
+
+type Status struct {
+	Code        StatusCode
+	Description string
+}
+
+// Attribute is a key and a string, int64 or bool value describing a span.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+func Int(key string, value int) Attribute {
+	return Attribute{Key: key, Value: int64(value)}
+}
+
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Event is something that happened at a point in time during a span, such
+// as an error.
+type Event struct {
+	Name       string
+	Time       time.Time
+	Attributes []Attribute
+}
+
+// Span is an operation of a trace. Spans that are not sampled only carry
+// their span context: they record nothing and are not exported. The recording
+// methods of a nil span do nothing either.
+type Span struct {
+	provider    *TracerProvider
+	name        string
+	kind        SpanKind
+	spanContext SpanContext
+	parent      SpanContext
+	start       time.Time
+
+	lock       sync.Mutex
+	end        time.Time
+	attributes []Attribute
+	events     []Event
+	status     Status
+}
+
+func (s *Span) recording() bool {
+	return s != nil && s.provider != nil && s.spanContext.sampled
+}
+
+func (s *Span) Name() string {
+	return s.name
+}
+
+func (s *Span) SpanKind() SpanKind {
+	return s.kind
+}
+
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.spanContext
+}
+
+// Parent is the span context of the parent span, which is invalid for the
+// root span of a trace.
+func (s *Span) Parent() SpanContext {
+	return s.parent
+}
+
+func (s *Span) StartTime() time.Time {
+	return s.start
+}
+
+func (s *Span) EndTime() time.Time {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.end
+}
+
+func (s *Span) Attributes() []Attribute {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]Attribute(nil), s.attributes...)
+}
+
+// Attribute returns the value of the last attribute set with key, or nil.
+func (s *Span) Attribute(key string) interface{} {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for i := len(s.attributes) - 1; i >= 0; i-- {
+		if s.attributes[i].Key == key {
+			return s.attributes[i].Value
+		}
+	}
+	return nil
+}
+
+func (s *Span) Events() []Event {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]Event(nil), s.events...)
+}
+
+func (s *Span) Status() Status {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.status
+}
+
+func (s *Span) SetAttributes(attributes ...Attribute) {
+	if !s.recording() {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.end.IsZero() {
+		s.attributes = append(s.attributes, attributes...)
+	}
+}
+
+func (s *Span) SetStatus(code StatusCode, description string) {
+	if !s.recording() {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.end.IsZero() {
+		s.status = Status{Code: code, Description: description}
+	}
+}
+
+// RecordError adds an exception event for err to the span.
+func (s *Span) RecordError(err error) {
+	if !s.recording() || err == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.end.IsZero() {
+		s.events = append(s.events, Event{
+			Name: "exception",
+			Time: time.Now(),
+			Attributes: []Attribute{
+				String("exception.type", fmt.Sprintf("%T", err)),
+				String("exception.message", err.Error()),
+			},
+		})
+	}
+}
+
+// End completes the span and hands it to the span processor of its tracer
+// provider. Calls after the first one do nothing.
+func (s *Span) End() {
+	if !s.recording() {
+		return
+	}
+	s.lock.Lock()
+	if !s.end.IsZero() {
+		s.lock.Unlock()
+		return
+	}
+	s.end = time.Now()
+	s.lock.Unlock()
+
+	s.provider.processor.OnEnd(s)
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpan returns a copy of ctx that carries span.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, span)
+}
+
+// SpanFromContext returns the span carried by ctx, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanContextKey{}).(*Span)
+	return span
+}
+
+// SpanContextFromContext returns the span context of the span carried by
+// ctx, which is invalid when there is none.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	return SpanFromContext(ctx).SpanContext()
+}
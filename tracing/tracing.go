@@ -0,0 +1,238 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/logger"
+)
+
+// TracerName is the instrumentation name of the spans gorouter creates.
+const TracerName = "code.cloudfoundry.org/gorouter"
+
+// SpanProcessor is handed every sampled span once it ends.
+type SpanProcessor interface {
+	OnEnd(span *Span)
+	Shutdown(ctx context.Context) error
+}
+
+// Sampler decides whether a new span is recorded, given the span context of
+// its parent, which is invalid for the root span of a trace.
+type Sampler func(parent SpanContext, traceID TraceID) bool
+
+// TraceIDRatioBased samples the fraction ratio of traces. The decision only
+// depends on the trace ID, so every process sampling with the same ratio
+// records the same traces.
+func TraceIDRatioBased(ratio float64) Sampler {
+	if ratio >= 1 {
+		return func(SpanContext, TraceID) bool { return true }
+	}
+	bound := uint64(ratio * (1 << 63))
+	return func(_ SpanContext, traceID TraceID) bool {
+		return binary.BigEndian.Uint64(traceID[8:16])>>1 < bound
+	}
+}
+
+// ParentBased follows the sampling decision of the parent span, and samples
+// root spans with root.
+func ParentBased(root Sampler) Sampler {
+	return func(parent SpanContext, traceID TraceID) bool {
+		if parent.IsValid() {
+			return parent.IsSampled()
+		}
+		return root(parent, traceID)
+	}
+}
+
+// TracerProvider starts spans and hands the sampled ones to its span
+// processor when they end.
+type TracerProvider struct {
+	sampler   Sampler
+	processor SpanProcessor
+	resource  []Attribute
+}
+
+// NewTracerProvider returns a tracer provider that samples spans as set in
+// tracing.open_telemetry and exports them over OTLP/HTTP. tlsConfig is used to
+// connect to the collector unless insecure is set.
+func NewTracerProvider(logger logger.Logger, c *config.Config, tlsConfig *tls.Config) (*TracerProvider, error) {
+	otelConfig := c.Tracing.OpenTelemetry
+
+	resource := []Attribute{
+		String("service.name", c.Logging.JobName),
+		String("service.instance.id", fmt.Sprintf("%s/%d", c.Ip, c.Index)),
+	}
+	exporter, err := NewExporter(otelConfig, tlsConfig, resource)
+	if err != nil {
+		return nil, err
+	}
+
+	sampler := TraceIDRatioBased(otelConfig.SampleRatio)
+	if otelConfig.ParentBased {
+		sampler = ParentBased(sampler)
+	}
+
+	return &TracerProvider{
+		sampler:   sampler,
+		processor: NewBatchSpanProcessor(logger, exporter, defaultBatchTimeout),
+		resource:  resource,
+	}, nil
+}
+
+// NewTracerProviderWithProcessor returns a tracer provider that samples every
+// span and hands it to processor.
+func NewTracerProviderWithProcessor(processor SpanProcessor) *TracerProvider {
+	return &TracerProvider{
+		sampler:   TraceIDRatioBased(1),
+		processor: processor,
+	}
+}
+
+// Shutdown exports the spans that are still buffered.
+func (p *TracerProvider) Shutdown(ctx context.Context) error {
+	return p.processor.Shutdown(ctx)
+}
+
+// StartOption sets a property of a span when it is started.
+type StartOption func(*Span)
+
+func WithSpanKind(kind SpanKind) StartOption {
+	return func(s *Span) { s.kind = kind }
+}
+
+func WithAttributes(attributes ...Attribute) StartOption {
+	return func(s *Span) { s.attributes = append(s.attributes, attributes...) }
+}
+
+// Start starts a span as a child of the span in ctx, or as the root span of
+// a new trace, and returns a copy of ctx that carries it.
+func (p *TracerProvider) Start(ctx context.Context, name string, opts ...StartOption) (context.Context, *Span) {
+	parent := SpanContextFromContext(ctx)
+
+	traceID := parent.traceID
+	if !parent.IsValid() {
+		traceID = newTraceID()
+	}
+
+	span := &Span{
+		provider: p,
+		name:     name,
+		kind:     SpanKindInternal,
+		spanContext: SpanContext{
+			traceID: traceID,
+			spanID:  newSpanID(),
+			sampled: p.sampler(parent, traceID),
+		},
+		parent: parent,
+		start:  time.Now(),
+	}
+	for _, opt := range opts {
+		opt(span)
+	}
+	return ContextWithSpan(ctx, span), span
+}
+
+// StartSpan starts a child of the span in ctx with the tracer provider that
+// created it. Without a span in ctx, e.g. when tracing is disabled, the
+// returned span records nothing.
+func StartSpan(ctx context.Context, name string, opts ...StartOption) (context.Context, *Span) {
+	parent := SpanFromContext(ctx)
+	if parent == nil || parent.provider == nil {
+		return ctx, nil
+	}
+	return parent.provider.Start(ctx, name, opts...)
+}
+
+const traceparentHeader = "traceparent"
+
+// Extract returns ctx with the trace context of the traceparent header of an
+// incoming request.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	sc, ok := parseTraceparent(header.Get(traceparentHeader))
+	if !ok {
+		return ctx
+	}
+	return ContextWithSpan(ctx, &Span{spanContext: sc})
+}
+
+// Inject sets the traceparent header of an outgoing request to the span in
+// ctx, so that spans of the backend become its children.
+func Inject(ctx context.Context, header http.Header) {
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	flags := "00"
+	if sc.sampled {
+		flags = "01"
+	}
+	header.Set(traceparentHeader, "00-"+sc.traceID.String()+"-"+sc.spanID.String()+"-"+flags)
+}
+
+// parseTraceparent parses a W3C traceparent header of version 00, or of a
+// later version, which starts with the same fields.
+func parseTraceparent(traceparent string) (SpanContext, bool) {
+	parts := strings.Split(traceparent, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return SpanContext{}, false
+	}
+
+	var sc SpanContext
+	if !decodeHex(sc.traceID[:], parts[1]) || !decodeHex(sc.spanID[:], parts[2]) || !sc.IsValid() {
+		return SpanContext{}, false
+	}
+	var flags [1]byte
+	if !decodeHex(flags[:], parts[3]) {
+		return SpanContext{}, false
+	}
+	sc.sampled = flags[0]&1 == 1
+	sc.remote = true
+	return sc, true
+}
+
+func decodeHex(dst []byte, s string) bool {
+	if len(s) != 2*len(dst) || strings.ToLower(s) != s {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err == nil
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+// SetStatusCode records the HTTP status code of a response on span. Server
+// errors mark the span as failed.
+func SetStatusCode(span *Span, statusCode int) {
+	span.SetAttributes(Int("http.status_code", statusCode))
+	if statusCode >= http.StatusInternalServerError {
+		span.SetStatus(StatusError, http.StatusText(statusCode))
+	}
+}
+
+// SetError records err on span and marks it as failed.
+func SetError(span *Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(StatusError, err.Error())
+}
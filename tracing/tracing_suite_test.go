@@ -0,0 +1,14 @@
+package tracing_test
+
+import (
+	"code.cloudfoundry.org/gorouter/test_util"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestTracing(t *testing.T) {
+	RegisterFailHandler(Fail)
+	test_util.RunSpecWithHoneyCombReporter(t, "Tracing Suite")
+}
@@ -0,0 +1,224 @@
+package tracing_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/test_util"
+	"code.cloudfoundry.org/gorouter/tracing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tracing", func() {
+	const (
+		traceID      = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentSpanID = "00f067aa0ba902b7"
+	)
+
+	var (
+		spanRecorder   *tracing.SpanRecorder
+		tracerProvider *tracing.TracerProvider
+	)
+
+	BeforeEach(func() {
+		spanRecorder = tracing.NewSpanRecorder()
+		tracerProvider = tracing.NewTracerProviderWithProcessor(spanRecorder)
+	})
+
+	Describe("StartSpan", func() {
+		It("starts a child of the span in the context", func() {
+			ctx, parent := tracerProvider.Start(context.Background(), "parent")
+			_, child := tracing.StartSpan(ctx, "child")
+			child.End()
+			parent.End()
+
+			Expect(spanRecorder.Ended()).To(HaveLen(2))
+			Expect(child.SpanContext().TraceID()).To(Equal(parent.SpanContext().TraceID()))
+			Expect(child.Parent()).To(Equal(parent.SpanContext()))
+		})
+
+		It("records nothing without a span in the context", func() {
+			ctx, span := tracing.StartSpan(context.Background(), "orphan")
+			span.SetAttributes(tracing.String("key", "value"))
+			tracing.SetError(span, errors.New("boom"))
+			span.End()
+
+			Expect(tracing.SpanContextFromContext(ctx).IsValid()).To(BeFalse())
+			Expect(spanRecorder.Ended()).To(BeEmpty())
+		})
+
+		It("ignores changes after the span ended", func() {
+			_, span := tracerProvider.Start(context.Background(), "span")
+			span.End()
+			span.SetAttributes(tracing.String("key", "value"))
+			span.End()
+
+			Expect(spanRecorder.Ended()).To(HaveLen(1))
+			Expect(span.Attribute("key")).To(BeNil())
+		})
+	})
+
+	Describe("Extract and Inject", func() {
+		It("continues the trace of a traceparent header", func() {
+			header := http.Header{}
+			header.Set("traceparent", "00-"+traceID+"-"+parentSpanID+"-01")
+
+			_, span := tracerProvider.Start(tracing.Extract(context.Background(), header), "span")
+
+			Expect(span.SpanContext().TraceID().String()).To(Equal(traceID))
+			Expect(span.Parent().SpanID().String()).To(Equal(parentSpanID))
+			Expect(span.Parent().IsRemote()).To(BeTrue())
+		})
+
+		It("ignores invalid traceparent headers", func() {
+			for _, traceparent := range []string{
+				"",
+				"00-" + traceID + "-" + parentSpanID,
+				"00-" + traceID + "-" + parentSpanID + "-01-extra",
+				"ff-" + traceID + "-" + parentSpanID + "-01",
+				"00-00000000000000000000000000000000-" + parentSpanID + "-01",
+				"00-" + traceID + "-0000000000000000-01",
+				"00-" + strings.ToUpper(traceID) + "-" + parentSpanID + "-01",
+			} {
+				header := http.Header{}
+				header.Set("traceparent", traceparent)
+
+				ctx := tracing.Extract(context.Background(), header)
+				Expect(tracing.SpanContextFromContext(ctx).IsValid()).To(BeFalse(), traceparent)
+			}
+		})
+
+		It("sets the traceparent header to the span in the context", func() {
+			ctx, span := tracerProvider.Start(context.Background(), "span")
+			header := http.Header{}
+			tracing.Inject(ctx, header)
+
+			Expect(header.Get("traceparent")).To(Equal("00-" + span.SpanContext().TraceID().String() + "-" + span.SpanContext().SpanID().String() + "-01"))
+		})
+
+		It("does not set the traceparent header without a span", func() {
+			header := http.Header{}
+			tracing.Inject(context.Background(), header)
+
+			Expect(header).NotTo(HaveKey("Traceparent"))
+		})
+	})
+
+	Describe("samplers", func() {
+		var root tracing.TraceID
+
+		It("samples all or no traces at the bounds of the ratio", func() {
+			Expect(tracing.TraceIDRatioBased(1)(tracing.SpanContext{}, root)).To(BeTrue())
+			Expect(tracing.TraceIDRatioBased(0)(tracing.SpanContext{}, root)).To(BeFalse())
+		})
+
+		It("follows the decision of the parent when parent based", func() {
+			sampler := tracing.ParentBased(tracing.TraceIDRatioBased(1))
+
+			for _, flags := range []string{"00", "01"} {
+				header := http.Header{}
+				header.Set("traceparent", "00-"+traceID+"-"+parentSpanID+"-"+flags)
+				parent := tracing.SpanContextFromContext(tracing.Extract(context.Background(), header))
+
+				Expect(sampler(parent, parent.TraceID())).To(Equal(flags == "01"))
+			}
+		})
+	})
+
+	Describe("BatchSpanProcessor", func() {
+		var (
+			collector *httptest.Server
+			requests  chan *http.Request
+			bodies    chan []byte
+		)
+
+		BeforeEach(func() {
+			requests = make(chan *http.Request, 10)
+			bodies = make(chan []byte, 10)
+			collector = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				requests <- r
+				bodies <- body
+			}))
+		})
+
+		AfterEach(func() {
+			collector.Close()
+		})
+
+		It("exports the spans over OTLP/HTTP when it shuts down", func() {
+			exporter, err := tracing.NewExporter(config.OpenTelemetryConfig{
+				Endpoint: strings.TrimPrefix(collector.URL, "http://"),
+				URLPath:  "/v1/traces",
+				Insecure: true,
+				Headers:  map[string]string{"Authorization": "Bearer some-token"},
+			}, nil, []tracing.Attribute{tracing.String("service.name", "gorouter")})
+			Expect(err).NotTo(HaveOccurred())
+
+			processor := tracing.NewBatchSpanProcessor(test_util.NewTestZapLogger("tracing"), exporter, time.Hour)
+			tracerProvider = tracing.NewTracerProviderWithProcessor(processor)
+
+			ctx, parent := tracerProvider.Start(context.Background(), "GET", tracing.WithSpanKind(tracing.SpanKindServer))
+			_, child := tracing.StartSpan(ctx, "backend", tracing.WithAttributes(tracing.Int("attempt", 1)))
+			tracing.SetError(child, errors.New("boom"))
+			child.End()
+			parent.End()
+
+			Expect(tracerProvider.Shutdown(context.Background())).To(Succeed())
+
+			var req *http.Request
+			Eventually(requests).Should(Receive(&req))
+			Expect(req.Method).To(Equal("POST"))
+			Expect(req.URL.Path).To(Equal("/v1/traces"))
+			Expect(req.Header.Get("Content-Type")).To(Equal("application/json"))
+			Expect(req.Header.Get("Authorization")).To(Equal("Bearer some-token"))
+
+			var body []byte
+			Eventually(bodies).Should(Receive(&body))
+			var export struct {
+				ResourceSpans []struct {
+					Resource struct {
+						Attributes []map[string]interface{} `json:"attributes"`
+					} `json:"resource"`
+					ScopeSpans []struct {
+						Scope struct {
+							Name string `json:"name"`
+						} `json:"scope"`
+						Spans []map[string]interface{} `json:"spans"`
+					} `json:"scopeSpans"`
+				} `json:"resourceSpans"`
+			}
+			Expect(json.Unmarshal(body, &export)).To(Succeed())
+			Expect(export.ResourceSpans).To(HaveLen(1))
+			Expect(export.ResourceSpans[0].Resource.Attributes).To(ConsistOf(map[string]interface{}{
+				"key":   "service.name",
+				"value": map[string]interface{}{"stringValue": "gorouter"},
+			}))
+			Expect(export.ResourceSpans[0].ScopeSpans[0].Scope.Name).To(Equal(tracing.TracerName))
+
+			spans := export.ResourceSpans[0].ScopeSpans[0].Spans
+			Expect(spans).To(HaveLen(2))
+			Expect(spans[0]["name"]).To(Equal("backend"))
+			Expect(spans[0]["traceId"]).To(Equal(parent.SpanContext().TraceID().String()))
+			Expect(spans[0]["parentSpanId"]).To(Equal(parent.SpanContext().SpanID().String()))
+			Expect(spans[0]["attributes"]).To(ConsistOf(map[string]interface{}{
+				"key":   "attempt",
+				"value": map[string]interface{}{"intValue": "1"},
+			}))
+			Expect(spans[0]["status"]).To(Equal(map[string]interface{}{"code": float64(2), "message": "boom"}))
+			Expect(spans[0]["events"]).To(HaveLen(1))
+			Expect(spans[1]["name"]).To(Equal("GET"))
+			Expect(spans[1]["kind"]).To(Equal(float64(tracing.SpanKindServer)))
+			Expect(spans[1]).NotTo(HaveKey("parentSpanId"))
+		})
+	})
+})